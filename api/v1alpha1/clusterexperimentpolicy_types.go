@@ -0,0 +1,98 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// ClusterExperimentPolicySpec defines the guardrails every ExperimentDeployment must respect.
+// Paths are dot-separated JSON paths relative to the workload spec, such as
+// "template.spec.hostNetwork". "*" matches a single field or list index, "**" matches any
+// number of them, and a path also covers all fields below it.
+type ClusterExperimentPolicySpec struct {
+	// AllowedPaths lists the paths overrideSpec may change. If empty, all paths not denied are allowed.
+	// +optional
+	AllowedPaths []string `json:"allowedPaths,omitempty"`
+
+	// DeniedPaths lists the paths overrideSpec must not change, such as
+	// "**.securityContext", "template.spec.hostNetwork", "template.spec.serviceAccountName"
+	// or "template.spec.volumes".
+	// +optional
+	DeniedPaths []string `json:"deniedPaths,omitempty"`
+
+	// MaxReplicas limits the experiment replicas, either to an absolute number or to a
+	// percentage of the source replicas, rounded up.
+	// +optional
+	MaxReplicas *intstr.IntOrString `json:"maxReplicas,omitempty"`
+
+	// AllowedSourceNamespaces lists the namespaces experiments may clone workloads from.
+	// If empty, all namespaces are allowed.
+	// +optional
+	AllowedSourceNamespaces []string `json:"allowedSourceNamespaces,omitempty"`
+
+	// AllowedImageRegistries lists the registries, optionally followed by a repository prefix
+	// such as "ghcr.io/my-org", that all experiment container images must come from.
+	// Images without a registry are resolved to docker.io. If empty, all registries are allowed.
+	// +optional
+	AllowedImageRegistries []string `json:"allowedImageRegistries,omitempty"`
+
+	// MaxExperimentsPerSource limits the number of concurrently running experiments of a source
+	// workload. Further experiments are queued until a slot frees up.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	MaxExperimentsPerSource *int32 `json:"maxExperimentsPerSource,omitempty"`
+
+	// MaxExperimentsPerNamespace limits the number of concurrently running experiments of a
+	// namespace. Further experiments are queued until a slot frees up.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	MaxExperimentsPerNamespace *int32 `json:"maxExperimentsPerNamespace,omitempty"`
+
+	// MaxTrafficSharePercent limits the share of the pods behind the Services of a source workload
+	// that belong to experiments in the Shared service mode, and so the share of its traffic they
+	// receive. Experiments exceeding it are queued until a slot frees up.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=100
+	MaxTrafficSharePercent *int32 `json:"maxTrafficSharePercent,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:path=clusterexperimentpolicies,scope=Cluster,shortName=exppolicy
+// +kubebuilder:printcolumn:name="Max Replicas",type="string",JSONPath=".spec.maxReplicas"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+// ClusterExperimentPolicy is the Schema for the clusterexperimentpolicies API
+type ClusterExperimentPolicy struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec ClusterExperimentPolicySpec `json:"spec,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// ClusterExperimentPolicyList contains a list of ClusterExperimentPolicy
+type ClusterExperimentPolicyList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ClusterExperimentPolicy `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ClusterExperimentPolicy{}, &ClusterExperimentPolicyList{})
+}
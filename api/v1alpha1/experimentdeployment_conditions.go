@@ -0,0 +1,119 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+// Condition types of ExperimentDeployments. Progressing, Degraded and Stalled follow the kstatus
+// conventions: they are abnormal-true, True while the experiment is in that state and False otherwise.
+const (
+	// ConditionTypeReady is True when the experiment workload is ready.
+	ConditionTypeReady = "Ready"
+	// ConditionTypeSynced is True when the experiment workload was rendered from the current spec.
+	ConditionTypeSynced = "Synced"
+	// ConditionTypeProgressing is True while the controller works towards a ready experiment,
+	// for example while the workload rolls out or the experiment is queued.
+	ConditionTypeProgressing = "Progressing"
+	// ConditionTypeDegraded is True when the experiment failed in a way the controller retries,
+	// for example when the source is missing or the workload exceeded its progress deadline.
+	ConditionTypeDegraded = "Degraded"
+	// ConditionTypeStalled is True when the experiment cannot make progress without a change of
+	// its spec or of the cluster, for example after a policy violation or an aborted analysis.
+	ConditionTypeStalled = "Stalled"
+	// ConditionTypeFieldConflict is True when fields of the experiment workload are owned by other
+	// field managers.
+	ConditionTypeFieldConflict = "FieldConflict"
+	// ConditionTypePeerIsolationIncomplete is True when the source headless Service of an isolated
+	// StatefulSet experiment still selects the experiment pods.
+	ConditionTypePeerIsolationIncomplete = "PeerIsolationIncomplete"
+)
+
+// Reasons of the conditions of ExperimentDeployments.
+const (
+	// ReasonReconcileSuccess means the experiment workload was rendered, and is ready on the Ready condition.
+	ReasonReconcileSuccess = "ReconcileSuccess"
+	// ReasonReconcileError means reconciling the experiment failed unexpectedly.
+	ReasonReconcileError = "ReconcileError"
+	// ReasonNotReady means the experiment workload is not ready yet.
+	ReasonNotReady = "NotReady"
+	// ReasonProgressing means the experiment Deployment rolls out a new revision.
+	ReasonProgressing = "Progressing"
+	// ReasonProgressDeadlineExceeded means the experiment Deployment did not progress within its deadline.
+	ReasonProgressDeadlineExceeded = "ProgressDeadlineExceeded"
+	// ReasonQueued means the experiment waits for a slot within the concurrency limits.
+	ReasonQueued = "Queued"
+	// ReasonRecreating means the experiment workload is replaced after immutable fields changed.
+	ReasonRecreating = "Recreating"
+	// ReasonSnapshotInProgress means the VolumeSnapshots seeding the experiment PVCs are not ready yet.
+	ReasonSnapshotInProgress = "SnapshotInProgress"
+	// ReasonSourceNotFound means the source workload does not exist.
+	ReasonSourceNotFound = "SourceNotFound"
+	// ReasonWorkloadRefNotFound means the workload referenced by the source Rollout does not exist.
+	ReasonWorkloadRefNotFound = "WorkloadRefNotFound"
+	// ReasonExperimentWorkloadNotFound means the experiment workload disappeared after it was rendered.
+	ReasonExperimentWorkloadNotFound = "ExperimentWorkloadNotFound"
+	// ReasonCrashLoopBackOff means experiment containers crash repeatedly.
+	ReasonCrashLoopBackOff = "CrashLoopBackOff"
+	// ReasonOOMKilled means experiment containers were killed for exceeding their memory limit.
+	ReasonOOMKilled = "OOMKilled"
+	// ReasonImagePullBackOff means the image of experiment containers cannot be pulled.
+	ReasonImagePullBackOff = "ImagePullBackOff"
+	// ReasonErrImagePull means pulling the image of experiment containers failed.
+	ReasonErrImagePull = "ErrImagePull"
+	// ReasonCreateContainerConfigError means experiment containers reference missing ConfigMaps or Secrets.
+	ReasonCreateContainerConfigError = "CreateContainerConfigError"
+	// ReasonUnschedulable means experiment pods cannot be scheduled.
+	ReasonUnschedulable = "Unschedulable"
+	// ReasonUpsertFailed means creating or updating the experiment workload failed.
+	ReasonUpsertFailed = "UpsertFailed"
+	// ReasonServiceIsolationFailed means the experiment Service of the service mode could not be rendered.
+	ReasonServiceIsolationFailed = "ServiceIsolationFailed"
+	// ReasonMirrorFailed means traffic mirroring could not be configured in Shadow service mode.
+	ReasonMirrorFailed = "MirrorFailed"
+	// ReasonNetworkIsolationFailed means the NetworkPolicy of the experiment could not be rendered.
+	ReasonNetworkIsolationFailed = "NetworkIsolationFailed"
+	// ReasonSnapshotFailed means the VolumeSnapshots seeding the experiment PVCs failed.
+	ReasonSnapshotFailed = "SnapshotFailed"
+	// ReasonValidationFailed means the spec of the experiment is invalid.
+	ReasonValidationFailed = "ValidationFailed"
+	// ReasonUnsupportedSourceKind means the kind of the source workload is not supported.
+	ReasonUnsupportedSourceKind = "UnsupportedSourceKind"
+	// ReasonConstructionFailed means the overrides cannot be applied to the source workload.
+	ReasonConstructionFailed = "ConstructionFailed"
+	// ReasonRolloutsNotSupported means the source is a Rollout but Argo Rollouts is not installed.
+	ReasonRolloutsNotSupported = "RolloutsNotSupported"
+	// ReasonAnalysisNotSupported means analysisTemplates are set but Argo Rollouts is not installed.
+	ReasonAnalysisNotSupported = "AnalysisNotSupported"
+	// ReasonSnapshotNotSupported means the data source requires VolumeSnapshots, which are not installed.
+	ReasonSnapshotNotSupported = "SnapshotNotSupported"
+	// ReasonAnalysisFailed means an analysis of the experiment failed and aborted it.
+	ReasonAnalysisFailed = "AnalysisFailed"
+	// ReasonAborted means the abort policy aborted the broken experiment.
+	ReasonAborted = "Aborted"
+	// ReasonPolicyViolation means the experiment violates a ClusterExperimentPolicy.
+	ReasonPolicyViolation = "PolicyViolation"
+	// ReasonRefNotPermitted means no ExperimentReferenceGrant permits the cross-namespace source.
+	ReasonRefNotPermitted = "RefNotPermitted"
+	// ReasonUnauthorized means the author of the experiment has no access to the source.
+	ReasonUnauthorized = "Unauthorized"
+	// ReasonImmutableFieldConflict means a change touches immutable fields and recreatePolicy is Fail.
+	ReasonImmutableFieldConflict = "ImmutableFieldConflict"
+	// ReasonFieldManagerConflict means fields of the experiment workload are owned by other field managers.
+	ReasonFieldManagerConflict = "FieldManagerConflict"
+	// ReasonHeadlessServiceShared means the source headless Service selects labels the experiment pods keep.
+	ReasonHeadlessServiceShared = "HeadlessServiceShared"
+	// ReasonRevisionNotFound means spec.rollbackTo names a revision that is not retained.
+	ReasonRevisionNotFound = "RevisionNotFound"
+)
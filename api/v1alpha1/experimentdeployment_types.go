@@ -1,6 +1,7 @@
 package v1alpha1
 
 import (
+	networkingv1 "k8s.io/api/networking/v1"
 	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
@@ -18,6 +19,227 @@ const (
 	SourceKindRollout SourceKind = "Rollout"
 )
 
+// CreatedByAnnotation records the author of an ExperimentDeployment as JSON-encoded
+// authentication.k8s.io/v1 UserInfo. It is set by the admission webhook when the experiment is
+// created or its spec is changed, and the controller authorizes the author against the source.
+const CreatedByAnnotation = "experimentcontroller.example.com/created-by"
+
+// CloudEventsSinkAnnotation on a namespace sets the URL the CloudEvents of its experiments are
+// published to, instead of the sink of the controller. An empty value disables the events.
+const CloudEventsSinkAnnotation = "experimentcontroller.example.com/cloudevents-sink"
+
+// ServiceMode defines how experiment pods are exposed to traffic
+// +kubebuilder:validation:Enum=Shared;Isolated;Shadow
+type ServiceMode string
+
+const (
+	// ServiceModeShared keeps the source pod labels so the source Services route traffic to experiment pods
+	ServiceModeShared ServiceMode = "Shared"
+	// ServiceModeIsolated removes experiment pods from the source Services and exposes them through a dedicated Service
+	ServiceModeIsolated ServiceMode = "Isolated"
+	// ServiceModeShadow is Isolated plus mirroring of source traffic to the dedicated Service where a service mesh is configured
+	ServiceModeShadow ServiceMode = "Shadow"
+)
+
+// NetworkIngressMode defines how ingress to the experiment pods is restricted
+// +kubebuilder:validation:Enum=CopySource;DenyAll;AllowAll
+type NetworkIngressMode string
+
+const (
+	// NetworkIngressCopySource copies the effective ingress rules of the source pods
+	NetworkIngressCopySource NetworkIngressMode = "CopySource"
+	// NetworkIngressDenyAll blocks all ingress to the experiment pods
+	NetworkIngressDenyAll NetworkIngressMode = "DenyAll"
+	// NetworkIngressAllowAll allows all ingress to the experiment pods
+	NetworkIngressAllowAll NetworkIngressMode = "AllowAll"
+)
+
+// NetworkIsolation defines the NetworkPolicy generated for the experiment pods.
+type NetworkIsolation struct {
+	// Ingress selects how ingress to the experiment pods is restricted.
+	// CopySource copies the ingress rules of the NetworkPolicies selecting the source pods.
+	// +optional
+	// +kubebuilder:default:=CopySource
+	Ingress NetworkIngressMode `json:"ingress,omitempty"`
+
+	// EgressAllow is the list of egress rules the experiment pods are limited to.
+	// If empty, egress is only restricted by EgressDenyCIDRs. Egress restrictions require the
+	// Isolated or Shadow service mode.
+	// +optional
+	EgressAllow []networkingv1.NetworkPolicyEgressRule `json:"egressAllow,omitempty"`
+
+	// EgressDenyCIDRs lists destination CIDRs the experiment pods must never reach,
+	// such as payment providers or production databases. They are excepted from the ipBlock
+	// peers only: pods selected by podSelector or namespaceSelector peers of EgressAllow stay
+	// reachable whatever their IP.
+	// +optional
+	EgressDenyCIDRs []string `json:"egressDenyCIDRs,omitempty"`
+
+	// AllowDNS keeps DNS egress (port 53) to the cluster DNS pods open when egress is restricted.
+	// Defaults to true.
+	// +optional
+	AllowDNS *bool `json:"allowDNS,omitempty"`
+}
+
+// RolloutStrategyField names a part of the source Rollout strategy that experiment Rollouts can keep
+// +kubebuilder:validation:Enum=CanaryService;StableService;TrafficRouting;Analysis;AntiAffinity;Steps
+type RolloutStrategyField string
+
+const (
+	// RolloutStrategyFieldCanaryService keeps canary.canaryService
+	RolloutStrategyFieldCanaryService RolloutStrategyField = "CanaryService"
+	// RolloutStrategyFieldStableService keeps canary.stableService
+	RolloutStrategyFieldStableService RolloutStrategyField = "StableService"
+	// RolloutStrategyFieldTrafficRouting keeps canary.trafficRouting, canary.pingPong and the traffic routing steps
+	RolloutStrategyFieldTrafficRouting RolloutStrategyField = "TrafficRouting"
+	// RolloutStrategyFieldAnalysis keeps canary.analysis and the analysis and experiment steps
+	RolloutStrategyFieldAnalysis RolloutStrategyField = "Analysis"
+	// RolloutStrategyFieldAntiAffinity keeps the antiAffinity of the strategy
+	RolloutStrategyFieldAntiAffinity RolloutStrategyField = "AntiAffinity"
+	// RolloutStrategyFieldSteps keeps canary.steps
+	RolloutStrategyFieldSteps RolloutStrategyField = "Steps"
+)
+
+// RolloutStrategyPolicy defines how the source Rollout strategy is sanitized for experiment Rollouts.
+type RolloutStrategyPolicy struct {
+	// Keep lists the parts of the source strategy copied to the experiment Rollout.
+	// Everything else referencing Services, traffic routing or analysis shared with the
+	// source Rollout is stripped.
+	// +optional
+	Keep []RolloutStrategyField `json:"keep,omitempty"`
+}
+
+// AnalysisTemplateRef references an Argo Rollouts AnalysisTemplate run against the experiment pods.
+type AnalysisTemplateRef struct {
+	// TemplateName is the name of the AnalysisTemplate in the experiment namespace,
+	// or of the ClusterAnalysisTemplate when ClusterScope is set.
+	// +kubebuilder:validation:MinLength=1
+	TemplateName string `json:"templateName"`
+
+	// ClusterScope references a ClusterAnalysisTemplate instead of an AnalysisTemplate.
+	// +optional
+	ClusterScope bool `json:"clusterScope,omitempty"`
+
+	// Args set template arguments. The experiment-name, experiment-namespace and
+	// experiment-pod-selector arguments are provided by the controller when the
+	// template declares them.
+	// +optional
+	Args []AnalysisArgument `json:"args,omitempty"`
+}
+
+// AnalysisArgument is an argument passed to an AnalysisRun.
+type AnalysisArgument struct {
+	// Name is the name of the template argument.
+	Name string `json:"name"`
+
+	// Value is the value of the argument.
+	Value string `json:"value"`
+}
+
+// AnalysisRunStatus reports an AnalysisRun created for the experiment.
+type AnalysisRunStatus struct {
+	// Name is the name of the AnalysisRun.
+	Name string `json:"name"`
+
+	// TemplateName is the name of the template the AnalysisRun was created from.
+	TemplateName string `json:"templateName"`
+
+	// ClusterScope is set when the template is a ClusterAnalysisTemplate.
+	// +optional
+	ClusterScope bool `json:"clusterScope,omitempty"`
+
+	// Phase is the phase of the AnalysisRun (Pending, Running, Successful, Failed, Error, Inconclusive).
+	// +optional
+	Phase string `json:"phase,omitempty"`
+
+	// Message explains the phase of the AnalysisRun.
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// StatefulSetPeerPolicy decides whether experiment pods may join the peer group of the source StatefulSet
+// +kubebuilder:validation:Enum=Isolated;Join
+type StatefulSetPeerPolicy string
+
+const (
+	// StatefulSetPeerPolicyIsolated registers the experiment pods in a dedicated headless Service
+	StatefulSetPeerPolicyIsolated StatefulSetPeerPolicy = "Isolated"
+	// StatefulSetPeerPolicyJoin keeps the headless Service of the source, so experiment pods are discovered as peers
+	StatefulSetPeerPolicyJoin StatefulSetPeerPolicy = "Join"
+)
+
+// StatefulSetOptions configures experiments of StatefulSet sources.
+type StatefulSetOptions struct {
+	// PeerPolicy decides whether the experiment pods may join the peer group of the source pods.
+	// Isolated creates a dedicated "<name>-headless" Service, rewrites serviceName to it and strips
+	// the labels selected by the source headless Service from the experiment pods, so peer-discovering
+	// systems do not join them to the source cluster; with the Shared service mode, labels also
+	// selected by production Services are kept. Join keeps the source serviceName and labels.
+	// Defaults to Join.
+	// +optional
+	PeerPolicy StatefulSetPeerPolicy `json:"peerPolicy,omitempty"`
+
+	// DataSource seeds the experiment PVCs with the data of the source PVCs,
+	// cloned through CSI VolumeSnapshots, instead of starting with empty volumes.
+	// +optional
+	DataSource *StatefulSetDataSource `json:"dataSource,omitempty"`
+}
+
+// StatefulSetDataSource defines the VolumeSnapshots the experiment PVCs are cloned from.
+type StatefulSetDataSource struct {
+	// VolumeSnapshotClassName is the class of the VolumeSnapshots taken of the source PVCs.
+	// Defaults to the default VolumeSnapshotClass of the cluster.
+	// +optional
+	VolumeSnapshotClassName string `json:"volumeSnapshotClassName,omitempty"`
+
+	// ExistingSnapshots clones the PVCs of a volumeClaimTemplate from an existing VolumeSnapshot
+	// in the experiment namespace instead of snapshotting the source PVCs.
+	// +optional
+	ExistingSnapshots []ExistingVolumeSnapshot `json:"existingSnapshots,omitempty"`
+}
+
+// ExistingVolumeSnapshot references a VolumeSnapshot seeding the PVCs of a volumeClaimTemplate.
+type ExistingVolumeSnapshot struct {
+	// ClaimTemplate is the name of the volumeClaimTemplate.
+	ClaimTemplate string `json:"claimTemplate"`
+
+	// SnapshotName is the name of the VolumeSnapshot all ordinals are cloned from.
+	SnapshotName string `json:"snapshotName"`
+}
+
+// DataSourceStatus reports the progress of the VolumeSnapshots seeding the experiment PVCs.
+type DataSourceStatus struct {
+	// Phase is Snapshotting while VolumeSnapshots are not ready to use, Ready once every
+	// experiment PVC exists, and Failed when a VolumeSnapshot failed.
+	// +optional
+	Phase string `json:"phase,omitempty"`
+
+	// Volumes reports the seeding of each experiment PVC.
+	// +optional
+	Volumes []VolumeSeedStatus `json:"volumes,omitempty"`
+}
+
+// VolumeSeedStatus reports the seeding of an experiment PVC.
+type VolumeSeedStatus struct {
+	// ClaimName is the name of the experiment PVC.
+	ClaimName string `json:"claimName"`
+
+	// SnapshotName is the name of the VolumeSnapshot the PVC is cloned from.
+	SnapshotName string `json:"snapshotName"`
+
+	// ReadyToUse is set once the VolumeSnapshot can be restored.
+	// +optional
+	ReadyToUse bool `json:"readyToUse,omitempty"`
+
+	// Created is set once the experiment PVC exists.
+	// +optional
+	Created bool `json:"created,omitempty"`
+
+	// Message reports VolumeSnapshot errors.
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
 // SourceRef defines a reference to the source workload.
 type SourceRef struct {
 	// Kind specifies the kind of the source workload.
@@ -56,8 +278,243 @@ type ExperimentDeploymentSpec struct {
 	// +kubebuilder:validation:Required
 	// +kubebuilder:pruning:PreserveUnknownFields
 	OverrideSpec apiextensionsv1.JSON `json:"overrideSpec"`
+
+	// ServiceMode controls whether the experiment pods receive production traffic.
+	// Shared keeps the source pod labels, so the source Services select the experiment pods.
+	// Isolated strips the labels matched by the source Service selectors and creates a
+	// dedicated "<name>-experiment" Service with the same ports.
+	// Shadow is Isolated plus mirroring of the source traffic to the dedicated Service
+	// wherever a service mesh (Istio VirtualService) is configured.
+	// +optional
+	// +kubebuilder:default:=Shared
+	ServiceMode ServiceMode `json:"serviceMode,omitempty"`
+
+	// NetworkIsolation generates a NetworkPolicy selecting the experiment pods.
+	// If not specified, no NetworkPolicy is created.
+	// +optional
+	NetworkIsolation *NetworkIsolation `json:"networkIsolation,omitempty"`
+
+	// RolloutStrategy controls which parts of the source Rollout strategy are kept.
+	// By default experiment Rollouts use a simple canary strategy without Services,
+	// traffic routing, analysis or steps, so they never fight the source Rollout.
+	// Strategy fields set in overrideSpec are always applied.
+	// +optional
+	RolloutStrategy *RolloutStrategyPolicy `json:"rolloutStrategy,omitempty"`
+
+	// AnalysisTemplates lists Argo Rollouts AnalysisTemplates or ClusterAnalysisTemplates
+	// run against the experiment pods. The experiment is aborted and scaled down to zero
+	// when one of the resulting AnalysisRuns fails. Requires the Argo Rollouts CRDs.
+	// +optional
+	AnalysisTemplates []AnalysisTemplateRef `json:"analysisTemplates,omitempty"`
+
+	// StatefulSet configures experiments of StatefulSet sources.
+	// +optional
+	StatefulSet *StatefulSetOptions `json:"statefulSet,omitempty"`
+
+	// Priority orders experiments waiting for a slot when concurrency limits are reached.
+	// Experiments with a higher priority start first, equal priorities start in creation order.
+	// +optional
+	Priority int32 `json:"priority,omitempty"`
+
+	// RecreatePolicy decides what happens when a change of the experiment cannot be applied
+	// because it changes immutable fields of the experiment workload, such as the selector of a
+	// Deployment or the volumeClaimTemplates of a StatefulSet.
+	// Recreate deletes the workload and creates it again.
+	// Fail leaves the workload unchanged and reports the conflict.
+	// BlueGreen creates the new workload next to the current one and deletes the current one
+	// once the new one is ready.
+	// +optional
+	// +kubebuilder:default:=Recreate
+	RecreatePolicy RecreatePolicy `json:"recreatePolicy,omitempty"`
+
+	// AbortPolicy aborts a broken experiment instead of retrying it indefinitely.
+	// If not specified, experiments are only aborted by failed analysis.
+	// +optional
+	AbortPolicy *AbortPolicy `json:"abortPolicy,omitempty"`
+
+	// NotificationProviderRefs lists the NotificationProviders in the namespace of the experiment
+	// notified about its lifecycle transitions.
+	// +optional
+	// +listType=map
+	// +listMapKey=name
+	NotificationProviderRefs []LocalObjectReference `json:"notificationProviderRefs,omitempty"`
+
+	// RollbackTo renders the overrides of an earlier revision from status.history instead of
+	// overrideSpec. Remove it to render overrideSpec again.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	RollbackTo *int64 `json:"rollbackTo,omitempty"`
+
+	// RevisionHistoryLimit is the number of revisions of the overrides retained for rollbacks.
+	// +optional
+	// +kubebuilder:default:=10
+	// +kubebuilder:validation:Minimum=1
+	RevisionHistoryLimit *int32 `json:"revisionHistoryLimit,omitempty"`
+}
+
+// AbortPolicy defines the limits a broken experiment is aborted at
+type AbortPolicy struct {
+	// MaxRestarts aborts the experiment once the containers of its pods restarted more often in total.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	MaxRestarts *int32 `json:"maxRestarts,omitempty"`
+
+	// MaxUnreadyDuration aborts the experiment once its workload has not been ready for this long.
+	// +optional
+	MaxUnreadyDuration *metav1.Duration `json:"maxUnreadyDuration,omitempty"`
+
+	// OnProgressDeadlineExceeded aborts the experiment once its workload exceeded its progress deadline.
+	// StatefulSets have no progress deadline, they exceed it after 10 minutes without becoming ready.
+	// +optional
+	OnProgressDeadlineExceeded bool `json:"onProgressDeadlineExceeded,omitempty"`
+
+	// Action decides what happens to the workload of an aborted experiment.
+	// ScaleToZero keeps the workload scaled down for inspection, Delete removes it.
+	// +optional
+	// +kubebuilder:default:=ScaleToZero
+	Action AbortAction `json:"action,omitempty"`
+}
+
+// AbortAction defines what happens to the workload of an aborted experiment
+// +kubebuilder:validation:Enum=ScaleToZero;Delete
+type AbortAction string
+
+const (
+	// AbortActionScaleToZero scales the experiment workload down to zero replicas
+	AbortActionScaleToZero AbortAction = "ScaleToZero"
+	// AbortActionDelete deletes the experiment workload
+	AbortActionDelete AbortAction = "Delete"
+)
+
+// AbortTrigger names the limit of the abort policy an experiment breached
+type AbortTrigger string
+
+const (
+	// AbortTriggerMaxRestarts means the experiment containers restarted too often
+	AbortTriggerMaxRestarts AbortTrigger = "MaxRestarts"
+	// AbortTriggerMaxUnreadyDuration means the experiment workload was not ready for too long
+	AbortTriggerMaxUnreadyDuration AbortTrigger = "MaxUnreadyDuration"
+	// AbortTriggerProgressDeadlineExceeded means the experiment workload exceeded its progress deadline
+	AbortTriggerProgressDeadlineExceeded AbortTrigger = "ProgressDeadlineExceeded"
+)
+
+// AbortStatus reports why the abort policy aborted the experiment
+type AbortStatus struct {
+	// Trigger is the limit of the abort policy the experiment breached.
+	Trigger AbortTrigger `json:"trigger"`
+
+	// Action is what happened to the experiment workload.
+	Action AbortAction `json:"action"`
+
+	// Message describes the breach.
+	// +optional
+	Message string `json:"message,omitempty"`
+
+	// Time is when the experiment was aborted.
+	Time metav1.Time `json:"time"`
+}
+
+// RecreatePolicy defines how immutable field changes of the experiment workload are applied
+// +kubebuilder:validation:Enum=Recreate;Fail;BlueGreen
+type RecreatePolicy string
+
+const (
+	// RecreatePolicyRecreate deletes the experiment workload and creates it again
+	RecreatePolicyRecreate RecreatePolicy = "Recreate"
+	// RecreatePolicyFail keeps the experiment workload and reports the conflict
+	RecreatePolicyFail RecreatePolicy = "Fail"
+	// RecreatePolicyBlueGreen replaces the experiment workload once its successor is ready
+	RecreatePolicyBlueGreen RecreatePolicy = "BlueGreen"
+)
+
+// RecreationStatus reports the last replacement of the experiment workload after immutable fields changed
+type RecreationStatus struct {
+	// Policy is the recreate policy that was applied.
+	Policy RecreatePolicy `json:"policy"`
+
+	// Name is the name of the replacement workload.
+	Name string `json:"name"`
+
+	// PreviousName is the name of the replaced workload.
+	// +optional
+	PreviousName string `json:"previousName,omitempty"`
+
+	// Message explains which change required the replacement.
+	// +optional
+	Message string `json:"message,omitempty"`
+
+	// StartTime is when the replacement started.
+	// +optional
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+
+	// CompletionTime is when the replacement workload took over. Unset while it is in progress.
+	// +optional
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+}
+
+// PodIssue counts the experiment pods affected by a problem
+type PodIssue struct {
+	// Reason is the waiting or termination reason of a container, like CrashLoopBackOff,
+	// ImagePullBackOff or OOMKilled, or Unschedulable for pods that cannot be scheduled.
+	Reason string `json:"reason"`
+
+	// Pods is the number of experiment pods with this problem.
+	Pods int32 `json:"pods"`
+
+	// Message is the message of one of the affected pods.
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// PodHealthStatus summarizes the health of the experiment pods
+type PodHealthStatus struct {
+	// Pods is the number of experiment pods.
+	Pods int32 `json:"pods"`
+
+	// ReadyPods is the number of ready experiment pods.
+	ReadyPods int32 `json:"readyPods"`
+
+	// Restarts is the total number of container restarts of the experiment pods.
+	Restarts int32 `json:"restarts"`
+
+	// Issues lists the problems of the experiment pods, most severe first.
+	// +optional
+	Issues []PodIssue `json:"issues,omitempty"`
+
+	// TimeToReady is the average time the ready experiment pods took from creation to readiness.
+	// +optional
+	TimeToReady *metav1.Duration `json:"timeToReady,omitempty"`
+
+	// SourceTimeToReady is the average time the ready source pods took from creation to readiness.
+	// +optional
+	SourceTimeToReady *metav1.Duration `json:"sourceTimeToReady,omitempty"`
 }
 
+// ExperimentPhase is a high-level summary of the lifecycle of an experiment
+// +kubebuilder:validation:Enum=Pending;Queued;Running;Paused;Succeeded;Failed;Aborted;Expired;Promoted
+type ExperimentPhase string
+
+const (
+	// ExperimentPhasePending means the experiment workload is not rendered or not ready yet
+	ExperimentPhasePending ExperimentPhase = "Pending"
+	// ExperimentPhaseQueued means the experiment waits for a slot within the concurrency limits
+	ExperimentPhaseQueued ExperimentPhase = "Queued"
+	// ExperimentPhaseRunning means the experiment workload is ready
+	ExperimentPhaseRunning ExperimentPhase = "Running"
+	// ExperimentPhasePaused means the experiment is scaled to zero replicas
+	ExperimentPhasePaused ExperimentPhase = "Paused"
+	// ExperimentPhaseSucceeded means every analysis of the experiment was successful
+	ExperimentPhaseSucceeded ExperimentPhase = "Succeeded"
+	// ExperimentPhaseFailed means an analysis of the experiment failed or the experiment is stalled
+	ExperimentPhaseFailed ExperimentPhase = "Failed"
+	// ExperimentPhaseAborted means the abort policy stopped the broken experiment
+	ExperimentPhaseAborted ExperimentPhase = "Aborted"
+	// ExperimentPhaseExpired means the experiment ended because its lifetime ran out
+	ExperimentPhaseExpired ExperimentPhase = "Expired"
+	// ExperimentPhasePromoted means the experiment ended because its overrides were promoted to the source
+	ExperimentPhasePromoted ExperimentPhase = "Promoted"
+)
+
 // ExperimentResourceRef defines a reference to a Kubernetes resource.
 type ExperimentResourceRef struct {
 	// Kind is the kind of the referenced resource (e.g., Deployment, StatefulSet, Rollout).
@@ -87,6 +544,12 @@ type ExperimentDeploymentStatus struct {
 	// +optional
 	ExperimentResourceRef *ExperimentResourceRef `json:"experimentResourceRef,omitempty"`
 
+	// WorkloadGeneration is the metadata.generation of the experiment workload observed after the
+	// controller last applied it. A newer generation means the workload was changed by someone
+	// else, and it is applied again.
+	// +optional
+	WorkloadGeneration int64 `json:"workloadGeneration,omitempty"`
+
 	// ObservedGeneration is the most recent generation observed by the controller.
 	// +optional
 	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
@@ -94,6 +557,143 @@ type ExperimentDeploymentStatus struct {
 	// ReadyReplicas is the number of ready replicas for the experiment workload.
 	// +optional
 	ReadyReplicas int32 `json:"readyReplicas,omitempty"`
+
+	// ExperimentServiceRef is a reference to the dedicated experiment Service
+	// created in Isolated and Shadow service modes.
+	// +optional
+	ExperimentServiceRef *ExperimentResourceRef `json:"experimentServiceRef,omitempty"`
+
+	// MirroredVirtualServices lists the Istio VirtualServices (namespace/name)
+	// that mirror traffic to the experiment Service in Shadow service mode.
+	// +optional
+	MirroredVirtualServices []string `json:"mirroredVirtualServices,omitempty"`
+
+	// NetworkPolicyRef is a reference to the NetworkPolicy generated for the experiment pods.
+	// +optional
+	NetworkPolicyRef *ExperimentResourceRef `json:"networkPolicyRef,omitempty"`
+
+	// AnalysisPhase summarizes the phases of the AnalysisRuns of the experiment.
+	// +optional
+	AnalysisPhase string `json:"analysisPhase,omitempty"`
+
+	// AnalysisRuns reports the AnalysisRuns created from spec.analysisTemplates.
+	// +optional
+	AnalysisRuns []AnalysisRunStatus `json:"analysisRuns,omitempty"`
+
+	// DataSource reports the seeding of the experiment PVCs from VolumeSnapshots.
+	// +optional
+	DataSource *DataSourceStatus `json:"dataSource,omitempty"`
+
+	// HeadlessServiceRef references the dedicated headless Service of isolated StatefulSet experiments.
+	// +optional
+	HeadlessServiceRef *ExperimentResourceRef `json:"headlessServiceRef,omitempty"`
+
+	// CreatedBy is the username of the author the experiment was last authorized against.
+	// +optional
+	CreatedBy string `json:"createdBy,omitempty"`
+
+	// Phase is a high-level summary of the lifecycle of the experiment.
+	// +optional
+	Phase ExperimentPhase `json:"phase,omitempty"`
+
+	// Recreation reports the last replacement of the experiment workload after immutable fields changed.
+	// +optional
+	Recreation *RecreationStatus `json:"recreation,omitempty"`
+
+	// PodHealth summarizes the health of the experiment pods.
+	// +optional
+	PodHealth *PodHealthStatus `json:"podHealth,omitempty"`
+
+	// Abort reports why the abort policy aborted the experiment.
+	// +optional
+	Abort *AbortStatus `json:"abort,omitempty"`
+
+	// Notifications reports the delivery of lifecycle notifications per NotificationProvider.
+	// +optional
+	// +listType=map
+	// +listMapKey=provider
+	Notifications []NotificationStatus `json:"notifications,omitempty"`
+
+	// CurrentRevision is the revision of the overrides the experiment workload was last rendered with.
+	// +optional
+	CurrentRevision int64 `json:"currentRevision,omitempty"`
+
+	// History lists the retained revisions of the overrides, oldest first. Their overrides are
+	// stored in ControllerRevisions owned by the experiment.
+	// +optional
+	// +listType=map
+	// +listMapKey=revision
+	History []ExperimentRevision `json:"history,omitempty"`
+}
+
+// RevisionOutcome is the readiness outcome of a revision
+// +kubebuilder:validation:Enum=Pending;Ready;Failed
+type RevisionOutcome string
+
+const (
+	// RevisionOutcomePending means the experiment workload has not become ready with the revision yet
+	RevisionOutcomePending RevisionOutcome = "Pending"
+	// RevisionOutcomeReady means the experiment workload became ready with the revision
+	RevisionOutcomeReady RevisionOutcome = "Ready"
+	// RevisionOutcomeFailed means the experiment failed or was aborted with the revision
+	RevisionOutcomeFailed RevisionOutcome = "Failed"
+)
+
+// ExperimentRevision describes a rendered revision of the overrides of an experiment
+type ExperimentRevision struct {
+	// Revision numbers the revisions of the experiment in the order they were first rendered.
+	Revision int64 `json:"revision"`
+
+	// OverrideHash identifies the overrides of the revision.
+	OverrideHash string `json:"overrideHash"`
+
+	// SourceGeneration is the generation of the source workload the revision was last rendered from.
+	// +optional
+	SourceGeneration int64 `json:"sourceGeneration,omitempty"`
+
+	// ChangedBy is the field manager that last changed the overrides of the revision.
+	// +optional
+	ChangedBy string `json:"changedBy,omitempty"`
+
+	// CreationTime is when the revision was first rendered.
+	CreationTime metav1.Time `json:"creationTime"`
+
+	// LastAppliedTime is when the experiment workload was last switched to the revision.
+	LastAppliedTime metav1.Time `json:"lastAppliedTime"`
+
+	// Outcome is whether the experiment workload became ready with the revision.
+	// +optional
+	Outcome RevisionOutcome `json:"outcome,omitempty"`
+}
+
+// NotificationStatus reports the delivery of notifications to a NotificationProvider
+type NotificationStatus struct {
+	// Provider is the name of the NotificationProvider.
+	Provider string `json:"provider"`
+
+	// LastEvent is the last lifecycle transition sent to the provider.
+	// +optional
+	LastEvent NotificationEventType `json:"lastEvent,omitempty"`
+
+	// LastDeliveryTime is when a notification was last delivered to the provider.
+	// +optional
+	LastDeliveryTime *metav1.Time `json:"lastDeliveryTime,omitempty"`
+
+	// Failures counts the failed delivery attempts since the last delivery.
+	// +optional
+	Failures int32 `json:"failures,omitempty"`
+
+	// LastError is the error of the last failed delivery.
+	// +optional
+	LastError string `json:"lastError,omitempty"`
+
+	// PendingEvent is the lifecycle transition whose delivery failed and is retried.
+	// +optional
+	PendingEvent NotificationEventType `json:"pendingEvent,omitempty"`
+
+	// LastAttemptTime is when the delivery of the pending transition was last attempted.
+	// +optional
+	LastAttemptTime *metav1.Time `json:"lastAttemptTime,omitempty"`
 }
 
 // +kubebuilder:object:root=true
@@ -103,6 +703,8 @@ type ExperimentDeploymentStatus struct {
 // +kubebuilder:printcolumn:name="Source Name",type="string",JSONPath=".spec.sourceRef.name"
 // +kubebuilder:printcolumn:name="Replicas",type="integer",JSONPath=".spec.replicas"
 // +kubebuilder:printcolumn:name="Ready",type="integer",JSONPath=".status.readyReplicas"
+// +kubebuilder:printcolumn:name="Phase",type="string",JSONPath=".status.phase"
+// +kubebuilder:printcolumn:name="Revision",type="integer",JSONPath=".status.currentRevision",priority=1
 // +kubebuilder:printcolumn:name="Status",type="string",JSONPath=".status.conditions[?(@.type=='Ready')].status"
 // +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
 // ExperimentDeployment is the Schema for the experimentdeployments API
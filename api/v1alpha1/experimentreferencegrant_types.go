@@ -0,0 +1,78 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ReferenceGrantFrom describes the ExperimentDeployments allowed to reference the granted workloads
+type ReferenceGrantFrom struct {
+	// Namespace is the namespace of the ExperimentDeployments.
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	Namespace string `json:"namespace"`
+}
+
+// ReferenceGrantTo describes the workloads in the namespace of the grant that may be referenced
+type ReferenceGrantTo struct {
+	// Kind is the kind of the workloads.
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Enum=Deployment;StatefulSet;Rollout
+	Kind SourceKind `json:"kind"`
+
+	// Name is the name of the workload. If empty, all workloads of the kind are granted.
+	// +optional
+	Name string `json:"name,omitempty"`
+}
+
+// ExperimentReferenceGrantSpec defines which namespaces may run experiments cloned from
+// workloads of the namespace of the grant
+type ExperimentReferenceGrantSpec struct {
+	// From lists the namespaces whose ExperimentDeployments may reference the workloads in To.
+	// +kubebuilder:validation:MinItems=1
+	From []ReferenceGrantFrom `json:"from"`
+
+	// To lists the workloads that may be referenced.
+	// +kubebuilder:validation:MinItems=1
+	To []ReferenceGrantTo `json:"to"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:shortName=expgrant
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+// ExperimentReferenceGrant allows ExperimentDeployments of other namespaces to clone workloads
+// of its namespace, similar to the Gateway API ReferenceGrant. ExperimentDeployments referencing
+// a workload of another namespace are only rendered when such a grant exists.
+type ExperimentReferenceGrant struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec ExperimentReferenceGrantSpec `json:"spec,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// ExperimentReferenceGrantList contains a list of ExperimentReferenceGrant
+type ExperimentReferenceGrantList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ExperimentReferenceGrant `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ExperimentReferenceGrant{}, &ExperimentReferenceGrantList{})
+}
@@ -0,0 +1,129 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// NotificationProviderType defines the payload format of a notification provider
+// +kubebuilder:validation:Enum=Generic;Slack;Teams
+type NotificationProviderType string
+
+const (
+	// NotificationProviderGeneric posts the notification as a JSON object
+	NotificationProviderGeneric NotificationProviderType = "Generic"
+	// NotificationProviderSlack posts a Slack incoming webhook message
+	NotificationProviderSlack NotificationProviderType = "Slack"
+	// NotificationProviderTeams posts a Microsoft Teams message with an Adaptive Card
+	NotificationProviderTeams NotificationProviderType = "Teams"
+)
+
+// NotificationEventType is a lifecycle transition of an experiment notifications are sent for
+// +kubebuilder:validation:Enum=Created;Ready;AnalysisCompleted;Failed;Aborted;Expired;Promoted;Deleted
+type NotificationEventType string
+
+const (
+	// NotificationEventCreated is sent when the experiment is reconciled for the first time
+	NotificationEventCreated NotificationEventType = "Created"
+	// NotificationEventReady is sent when the experiment workload becomes ready
+	NotificationEventReady NotificationEventType = "Ready"
+	// NotificationEventAnalysisCompleted is sent when the analysis of the experiment succeeded or failed
+	NotificationEventAnalysisCompleted NotificationEventType = "AnalysisCompleted"
+	// NotificationEventFailed is sent when the experiment stalls
+	NotificationEventFailed NotificationEventType = "Failed"
+	// NotificationEventAborted is sent when the abort policy aborts the experiment
+	NotificationEventAborted NotificationEventType = "Aborted"
+	// NotificationEventExpired is sent when the lifetime of the experiment runs out
+	NotificationEventExpired NotificationEventType = "Expired"
+	// NotificationEventPromoted is sent when the experiment is promoted to its source
+	NotificationEventPromoted NotificationEventType = "Promoted"
+	// NotificationEventDeleted is sent when the experiment is deleted
+	NotificationEventDeleted NotificationEventType = "Deleted"
+)
+
+// NotificationSecretAddressKey is the key of the webhook URL in the Secret of a notification provider
+const NotificationSecretAddressKey = "address"
+
+// LocalObjectReference references an object in the namespace of the referencing object
+type LocalObjectReference struct {
+	// Name is the name of the object.
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+}
+
+// NotificationProviderSpec defines where and how notifications are delivered
+// +kubebuilder:validation:XValidation:rule="has(self.address) != has(self.secretRef)",message="exactly one of address and secretRef must be set"
+type NotificationProviderSpec struct {
+	// Type is the payload format of the webhook.
+	// +kubebuilder:validation:Required
+	Type NotificationProviderType `json:"type"`
+
+	// Address is the URL of the webhook.
+	// +optional
+	// +kubebuilder:validation:Pattern=`^https?://`
+	Address string `json:"address,omitempty"`
+
+	// SecretRef references a Secret in the namespace of the provider whose "address" key holds
+	// the URL of the webhook, for URLs embedding a token such as Slack and Teams webhooks.
+	// +optional
+	SecretRef *LocalObjectReference `json:"secretRef,omitempty"`
+
+	// EventTypes limits the notifications to these lifecycle transitions. If empty, all
+	// transitions are sent.
+	// +optional
+	EventTypes []NotificationEventType `json:"eventTypes,omitempty"`
+
+	// Retries is the number of times a failed delivery is retried by later reconciles of the
+	// experiment, with exponential backoff.
+	// +optional
+	// +kubebuilder:default:=3
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=10
+	Retries *int32 `json:"retries,omitempty"`
+
+	// Timeout limits each delivery attempt.
+	// +optional
+	// +kubebuilder:default:="5s"
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:shortName=expnotify
+// +kubebuilder:printcolumn:name="Type",type="string",JSONPath=".spec.type"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+// NotificationProvider is a webhook notified about the lifecycle transitions of the
+// ExperimentDeployments of its namespace that reference it in spec.notificationProviderRefs.
+type NotificationProvider struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec NotificationProviderSpec `json:"spec,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// NotificationProviderList contains a list of NotificationProvider
+type NotificationProviderList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []NotificationProvider `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&NotificationProvider{}, &NotificationProviderList{})
+}
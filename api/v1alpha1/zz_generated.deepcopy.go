@@ -21,10 +21,236 @@ limitations under the License.
 package v1alpha1
 
 import (
+	networkingv1 "k8s.io/api/networking/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AbortPolicy) DeepCopyInto(out *AbortPolicy) {
+	*out = *in
+	if in.MaxRestarts != nil {
+		in, out := &in.MaxRestarts, &out.MaxRestarts
+		*out = new(int32)
+		**out = **in
+	}
+	if in.MaxUnreadyDuration != nil {
+		in, out := &in.MaxUnreadyDuration, &out.MaxUnreadyDuration
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AbortPolicy.
+func (in *AbortPolicy) DeepCopy() *AbortPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(AbortPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AbortStatus) DeepCopyInto(out *AbortStatus) {
+	*out = *in
+	in.Time.DeepCopyInto(&out.Time)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AbortStatus.
+func (in *AbortStatus) DeepCopy() *AbortStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(AbortStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AnalysisArgument) DeepCopyInto(out *AnalysisArgument) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AnalysisArgument.
+func (in *AnalysisArgument) DeepCopy() *AnalysisArgument {
+	if in == nil {
+		return nil
+	}
+	out := new(AnalysisArgument)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AnalysisRunStatus) DeepCopyInto(out *AnalysisRunStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AnalysisRunStatus.
+func (in *AnalysisRunStatus) DeepCopy() *AnalysisRunStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(AnalysisRunStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AnalysisTemplateRef) DeepCopyInto(out *AnalysisTemplateRef) {
+	*out = *in
+	if in.Args != nil {
+		in, out := &in.Args, &out.Args
+		*out = make([]AnalysisArgument, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AnalysisTemplateRef.
+func (in *AnalysisTemplateRef) DeepCopy() *AnalysisTemplateRef {
+	if in == nil {
+		return nil
+	}
+	out := new(AnalysisTemplateRef)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterExperimentPolicy) DeepCopyInto(out *ClusterExperimentPolicy) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterExperimentPolicy.
+func (in *ClusterExperimentPolicy) DeepCopy() *ClusterExperimentPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterExperimentPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ClusterExperimentPolicy) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterExperimentPolicyList) DeepCopyInto(out *ClusterExperimentPolicyList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ClusterExperimentPolicy, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterExperimentPolicyList.
+func (in *ClusterExperimentPolicyList) DeepCopy() *ClusterExperimentPolicyList {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterExperimentPolicyList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ClusterExperimentPolicyList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterExperimentPolicySpec) DeepCopyInto(out *ClusterExperimentPolicySpec) {
+	*out = *in
+	if in.AllowedPaths != nil {
+		in, out := &in.AllowedPaths, &out.AllowedPaths
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.DeniedPaths != nil {
+		in, out := &in.DeniedPaths, &out.DeniedPaths
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.MaxReplicas != nil {
+		in, out := &in.MaxReplicas, &out.MaxReplicas
+		*out = new(intstr.IntOrString)
+		**out = **in
+	}
+	if in.AllowedSourceNamespaces != nil {
+		in, out := &in.AllowedSourceNamespaces, &out.AllowedSourceNamespaces
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.AllowedImageRegistries != nil {
+		in, out := &in.AllowedImageRegistries, &out.AllowedImageRegistries
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.MaxExperimentsPerSource != nil {
+		in, out := &in.MaxExperimentsPerSource, &out.MaxExperimentsPerSource
+		*out = new(int32)
+		**out = **in
+	}
+	if in.MaxExperimentsPerNamespace != nil {
+		in, out := &in.MaxExperimentsPerNamespace, &out.MaxExperimentsPerNamespace
+		*out = new(int32)
+		**out = **in
+	}
+	if in.MaxTrafficSharePercent != nil {
+		in, out := &in.MaxTrafficSharePercent, &out.MaxTrafficSharePercent
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterExperimentPolicySpec.
+func (in *ClusterExperimentPolicySpec) DeepCopy() *ClusterExperimentPolicySpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterExperimentPolicySpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DataSourceStatus) DeepCopyInto(out *DataSourceStatus) {
+	*out = *in
+	if in.Volumes != nil {
+		in, out := &in.Volumes, &out.Volumes
+		*out = make([]VolumeSeedStatus, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DataSourceStatus.
+func (in *DataSourceStatus) DeepCopy() *DataSourceStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(DataSourceStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ExperimentDeployment) DeepCopyInto(out *ExperimentDeployment) {
 	*out = *in
@@ -94,6 +320,48 @@ func (in *ExperimentDeploymentSpec) DeepCopyInto(out *ExperimentDeploymentSpec)
 		**out = **in
 	}
 	in.OverrideSpec.DeepCopyInto(&out.OverrideSpec)
+	if in.NetworkIsolation != nil {
+		in, out := &in.NetworkIsolation, &out.NetworkIsolation
+		*out = new(NetworkIsolation)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.RolloutStrategy != nil {
+		in, out := &in.RolloutStrategy, &out.RolloutStrategy
+		*out = new(RolloutStrategyPolicy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.AnalysisTemplates != nil {
+		in, out := &in.AnalysisTemplates, &out.AnalysisTemplates
+		*out = make([]AnalysisTemplateRef, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.StatefulSet != nil {
+		in, out := &in.StatefulSet, &out.StatefulSet
+		*out = new(StatefulSetOptions)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.AbortPolicy != nil {
+		in, out := &in.AbortPolicy, &out.AbortPolicy
+		*out = new(AbortPolicy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.NotificationProviderRefs != nil {
+		in, out := &in.NotificationProviderRefs, &out.NotificationProviderRefs
+		*out = make([]LocalObjectReference, len(*in))
+		copy(*out, *in)
+	}
+	if in.RollbackTo != nil {
+		in, out := &in.RollbackTo, &out.RollbackTo
+		*out = new(int64)
+		**out = **in
+	}
+	if in.RevisionHistoryLimit != nil {
+		in, out := &in.RevisionHistoryLimit, &out.RevisionHistoryLimit
+		*out = new(int32)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExperimentDeploymentSpec.
@@ -121,6 +389,65 @@ func (in *ExperimentDeploymentStatus) DeepCopyInto(out *ExperimentDeploymentStat
 		*out = new(ExperimentResourceRef)
 		**out = **in
 	}
+	if in.ExperimentServiceRef != nil {
+		in, out := &in.ExperimentServiceRef, &out.ExperimentServiceRef
+		*out = new(ExperimentResourceRef)
+		**out = **in
+	}
+	if in.MirroredVirtualServices != nil {
+		in, out := &in.MirroredVirtualServices, &out.MirroredVirtualServices
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.NetworkPolicyRef != nil {
+		in, out := &in.NetworkPolicyRef, &out.NetworkPolicyRef
+		*out = new(ExperimentResourceRef)
+		**out = **in
+	}
+	if in.AnalysisRuns != nil {
+		in, out := &in.AnalysisRuns, &out.AnalysisRuns
+		*out = make([]AnalysisRunStatus, len(*in))
+		copy(*out, *in)
+	}
+	if in.DataSource != nil {
+		in, out := &in.DataSource, &out.DataSource
+		*out = new(DataSourceStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.HeadlessServiceRef != nil {
+		in, out := &in.HeadlessServiceRef, &out.HeadlessServiceRef
+		*out = new(ExperimentResourceRef)
+		**out = **in
+	}
+	if in.Recreation != nil {
+		in, out := &in.Recreation, &out.Recreation
+		*out = new(RecreationStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.PodHealth != nil {
+		in, out := &in.PodHealth, &out.PodHealth
+		*out = new(PodHealthStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Abort != nil {
+		in, out := &in.Abort, &out.Abort
+		*out = new(AbortStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Notifications != nil {
+		in, out := &in.Notifications, &out.Notifications
+		*out = make([]NotificationStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.History != nil {
+		in, out := &in.History, &out.History
+		*out = make([]ExperimentRevision, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExperimentDeploymentStatus.
@@ -133,6 +460,89 @@ func (in *ExperimentDeploymentStatus) DeepCopy() *ExperimentDeploymentStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExperimentReferenceGrant) DeepCopyInto(out *ExperimentReferenceGrant) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExperimentReferenceGrant.
+func (in *ExperimentReferenceGrant) DeepCopy() *ExperimentReferenceGrant {
+	if in == nil {
+		return nil
+	}
+	out := new(ExperimentReferenceGrant)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ExperimentReferenceGrant) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExperimentReferenceGrantList) DeepCopyInto(out *ExperimentReferenceGrantList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ExperimentReferenceGrant, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExperimentReferenceGrantList.
+func (in *ExperimentReferenceGrantList) DeepCopy() *ExperimentReferenceGrantList {
+	if in == nil {
+		return nil
+	}
+	out := new(ExperimentReferenceGrantList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ExperimentReferenceGrantList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExperimentReferenceGrantSpec) DeepCopyInto(out *ExperimentReferenceGrantSpec) {
+	*out = *in
+	if in.From != nil {
+		in, out := &in.From, &out.From
+		*out = make([]ReferenceGrantFrom, len(*in))
+		copy(*out, *in)
+	}
+	if in.To != nil {
+		in, out := &in.To, &out.To
+		*out = make([]ReferenceGrantTo, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExperimentReferenceGrantSpec.
+func (in *ExperimentReferenceGrantSpec) DeepCopy() *ExperimentReferenceGrantSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ExperimentReferenceGrantSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ExperimentResourceRef) DeepCopyInto(out *ExperimentResourceRef) {
 	*out = *in
@@ -148,6 +558,319 @@ func (in *ExperimentResourceRef) DeepCopy() *ExperimentResourceRef {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExperimentRevision) DeepCopyInto(out *ExperimentRevision) {
+	*out = *in
+	in.CreationTime.DeepCopyInto(&out.CreationTime)
+	in.LastAppliedTime.DeepCopyInto(&out.LastAppliedTime)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExperimentRevision.
+func (in *ExperimentRevision) DeepCopy() *ExperimentRevision {
+	if in == nil {
+		return nil
+	}
+	out := new(ExperimentRevision)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExistingVolumeSnapshot) DeepCopyInto(out *ExistingVolumeSnapshot) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExistingVolumeSnapshot.
+func (in *ExistingVolumeSnapshot) DeepCopy() *ExistingVolumeSnapshot {
+	if in == nil {
+		return nil
+	}
+	out := new(ExistingVolumeSnapshot)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LocalObjectReference) DeepCopyInto(out *LocalObjectReference) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LocalObjectReference.
+func (in *LocalObjectReference) DeepCopy() *LocalObjectReference {
+	if in == nil {
+		return nil
+	}
+	out := new(LocalObjectReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NetworkIsolation) DeepCopyInto(out *NetworkIsolation) {
+	*out = *in
+	if in.EgressAllow != nil {
+		in, out := &in.EgressAllow, &out.EgressAllow
+		*out = make([]networkingv1.NetworkPolicyEgressRule, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.EgressDenyCIDRs != nil {
+		in, out := &in.EgressDenyCIDRs, &out.EgressDenyCIDRs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.AllowDNS != nil {
+		in, out := &in.AllowDNS, &out.AllowDNS
+		*out = new(bool)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NetworkIsolation.
+func (in *NetworkIsolation) DeepCopy() *NetworkIsolation {
+	if in == nil {
+		return nil
+	}
+	out := new(NetworkIsolation)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NotificationProvider) DeepCopyInto(out *NotificationProvider) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NotificationProvider.
+func (in *NotificationProvider) DeepCopy() *NotificationProvider {
+	if in == nil {
+		return nil
+	}
+	out := new(NotificationProvider)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *NotificationProvider) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NotificationProviderList) DeepCopyInto(out *NotificationProviderList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]NotificationProvider, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NotificationProviderList.
+func (in *NotificationProviderList) DeepCopy() *NotificationProviderList {
+	if in == nil {
+		return nil
+	}
+	out := new(NotificationProviderList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *NotificationProviderList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NotificationProviderSpec) DeepCopyInto(out *NotificationProviderSpec) {
+	*out = *in
+	if in.SecretRef != nil {
+		in, out := &in.SecretRef, &out.SecretRef
+		*out = new(LocalObjectReference)
+		**out = **in
+	}
+	if in.EventTypes != nil {
+		in, out := &in.EventTypes, &out.EventTypes
+		*out = make([]NotificationEventType, len(*in))
+		copy(*out, *in)
+	}
+	if in.Retries != nil {
+		in, out := &in.Retries, &out.Retries
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Timeout != nil {
+		in, out := &in.Timeout, &out.Timeout
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NotificationProviderSpec.
+func (in *NotificationProviderSpec) DeepCopy() *NotificationProviderSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(NotificationProviderSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NotificationStatus) DeepCopyInto(out *NotificationStatus) {
+	*out = *in
+	if in.LastDeliveryTime != nil {
+		in, out := &in.LastDeliveryTime, &out.LastDeliveryTime
+		*out = (*in).DeepCopy()
+	}
+	if in.LastAttemptTime != nil {
+		in, out := &in.LastAttemptTime, &out.LastAttemptTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NotificationStatus.
+func (in *NotificationStatus) DeepCopy() *NotificationStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(NotificationStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PodHealthStatus) DeepCopyInto(out *PodHealthStatus) {
+	*out = *in
+	if in.Issues != nil {
+		in, out := &in.Issues, &out.Issues
+		*out = make([]PodIssue, len(*in))
+		copy(*out, *in)
+	}
+	if in.TimeToReady != nil {
+		in, out := &in.TimeToReady, &out.TimeToReady
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.SourceTimeToReady != nil {
+		in, out := &in.SourceTimeToReady, &out.SourceTimeToReady
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PodHealthStatus.
+func (in *PodHealthStatus) DeepCopy() *PodHealthStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(PodHealthStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PodIssue) DeepCopyInto(out *PodIssue) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PodIssue.
+func (in *PodIssue) DeepCopy() *PodIssue {
+	if in == nil {
+		return nil
+	}
+	out := new(PodIssue)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RecreationStatus) DeepCopyInto(out *RecreationStatus) {
+	*out = *in
+	if in.StartTime != nil {
+		in, out := &in.StartTime, &out.StartTime
+		*out = (*in).DeepCopy()
+	}
+	if in.CompletionTime != nil {
+		in, out := &in.CompletionTime, &out.CompletionTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RecreationStatus.
+func (in *RecreationStatus) DeepCopy() *RecreationStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(RecreationStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReferenceGrantFrom) DeepCopyInto(out *ReferenceGrantFrom) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReferenceGrantFrom.
+func (in *ReferenceGrantFrom) DeepCopy() *ReferenceGrantFrom {
+	if in == nil {
+		return nil
+	}
+	out := new(ReferenceGrantFrom)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReferenceGrantTo) DeepCopyInto(out *ReferenceGrantTo) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReferenceGrantTo.
+func (in *ReferenceGrantTo) DeepCopy() *ReferenceGrantTo {
+	if in == nil {
+		return nil
+	}
+	out := new(ReferenceGrantTo)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RolloutStrategyPolicy) DeepCopyInto(out *RolloutStrategyPolicy) {
+	*out = *in
+	if in.Keep != nil {
+		in, out := &in.Keep, &out.Keep
+		*out = make([]RolloutStrategyField, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RolloutStrategyPolicy.
+func (in *RolloutStrategyPolicy) DeepCopy() *RolloutStrategyPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(RolloutStrategyPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SourceRef) DeepCopyInto(out *SourceRef) {
 	*out = *in
@@ -162,3 +885,58 @@ func (in *SourceRef) DeepCopy() *SourceRef {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StatefulSetDataSource) DeepCopyInto(out *StatefulSetDataSource) {
+	*out = *in
+	if in.ExistingSnapshots != nil {
+		in, out := &in.ExistingSnapshots, &out.ExistingSnapshots
+		*out = make([]ExistingVolumeSnapshot, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StatefulSetDataSource.
+func (in *StatefulSetDataSource) DeepCopy() *StatefulSetDataSource {
+	if in == nil {
+		return nil
+	}
+	out := new(StatefulSetDataSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StatefulSetOptions) DeepCopyInto(out *StatefulSetOptions) {
+	*out = *in
+	if in.DataSource != nil {
+		in, out := &in.DataSource, &out.DataSource
+		*out = new(StatefulSetDataSource)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StatefulSetOptions.
+func (in *StatefulSetOptions) DeepCopy() *StatefulSetOptions {
+	if in == nil {
+		return nil
+	}
+	out := new(StatefulSetOptions)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VolumeSeedStatus) DeepCopyInto(out *VolumeSeedStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VolumeSeedStatus.
+func (in *VolumeSeedStatus) DeepCopy() *VolumeSeedStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(VolumeSeedStatus)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -17,23 +17,32 @@ limitations under the License.
 package main
 
 import (
+	"context"
 	"crypto/tls"
 	"flag"
+	"fmt"
+	"maps"
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
 	// to ensure that exec-entrypoint and run can make use of them.
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
 
 	rolloutsv1alpha1 "github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/cache"
 	"sigs.k8s.io/controller-runtime/pkg/certwatcher"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 	"sigs.k8s.io/controller-runtime/pkg/metrics/filters"
@@ -41,7 +50,12 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
 
 	experimentcontrollerv1alpha1 "experimentcontroller.example.com/experiment-deployment/api/v1alpha1"
+	"experimentcontroller.example.com/experiment-deployment/internal/config"
 	"experimentcontroller.example.com/experiment-deployment/internal/controller"
+	"experimentcontroller.example.com/experiment-deployment/internal/notification"
+	"experimentcontroller.example.com/experiment-deployment/internal/sharding"
+	"experimentcontroller.example.com/experiment-deployment/internal/tracing"
+	webhookv1alpha1 "experimentcontroller.example.com/experiment-deployment/internal/webhook/v1alpha1"
 	// +kubebuilder:scaffold:imports
 )
 
@@ -68,6 +82,16 @@ func main() {
 	var secureMetrics bool
 	var enableHTTP2 bool
 	var watchNamespaces string
+	var namespaceSelector, experimentSelector string
+	var shardCount, shardID int
+	var shardLeaseNamespace string
+	var enableWebhooks bool
+	var maxExperimentsPerSource, maxExperimentsPerNamespace, maxTrafficSharePercent int
+	var cloudEventsSink, cloudEventsMode string
+	var otlpEndpoint string
+	var otlpInsecure bool
+	var tracingSampleRatio float64
+	var configFile string
 	var tlsOpts []func(*tls.Config)
 	flag.StringVar(&metricsAddr, "metrics-bind-address", "0", "The address the metrics endpoint binds to. "+
 		"Use :8443 for HTTPS or :8080 for HTTP, or leave as 0 to disable the metrics service.")
@@ -88,6 +112,43 @@ func main() {
 		"If set, HTTP/2 will be enabled for the metrics and webhook servers")
 	flag.StringVar(&watchNamespaces, "watch-namespaces", "",
 		"Comma-separated list of namespaces to watch. If empty, watches all namespaces (cluster-scoped).")
+	flag.StringVar(&namespaceSelector, "namespace-selector", "",
+		"Label selector of the namespaces whose experiments are handled, e.g. experiments=enabled. Namespaces are "+
+			"picked up and dropped as their labels change. Combined with --watch-namespaces, it selects among those. "+
+			"If empty, the experiments of all watched namespaces are handled.")
+	flag.StringVar(&experimentSelector, "experiment-selector", "",
+		"Label selector of the ExperimentDeployments handled, e.g. controller-version=canary. Only the matching "+
+			"experiments are cached. If empty, all experiments are handled.")
+	flag.IntVar(&shardCount, "shard-count", 1,
+		"Number of shards the namespaces are hashed to, each handled by one replica. 1 disables sharding.")
+	flag.IntVar(&shardID, "shard-id", -1,
+		"Shard handled by this replica, between 0 and --shard-count - 1. If unset, the replicas share the shards "+
+			"with Leases, rebalanced as replicas come and go, and --leader-elect is ignored.")
+	flag.StringVar(&shardLeaseNamespace, "shard-lease-namespace", "",
+		"Namespace of the shard Leases. Defaults to the namespace of the controller pod.")
+	flag.BoolVar(&enableWebhooks, "enable-webhooks", false,
+		"If set, the admission webhooks are served. Requires a serving certificate, see --webhook-cert-path.")
+	flag.IntVar(&maxExperimentsPerSource, "max-experiments-per-source", 0,
+		"Maximum number of experiments running at the same time per source workload. 0 means unlimited.")
+	flag.IntVar(&maxExperimentsPerNamespace, "max-experiments-per-namespace", 0,
+		"Maximum number of experiments running at the same time per namespace. 0 means unlimited.")
+	flag.IntVar(&maxTrafficSharePercent, "max-experiment-traffic-share", 0,
+		"Maximum percentage of the pods behind the Services of a source workload that may belong to Shared experiments. "+
+			"0 means unlimited.")
+	flag.StringVar(&cloudEventsSink, "cloudevents-sink", "",
+		"The URL CloudEvents about experiment lifecycle transitions are posted to. Namespaces can set their own "+
+			"sink with the experimentcontroller.example.com/cloudevents-sink annotation. If empty, only those are used.")
+	flag.StringVar(&cloudEventsMode, "cloudevents-mode", string(notification.CloudEventsModeBinary),
+		"The HTTP content mode of CloudEvents, binary or structured.")
+	flag.StringVar(&otlpEndpoint, "otlp-endpoint", "",
+		"The host:port of the OTLP gRPC receiver reconcile traces are exported to. If empty, tracing is disabled.")
+	flag.BoolVar(&otlpInsecure, "otlp-insecure", false,
+		"If set, traces are exported to the OTLP receiver without TLS.")
+	flag.Float64Var(&tracingSampleRatio, "tracing-sample-ratio", 1,
+		"The fraction of reconciles traced, between 0 and 1.")
+	flag.StringVar(&configFile, "config", "",
+		"The path of the controller config file, usually mounted from a ConfigMap. The file is reloaded when it "+
+			"changes. If empty, the defaults are used.")
 	opts := zap.Options{
 		Development: true,
 	}
@@ -96,6 +157,72 @@ func main() {
 
 	ctrl.SetLogger(zap.New(zap.UseFlagOptions(&opts)))
 
+	switch notification.CloudEventsMode(cloudEventsMode) {
+	case notification.CloudEventsModeBinary, notification.CloudEventsModeStructured:
+	default:
+		setupLog.Error(fmt.Errorf("unsupported mode %q", cloudEventsMode), "invalid --cloudevents-mode")
+		os.Exit(1)
+	}
+
+	if maxExperimentsPerSource < 0 || maxExperimentsPerNamespace < 0 || maxTrafficSharePercent < 0 || maxTrafficSharePercent > 100 {
+		setupLog.Error(nil, "Invalid concurrency limits, the experiment limits must not be negative "+
+			"and --max-experiment-traffic-share must be between 0 and 100")
+		os.Exit(1)
+	}
+
+	var selector labels.Selector
+	if namespaceSelector != "" {
+		var err error
+		if selector, err = labels.Parse(namespaceSelector); err != nil {
+			setupLog.Error(err, "invalid --namespace-selector")
+			os.Exit(1)
+		}
+	}
+
+	var experimentLabels labels.Selector
+	if experimentSelector != "" {
+		var err error
+		if experimentLabels, err = labels.Parse(experimentSelector); err != nil {
+			setupLog.Error(err, "invalid --experiment-selector")
+			os.Exit(1)
+		}
+	}
+
+	if shardCount < 1 || shardID >= shardCount || shardID < -1 {
+		setupLog.Error(nil, "Invalid sharding, --shard-count must be at least 1 and --shard-id between 0 and --shard-count - 1")
+		os.Exit(1)
+	}
+	leaseSharding := shardCount > 1 && shardID < 0
+	if leaseSharding {
+		if shardLeaseNamespace == "" {
+			namespace, err := os.ReadFile("/var/run/secrets/kubernetes.io/serviceaccount/namespace")
+			if err != nil {
+				setupLog.Error(err, "unable to find the namespace of the shard Leases, set --shard-lease-namespace")
+				os.Exit(1)
+			}
+			shardLeaseNamespace = strings.TrimSpace(string(namespace))
+		}
+		if enableLeaderElection {
+			setupLog.Info("Disabling leader election, the replicas share the shards with Leases")
+			enableLeaderElection = false
+		}
+	}
+
+	// Replicas pinned to a shard or restricted to some experiments elect a leader among the
+	// replicas handling the same experiments
+	leaderElectionID := sharding.LeaderElectionID("73f42a3f.experimentcontroller.example.com",
+		shardCount, shardID, experimentSelector)
+
+	controllerConfig := config.DefaultControllerConfig()
+	if configFile != "" {
+		var err error
+		if controllerConfig, err = config.Load(configFile); err != nil {
+			setupLog.Error(err, "unable to load the controller config")
+			os.Exit(1)
+		}
+	}
+	configStore := config.NewStore(controllerConfig)
+
 	setupLog.Info("Starting experiment controller manager")
 
 	// if the enable-http2 flag is false (the default), http/2 should be disabled
@@ -213,7 +340,7 @@ func main() {
 			WebhookServer:          webhookServer,
 			HealthProbeBindAddress: probeAddr,
 			LeaderElection:         enableLeaderElection,
-			LeaderElectionID:       "73f42a3f.experimentcontroller.example.com",
+			LeaderElectionID:       leaderElectionID,
 			Cache: cache.Options{
 				DefaultNamespaces: namespaceMap,
 			},
@@ -228,25 +355,146 @@ func main() {
 			WebhookServer:          webhookServer,
 			HealthProbeBindAddress: probeAddr,
 			LeaderElection:         enableLeaderElection,
-			LeaderElectionID:       "73f42a3f.experimentcontroller.example.com",
+			LeaderElectionID:       leaderElectionID,
+		}
+	}
+
+	// Secrets of NotificationProviders and the CloudEvents sinks of namespaces are read on demand,
+	// so the controller needs no access to list and watch all Secrets and Namespaces. Namespaces
+	// are watched, and so cached, when they are selected by label.
+	uncached := []client.Object{&corev1.Secret{}}
+	if selector == nil {
+		uncached = append(uncached, &corev1.Namespace{})
+	}
+	cacheConfig.Client = client.Options{
+		Cache: &client.CacheOptions{DisableFor: uncached},
+	}
+
+	// Only the workloads created by the controller and their pods are cached, source workloads and
+	// their pods are read from the API server and only the metadata of the workloads is cached to
+	// watch them
+	managedBy := controller.ManagedBySelector(controllerConfig.LabelDomain)
+	cacheConfig.Cache.ByObject = map[client.Object]cache.ByObject{
+		&appsv1.Deployment{}:  {Label: managedBy},
+		&appsv1.StatefulSet{}: {Label: managedBy},
+		&corev1.Pod{}:         {Label: controller.ExperimentPodSelector(controllerConfig.LabelDomain)},
+	}
+	// The cache fails on kinds missing from the cluster, Rollouts are only filtered when installed
+	cacheConfig.NewCache = func(restConfig *rest.Config, opts cache.Options) (cache.Cache, error) {
+		rolloutGVK := rolloutsv1alpha1.SchemeGroupVersion.WithKind("Rollout")
+		if _, err := opts.Mapper.RESTMapping(rolloutGVK.GroupKind(), rolloutGVK.Version); err == nil {
+			opts.ByObject = maps.Clone(opts.ByObject)
+			opts.ByObject[&rolloutsv1alpha1.Rollout{}] = cache.ByObject{Label: managedBy}
 		}
+		return cache.New(restConfig, opts)
+	}
+	// Only the experiments handled by this replica are cached
+	if experimentLabels != nil {
+		cacheConfig.Cache.ByObject[&experimentcontrollerv1alpha1.ExperimentDeployment{}] = cache.ByObject{Label: experimentLabels}
 	}
 
+	tracerProvider, shutdownTracing, err := tracing.NewTracerProvider(context.Background(), tracing.Options{
+		Endpoint:    otlpEndpoint,
+		Insecure:    otlpInsecure,
+		SampleRatio: tracingSampleRatio,
+	})
+	if err != nil {
+		setupLog.Error(err, "unable to set up tracing")
+		os.Exit(1)
+	}
+	tracing.SetGlobal(tracerProvider)
+
 	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), cacheConfig)
 	if err != nil {
 		setupLog.Error(err, "unable to start manager")
 		os.Exit(1)
 	}
 
+	// The metadata of source workloads is cached apart, the workloads of the manager cache are
+	// filtered by label
+	sourceCache, err := cache.New(mgr.GetConfig(), cache.Options{
+		Scheme:            mgr.GetScheme(),
+		Mapper:            mgr.GetRESTMapper(),
+		DefaultNamespaces: cacheConfig.Cache.DefaultNamespaces,
+	})
+	if err != nil {
+		setupLog.Error(err, "unable to create the source workload cache")
+		os.Exit(1)
+	}
+	if err := mgr.Add(sourceCache); err != nil {
+		setupLog.Error(err, "unable to add the source workload cache to manager")
+		os.Exit(1)
+	}
+
+	var shardOwner sharding.Owner = sharding.Static(shardID)
+	if leaseSharding {
+		// The Leases are read without the cache, which may not cover the controller namespace
+		leaseClient, err := client.New(mgr.GetConfig(), client.Options{Scheme: mgr.GetScheme()})
+		if err != nil {
+			setupLog.Error(err, "unable to create the shard Lease client")
+			os.Exit(1)
+		}
+		identity, err := os.Hostname()
+		if err != nil {
+			setupLog.Error(err, "unable to get the hostname for the shard Leases")
+			os.Exit(1)
+		}
+		leaseOwner := sharding.NewLeaseOwner(leaseClient, sharding.LeaseOptions{
+			Namespace: shardLeaseNamespace,
+			Name:      "experiment-controller",
+			Identity:  identity,
+			Count:     shardCount,
+		})
+		if err := mgr.Add(leaseOwner); err != nil {
+			setupLog.Error(err, "unable to add shard Lease owner to manager")
+			os.Exit(1)
+		}
+		shardOwner = leaseOwner
+	}
+
 	if err = (&controller.ExperimentDeploymentReconciler{
-		Client: mgr.GetClient(),
-		Scheme: mgr.GetScheme(),
+		Client:           mgr.GetClient(),
+		Scheme:           mgr.GetScheme(),
+		AuthorizeAuthors: enableWebhooks,
+		ConcurrencyLimits: controller.ConcurrencyLimits{
+			MaxExperimentsPerSource:    int32(maxExperimentsPerSource),
+			MaxExperimentsPerNamespace: int32(maxExperimentsPerNamespace),
+			MaxTrafficSharePercent:     int32(maxTrafficSharePercent),
+		},
+		CloudEvents: controller.CloudEventsConfig{
+			Sink: cloudEventsSink,
+			Mode: notification.CloudEventsMode(cloudEventsMode),
+		},
+		TracerProvider:     tracerProvider,
+		Config:             configStore,
+		APIReader:          mgr.GetAPIReader(),
+		SourceCache:        sourceCache,
+		NamespaceSelector:  selector,
+		ExperimentSelector: experimentLabels,
+		Sharding: controller.ShardingConfig{
+			Count: shardCount,
+			Owner: shardOwner,
+		},
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "ExperimentDeployment")
 		os.Exit(1)
 	}
+	if enableWebhooks {
+		if err = webhookv1alpha1.SetupExperimentDeploymentWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "ExperimentDeployment")
+			os.Exit(1)
+		}
+	}
 	// +kubebuilder:scaffold:builder
 
+	if configFile != "" {
+		setupLog.Info("Adding controller config watcher to manager", "config", configFile)
+		if err := mgr.Add(config.NewWatcher(configFile, configStore, config.DefaultReloadInterval)); err != nil {
+			setupLog.Error(err, "unable to add controller config watcher to manager")
+			os.Exit(1)
+		}
+	}
+
 	if metricsCertWatcher != nil {
 		setupLog.Info("Adding metrics certificate watcher to manager")
 		if err := mgr.Add(metricsCertWatcher); err != nil {
@@ -277,4 +525,11 @@ func main() {
 		setupLog.Error(err, "problem running manager")
 		os.Exit(1)
 	}
+
+	// Export the spans of the last reconciles before exiting
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := shutdownTracing(shutdownCtx); err != nil {
+		setupLog.Error(err, "unable to flush traces")
+	}
 }
@@ -0,0 +1,270 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package config loads the versioned configuration file of the controller, usually mounted from
+// a ConfigMap, and reloads it when it changes.
+package config
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"golang.org/x/time/rate"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// APIVersion is the version of the configuration file format
+	APIVersion = "config.experimentcontroller.example.com/v1alpha1"
+	// Kind is the kind of the configuration file
+	Kind = "ControllerConfig"
+
+	// DefaultLabelDomain prefixes the labels the controller sets on the objects it creates
+	DefaultLabelDomain = "experiment-controller.example.com"
+	// DefaultFinalizer is the finalizer the controller adds to ExperimentDeployments
+	DefaultFinalizer = "experimentdeployments.experimentcontroller.example.com/finalizer"
+)
+
+// Feature names a behaviour of the controller that can be switched off
+type Feature string
+
+const (
+	// FeatureNotifications delivers lifecycle notifications to NotificationProviders
+	FeatureNotifications Feature = "Notifications"
+	// FeatureCloudEvents publishes CloudEvents about lifecycle transitions
+	FeatureCloudEvents Feature = "CloudEvents"
+	// FeatureRevisionHistory records the overrides of experiments in ControllerRevisions
+	FeatureRevisionHistory Feature = "RevisionHistory"
+)
+
+// features are the known features, all enabled by default
+var features = []Feature{FeatureNotifications, FeatureCloudEvents, FeatureRevisionHistory}
+
+// ControllerConfig is the configuration file of the controller
+type ControllerConfig struct {
+	metav1.TypeMeta `json:",inline"`
+
+	// Requeue is the requeue and backoff policy of reconciles
+	Requeue RequeuePolicy `json:"requeue"`
+	// Defaults are the values of the experiments that do not set them
+	Defaults ExperimentDefaults `json:"defaults"`
+	// LabelDomain prefixes the labels the controller sets on the objects it creates. Changing it
+	// orphans the objects created before.
+	LabelDomain string `json:"labelDomain"`
+	// Finalizer is the finalizer the controller adds to ExperimentDeployments. Changing it leaves
+	// the previous finalizer on existing experiments.
+	Finalizer string `json:"finalizer"`
+	// MaxConcurrentReconciles is the number of experiments reconciled at the same time
+	MaxConcurrentReconciles int `json:"maxConcurrentReconciles"`
+	// RateLimiter limits the rate of reconciles across all experiments
+	RateLimiter RateLimiter `json:"rateLimiter"`
+	// Features switch behaviours of the controller on or off. Features not listed are enabled.
+	Features map[Feature]bool `json:"features,omitempty"`
+	// NetworkIsolation configures the NetworkPolicies isolating experiment pods
+	NetworkIsolation NetworkIsolation `json:"networkIsolation"`
+}
+
+// RequeuePolicy configures when experiments are reconciled again
+type RequeuePolicy struct {
+	// NotReadyInterval is the delay before checking again on an experiment workload that is not ready
+	NotReadyInterval metav1.Duration `json:"notReadyInterval"`
+	// WaitingInterval is the delay before reconciling again an experiment waiting for its source,
+	// a reference grant or a concurrency slot
+	WaitingInterval metav1.Duration `json:"waitingInterval"`
+	// Backoff is the backoff of the reconciles of an experiment that fail
+	Backoff Backoff `json:"backoff"`
+}
+
+// Backoff is an exponential backoff
+type Backoff struct {
+	// BaseDelay is the delay after the first failure, doubled after each further failure
+	BaseDelay metav1.Duration `json:"baseDelay"`
+	// MaxDelay caps the delay
+	MaxDelay metav1.Duration `json:"maxDelay"`
+}
+
+// ExperimentDefaults are the values of the experiments that do not set them
+type ExperimentDefaults struct {
+	// Replicas is the number of replicas of experiments without spec.replicas
+	Replicas int32 `json:"replicas"`
+}
+
+// NetworkIsolation configures the NetworkPolicies isolating experiment pods
+type NetworkIsolation struct {
+	// DNSNamespace is the namespace of the cluster DNS pods experiment pods may query when their
+	// egress is restricted
+	DNSNamespace string `json:"dnsNamespace"`
+	// DNSPodSelector is the label selector of the cluster DNS pods, like k8s-app=kube-dns
+	DNSPodSelector string `json:"dnsPodSelector"`
+}
+
+// RateLimiter is a token bucket limiting the rate of reconciles
+type RateLimiter struct {
+	// QPS is the sustained number of reconciles per second
+	QPS float64 `json:"qps"`
+	// Burst is the number of reconciles allowed above QPS at once
+	Burst int `json:"burst"`
+}
+
+// DefaultControllerConfig returns the configuration of the controller without a configuration file
+func DefaultControllerConfig() *ControllerConfig {
+	return &ControllerConfig{
+		TypeMeta: metav1.TypeMeta{APIVersion: APIVersion, Kind: Kind},
+		Requeue: RequeuePolicy{
+			NotReadyInterval: metav1.Duration{Duration: 15 * time.Second},
+			WaitingInterval:  metav1.Duration{Duration: 30 * time.Second},
+			Backoff: Backoff{
+				BaseDelay: metav1.Duration{Duration: 5 * time.Millisecond},
+				MaxDelay:  metav1.Duration{Duration: 1000 * time.Second},
+			},
+		},
+		Defaults:                ExperimentDefaults{Replicas: 1},
+		LabelDomain:             DefaultLabelDomain,
+		Finalizer:               DefaultFinalizer,
+		MaxConcurrentReconciles: 1,
+		RateLimiter:             RateLimiter{QPS: 10, Burst: 100},
+		NetworkIsolation: NetworkIsolation{
+			DNSNamespace:   "kube-system",
+			DNSPodSelector: "k8s-app=kube-dns",
+		},
+	}
+}
+
+// Parse decodes and validates a configuration file. Fields it does not set keep their defaults;
+// unknown fields are rejected.
+func Parse(data []byte) (*ControllerConfig, error) {
+	cfg := DefaultControllerConfig()
+	cfg.TypeMeta = metav1.TypeMeta{}
+	if err := yaml.UnmarshalStrict(data, cfg); err != nil {
+		return nil, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// Load reads, decodes and validates the configuration file at the path
+func Load(path string) (*ControllerConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read controller config: %w", err)
+	}
+	cfg, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid controller config %s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// Validate reports all the invalid fields of the configuration
+func (c *ControllerConfig) Validate() error {
+	var allErrs field.ErrorList
+	if c.APIVersion != APIVersion {
+		allErrs = append(allErrs, field.NotSupported(field.NewPath("apiVersion"), c.APIVersion, []string{APIVersion}))
+	}
+	if c.Kind != Kind {
+		allErrs = append(allErrs, field.NotSupported(field.NewPath("kind"), c.Kind, []string{Kind}))
+	}
+
+	requeuePath := field.NewPath("requeue")
+	allErrs = append(allErrs, validatePositive(requeuePath.Child("notReadyInterval"), c.Requeue.NotReadyInterval)...)
+	allErrs = append(allErrs, validatePositive(requeuePath.Child("waitingInterval"), c.Requeue.WaitingInterval)...)
+	backoffPath := requeuePath.Child("backoff")
+	allErrs = append(allErrs, validatePositive(backoffPath.Child("baseDelay"), c.Requeue.Backoff.BaseDelay)...)
+	if c.Requeue.Backoff.MaxDelay.Duration < c.Requeue.Backoff.BaseDelay.Duration {
+		allErrs = append(allErrs, field.Invalid(backoffPath.Child("maxDelay"), c.Requeue.Backoff.MaxDelay.Duration.String(),
+			"must not be less than baseDelay"))
+	}
+
+	if c.Defaults.Replicas < 0 {
+		allErrs = append(allErrs, field.Invalid(field.NewPath("defaults", "replicas"), c.Defaults.Replicas, "must not be negative"))
+	}
+	for _, msg := range validation.IsDNS1123Subdomain(c.LabelDomain) {
+		allErrs = append(allErrs, field.Invalid(field.NewPath("labelDomain"), c.LabelDomain, msg))
+	}
+	if !strings.Contains(c.Finalizer, "/") {
+		allErrs = append(allErrs, field.Invalid(field.NewPath("finalizer"), c.Finalizer, "must be prefixed with a domain, like example.com/name"))
+	} else {
+		for _, msg := range validation.IsQualifiedName(c.Finalizer) {
+			allErrs = append(allErrs, field.Invalid(field.NewPath("finalizer"), c.Finalizer, msg))
+		}
+	}
+	if c.MaxConcurrentReconciles < 1 {
+		allErrs = append(allErrs, field.Invalid(field.NewPath("maxConcurrentReconciles"), c.MaxConcurrentReconciles, "must be at least 1"))
+	}
+
+	rateLimiterPath := field.NewPath("rateLimiter")
+	if c.RateLimiter.QPS <= 0 {
+		allErrs = append(allErrs, field.Invalid(rateLimiterPath.Child("qps"), c.RateLimiter.QPS, "must be positive"))
+	}
+	if c.RateLimiter.Burst < 1 {
+		allErrs = append(allErrs, field.Invalid(rateLimiterPath.Child("burst"), c.RateLimiter.Burst, "must be at least 1"))
+	}
+
+	networkIsolationPath := field.NewPath("networkIsolation")
+	for _, msg := range validation.IsDNS1123Label(c.NetworkIsolation.DNSNamespace) {
+		allErrs = append(allErrs, field.Invalid(networkIsolationPath.Child("dnsNamespace"), c.NetworkIsolation.DNSNamespace, msg))
+	}
+	if _, err := metav1.ParseToLabelSelector(c.NetworkIsolation.DNSPodSelector); err != nil {
+		allErrs = append(allErrs, field.Invalid(networkIsolationPath.Child("dnsPodSelector"), c.NetworkIsolation.DNSPodSelector, err.Error()))
+	}
+
+	supported := make([]string, 0, len(features))
+	for _, feature := range features {
+		supported = append(supported, string(feature))
+	}
+	for feature := range c.Features {
+		if !slices.Contains(features, feature) {
+			allErrs = append(allErrs, field.NotSupported(field.NewPath("features").Key(string(feature)), feature, supported))
+		}
+	}
+	return allErrs.ToAggregate()
+}
+
+// validatePositive checks that a duration is positive
+func validatePositive(path *field.Path, duration metav1.Duration) field.ErrorList {
+	if duration.Duration <= 0 {
+		return field.ErrorList{field.Invalid(path, duration.Duration.String(), "must be positive")}
+	}
+	return nil
+}
+
+// Enabled reports whether a feature is enabled
+func (c *ControllerConfig) Enabled(feature Feature) bool {
+	enabled, ok := c.Features[feature]
+	return !ok || enabled
+}
+
+// NewRateLimiter returns the rate limiter of the reconciles: the backoff of each experiment,
+// capped by the rate limit across all experiments
+func (c *ControllerConfig) NewRateLimiter() workqueue.TypedRateLimiter[reconcile.Request] {
+	return workqueue.NewTypedMaxOfRateLimiter(
+		workqueue.NewTypedItemExponentialFailureRateLimiter[reconcile.Request](
+			c.Requeue.Backoff.BaseDelay.Duration, c.Requeue.Backoff.MaxDelay.Duration),
+		&workqueue.TypedBucketRateLimiter[reconcile.Request]{
+			Limiter: rate.NewLimiter(rate.Limit(c.RateLimiter.QPS), c.RateLimiter.Burst),
+		},
+	)
+}
@@ -0,0 +1,153 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestConfig(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Config Suite")
+}
+
+var _ = Describe("Controller config", func() {
+	var path string
+
+	BeforeEach(func() {
+		path = filepath.Join(GinkgoT().TempDir(), "config.yaml")
+	})
+
+	write := func(content string) {
+		Expect(os.WriteFile(path, []byte(content), 0o644)).To(Succeed())
+	}
+
+	It("should default the fields the file does not set", func() {
+		write(`apiVersion: config.experimentcontroller.example.com/v1alpha1
+kind: ControllerConfig
+requeue:
+  notReadyInterval: 5s
+maxConcurrentReconciles: 4
+features:
+  CloudEvents: false
+`)
+		cfg, err := Load(path)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cfg.Requeue.NotReadyInterval.Duration).To(Equal(5 * time.Second))
+		Expect(cfg.Requeue.WaitingInterval.Duration).To(Equal(30 * time.Second))
+		Expect(cfg.Defaults.Replicas).To(Equal(int32(1)))
+		Expect(cfg.LabelDomain).To(Equal(DefaultLabelDomain))
+		Expect(cfg.Finalizer).To(Equal(DefaultFinalizer))
+		Expect(cfg.MaxConcurrentReconciles).To(Equal(4))
+		Expect(cfg.Enabled(FeatureCloudEvents)).To(BeFalse())
+		Expect(cfg.Enabled(FeatureNotifications)).To(BeTrue())
+		Expect(cfg.NetworkIsolation.DNSNamespace).To(Equal("kube-system"))
+		Expect(cfg.NetworkIsolation.DNSPodSelector).To(Equal("k8s-app=kube-dns"))
+	})
+
+	It("should report every invalid field", func() {
+		write(`apiVersion: v1
+kind: ControllerConfig
+requeue:
+  notReadyInterval: 0s
+  backoff:
+    baseDelay: 1s
+    maxDelay: 10ms
+labelDomain: Not_A_Domain
+finalizer: no-domain
+maxConcurrentReconciles: 0
+features:
+  Teleport: true
+networkIsolation:
+  dnsNamespace: Kube_System
+  dnsPodSelector: "k8s-app in (kube-dns"
+`)
+		_, err := Load(path)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring(path))
+		Expect(err.Error()).To(ContainSubstring(`apiVersion: Unsupported value: "v1"`))
+		Expect(err.Error()).To(ContainSubstring("requeue.notReadyInterval: Invalid value: \"0s\": must be positive"))
+		Expect(err.Error()).To(ContainSubstring("requeue.backoff.maxDelay: Invalid value: \"10ms\": must not be less than baseDelay"))
+		Expect(err.Error()).To(ContainSubstring("labelDomain: Invalid value"))
+		Expect(err.Error()).To(ContainSubstring("finalizer: Invalid value: \"no-domain\": must be prefixed with a domain"))
+		Expect(err.Error()).To(ContainSubstring("maxConcurrentReconciles: Invalid value: 0: must be at least 1"))
+		Expect(err.Error()).To(ContainSubstring(`features[Teleport]: Unsupported value: "Teleport"`))
+		Expect(err.Error()).To(ContainSubstring(`networkIsolation.dnsNamespace: Invalid value: "Kube_System"`))
+		Expect(err.Error()).To(ContainSubstring(`networkIsolation.dnsPodSelector: Invalid value: "k8s-app in (kube-dns"`))
+	})
+
+	It("should reject unknown fields and a missing file", func() {
+		write(`apiVersion: config.experimentcontroller.example.com/v1alpha1
+kind: ControllerConfig
+requeueInterval: 5s
+`)
+		_, err := Load(path)
+		Expect(err).To(MatchError(ContainSubstring(`unknown field "requeueInterval"`)))
+
+		_, err = Load(filepath.Join(filepath.Dir(path), "missing.yaml"))
+		Expect(err).To(MatchError(ContainSubstring("failed to read controller config")))
+	})
+
+	It("should reload changes and keep the startup-only fields and the last valid config", func() {
+		ctx := context.Background()
+		write(`apiVersion: config.experimentcontroller.example.com/v1alpha1
+kind: ControllerConfig
+`)
+		initial, err := Load(path)
+		Expect(err).NotTo(HaveOccurred())
+		store := NewStore(initial)
+		watcher := NewWatcher(path, store, time.Second)
+		Expect(watcher.Reload(ctx)).To(Succeed())
+		Expect(store.Get()).To(BeIdenticalTo(initial))
+
+		write(`apiVersion: config.experimentcontroller.example.com/v1alpha1
+kind: ControllerConfig
+requeue:
+  waitingInterval: 1m
+defaults:
+  replicas: 2
+labelDomain: other.example.com
+maxConcurrentReconciles: 8
+`)
+		Expect(watcher.Reload(ctx)).To(Succeed())
+		Expect(store.Get().Requeue.WaitingInterval.Duration).To(Equal(time.Minute))
+		Expect(store.Get().Defaults.Replicas).To(Equal(int32(2)))
+		Expect(store.Get().LabelDomain).To(Equal(DefaultLabelDomain))
+		Expect(store.Get().MaxConcurrentReconciles).To(Equal(1))
+
+		write(`apiVersion: config.experimentcontroller.example.com/v1alpha1
+kind: ControllerConfig
+defaults:
+  replicas: -1
+`)
+		Expect(watcher.Reload(ctx)).To(MatchError(ContainSubstring("defaults.replicas")))
+		Expect(store.Get().Defaults.Replicas).To(Equal(int32(2)))
+	})
+
+	It("should hold the defaults without a store", func() {
+		var store *Store
+		Expect(store.Get()).To(Equal(DefaultControllerConfig()))
+		Expect(DefaultControllerConfig().Validate()).To(Succeed())
+	})
+})
@@ -0,0 +1,147 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"reflect"
+	"sync/atomic"
+	"time"
+
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// DefaultReloadInterval is how often the configuration file is checked for changes
+const DefaultReloadInterval = 10 * time.Second
+
+// Store holds the current configuration of the controller. A nil store holds the defaults.
+type Store struct {
+	current atomic.Pointer[ControllerConfig]
+}
+
+// NewStore returns a store holding the configuration
+func NewStore(cfg *ControllerConfig) *Store {
+	s := &Store{}
+	s.current.Store(cfg)
+	return s
+}
+
+// Get returns the current configuration. It must not be modified.
+func (s *Store) Get() *ControllerConfig {
+	if s == nil {
+		return DefaultControllerConfig()
+	}
+	if cfg := s.current.Load(); cfg != nil {
+		return cfg
+	}
+	return DefaultControllerConfig()
+}
+
+// Watcher reloads the configuration file into a store when it changes. It polls the file, since
+// the kubelet updates mounted ConfigMaps by swapping symlinks.
+type Watcher struct {
+	path     string
+	store    *Store
+	interval time.Duration
+	last     []byte
+}
+
+// NewWatcher returns a watcher reloading the configuration file at the path into the store
+func NewWatcher(path string, store *Store, interval time.Duration) *Watcher {
+	return &Watcher{path: path, store: store, interval: interval}
+}
+
+// Start polls the configuration file until the context is done
+func (w *Watcher) Start(ctx context.Context) error {
+	log := logf.FromContext(ctx).WithName("config")
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			if err := w.Reload(ctx); err != nil {
+				log.Error(err, "Failed to reload the controller config, keeping the current one", "path", w.path)
+			}
+		}
+	}
+}
+
+// NeedLeaderElection reports that every replica reloads its configuration
+func (w *Watcher) NeedLeaderElection() bool {
+	return false
+}
+
+// Reload loads the configuration file into the store if its content changed. An invalid file
+// leaves the current configuration in place. Fields only read at startup keep their values.
+func (w *Watcher) Reload(ctx context.Context) error {
+	data, err := os.ReadFile(w.path)
+	if err != nil {
+		return fmt.Errorf("failed to read controller config: %w", err)
+	}
+	if w.last != nil && bytes.Equal(data, w.last) {
+		return nil
+	}
+	next, err := Parse(data)
+	if err != nil {
+		return fmt.Errorf("invalid controller config %s: %w", w.path, err)
+	}
+	w.last = data
+
+	log := logf.FromContext(ctx).WithName("config")
+	current := w.store.Get()
+	if ignored := keepStartupFields(next, current); len(ignored) > 0 {
+		log.Info("Changes to the controller config take effect on restart", "fields", ignored)
+	}
+	if reflect.DeepEqual(next, current) {
+		return nil
+	}
+	w.store.current.Store(next)
+	log.Info("Reloaded the controller config", "path", w.path)
+	return nil
+}
+
+// keepStartupFields copies the fields only read at startup from the current configuration to
+// the next one and returns the names of those that differed
+func keepStartupFields(next, current *ControllerConfig) []string {
+	var ignored []string
+	if next.LabelDomain != current.LabelDomain {
+		ignored = append(ignored, "labelDomain")
+		next.LabelDomain = current.LabelDomain
+	}
+	if next.Finalizer != current.Finalizer {
+		ignored = append(ignored, "finalizer")
+		next.Finalizer = current.Finalizer
+	}
+	if next.MaxConcurrentReconciles != current.MaxConcurrentReconciles {
+		ignored = append(ignored, "maxConcurrentReconciles")
+		next.MaxConcurrentReconciles = current.MaxConcurrentReconciles
+	}
+	if next.RateLimiter != current.RateLimiter {
+		ignored = append(ignored, "rateLimiter")
+		next.RateLimiter = current.RateLimiter
+	}
+	if next.Requeue.Backoff != current.Requeue.Backoff {
+		ignored = append(ignored, "requeue.backoff")
+		next.Requeue.Backoff = current.Requeue.Backoff
+	}
+	return ignored
+}
@@ -0,0 +1,163 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	experimentcontrollercomv1alpha1 "experimentcontroller.example.com/experiment-deployment/api/v1alpha1"
+	rolloutsv1alpha1 "github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+)
+
+// statefulSetProgressDeadline is the progress deadline of StatefulSets, which have none of their
+// own. It matches the default progressDeadlineSeconds of Deployments.
+const statefulSetProgressDeadline = 10 * time.Minute
+
+// isExperimentAborted reports whether a failed analysis or the abort policy aborted the experiment
+func isExperimentAborted(experimentCR *experimentcontrollercomv1alpha1.ExperimentDeployment) bool {
+	return isAnalysisFailed(experimentCR) || experimentCR.Status.Abort != nil
+}
+
+// isWorkloadDeletedOnAbort reports whether the abort policy aborted the experiment and deleted its workload
+func isWorkloadDeletedOnAbort(experimentCR *experimentcontrollercomv1alpha1.ExperimentDeployment) bool {
+	return experimentCR.Status.Abort != nil && experimentCR.Status.Abort.Action == experimentcontrollercomv1alpha1.AbortActionDelete
+}
+
+// enforceAbortPolicy aborts the experiment once its workload breaches a limit of the abort policy.
+// The workload is deleted right away for the Delete action, and scaled down by the next reconcile
+// otherwise.
+func (r *ExperimentDeploymentReconciler) enforceAbortPolicy(
+	ctx context.Context,
+	experimentCR *experimentcontrollercomv1alpha1.ExperimentDeployment,
+	workload, source client.Object) error {
+
+	log := logf.FromContext(ctx)
+	policy := experimentCR.Spec.AbortPolicy
+	if policy == nil || isExperimentAborted(experimentCR) {
+		return nil
+	}
+
+	trigger, message, err := r.abortPolicyBreach(ctx, experimentCR, workload, source)
+	if err != nil || trigger == "" {
+		return err
+	}
+
+	action := policy.Action
+	if action == "" {
+		action = experimentcontrollercomv1alpha1.AbortActionScaleToZero
+	}
+	log.Info("Abort policy breached, aborting experiment", "trigger", trigger, "action", action)
+	r.Recorder.Eventf(experimentCR, corev1.EventTypeWarning, "Aborted", "Experiment aborted, %s: %s", trigger, message)
+	experimentCR.Status.Abort = &experimentcontrollercomv1alpha1.AbortStatus{
+		Trigger: trigger,
+		Action:  action,
+		Message: message,
+		Time:    metav1.Now(),
+	}
+
+	if action == experimentcontrollercomv1alpha1.AbortActionDelete {
+		if err := r.Delete(ctx, workload, client.PropagationPolicy(metav1.DeletePropagationBackground)); err != nil && !k8serrors.IsNotFound(err) {
+			log.Error(err, "Failed to delete the workload of the aborted experiment", "name", workload.GetName())
+			return err
+		}
+		experimentCR.Status.ExperimentResourceRef = nil
+		experimentCR.Status.ReadyReplicas = 0
+	}
+	return nil
+}
+
+// abortPolicyBreach returns the first limit of the abort policy the experiment breached, if any
+func (r *ExperimentDeploymentReconciler) abortPolicyBreach(
+	ctx context.Context,
+	experimentCR *experimentcontrollercomv1alpha1.ExperimentDeployment,
+	workload, source client.Object) (experimentcontrollercomv1alpha1.AbortTrigger, string, error) {
+
+	policy := experimentCR.Spec.AbortPolicy
+	if policy.MaxRestarts != nil {
+		podHealth, err := r.summarizePodHealth(ctx, experimentCR, source)
+		if err != nil {
+			return "", "", err
+		}
+		experimentCR.Status.PodHealth = podHealth
+		if podHealth.Restarts > *policy.MaxRestarts {
+			return experimentcontrollercomv1alpha1.AbortTriggerMaxRestarts,
+				fmt.Sprintf("containers of the experiment pods restarted %d times, more than the limit of %d", podHealth.Restarts, *policy.MaxRestarts), nil
+		}
+	}
+
+	unreadyFor := experimentUnreadyDuration(experimentCR, workload)
+	if policy.OnProgressDeadlineExceeded {
+		if exceeded, message := progressDeadlineExceeded(workload, unreadyFor); exceeded {
+			return experimentcontrollercomv1alpha1.AbortTriggerProgressDeadlineExceeded, message, nil
+		}
+	}
+	if policy.MaxUnreadyDuration != nil && unreadyFor > policy.MaxUnreadyDuration.Duration {
+		return experimentcontrollercomv1alpha1.AbortTriggerMaxUnreadyDuration,
+			fmt.Sprintf("the experiment workload was not ready for %s, longer than the limit of %s", unreadyFor.Round(time.Second), policy.MaxUnreadyDuration.Duration), nil
+	}
+	return "", "", nil
+}
+
+// experimentUnreadyDuration returns how long the experiment workload has not been ready. It is measured
+// from the last transition of the Ready condition, or from the creation of the workload if it is younger,
+// so time spent queued or in a recreation does not count.
+func experimentUnreadyDuration(experimentCR *experimentcontrollercomv1alpha1.ExperimentDeployment, workload client.Object) time.Duration {
+	ready := meta.FindStatusCondition(experimentCR.Status.Conditions, ConditionTypeReady)
+	if ready == nil || ready.Status == metav1.ConditionTrue || isWorkloadReady(workload) {
+		return 0
+	}
+	since := ready.LastTransitionTime.Time
+	if created := workload.GetCreationTimestamp().Time; created.After(since) {
+		since = created
+	}
+	return time.Since(since)
+}
+
+// progressDeadlineExceeded reports whether the experiment workload exceeded its progress deadline
+func progressDeadlineExceeded(workload client.Object, unreadyFor time.Duration) (bool, string) {
+	switch w := workload.(type) {
+	case *appsv1.Deployment:
+		condition := getDeploymentCondition(w.Status, appsv1.DeploymentProgressing)
+		if condition != nil && condition.Status == corev1.ConditionFalse &&
+			condition.Reason == experimentcontrollercomv1alpha1.ReasonProgressDeadlineExceeded {
+			return true, condition.Message
+		}
+	case *rolloutsv1alpha1.Rollout:
+		for _, condition := range w.Status.Conditions {
+			if condition.Type == rolloutsv1alpha1.RolloutProgressing && condition.Status == corev1.ConditionFalse &&
+				condition.Reason == experimentcontrollercomv1alpha1.ReasonProgressDeadlineExceeded {
+				return true, condition.Message
+			}
+		}
+	case *appsv1.StatefulSet:
+		if unreadyFor > statefulSetProgressDeadline {
+			return true, fmt.Sprintf("StatefulSet %q was not ready for %s, longer than the progress deadline of %s",
+				w.Name, unreadyFor.Round(time.Second), statefulSetProgressDeadline)
+		}
+	}
+	return false, ""
+}
@@ -0,0 +1,232 @@
+package controller
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	experimentcontrollercomv1alpha1 "experimentcontroller.example.com/experiment-deployment/api/v1alpha1"
+	rolloutsv1alpha1 "github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+)
+
+var _ = Describe("Abort Policy", func() {
+	var (
+		ctx            context.Context
+		reconciler     *ExperimentDeploymentReconciler
+		recorder       *record.FakeRecorder
+		fakeClient     client.Client
+		namespacedName types.NamespacedName
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		scheme := runtime.NewScheme()
+		Expect(experimentcontrollercomv1alpha1.AddToScheme(scheme)).To(Succeed())
+		Expect(appsv1.AddToScheme(scheme)).To(Succeed())
+		Expect(corev1.AddToScheme(scheme)).To(Succeed())
+		fakeClient = fake.NewClientBuilder().WithScheme(scheme).
+			WithStatusSubresource(&experimentcontrollercomv1alpha1.ExperimentDeployment{}).
+			WithInterceptorFuncs(withServerSideApply(interceptor.Funcs{})).Build()
+		recorder = record.NewFakeRecorder(100)
+		reconciler = &ExperimentDeploymentReconciler{
+			Client:   fakeClient,
+			Scheme:   scheme,
+			Recorder: recorder,
+		}
+		namespacedName = types.NamespacedName{Name: testExperimentCRName, Namespace: testNamespace}
+
+		Expect(fakeClient.Create(ctx, &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: testNamespace},
+			Spec: appsv1.DeploymentSpec{
+				Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "web"}},
+				Template: corev1.PodTemplateSpec{
+					ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": "web"}},
+					Spec: corev1.PodSpec{
+						Containers: []corev1.Container{{Name: "web", Image: "nginx:1.27"}},
+					},
+				},
+			},
+		})).To(Succeed())
+	})
+
+	createExperiment := func(policy *experimentcontrollercomv1alpha1.AbortPolicy) {
+		Expect(fakeClient.Create(ctx, &experimentcontrollercomv1alpha1.ExperimentDeployment{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:       testExperimentCRName,
+				Namespace:  testNamespace,
+				Finalizers: []string{experimentDeploymentFinalizer},
+			},
+			Spec: experimentcontrollercomv1alpha1.ExperimentDeploymentSpec{
+				SourceRef: experimentcontrollercomv1alpha1.SourceRef{
+					Kind: experimentcontrollercomv1alpha1.SourceKindDeployment,
+					Name: "web",
+				},
+				OverrideSpec: apiextensionsv1.JSON{Raw: []byte(`{"template":{"spec":{"containers":[{"name":"web","image":"nginx:broken"}]}}}`)},
+				AbortPolicy:  policy,
+			},
+		})).To(Succeed())
+	}
+
+	reconcile := func() (ctrl.Result, *experimentcontrollercomv1alpha1.ExperimentDeployment) {
+		result, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+		Expect(err).NotTo(HaveOccurred())
+		updatedCR := &experimentcontrollercomv1alpha1.ExperimentDeployment{}
+		Expect(fakeClient.Get(ctx, namespacedName, updatedCR)).To(Succeed())
+		return result, updatedCR
+	}
+
+	drainWarnings := func() []string {
+		var warnings []string
+		for {
+			select {
+			case event := <-recorder.Events:
+				warnings = append(warnings, event)
+			default:
+				return warnings
+			}
+		}
+	}
+
+	It("should scale the experiment to zero once its containers restarted too often", func() {
+		createExperiment(&experimentcontrollercomv1alpha1.AbortPolicy{MaxRestarts: ptr.To(int32(3))})
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "exp-crashing",
+				Namespace: testNamespace,
+				Labels: map[string]string{
+					"experiment-controller.example.com/cr-name": testExperimentCRName,
+					"experiment-controller.example.com/role":    ExperimentRoleValue,
+				},
+			},
+			Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "web", Image: "nginx:broken"}}},
+		}
+		Expect(fakeClient.Create(ctx, pod)).To(Succeed())
+		pod.Status.ContainerStatuses = []corev1.ContainerStatus{{Name: "web", RestartCount: 4}}
+		Expect(fakeClient.Status().Update(ctx, pod)).To(Succeed())
+
+		result, updatedCR := reconcile()
+
+		Expect(result.Requeue).To(BeTrue())
+		Expect(updatedCR.Status.Abort).NotTo(BeNil())
+		Expect(updatedCR.Status.Abort.Trigger).To(Equal(experimentcontrollercomv1alpha1.AbortTriggerMaxRestarts))
+		Expect(updatedCR.Status.Abort.Action).To(Equal(experimentcontrollercomv1alpha1.AbortActionScaleToZero))
+		Expect(updatedCR.Status.Phase).To(Equal(experimentcontrollercomv1alpha1.ExperimentPhaseAborted))
+		Expect(updatedCR.Status.PodHealth.Restarts).To(Equal(int32(4)))
+		ready := meta.FindStatusCondition(updatedCR.Status.Conditions, ConditionTypeReady)
+		Expect(ready.Reason).To(Equal(experimentcontrollercomv1alpha1.ReasonAborted))
+		Expect(ready.Message).To(ContainSubstring("MaxRestarts"))
+		Expect(meta.IsStatusConditionTrue(updatedCR.Status.Conditions, ConditionTypeStalled)).To(BeTrue())
+		Expect(drainWarnings()).To(ContainElement(HavePrefix("Warning Aborted Experiment aborted, MaxRestarts")))
+
+		// The next reconcile scales the experiment down and keeps it aborted
+		result, updatedCR = reconcile()
+		Expect(result.Requeue).To(BeFalse())
+		Expect(updatedCR.Status.Phase).To(Equal(experimentcontrollercomv1alpha1.ExperimentPhaseAborted))
+		experimentDeployment := &appsv1.Deployment{}
+		Expect(fakeClient.Get(ctx, namespacedName, experimentDeployment)).To(Succeed())
+		Expect(*experimentDeployment.Spec.Replicas).To(Equal(int32(0)))
+	})
+
+	It("should delete the experiment workload once it exceeds its progress deadline", func() {
+		createExperiment(&experimentcontrollercomv1alpha1.AbortPolicy{
+			OnProgressDeadlineExceeded: true,
+			Action:                     experimentcontrollercomv1alpha1.AbortActionDelete,
+		})
+		_, updatedCR := reconcile()
+		Expect(updatedCR.Status.Abort).To(BeNil())
+
+		experimentDeployment := &appsv1.Deployment{}
+		Expect(fakeClient.Get(ctx, namespacedName, experimentDeployment)).To(Succeed())
+		experimentDeployment.Status.Conditions = []appsv1.DeploymentCondition{{
+			Type:    appsv1.DeploymentProgressing,
+			Status:  corev1.ConditionFalse,
+			Reason:  experimentcontrollercomv1alpha1.ReasonProgressDeadlineExceeded,
+			Message: `ReplicaSet "test-experiment-7d9f" has timed out progressing.`,
+		}}
+		Expect(fakeClient.Status().Update(ctx, experimentDeployment)).To(Succeed())
+
+		_, updatedCR = reconcile()
+
+		Expect(updatedCR.Status.Abort).NotTo(BeNil())
+		Expect(updatedCR.Status.Abort.Trigger).To(Equal(experimentcontrollercomv1alpha1.AbortTriggerProgressDeadlineExceeded))
+		Expect(updatedCR.Status.Abort.Message).To(ContainSubstring("has timed out progressing"))
+		Expect(updatedCR.Status.ExperimentResourceRef).To(BeNil())
+		Expect(k8serrors.IsNotFound(fakeClient.Get(ctx, namespacedName, &appsv1.Deployment{}))).To(BeTrue())
+
+		// The deleted workload is not created again
+		result, updatedCR := reconcile()
+		Expect(result).To(Equal(ctrl.Result{}))
+		Expect(updatedCR.Status.Phase).To(Equal(experimentcontrollercomv1alpha1.ExperimentPhaseAborted))
+		Expect(meta.FindStatusCondition(updatedCR.Status.Conditions, ConditionTypeSynced).Message).To(Equal("Experiment workload is deleted"))
+		Expect(k8serrors.IsNotFound(fakeClient.Get(ctx, namespacedName, &appsv1.Deployment{}))).To(BeTrue())
+	})
+
+	It("should abort experiments that are not ready for too long", func() {
+		createExperiment(&experimentcontrollercomv1alpha1.AbortPolicy{
+			MaxUnreadyDuration: &metav1.Duration{Duration: 15 * time.Minute},
+		})
+		_, updatedCR := reconcile()
+		Expect(updatedCR.Status.Abort).To(BeNil())
+
+		meta.FindStatusCondition(updatedCR.Status.Conditions, ConditionTypeReady).LastTransitionTime = metav1.NewTime(time.Now().Add(-time.Hour))
+		Expect(fakeClient.Status().Update(ctx, updatedCR)).To(Succeed())
+
+		_, updatedCR = reconcile()
+
+		Expect(updatedCR.Status.Abort).NotTo(BeNil())
+		Expect(updatedCR.Status.Abort.Trigger).To(Equal(experimentcontrollercomv1alpha1.AbortTriggerMaxUnreadyDuration))
+		Expect(updatedCR.Status.Abort.Message).To(ContainSubstring("longer than the limit of 15m0s"))
+	})
+
+	It("should leave experiments without an abort policy running", func() {
+		createExperiment(nil)
+		_, updatedCR := reconcile()
+
+		meta.FindStatusCondition(updatedCR.Status.Conditions, ConditionTypeReady).LastTransitionTime = metav1.NewTime(time.Now().Add(-24 * time.Hour))
+		Expect(fakeClient.Status().Update(ctx, updatedCR)).To(Succeed())
+
+		_, updatedCR = reconcile()
+		Expect(updatedCR.Status.Abort).To(BeNil())
+		Expect(updatedCR.Status.Phase).To(Equal(experimentcontrollercomv1alpha1.ExperimentPhasePending))
+	})
+
+	It("should detect exceeded progress deadlines of all workload kinds", func() {
+		rollout := &rolloutsv1alpha1.Rollout{
+			Status: rolloutsv1alpha1.RolloutStatus{Conditions: []rolloutsv1alpha1.RolloutCondition{{
+				Type:    rolloutsv1alpha1.RolloutProgressing,
+				Status:  corev1.ConditionFalse,
+				Reason:  experimentcontrollercomv1alpha1.ReasonProgressDeadlineExceeded,
+				Message: `ReplicaSet "web-6c5f" has timed out progressing.`,
+			}}},
+		}
+		exceeded, message := progressDeadlineExceeded(rollout, 0)
+		Expect(exceeded).To(BeTrue())
+		Expect(message).To(ContainSubstring("has timed out progressing"))
+
+		statefulSet := &appsv1.StatefulSet{ObjectMeta: metav1.ObjectMeta{Name: testExperimentCRName}}
+		exceeded, _ = progressDeadlineExceeded(statefulSet, 5*time.Minute)
+		Expect(exceeded).To(BeFalse())
+		exceeded, message = progressDeadlineExceeded(statefulSet, 11*time.Minute)
+		Expect(exceeded).To(BeTrue())
+		Expect(message).To(ContainSubstring("longer than the progress deadline of 10m0s"))
+
+		exceeded, _ = progressDeadlineExceeded(&appsv1.Deployment{}, time.Hour)
+		Expect(exceeded).To(BeFalse())
+	})
+})
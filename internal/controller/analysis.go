@@ -0,0 +1,352 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	rolloutsv1alpha1 "github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	experimentcontrollercomv1alpha1 "experimentcontroller.example.com/experiment-deployment/api/v1alpha1"
+)
+
+const (
+	// Arguments provided to AnalysisRuns when their template declares them
+	analysisArgExperimentName        = "experiment-name"
+	analysisArgExperimentNamespace   = "experiment-namespace"
+	analysisArgExperimentPodSelector = "experiment-pod-selector"
+)
+
+// analysisRunGVK is the Argo Rollouts AnalysisRun kind created for analysisTemplates
+var analysisRunGVK = rolloutsv1alpha1.SchemeGroupVersion.WithKind("AnalysisRun")
+
+// analysisRunName returns the name of the AnalysisRun created for a template reference. Template
+// names may contain any character of a run name, so a short hash of the template kind and name
+// keeps the runs of an AnalysisTemplate and a ClusterAnalysisTemplate of similar names apart.
+func analysisRunName(experimentCR *experimentcontrollercomv1alpha1.ExperimentDeployment, ref experimentcontrollercomv1alpha1.AnalysisTemplateRef) string {
+	kind := "AnalysisTemplate"
+	if ref.ClusterScope {
+		kind = "ClusterAnalysisTemplate"
+	}
+	sum := sha256.Sum256([]byte(kind + "/" + ref.TemplateName))
+	suffix := "-" + hex.EncodeToString(sum[:])[:8]
+	prefix := fmt.Sprintf("%s-%s", experimentCR.Name, ref.TemplateName)
+	if maxPrefix := validation.DNS1123SubdomainMaxLength - len(suffix); len(prefix) > maxPrefix {
+		prefix = strings.TrimRight(prefix[:maxPrefix], "-.")
+	}
+	return prefix + suffix
+}
+
+// experimentPodSelector returns the label selector matching the experiment pods
+func (r *ExperimentDeploymentReconciler) experimentPodSelector(experimentCR *experimentcontrollercomv1alpha1.ExperimentDeployment) string {
+	return labels.Set{
+		r.labelKey(labelCRName): experimentCR.Name,
+		r.labelKey(labelRole):   ExperimentRoleValue,
+	}.String()
+}
+
+// isAnalysisFailed reports whether a failed analysis aborted the experiment
+func isAnalysisFailed(experimentCR *experimentcontrollercomv1alpha1.ExperimentDeployment) bool {
+	switch rolloutsv1alpha1.AnalysisPhase(experimentCR.Status.AnalysisPhase) {
+	case rolloutsv1alpha1.AnalysisPhaseFailed, rolloutsv1alpha1.AnalysisPhaseError:
+		return true
+	}
+	return false
+}
+
+// isAnalysisSupported checks if the AnalysisRun type is registered and its CRD exists in the cluster
+func (r *ExperimentDeploymentReconciler) isAnalysisSupported() bool {
+	if _, err := r.Scheme.New(analysisRunGVK); err != nil {
+		return false
+	}
+	_, err := r.RESTMapper().RESTMapping(analysisRunGVK.GroupKind(), analysisRunGVK.Version)
+	return err == nil
+}
+
+// reconcileAnalysisRuns creates an AnalysisRun for every template referenced by the experiment,
+// reports their phases in status and terminates the remaining runs once one of them fails
+func (r *ExperimentDeploymentReconciler) reconcileAnalysisRuns(ctx context.Context, experimentCR *experimentcontrollercomv1alpha1.ExperimentDeployment) error {
+	log := logf.FromContext(ctx)
+
+	wasAborted := isExperimentAborted(experimentCR)
+	analysisFailed := isAnalysisFailed(experimentCR)
+	previousPhase := experimentCR.Status.AnalysisPhase
+	if len(experimentCR.Spec.AnalysisTemplates) == 0 && len(experimentCR.Status.AnalysisRuns) == 0 && !wasAborted {
+		experimentCR.Status.AnalysisPhase = ""
+		return nil
+	}
+
+	desiredRuns := make(map[string]bool)
+	var runs []*rolloutsv1alpha1.AnalysisRun
+	var runStatuses []experimentcontrollercomv1alpha1.AnalysisRunStatus
+
+	for _, ref := range experimentCR.Spec.AnalysisTemplates {
+		name := analysisRunName(experimentCR, ref)
+		desiredRuns[name] = true
+
+		run := &rolloutsv1alpha1.AnalysisRun{}
+		err := r.Get(ctx, client.ObjectKey{Name: name, Namespace: experimentCR.Namespace}, run)
+		if k8serrors.IsNotFound(err) {
+			if wasAborted {
+				// Aborted experiments do not start new analysis
+				continue
+			}
+			run, err = r.createAnalysisRun(ctx, experimentCR, ref)
+		}
+		if err != nil {
+			log.Error(err, "Failed to reconcile AnalysisRun", "name", name, "template", ref.TemplateName)
+			r.Recorder.Eventf(experimentCR, corev1.EventTypeWarning, "AnalysisFailed", "Failed to reconcile AnalysisRun %s: %s", name, err.Error())
+			r.updateStatusConditions(experimentCR, experimentcontrollercomv1alpha1.ReasonAnalysisFailed, fmt.Sprintf("Failed to reconcile AnalysisRun %s: %s", name, err.Error()))
+			return err
+		}
+
+		phase := run.Status.Phase
+		if phase == "" {
+			phase = rolloutsv1alpha1.AnalysisPhasePending
+		}
+		runs = append(runs, run)
+		runStatuses = append(runStatuses, experimentcontrollercomv1alpha1.AnalysisRunStatus{
+			Name:         run.Name,
+			TemplateName: ref.TemplateName,
+			ClusterScope: ref.ClusterScope,
+			Phase:        string(phase),
+			Message:      run.Status.Message,
+		})
+	}
+
+	if err := r.deleteStaleAnalysisRuns(ctx, experimentCR, desiredRuns); err != nil {
+		return err
+	}
+
+	experimentCR.Status.AnalysisRuns = runStatuses
+	experimentCR.Status.AnalysisPhase = string(aggregateAnalysisPhase(runStatuses))
+	if analysisFailed {
+		// An aborted experiment stays aborted, even if the failed run is removed
+		experimentCR.Status.AnalysisPhase = previousPhase
+	}
+
+	if isAnalysisFailed(experimentCR) && !wasAborted {
+		log.Info("Analysis failed, aborting experiment", "analysisPhase", experimentCR.Status.AnalysisPhase)
+		r.Recorder.Eventf(experimentCR, corev1.EventTypeWarning, "AnalysisFailed", "Analysis %s, aborting experiment", experimentCR.Status.AnalysisPhase)
+	}
+	if isExperimentAborted(experimentCR) {
+		for _, run := range runs {
+			if run.Status.Phase.Completed() || run.Spec.Terminate {
+				continue
+			}
+			run.Spec.Terminate = true
+			if err := r.Update(ctx, run); err != nil {
+				log.Error(err, "Failed to terminate AnalysisRun", "name", run.Name)
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// aggregateAnalysisPhase summarizes the phases of the AnalysisRuns, the worst phase wins
+func aggregateAnalysisPhase(runStatuses []experimentcontrollercomv1alpha1.AnalysisRunStatus) rolloutsv1alpha1.AnalysisPhase {
+	if len(runStatuses) == 0 {
+		return ""
+	}
+	precedence := []rolloutsv1alpha1.AnalysisPhase{
+		rolloutsv1alpha1.AnalysisPhaseFailed,
+		rolloutsv1alpha1.AnalysisPhaseError,
+		rolloutsv1alpha1.AnalysisPhaseInconclusive,
+		rolloutsv1alpha1.AnalysisPhaseRunning,
+		rolloutsv1alpha1.AnalysisPhasePending,
+	}
+	for _, phase := range precedence {
+		for _, runStatus := range runStatuses {
+			if rolloutsv1alpha1.AnalysisPhase(runStatus.Phase) == phase {
+				return phase
+			}
+		}
+	}
+	return rolloutsv1alpha1.AnalysisPhaseSuccessful
+}
+
+// createAnalysisRun creates the AnalysisRun of a template reference, owned by the experiment
+func (r *ExperimentDeploymentReconciler) createAnalysisRun(
+	ctx context.Context,
+	experimentCR *experimentcontrollercomv1alpha1.ExperimentDeployment,
+	ref experimentcontrollercomv1alpha1.AnalysisTemplateRef) (*rolloutsv1alpha1.AnalysisRun, error) {
+
+	templateSpec, err := r.resolveAnalysisTemplate(ctx, experimentCR.Namespace, rolloutsv1alpha1.AnalysisTemplateRef{
+		TemplateName: ref.TemplateName,
+		ClusterScope: ref.ClusterScope,
+	}, make(map[string]bool))
+	if err != nil {
+		return nil, err
+	}
+
+	run := r.constructAnalysisRun(experimentCR, ref, templateSpec)
+	if err := controllerutil.SetControllerReference(experimentCR, run, r.Scheme); err != nil {
+		return nil, err
+	}
+	if err := r.Create(ctx, run); err != nil {
+		return nil, err
+	}
+
+	logf.FromContext(ctx).Info("Created AnalysisRun", "name", run.Name, "template", ref.TemplateName)
+	r.Recorder.Eventf(experimentCR, corev1.EventTypeNormal, "AnalysisStarted", "Created AnalysisRun %s from template %s", run.Name, ref.TemplateName)
+	return run, nil
+}
+
+// resolveAnalysisTemplate fetches an AnalysisTemplate or ClusterAnalysisTemplate and merges the
+// templates it references into a single spec
+func (r *ExperimentDeploymentReconciler) resolveAnalysisTemplate(
+	ctx context.Context,
+	namespace string,
+	ref rolloutsv1alpha1.AnalysisTemplateRef,
+	visited map[string]bool) (*rolloutsv1alpha1.AnalysisTemplateSpec, error) {
+
+	key := fmt.Sprintf("%t/%s", ref.ClusterScope, ref.TemplateName)
+	if visited[key] {
+		return nil, fmt.Errorf("analysis template %s references itself", ref.TemplateName)
+	}
+	visited[key] = true
+
+	var spec rolloutsv1alpha1.AnalysisTemplateSpec
+	if ref.ClusterScope {
+		template := &rolloutsv1alpha1.ClusterAnalysisTemplate{}
+		if err := r.Get(ctx, client.ObjectKey{Name: ref.TemplateName}, template); err != nil {
+			return nil, fmt.Errorf("failed to get ClusterAnalysisTemplate %s: %w", ref.TemplateName, err)
+		}
+		spec = *template.Spec.DeepCopy()
+	} else {
+		template := &rolloutsv1alpha1.AnalysisTemplate{}
+		if err := r.Get(ctx, client.ObjectKey{Name: ref.TemplateName, Namespace: namespace}, template); err != nil {
+			return nil, fmt.Errorf("failed to get AnalysisTemplate %s/%s: %w", namespace, ref.TemplateName, err)
+		}
+		spec = *template.Spec.DeepCopy()
+	}
+
+	nested := spec.Templates
+	spec.Templates = nil
+	for _, nestedRef := range nested {
+		nestedSpec, err := r.resolveAnalysisTemplate(ctx, namespace, nestedRef, visited)
+		if err != nil {
+			return nil, err
+		}
+		spec.Metrics = append(spec.Metrics, nestedSpec.Metrics...)
+		spec.DryRun = append(spec.DryRun, nestedSpec.DryRun...)
+		spec.MeasurementRetention = append(spec.MeasurementRetention, nestedSpec.MeasurementRetention...)
+		for _, arg := range nestedSpec.Args {
+			if findAnalysisArgument(spec.Args, arg.Name) == nil {
+				spec.Args = append(spec.Args, arg)
+			}
+		}
+	}
+	return &spec, nil
+}
+
+// findAnalysisArgument returns the argument with the given name
+func findAnalysisArgument(args []rolloutsv1alpha1.Argument, name string) *rolloutsv1alpha1.Argument {
+	for i := range args {
+		if args[i].Name == name {
+			return &args[i]
+		}
+	}
+	return nil
+}
+
+// constructAnalysisRun builds the AnalysisRun of a resolved template. The template arguments are
+// filled from the reference first, then from the experiment name, namespace and pod selector.
+func (r *ExperimentDeploymentReconciler) constructAnalysisRun(
+	experimentCR *experimentcontrollercomv1alpha1.ExperimentDeployment,
+	ref experimentcontrollercomv1alpha1.AnalysisTemplateRef,
+	templateSpec *rolloutsv1alpha1.AnalysisTemplateSpec) *rolloutsv1alpha1.AnalysisRun {
+
+	provided := map[string]string{
+		analysisArgExperimentName:        experimentCR.Name,
+		analysisArgExperimentNamespace:   experimentCR.Namespace,
+		analysisArgExperimentPodSelector: r.experimentPodSelector(experimentCR),
+	}
+	for _, arg := range ref.Args {
+		provided[arg.Name] = arg.Value
+	}
+
+	args := make([]rolloutsv1alpha1.Argument, 0, len(templateSpec.Args))
+	for _, arg := range templateSpec.Args {
+		if value, ok := provided[arg.Name]; ok {
+			arg.Value = &value
+			arg.ValueFrom = nil
+		}
+		args = append(args, arg)
+	}
+
+	return &rolloutsv1alpha1.AnalysisRun{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      analysisRunName(experimentCR, ref),
+			Namespace: experimentCR.Namespace,
+			Labels: map[string]string{
+				r.labelKey(labelManagedBy): ManagedByValue,
+				r.labelKey(labelCRName):    experimentCR.Name,
+			},
+		},
+		Spec: rolloutsv1alpha1.AnalysisRunSpec{
+			Metrics:              templateSpec.Metrics,
+			Args:                 args,
+			DryRun:               templateSpec.DryRun,
+			MeasurementRetention: templateSpec.MeasurementRetention,
+		},
+	}
+}
+
+// deleteStaleAnalysisRuns deletes the AnalysisRuns of templates no longer referenced by the experiment
+func (r *ExperimentDeploymentReconciler) deleteStaleAnalysisRuns(
+	ctx context.Context,
+	experimentCR *experimentcontrollercomv1alpha1.ExperimentDeployment,
+	desiredRuns map[string]bool) error {
+
+	runList := &rolloutsv1alpha1.AnalysisRunList{}
+	if err := r.List(ctx, runList,
+		client.InNamespace(experimentCR.Namespace),
+		client.MatchingLabels{r.labelKey(labelCRName): experimentCR.Name}); err != nil {
+		if meta.IsNoMatchError(err) || runtime.IsNotRegisteredError(err) {
+			return nil
+		}
+		return err
+	}
+
+	for i := range runList.Items {
+		run := &runList.Items[i]
+		if desiredRuns[run.Name] || !metav1.IsControlledBy(run, experimentCR) {
+			continue
+		}
+		if err := r.Delete(ctx, run); err != nil && !k8serrors.IsNotFound(err) {
+			logf.FromContext(ctx).Error(err, "Failed to delete stale AnalysisRun", "name", run.Name)
+			return err
+		}
+	}
+	return nil
+}
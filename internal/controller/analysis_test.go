@@ -0,0 +1,272 @@
+package controller
+
+import (
+	"context"
+	"strings"
+
+	rolloutsv1alpha1 "github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	experimentcontrollercomv1alpha1 "experimentcontroller.example.com/experiment-deployment/api/v1alpha1"
+)
+
+var _ = Describe("Analysis", func() {
+	var (
+		ctx              context.Context
+		reconciler       *ExperimentDeploymentReconciler
+		fakeClient       client.Client
+		scheme           *runtime.Scheme
+		sourceDeployment *appsv1.Deployment
+		experimentCR     *experimentcontrollercomv1alpha1.ExperimentDeployment
+		namespacedName   types.NamespacedName
+	)
+
+	newReconciler := func(withRollouts bool) {
+		scheme = runtime.NewScheme()
+		Expect(experimentcontrollercomv1alpha1.AddToScheme(scheme)).To(Succeed())
+		Expect(appsv1.AddToScheme(scheme)).To(Succeed())
+		Expect(corev1.AddToScheme(scheme)).To(Succeed())
+		if withRollouts {
+			Expect(rolloutsv1alpha1.AddToScheme(scheme)).To(Succeed())
+		}
+		// Map every registered kind, as the Argo Rollouts CRDs would be in a real cluster
+		mapper := meta.NewDefaultRESTMapper(nil)
+		for gvk := range scheme.AllKnownTypes() {
+			scope := meta.RESTScopeNamespace
+			if gvk.Kind == "ClusterAnalysisTemplate" {
+				scope = meta.RESTScopeRoot
+			}
+			mapper.Add(gvk, scope)
+		}
+		fakeClient = fake.NewClientBuilder().WithScheme(scheme).WithInterceptorFuncs(withServerSideApply(interceptor.Funcs{})).WithRESTMapper(mapper).
+			WithStatusSubresource(&experimentcontrollercomv1alpha1.ExperimentDeployment{}).Build()
+		reconciler = &ExperimentDeploymentReconciler{
+			Client:   fakeClient,
+			Scheme:   scheme,
+			Recorder: record.NewFakeRecorder(100),
+		}
+	}
+
+	newTemplate := func(name string, args ...string) *rolloutsv1alpha1.AnalysisTemplate {
+		template := &rolloutsv1alpha1.AnalysisTemplate{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: testNamespace},
+			Spec: rolloutsv1alpha1.AnalysisTemplateSpec{
+				Metrics: []rolloutsv1alpha1.Metric{{
+					Name:             name,
+					SuccessCondition: "result[0] >= 0.95",
+					Provider: rolloutsv1alpha1.MetricProvider{
+						Prometheus: &rolloutsv1alpha1.PrometheusMetric{Query: "up"},
+					},
+				}},
+			},
+		}
+		for _, arg := range args {
+			template.Spec.Args = append(template.Spec.Args, rolloutsv1alpha1.Argument{Name: arg})
+		}
+		return template
+	}
+
+	getAnalysisRun := func(templateName string) *rolloutsv1alpha1.AnalysisRun {
+		name := analysisRunName(experimentCR, experimentcontrollercomv1alpha1.AnalysisTemplateRef{TemplateName: templateName})
+		run := &rolloutsv1alpha1.AnalysisRun{}
+		Expect(fakeClient.Get(ctx, types.NamespacedName{Name: name, Namespace: testNamespace}, run)).To(Succeed())
+		return run
+	}
+
+	getExperimentCR := func() *experimentcontrollercomv1alpha1.ExperimentDeployment {
+		updatedCR := &experimentcontrollercomv1alpha1.ExperimentDeployment{}
+		Expect(fakeClient.Get(ctx, namespacedName, updatedCR)).To(Succeed())
+		return updatedCR
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		newReconciler(true)
+
+		namespacedName = types.NamespacedName{Name: testExperimentCRName, Namespace: testNamespace}
+		sourceDeployment = &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "source-deployment", Namespace: testNamespace},
+			Spec: appsv1.DeploymentSpec{
+				Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "source-app"}},
+				Template: corev1.PodTemplateSpec{
+					ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": "source-app"}},
+					Spec: corev1.PodSpec{
+						Containers: []corev1.Container{{Name: "test-container", Image: "nginx:1.14"}},
+					},
+				},
+			},
+		}
+		experimentCR = &experimentcontrollercomv1alpha1.ExperimentDeployment{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:       testExperimentCRName,
+				Namespace:  testNamespace,
+				Finalizers: []string{experimentDeploymentFinalizer},
+			},
+			Spec: experimentcontrollercomv1alpha1.ExperimentDeploymentSpec{
+				SourceRef: experimentcontrollercomv1alpha1.SourceRef{
+					Kind: experimentcontrollercomv1alpha1.SourceKindDeployment,
+					Name: "source-deployment",
+				},
+				Replicas:     func() *int32 { r := int32(2); return &r }(),
+				OverrideSpec: apiextensionsv1.JSON{Raw: []byte("{}")},
+				AnalysisTemplates: []experimentcontrollercomv1alpha1.AnalysisTemplateRef{
+					{TemplateName: "success-rate", Args: []experimentcontrollercomv1alpha1.AnalysisArgument{{Name: "threshold", Value: "0.95"}}},
+				},
+			},
+		}
+	})
+
+	It("should create an AnalysisRun with the experiment arguments", func() {
+		Expect(fakeClient.Create(ctx, sourceDeployment)).To(Succeed())
+		Expect(fakeClient.Create(ctx, newTemplate("success-rate", "experiment-name", "experiment-pod-selector", "threshold", "service"))).To(Succeed())
+		Expect(fakeClient.Create(ctx, experimentCR)).To(Succeed())
+
+		_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+		Expect(err).NotTo(HaveOccurred())
+
+		run := getAnalysisRun("success-rate")
+		Expect(run.Spec.Metrics).To(HaveLen(1))
+		Expect(run.Labels).To(HaveKeyWithValue("experiment-controller.example.com/cr-name", testExperimentCRName))
+		Expect(metav1.IsControlledBy(run, experimentCR)).To(BeTrue())
+
+		argValues := map[string]*string{}
+		for _, arg := range run.Spec.Args {
+			argValues[arg.Name] = arg.Value
+		}
+		Expect(*argValues["experiment-name"]).To(Equal(testExperimentCRName))
+		Expect(*argValues["experiment-pod-selector"]).To(Equal(
+			"experiment-controller.example.com/cr-name=" + testExperimentCRName + ",experiment-controller.example.com/role=experiment"))
+		Expect(*argValues["threshold"]).To(Equal("0.95"))
+		Expect(argValues["service"]).To(BeNil())
+		Expect(argValues).NotTo(HaveKey("experiment-namespace"))
+
+		updatedCR := getExperimentCR()
+		Expect(updatedCR.Status.AnalysisPhase).To(Equal("Pending"))
+		Expect(updatedCR.Status.AnalysisRuns).To(HaveLen(1))
+		Expect(updatedCR.Status.AnalysisRuns[0].TemplateName).To(Equal("success-rate"))
+	})
+
+	It("should merge nested and cluster-scoped templates", func() {
+		clusterTemplate := &rolloutsv1alpha1.ClusterAnalysisTemplate{
+			ObjectMeta: metav1.ObjectMeta{Name: "latency"},
+			Spec:       newTemplate("latency", "experiment-namespace").Spec,
+		}
+		template := newTemplate("success-rate")
+		template.Spec.Templates = []rolloutsv1alpha1.AnalysisTemplateRef{{TemplateName: "latency", ClusterScope: true}}
+		Expect(fakeClient.Create(ctx, sourceDeployment)).To(Succeed())
+		Expect(fakeClient.Create(ctx, clusterTemplate)).To(Succeed())
+		Expect(fakeClient.Create(ctx, template)).To(Succeed())
+		Expect(fakeClient.Create(ctx, experimentCR)).To(Succeed())
+
+		_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+		Expect(err).NotTo(HaveOccurred())
+
+		run := getAnalysisRun("success-rate")
+		Expect(run.Spec.Metrics).To(HaveLen(2))
+		Expect(run.Spec.Args).To(HaveLen(1))
+		Expect(*run.Spec.Args[0].Value).To(Equal(testNamespace))
+	})
+
+	It("should abort and scale down the experiment when a run fails", func() {
+		experimentCR.Spec.AnalysisTemplates = append(experimentCR.Spec.AnalysisTemplates,
+			experimentcontrollercomv1alpha1.AnalysisTemplateRef{TemplateName: "latency"})
+		Expect(fakeClient.Create(ctx, sourceDeployment)).To(Succeed())
+		Expect(fakeClient.Create(ctx, newTemplate("success-rate"))).To(Succeed())
+		Expect(fakeClient.Create(ctx, newTemplate("latency"))).To(Succeed())
+		Expect(fakeClient.Create(ctx, experimentCR)).To(Succeed())
+
+		_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+		Expect(err).NotTo(HaveOccurred())
+
+		failedRun := getAnalysisRun("success-rate")
+		failedRun.Status.Phase = rolloutsv1alpha1.AnalysisPhaseFailed
+		failedRun.Status.Message = "metric success-rate assessed Failed"
+		Expect(fakeClient.Update(ctx, failedRun)).To(Succeed())
+		runningRun := getAnalysisRun("latency")
+		runningRun.Status.Phase = rolloutsv1alpha1.AnalysisPhaseRunning
+		Expect(fakeClient.Update(ctx, runningRun)).To(Succeed())
+
+		result, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.Requeue).To(BeTrue())
+
+		updatedCR := getExperimentCR()
+		Expect(updatedCR.Status.AnalysisPhase).To(Equal("Failed"))
+		Expect(updatedCR.Status.AnalysisRuns[0].Message).To(Equal("metric success-rate assessed Failed"))
+		readyCondition := meta.FindStatusCondition(updatedCR.Status.Conditions, ConditionTypeReady)
+		Expect(readyCondition.Status).To(Equal(metav1.ConditionFalse))
+		Expect(readyCondition.Reason).To(Equal("AnalysisFailed"))
+		Expect(getAnalysisRun("latency").Spec.Terminate).To(BeTrue())
+
+		_, err = reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+		Expect(err).NotTo(HaveOccurred())
+
+		experimentDeployment := &appsv1.Deployment{}
+		Expect(fakeClient.Get(ctx, namespacedName, experimentDeployment)).To(Succeed())
+		Expect(*experimentDeployment.Spec.Replicas).To(Equal(int32(0)))
+	})
+
+	It("should delete the runs of templates no longer referenced", func() {
+		Expect(fakeClient.Create(ctx, sourceDeployment)).To(Succeed())
+		Expect(fakeClient.Create(ctx, newTemplate("success-rate"))).To(Succeed())
+		Expect(fakeClient.Create(ctx, experimentCR)).To(Succeed())
+
+		_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+		Expect(err).NotTo(HaveOccurred())
+		getAnalysisRun("success-rate")
+
+		updatedCR := getExperimentCR()
+		updatedCR.Spec.AnalysisTemplates = nil
+		Expect(fakeClient.Update(ctx, updatedCR)).To(Succeed())
+
+		_, err = reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+		Expect(err).NotTo(HaveOccurred())
+
+		runs := &rolloutsv1alpha1.AnalysisRunList{}
+		Expect(fakeClient.List(ctx, runs, client.InNamespace(testNamespace))).To(Succeed())
+		Expect(runs.Items).To(BeEmpty())
+		updatedCR = getExperimentCR()
+		Expect(updatedCR.Status.AnalysisPhase).To(BeEmpty())
+		Expect(updatedCR.Status.AnalysisRuns).To(BeEmpty())
+	})
+
+	It("should fail cleanly when the Argo Rollouts CRDs are missing", func() {
+		newReconciler(false)
+		Expect(fakeClient.Create(ctx, sourceDeployment)).To(Succeed())
+		Expect(fakeClient.Create(ctx, experimentCR)).To(Succeed())
+
+		_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("analysis is not installed"))
+
+		readyCondition := meta.FindStatusCondition(getExperimentCR().Status.Conditions, ConditionTypeReady)
+		Expect(readyCondition).NotTo(BeNil())
+		Expect(readyCondition.Reason).To(Equal("AnalysisNotSupported"))
+
+		deployment := &appsv1.Deployment{}
+		Expect(fakeClient.Get(ctx, namespacedName, deployment)).NotTo(Succeed())
+	})
+
+	It("should name the runs of namespaced and cluster templates apart", func() {
+		namespaced := analysisRunName(experimentCR, experimentcontrollercomv1alpha1.AnalysisTemplateRef{TemplateName: "cluster-latency"})
+		cluster := analysisRunName(experimentCR, experimentcontrollercomv1alpha1.AnalysisTemplateRef{TemplateName: "latency", ClusterScope: true})
+		Expect(namespaced).NotTo(Equal(cluster))
+
+		long := analysisRunName(experimentCR, experimentcontrollercomv1alpha1.AnalysisTemplateRef{TemplateName: strings.Repeat("a", 253)})
+		Expect(validation.IsDNS1123Subdomain(long)).To(BeEmpty())
+	})
+})
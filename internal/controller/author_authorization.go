@@ -0,0 +1,131 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	experimentcontrollercomv1alpha1 "experimentcontroller.example.com/experiment-deployment/api/v1alpha1"
+)
+
+// workloadResources maps the supported source kinds to their API resources
+var workloadResources = map[experimentcontrollercomv1alpha1.SourceKind]schema.GroupResource{
+	experimentcontrollercomv1alpha1.SourceKindDeployment:  {Group: "apps", Resource: "deployments"},
+	experimentcontrollercomv1alpha1.SourceKindStatefulSet: {Group: "apps", Resource: "statefulsets"},
+	experimentcontrollercomv1alpha1.SourceKindRollout:     {Group: "argoproj.io", Resource: "rollouts"},
+}
+
+// isAuthorAuthorized checks with SubjectAccessReviews that the author recorded by the admission
+// webhook may get the source workload and create its kind in the namespace of the experiment,
+// so the broad RBAC of the controller cannot be used to clone workloads the author has no access to.
+// It sets the Unauthorized condition and returns false when a check fails.
+func (r *ExperimentDeploymentReconciler) isAuthorAuthorized(
+	ctx context.Context,
+	experimentCR *experimentcontrollercomv1alpha1.ExperimentDeployment,
+	sourceNamespace string) (bool, error) {
+
+	if !r.AuthorizeAuthors {
+		return true, nil
+	}
+	log := logf.FromContext(ctx)
+
+	unauthorized := func(message string) (bool, error) {
+		log.Info("ExperimentDeployment author not authorized", "reason", message)
+		r.Recorder.Event(experimentCR, corev1.EventTypeWarning, "Unauthorized", message)
+		r.updateStatusConditions(experimentCR, experimentcontrollercomv1alpha1.ReasonUnauthorized, message)
+		return false, nil
+	}
+
+	createdBy, ok := experimentCR.Annotations[experimentcontrollercomv1alpha1.CreatedByAnnotation]
+	if !ok {
+		return unauthorized(fmt.Sprintf("The author of the experiment is unknown, the %s annotation is set by the admission webhook",
+			experimentcontrollercomv1alpha1.CreatedByAnnotation))
+	}
+	author := authenticationv1.UserInfo{}
+	if err := json.Unmarshal([]byte(createdBy), &author); err != nil || author.Username == "" {
+		return unauthorized(fmt.Sprintf("The %s annotation does not contain a valid author", experimentcontrollercomv1alpha1.CreatedByAnnotation))
+	}
+	experimentCR.Status.CreatedBy = author.Username
+
+	resource, ok := workloadResources[experimentCR.Spec.SourceRef.Kind]
+	if !ok {
+		return false, fmt.Errorf("unsupported source kind: %s", experimentCR.Spec.SourceRef.Kind)
+	}
+	checks := []authorizationv1.ResourceAttributes{
+		{
+			Namespace: sourceNamespace,
+			Verb:      "get",
+			Group:     resource.Group,
+			Resource:  resource.Resource,
+			Name:      experimentCR.Spec.SourceRef.Name,
+		},
+		{
+			Namespace: experimentCR.Namespace,
+			Verb:      "create",
+			Group:     resource.Group,
+			Resource:  resource.Resource,
+		},
+	}
+	for _, check := range checks {
+		allowed, err := r.subjectAccessReview(ctx, author, check)
+		if err != nil {
+			log.Error(err, "Failed to create SubjectAccessReview", "username", author.Username)
+			return false, err
+		}
+		if !allowed {
+			target := check.Resource
+			if check.Name != "" {
+				target = fmt.Sprintf("%s %s", check.Resource, check.Name)
+			}
+			return unauthorized(fmt.Sprintf("User %s cannot %s %s in namespace %s", author.Username, check.Verb, target, check.Namespace))
+		}
+	}
+	return true, nil
+}
+
+// subjectAccessReview reports whether the user is allowed the given resource access
+func (r *ExperimentDeploymentReconciler) subjectAccessReview(
+	ctx context.Context,
+	author authenticationv1.UserInfo,
+	attributes authorizationv1.ResourceAttributes) (bool, error) {
+
+	extra := make(map[string]authorizationv1.ExtraValue, len(author.Extra))
+	for key, value := range author.Extra {
+		extra[key] = authorizationv1.ExtraValue(value)
+	}
+	review := &authorizationv1.SubjectAccessReview{
+		Spec: authorizationv1.SubjectAccessReviewSpec{
+			ResourceAttributes: &attributes,
+			User:               author.Username,
+			UID:                author.UID,
+			Groups:             author.Groups,
+			Extra:              extra,
+		},
+	}
+	if err := r.Create(ctx, review); err != nil {
+		return false, err
+	}
+	return review.Status.Allowed, nil
+}
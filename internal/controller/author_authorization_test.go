@@ -0,0 +1,170 @@
+package controller
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	experimentcontrollercomv1alpha1 "experimentcontroller.example.com/experiment-deployment/api/v1alpha1"
+)
+
+var _ = Describe("Author Authorization", func() {
+	var (
+		ctx              context.Context
+		reconciler       *ExperimentDeploymentReconciler
+		fakeClient       client.Client
+		reviews          []authorizationv1.SubjectAccessReviewSpec
+		allowedVerbs     map[string]bool
+		sourceDeployment *appsv1.Deployment
+		experimentCR     *experimentcontrollercomv1alpha1.ExperimentDeployment
+		namespacedName   types.NamespacedName
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		scheme := runtime.NewScheme()
+		Expect(experimentcontrollercomv1alpha1.AddToScheme(scheme)).To(Succeed())
+		Expect(appsv1.AddToScheme(scheme)).To(Succeed())
+		Expect(corev1.AddToScheme(scheme)).To(Succeed())
+		Expect(authorizationv1.AddToScheme(scheme)).To(Succeed())
+
+		// Answer SubjectAccessReviews like an authorizer allowing the verbs in allowedVerbs to alice
+		reviews = nil
+		allowedVerbs = map[string]bool{"get": true, "create": true}
+		fakeClient = fake.NewClientBuilder().
+			WithScheme(scheme).
+			WithStatusSubresource(&experimentcontrollercomv1alpha1.ExperimentDeployment{}).
+			WithInterceptorFuncs(withServerSideApply(interceptor.Funcs{
+				Create: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.CreateOption) error {
+					review, ok := obj.(*authorizationv1.SubjectAccessReview)
+					if !ok {
+						return c.Create(ctx, obj, opts...)
+					}
+					reviews = append(reviews, review.Spec)
+					review.Status.Allowed = review.Spec.User == "alice" && allowedVerbs[review.Spec.ResourceAttributes.Verb]
+					return nil
+				},
+			})).
+			Build()
+		reconciler = &ExperimentDeploymentReconciler{
+			Client:           fakeClient,
+			Scheme:           scheme,
+			Recorder:         record.NewFakeRecorder(100),
+			AuthorizeAuthors: true,
+		}
+
+		namespacedName = types.NamespacedName{Name: testExperimentCRName, Namespace: testNamespace}
+		sourceDeployment = &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: testNamespace},
+			Spec: appsv1.DeploymentSpec{
+				Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "web"}},
+				Template: corev1.PodTemplateSpec{
+					ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": "web"}},
+					Spec: corev1.PodSpec{
+						Containers: []corev1.Container{{Name: "web", Image: "nginx:1.27"}},
+					},
+				},
+			},
+		}
+		experimentCR = &experimentcontrollercomv1alpha1.ExperimentDeployment{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:       testExperimentCRName,
+				Namespace:  testNamespace,
+				Finalizers: []string{experimentDeploymentFinalizer},
+				Annotations: map[string]string{
+					experimentcontrollercomv1alpha1.CreatedByAnnotation: `{"username":"alice","groups":["team-a"]}`,
+				},
+			},
+			Spec: experimentcontrollercomv1alpha1.ExperimentDeploymentSpec{
+				SourceRef: experimentcontrollercomv1alpha1.SourceRef{
+					Kind: experimentcontrollercomv1alpha1.SourceKindDeployment,
+					Name: "web",
+				},
+				OverrideSpec: apiextensionsv1.JSON{Raw: []byte(`{}`)},
+			},
+		}
+		Expect(fakeClient.Create(ctx, sourceDeployment)).To(Succeed())
+	})
+
+	getExperimentCR := func() *experimentcontrollercomv1alpha1.ExperimentDeployment {
+		updatedCR := &experimentcontrollercomv1alpha1.ExperimentDeployment{}
+		Expect(fakeClient.Get(ctx, namespacedName, updatedCR)).To(Succeed())
+		return updatedCR
+	}
+
+	expectUnauthorized := func(message string) {
+		_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+		Expect(err).NotTo(HaveOccurred())
+
+		err = fakeClient.Get(ctx, namespacedName, &appsv1.Deployment{})
+		Expect(k8serrors.IsNotFound(err)).To(BeTrue())
+		readyCondition := meta.FindStatusCondition(getExperimentCR().Status.Conditions, ConditionTypeReady)
+		Expect(readyCondition).NotTo(BeNil())
+		Expect(readyCondition.Reason).To(Equal("Unauthorized"))
+		Expect(readyCondition.Message).To(ContainSubstring(message))
+	}
+
+	It("should render experiments whose author can get the source and create its kind", func() {
+		Expect(fakeClient.Create(ctx, experimentCR)).To(Succeed())
+
+		_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(fakeClient.Get(ctx, namespacedName, &appsv1.Deployment{})).To(Succeed())
+		Expect(getExperimentCR().Status.CreatedBy).To(Equal("alice"))
+
+		Expect(reviews).To(HaveLen(2))
+		Expect(reviews[0].User).To(Equal("alice"))
+		Expect(reviews[0].Groups).To(ConsistOf("team-a"))
+		Expect(*reviews[0].ResourceAttributes).To(Equal(authorizationv1.ResourceAttributes{
+			Namespace: testNamespace, Verb: "get", Group: "apps", Resource: "deployments", Name: "web",
+		}))
+		Expect(*reviews[1].ResourceAttributes).To(Equal(authorizationv1.ResourceAttributes{
+			Namespace: testNamespace, Verb: "create", Group: "apps", Resource: "deployments",
+		}))
+	})
+
+	It("should not render experiments whose author cannot get the source", func() {
+		allowedVerbs["get"] = false
+		Expect(fakeClient.Create(ctx, experimentCR)).To(Succeed())
+		expectUnauthorized("User alice cannot get deployments web in namespace test-namespace")
+	})
+
+	It("should not render experiments whose author cannot create the target kind", func() {
+		allowedVerbs["create"] = false
+		Expect(fakeClient.Create(ctx, experimentCR)).To(Succeed())
+		expectUnauthorized("User alice cannot create deployments in namespace test-namespace")
+	})
+
+	It("should not render experiments without a recorded author", func() {
+		experimentCR.Annotations = nil
+		Expect(fakeClient.Create(ctx, experimentCR)).To(Succeed())
+		expectUnauthorized("author of the experiment is unknown")
+		Expect(reviews).To(BeEmpty())
+	})
+
+	It("should skip the checks when author authorization is disabled", func() {
+		reconciler.AuthorizeAuthors = false
+		experimentCR.Annotations = nil
+		Expect(fakeClient.Create(ctx, experimentCR)).To(Succeed())
+
+		_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(fakeClient.Get(ctx, namespacedName, &appsv1.Deployment{})).To(Succeed())
+		Expect(reviews).To(BeEmpty())
+	})
+})
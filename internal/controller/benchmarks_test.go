@@ -3,6 +3,8 @@ package controller
 import (
 	"context"
 	"encoding/json"
+	"fmt"
+	"runtime"
 	"testing"
 
 	appsv1 "k8s.io/api/apps/v1"
@@ -10,20 +12,30 @@ import (
 	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/runtime"
+	k8sruntime "k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/informers"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+	metadatafake "k8s.io/client-go/metadata/fake"
+	"k8s.io/client-go/metadata/metadatainformer"
+	toolscache "k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
 
 	experimentcontrollercomv1alpha1 "experimentcontroller.example.com/experiment-deployment/api/v1alpha1"
+	"experimentcontroller.example.com/experiment-deployment/internal/config"
 )
 
 func BenchmarkConstructExperimentDeployment(b *testing.B) {
-	scheme := runtime.NewScheme()
+	scheme := k8sruntime.NewScheme()
 	_ = experimentcontrollercomv1alpha1.AddToScheme(scheme)
 	_ = appsv1.AddToScheme(scheme)
 	_ = corev1.AddToScheme(scheme)
 
-	fakeClient := fake.NewClientBuilder().WithScheme(scheme).Build()
+	fakeClient := fake.NewClientBuilder().WithScheme(scheme).WithInterceptorFuncs(withServerSideApply(interceptor.Funcs{})).Build()
 	reconciler := &ExperimentDeploymentReconciler{
 		Client:   fakeClient,
 		Scheme:   scheme,
@@ -101,12 +113,12 @@ func BenchmarkConstructExperimentDeployment(b *testing.B) {
 }
 
 func BenchmarkConstructExperimentDeploymentLargeSpec(b *testing.B) {
-	scheme := runtime.NewScheme()
+	scheme := k8sruntime.NewScheme()
 	_ = experimentcontrollercomv1alpha1.AddToScheme(scheme)
 	_ = appsv1.AddToScheme(scheme)
 	_ = corev1.AddToScheme(scheme)
 
-	fakeClient := fake.NewClientBuilder().WithScheme(scheme).Build()
+	fakeClient := fake.NewClientBuilder().WithScheme(scheme).WithInterceptorFuncs(withServerSideApply(interceptor.Funcs{})).Build()
 	reconciler := &ExperimentDeploymentReconciler{
 		Client:   fakeClient,
 		Scheme:   scheme,
@@ -248,7 +260,7 @@ func BenchmarkConstructExperimentDeploymentLargeSpec(b *testing.B) {
 }
 
 func BenchmarkReconcileHappyPath(b *testing.B) {
-	scheme := runtime.NewScheme()
+	scheme := k8sruntime.NewScheme()
 	_ = experimentcontrollercomv1alpha1.AddToScheme(scheme)
 	_ = appsv1.AddToScheme(scheme)
 	_ = corev1.AddToScheme(scheme)
@@ -298,7 +310,7 @@ func BenchmarkReconcileHappyPath(b *testing.B) {
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
 		b.StopTimer()
-		fakeClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(sourceDeployment, experimentCR).Build()
+		fakeClient := fake.NewClientBuilder().WithScheme(scheme).WithInterceptorFuncs(withServerSideApply(interceptor.Funcs{})).WithObjects(sourceDeployment, experimentCR).Build()
 		reconciler := &ExperimentDeploymentReconciler{
 			Client:   fakeClient,
 			Scheme:   scheme,
@@ -307,9 +319,251 @@ func BenchmarkReconcileHappyPath(b *testing.B) {
 		ctx := context.Background()
 		b.StartTimer()
 
-		_, err := reconciler.reconcileDeploymentExperiment(ctx, experimentCR, "test-namespace")
+		_, _, err := reconciler.reconcileDeploymentExperiment(ctx, experimentCR, "test-namespace")
 		if err != nil {
 			b.Fatal(err)
 		}
 	}
 }
+
+// BenchmarkReconcileAPIWrites reports the API writes of reconciles of a settled experiment,
+// like the periodic requeues of experiments that are not ready. Unchanged experiments are not
+// written at all, where every reconcile used to apply the workload and update the status.
+func BenchmarkReconcileAPIWrites(b *testing.B) {
+	scheme := k8sruntime.NewScheme()
+	_ = experimentcontrollercomv1alpha1.AddToScheme(scheme)
+	_ = appsv1.AddToScheme(scheme)
+	_ = corev1.AddToScheme(scheme)
+
+	newSource := func() *appsv1.Deployment {
+		return &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "source-deployment",
+				Namespace: "test-namespace",
+			},
+			Spec: appsv1.DeploymentSpec{
+				Replicas: func() *int32 { r := int32(3); return &r }(),
+				Selector: &metav1.LabelSelector{
+					MatchLabels: map[string]string{"app": "source-app"},
+				},
+				Template: corev1.PodTemplateSpec{
+					ObjectMeta: metav1.ObjectMeta{
+						Labels: map[string]string{"app": "source-app"},
+					},
+					Spec: corev1.PodSpec{
+						Containers: []corev1.Container{
+							{
+								Name:  "test-container",
+								Image: "nginx:1.14",
+							},
+						},
+					},
+				},
+			},
+		}
+	}
+
+	experimentCR := &experimentcontrollercomv1alpha1.ExperimentDeployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       "experiment-cr",
+			Namespace:  "test-namespace",
+			Finalizers: []string{experimentDeploymentFinalizer},
+		},
+		Spec: experimentcontrollercomv1alpha1.ExperimentDeploymentSpec{
+			SourceRef: experimentcontrollercomv1alpha1.SourceRef{
+				Kind: experimentcontrollercomv1alpha1.SourceKindDeployment,
+				Name: "source-deployment",
+			},
+			Replicas:     func() *int32 { r := int32(1); return &r }(),
+			OverrideSpec: apiextensionsv1.JSON{Raw: []byte("{}")},
+		},
+	}
+	request := ctrl.Request{NamespacedName: types.NamespacedName{Name: "experiment-cr", Namespace: "test-namespace"}}
+
+	for _, bc := range []struct {
+		name string
+		// changeSource changes the source between reconciles, so that every reconcile writes
+		changeSource bool
+	}{
+		{name: "Unchanged"},
+		{name: "SourceChanged", changeSource: true},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			writes := 0
+			countWrites := false
+			fakeClient := fake.NewClientBuilder().WithScheme(scheme).
+				WithStatusSubresource(&experimentcontrollercomv1alpha1.ExperimentDeployment{}).
+				WithObjects(newSource(), experimentCR.DeepCopy()).
+				WithInterceptorFuncs(interceptor.Funcs{
+					Patch: func(ctx context.Context, c client.WithWatch, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+						if countWrites {
+							writes++
+						}
+						return applyPatch(ctx, c, obj, patch, opts...)
+					},
+					SubResourcePatch: func(ctx context.Context, c client.Client, subResourceName string, obj client.Object, patch client.Patch, opts ...client.SubResourcePatchOption) error {
+						if countWrites {
+							writes++
+						}
+						return c.SubResource(subResourceName).Patch(ctx, obj, patch, opts...)
+					},
+				}).Build()
+			reconciler := &ExperimentDeploymentReconciler{
+				Client:   fakeClient,
+				Scheme:   scheme,
+				Recorder: record.NewFakeRecorder(b.N + 100),
+			}
+			ctx := context.Background()
+			if _, err := reconciler.Reconcile(ctx, request); err != nil {
+				b.Fatal(err)
+			}
+
+			images := []string{"nginx:1.15", "nginx:1.14"}
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if bc.changeSource {
+					b.StopTimer()
+					source := &appsv1.Deployment{}
+					if err := fakeClient.Get(ctx, client.ObjectKeyFromObject(newSource()), source); err != nil {
+						b.Fatal(err)
+					}
+					source.Spec.Template.Spec.Containers[0].Image = images[i%len(images)]
+					if err := fakeClient.Update(ctx, source); err != nil {
+						b.Fatal(err)
+					}
+					b.StartTimer()
+				}
+
+				countWrites = true
+				if _, err := reconciler.Reconcile(ctx, request); err != nil {
+					b.Fatal(err)
+				}
+				countWrites = false
+			}
+			b.ReportMetric(float64(writes)/float64(b.N), "writes/op")
+		})
+	}
+}
+
+// BenchmarkCacheMemory reports the heap used to cache the Deployments of a synthetic namespace
+// holding thousands of source Deployments and a few experiment Deployments:
+//
+//   - Full caches every Deployment, as the controller used to
+//   - Managed caches the experiment Deployments selected by the managed-by label
+//   - SourceMetadata caches the metadata of every Deployment, to watch the sources
+//
+// The controller now runs Managed and SourceMetadata in place of Full.
+func BenchmarkCacheMemory(b *testing.B) {
+	const (
+		sourceDeployments     = 5000
+		experimentDeployments = 50
+	)
+	labelDomain := config.DefaultLabelDomain
+
+	deployment := func(name string, labels map[string]string) *appsv1.Deployment {
+		return &appsv1.Deployment{
+			TypeMeta: metav1.TypeMeta{APIVersion: "apps/v1", Kind: "Deployment"},
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        name,
+				Namespace:   "synthetic",
+				Labels:      labels,
+				Annotations: map[string]string{"deployment.kubernetes.io/revision": "3"},
+			},
+			Spec: appsv1.DeploymentSpec{
+				Replicas: func() *int32 { r := int32(3); return &r }(),
+				Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": name}},
+				Template: corev1.PodTemplateSpec{
+					ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": name}},
+					Spec: corev1.PodSpec{
+						Containers: []corev1.Container{{
+							Name:  "app",
+							Image: "registry.example.com/team/" + name + ":1.0.0",
+							Ports: []corev1.ContainerPort{{ContainerPort: 8080, Name: "http"}},
+							Env: []corev1.EnvVar{
+								{Name: "LOG_LEVEL", Value: "info"},
+								{Name: "SERVICE_NAME", Value: name},
+								{Name: "OTEL_EXPORTER_OTLP_ENDPOINT", Value: "http://otel-collector.observability:4317"},
+							},
+							Resources: corev1.ResourceRequirements{
+								Requests: corev1.ResourceList{
+									corev1.ResourceCPU:    resource.MustParse("100m"),
+									corev1.ResourceMemory: resource.MustParse("128Mi"),
+								},
+							},
+						}},
+					},
+				},
+			},
+		}
+	}
+
+	var objects []k8sruntime.Object
+	var metadataObjects []k8sruntime.Object
+	for i := 0; i < sourceDeployments+experimentDeployments; i++ {
+		name := fmt.Sprintf("app-%d", i)
+		labels := map[string]string{"app": name}
+		if i >= sourceDeployments {
+			labels[labelDomain+"/"+labelManagedBy] = ManagedByValue
+		}
+		obj := deployment(name, labels)
+		objects = append(objects, obj)
+		metadataObjects = append(metadataObjects, &metav1.PartialObjectMetadata{TypeMeta: obj.TypeMeta, ObjectMeta: obj.ObjectMeta})
+	}
+	clientset := k8sfake.NewClientset(objects...)
+	metadataScheme := k8sruntime.NewScheme()
+	_ = metav1.AddMetaToScheme(metadataScheme)
+	metadataClient := metadatafake.NewSimpleMetadataClient(metadataScheme, metadataObjects...)
+
+	for _, bc := range []struct {
+		name     string
+		informer func() toolscache.SharedIndexInformer
+	}{
+		{
+			name: "Full",
+			informer: func() toolscache.SharedIndexInformer {
+				return informers.NewSharedInformerFactory(clientset, 0).Apps().V1().Deployments().Informer()
+			},
+		},
+		{
+			name: "Managed",
+			informer: func() toolscache.SharedIndexInformer {
+				return informers.NewSharedInformerFactoryWithOptions(clientset, 0, informers.WithTweakListOptions(func(opts *metav1.ListOptions) {
+					opts.LabelSelector = ManagedBySelector(labelDomain).String()
+				})).Apps().V1().Deployments().Informer()
+			},
+		},
+		{
+			name: "SourceMetadata",
+			informer: func() toolscache.SharedIndexInformer {
+				return metadatainformer.NewSharedInformerFactory(metadataClient, 0).
+					ForResource(appsv1.SchemeGroupVersion.WithResource("deployments")).Informer()
+			},
+		},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			var heap, cached uint64
+			for i := 0; i < b.N; i++ {
+				var before, after runtime.MemStats
+				runtime.GC()
+				runtime.ReadMemStats(&before)
+
+				informer := bc.informer()
+				stop := make(chan struct{})
+				go informer.Run(stop)
+				if !toolscache.WaitForCacheSync(stop, informer.HasSynced) {
+					b.Fatal("cache did not sync")
+				}
+				runtime.GC()
+				runtime.ReadMemStats(&after)
+				if after.HeapAlloc > before.HeapAlloc {
+					heap += after.HeapAlloc - before.HeapAlloc
+				}
+				cached += uint64(len(informer.GetStore().ListKeys()))
+				close(stop)
+				runtime.KeepAlive(informer)
+			}
+			b.ReportMetric(float64(heap)/float64(b.N)/(1<<20), "MiB/cache")
+			b.ReportMetric(float64(cached)/float64(b.N), "objects/cache")
+		})
+	}
+}
@@ -0,0 +1,62 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	experimentcontrollercomv1alpha1 "experimentcontroller.example.com/experiment-deployment/api/v1alpha1"
+)
+
+// specHash returns the hash of the configuration applied to an experiment workload. Maps are
+// marshaled with sorted keys, so the same configuration always has the same hash.
+func specHash(applied *unstructured.Unstructured) (string, error) {
+	data, err := json.Marshal(applied.Object)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])[:16], nil
+}
+
+// updateStatus writes the status of an experiment when it differs from the cached one, as a
+// merge patch retried on conflicts
+func (r *ExperimentDeploymentReconciler) updateStatus(ctx context.Context, experimentCR *experimentcontrollercomv1alpha1.ExperimentDeployment) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		current := &experimentcontrollercomv1alpha1.ExperimentDeployment{}
+		if err := r.Get(ctx, client.ObjectKeyFromObject(experimentCR), current); err != nil {
+			return err
+		}
+		if equality.Semantic.DeepEqual(current.Status, experimentCR.Status) {
+			logf.FromContext(ctx).V(1).Info("ExperimentDeployment status unchanged, skipping update")
+			return nil
+		}
+		// The lock fails the patch when the cached experiment is stale, the status is then
+		// patched again onto the latest experiment
+		experimentCR.ResourceVersion = current.ResourceVersion
+		return r.Status().Patch(ctx, experimentCR, client.MergeFromWithOptions(current, client.MergeFromWithOptimisticLock{}))
+	})
+}
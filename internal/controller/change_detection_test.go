@@ -0,0 +1,151 @@
+package controller
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	experimentcontrollercomv1alpha1 "experimentcontroller.example.com/experiment-deployment/api/v1alpha1"
+)
+
+var _ = Describe("Change detection", func() {
+	var (
+		ctx            context.Context
+		reconciler     *ExperimentDeploymentReconciler
+		fakeClient     client.Client
+		statusWrites   int
+		conflicts      int
+		namespacedName types.NamespacedName
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		scheme := runtime.NewScheme()
+		Expect(experimentcontrollercomv1alpha1.AddToScheme(scheme)).To(Succeed())
+		Expect(appsv1.AddToScheme(scheme)).To(Succeed())
+		Expect(corev1.AddToScheme(scheme)).To(Succeed())
+
+		// Count the status writes of experiments and fail the first conflicts patches like the API server does
+		// when the experiment changed since it was read
+		statusWrites, conflicts = 0, 0
+		fakeClient = fake.NewClientBuilder().WithScheme(scheme).
+			WithStatusSubresource(&experimentcontrollercomv1alpha1.ExperimentDeployment{}).
+			WithInterceptorFuncs(withServerSideApply(interceptor.Funcs{
+				SubResourceUpdate: func(ctx context.Context, c client.Client, subResourceName string, obj client.Object, opts ...client.SubResourceUpdateOption) error {
+					if _, ok := obj.(*experimentcontrollercomv1alpha1.ExperimentDeployment); ok {
+						statusWrites++
+					}
+					return c.SubResource(subResourceName).Update(ctx, obj, opts...)
+				},
+				SubResourcePatch: func(ctx context.Context, c client.Client, subResourceName string, obj client.Object, patch client.Patch, opts ...client.SubResourcePatchOption) error {
+					statusWrites++
+					if conflicts > 0 {
+						conflicts--
+						return k8serrors.NewConflict(schema.GroupResource{Resource: "experimentdeployments"}, obj.GetName(), nil)
+					}
+					return c.SubResource(subResourceName).Patch(ctx, obj, patch, opts...)
+				},
+			})).Build()
+		reconciler = &ExperimentDeploymentReconciler{
+			Client:   fakeClient,
+			Scheme:   scheme,
+			Recorder: record.NewFakeRecorder(100),
+		}
+
+		namespacedName = types.NamespacedName{Name: testExperimentCRName, Namespace: testNamespace}
+		Expect(fakeClient.Create(ctx, &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: testNamespace},
+			Spec: appsv1.DeploymentSpec{
+				Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "web"}},
+				Template: corev1.PodTemplateSpec{
+					ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": "web"}},
+					Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "web", Image: "nginx:1.27"}}},
+				},
+			},
+		})).To(Succeed())
+		Expect(fakeClient.Create(ctx, &experimentcontrollercomv1alpha1.ExperimentDeployment{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:       testExperimentCRName,
+				Namespace:  testNamespace,
+				Finalizers: []string{experimentDeploymentFinalizer},
+			},
+			Spec: experimentcontrollercomv1alpha1.ExperimentDeploymentSpec{
+				SourceRef: experimentcontrollercomv1alpha1.SourceRef{
+					Kind: experimentcontrollercomv1alpha1.SourceKindDeployment,
+					Name: "web",
+				},
+				OverrideSpec: apiextensionsv1.JSON{Raw: []byte(`{}`)},
+			},
+		})).To(Succeed())
+	})
+
+	reconcileExperiment := func() {
+		_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+		Expect(err).NotTo(HaveOccurred())
+	}
+
+	getExperimentCR := func() *experimentcontrollercomv1alpha1.ExperimentDeployment {
+		experimentCR := &experimentcontrollercomv1alpha1.ExperimentDeployment{}
+		Expect(fakeClient.Get(ctx, namespacedName, experimentCR)).To(Succeed())
+		return experimentCR
+	}
+
+	It("should only write the status when it changes", func() {
+		reconcileExperiment()
+		Expect(statusWrites).To(Equal(1))
+		Expect(getExperimentCR().Status.ObservedGeneration).To(Equal(getExperimentCR().Generation))
+
+		reconcileExperiment()
+		reconcileExperiment()
+		Expect(statusWrites).To(Equal(1))
+
+		// The experiment Deployment becoming available changes the status
+		deployment := &appsv1.Deployment{}
+		Expect(fakeClient.Get(ctx, namespacedName, deployment)).To(Succeed())
+		deployment.Status.Replicas = 1
+		deployment.Status.ReadyReplicas = 1
+		deployment.Status.AvailableReplicas = 1
+		deployment.Status.UpdatedReplicas = 1
+		deployment.Status.ObservedGeneration = deployment.Generation
+		Expect(fakeClient.Status().Update(ctx, deployment)).To(Succeed())
+		reconcileExperiment()
+		Expect(statusWrites).To(Equal(2))
+	})
+
+	It("should patch the status again onto the latest experiment on conflicts", func() {
+		conflicts = 2
+		reconcileExperiment()
+
+		Expect(statusWrites).To(Equal(3))
+		Expect(getExperimentCR().Status.Conditions).NotTo(BeEmpty())
+	})
+
+	It("should hash the same configuration to the same value", func() {
+		applied := func(image string) *unstructured.Unstructured {
+			return &unstructured.Unstructured{Object: map[string]interface{}{
+				"metadata": map[string]interface{}{"name": "web", "labels": map[string]interface{}{"b": "2", "a": "1"}},
+				"spec":     map[string]interface{}{"image": image},
+			}}
+		}
+		hash, err := specHash(applied("nginx:1.27"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(hash).To(HaveLen(16))
+		Expect(specHash(applied("nginx:1.27"))).To(Equal(hash))
+		Expect(specHash(applied("nginx:1.28"))).NotTo(Equal(hash))
+	})
+})
@@ -0,0 +1,123 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	experimentcontrollercomv1alpha1 "experimentcontroller.example.com/experiment-deployment/api/v1alpha1"
+	"experimentcontroller.example.com/experiment-deployment/internal/notification"
+)
+
+// CloudEventsConfig configures the CloudEvents published about experiment lifecycle transitions
+type CloudEventsConfig struct {
+	// Sink is the URL events are posted to, unless the namespace of the experiment sets its own
+	// sink. Without a sink no events are published.
+	Sink string
+	// Mode is the HTTP content mode of the events, binary if empty
+	Mode notification.CloudEventsMode
+}
+
+// overrideHash returns a short hash of the overrides of an experiment that does not depend on
+// their formatting
+func overrideHash(experimentCR *experimentcontrollercomv1alpha1.ExperimentDeployment) string {
+	raw := experimentCR.Spec.OverrideSpec.Raw
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+	var overrides interface{}
+	if err := decoder.Decode(&overrides); err == nil {
+		if canonical, err := json.Marshal(overrides); err == nil {
+			raw = canonical
+		}
+	}
+	sum := sha256.Sum256(raw)
+	return hex.EncodeToString(sum[:])[:16]
+}
+
+// cloudEventsSink returns the sink of the experiments of a namespace. The sink annotation of the
+// namespace takes precedence over the controller sink; an empty annotation disables the events.
+func (r *ExperimentDeploymentReconciler) cloudEventsSink(ctx context.Context, namespace string) (string, error) {
+	ns := &corev1.Namespace{}
+	if err := r.Get(ctx, types.NamespacedName{Name: namespace}, ns); err != nil {
+		// Namespace-scoped installations cannot read namespaces and only use the controller sink
+		if apierrors.IsNotFound(err) || apierrors.IsForbidden(err) {
+			return r.CloudEvents.Sink, nil
+		}
+		return "", fmt.Errorf("failed to get namespace %s: %w", namespace, err)
+	}
+	if sink, ok := ns.Annotations[experimentcontrollercomv1alpha1.CloudEventsSinkAnnotation]; ok {
+		return sink, nil
+	}
+	return r.CloudEvents.Sink, nil
+}
+
+// newCloudEventsPublisher returns the publisher of the CloudEvents of the reconciler, which
+// resolves the sinks of the namespaces in the background
+func (r *ExperimentDeploymentReconciler) newCloudEventsPublisher() *notification.Publisher {
+	return notification.NewPublisher(r.notifier(), r.CloudEvents.Mode, r.cloudEventsSink, defaultNotificationRetries)
+}
+
+// publishCloudEvent queues a lifecycle transition of an experiment for publishing to the
+// CloudEvents sink of its namespace. Failed deliveries do not fail the reconcile.
+func (r *ExperimentDeploymentReconciler) publishCloudEvent(
+	ctx context.Context,
+	experimentCR *experimentcontrollercomv1alpha1.ExperimentDeployment,
+	event notification.Event) {
+
+	if r.publisher == nil {
+		return
+	}
+	data := notification.ExperimentData{
+		Experiment:    experimentCR.Name,
+		Namespace:     experimentCR.Namespace,
+		SourceRef:     experimentCR.Spec.SourceRef,
+		OverrideHash:  overrideHash(experimentCR),
+		Replicas:      r.experimentReplicas(experimentCR),
+		ReadyReplicas: experimentCR.Status.ReadyReplicas,
+		Labels:        experimentCR.Labels,
+		Phase:         event.Phase,
+		AnalysisPhase: experimentCR.Status.AnalysisPhase,
+		Reason:        event.Reason,
+		Message:       event.Message,
+	}
+	// The id is stable across the redeliveries of a transition, so sinks can deduplicate them
+	id := fmt.Sprintf("%s-%s-%s", experimentCR.UID, experimentCR.ResourceVersion, strings.ToLower(string(event.Type)))
+	cloudEvent := notification.NewCloudEvent(id, event.Type, data, event.Time)
+
+	log := logf.FromContext(ctx)
+	experiment := experimentCR.DeepCopy()
+	r.publisher.Publish(&notification.Publication{
+		Namespace: experimentCR.Namespace,
+		Event:     cloudEvent,
+		OnFailure: func(err error) {
+			log.Error(err, "Failed to publish CloudEvent", "type", cloudEvent.Type)
+			r.Recorder.Eventf(experiment, corev1.EventTypeWarning, "CloudEventFailed",
+				"Failed to publish %s CloudEvent: %s", cloudEvent.Type, err.Error())
+		},
+	})
+}
@@ -0,0 +1,218 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	experimentcontrollercomv1alpha1 "experimentcontroller.example.com/experiment-deployment/api/v1alpha1"
+	"experimentcontroller.example.com/experiment-deployment/internal/notification"
+)
+
+var _ = Describe("CloudEvents", func() {
+	var (
+		ctx            context.Context
+		reconciler     *ExperimentDeploymentReconciler
+		fakeClient     client.Client
+		namespacedName types.NamespacedName
+		controllerSink *httptest.Server
+		namespaceSink  *httptest.Server
+		mu             sync.Mutex
+		received       map[string][]http.Header
+		bodies         map[string][][]byte
+	)
+
+	newSink := func(name string) *httptest.Server {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+			mu.Lock()
+			defer mu.Unlock()
+			received[name] = append(received[name], r.Header.Clone())
+			bodies[name] = append(bodies[name], body)
+		}))
+		DeferCleanup(server.Close)
+		return server
+	}
+
+	receivedTypes := func(name string) []string {
+		mu.Lock()
+		defer mu.Unlock()
+		var types []string
+		for _, header := range received[name] {
+			types = append(types, header.Get("ce-type"))
+		}
+		return types
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		received = map[string][]http.Header{}
+		bodies = map[string][][]byte{}
+		controllerSink = newSink("controller")
+		namespaceSink = newSink("namespace")
+
+		scheme := runtime.NewScheme()
+		Expect(experimentcontrollercomv1alpha1.AddToScheme(scheme)).To(Succeed())
+		Expect(appsv1.AddToScheme(scheme)).To(Succeed())
+		Expect(corev1.AddToScheme(scheme)).To(Succeed())
+		fakeClient = fake.NewClientBuilder().WithScheme(scheme).
+			WithStatusSubresource(&experimentcontrollercomv1alpha1.ExperimentDeployment{}).
+			WithInterceptorFuncs(withServerSideApply(interceptor.Funcs{})).Build()
+		reconciler = &ExperimentDeploymentReconciler{
+			Client:      fakeClient,
+			Scheme:      scheme,
+			Recorder:    record.NewFakeRecorder(100),
+			Notifier:    &notification.Notifier{Client: controllerSink.Client(), Backoff: wait.Backoff{Duration: time.Millisecond}},
+			CloudEvents: CloudEventsConfig{Sink: controllerSink.URL, Mode: notification.CloudEventsModeBinary},
+		}
+		reconciler.publisher = reconciler.newCloudEventsPublisher()
+		publisherCtx, stopPublisher := context.WithCancel(ctx)
+		go func() {
+			defer GinkgoRecover()
+			Expect(reconciler.publisher.Start(publisherCtx)).To(Succeed())
+		}()
+		DeferCleanup(stopPublisher)
+		namespacedName = types.NamespacedName{Name: testExperimentCRName, Namespace: testNamespace}
+
+		Expect(fakeClient.Create(ctx, &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: testNamespace},
+			Spec: appsv1.DeploymentSpec{
+				Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "web"}},
+				Template: corev1.PodTemplateSpec{
+					ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": "web"}},
+					Spec: corev1.PodSpec{
+						Containers: []corev1.Container{{Name: "web", Image: "nginx:1.27"}},
+					},
+				},
+			},
+		})).To(Succeed())
+		Expect(fakeClient.Create(ctx, &experimentcontrollercomv1alpha1.ExperimentDeployment{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:       testExperimentCRName,
+				Namespace:  testNamespace,
+				Labels:     map[string]string{"team": "web"},
+				Finalizers: []string{experimentDeploymentFinalizer},
+			},
+			Spec: experimentcontrollercomv1alpha1.ExperimentDeploymentSpec{
+				SourceRef: experimentcontrollercomv1alpha1.SourceRef{
+					Kind: experimentcontrollercomv1alpha1.SourceKindDeployment,
+					Name: "web",
+				},
+				Replicas:     ptr.To(int32(2)),
+				OverrideSpec: apiextensionsv1.JSON{Raw: []byte(`{"template": {"spec": {"containers": [{"name": "web", "image": "nginx:1.28"}]}}}`)},
+			},
+		})).To(Succeed())
+	})
+
+	reconcile := func() *experimentcontrollercomv1alpha1.ExperimentDeployment {
+		_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+		Expect(err).NotTo(HaveOccurred())
+		updatedCR := &experimentcontrollercomv1alpha1.ExperimentDeployment{}
+		Expect(fakeClient.Get(ctx, namespacedName, updatedCR)).To(Succeed())
+		return updatedCR
+	}
+
+	It("should publish the lifecycle transitions to the controller sink", func() {
+		updatedCR := reconcile()
+		Eventually(func() []string { return receivedTypes("controller") }).Should(Equal([]string{"com.example.experimentcontroller.experiment.created"}))
+
+		experimentDeployment := &appsv1.Deployment{}
+		Expect(fakeClient.Get(ctx, namespacedName, experimentDeployment)).To(Succeed())
+		experimentDeployment.Status.Replicas = 2
+		experimentDeployment.Status.ReadyReplicas = 2
+		experimentDeployment.Status.UpdatedReplicas = 2
+		Expect(fakeClient.Status().Update(ctx, experimentDeployment)).To(Succeed())
+		reconcile()
+		reconcile()
+		Eventually(func() []string { return receivedTypes("controller") }).Should(Equal([]string{
+			"com.example.experimentcontroller.experiment.created",
+			"com.example.experimentcontroller.experiment.workload-ready",
+		}))
+
+		mu.Lock()
+		header, body := received["controller"][1], bodies["controller"][1]
+		mu.Unlock()
+		Expect(header.Get("ce-specversion")).To(Equal("1.0"))
+		Expect(header.Get("ce-subject")).To(Equal(testExperimentCRName))
+		var data notification.ExperimentData
+		Expect(json.Unmarshal(body, &data)).To(Succeed())
+		Expect(data.SourceRef.Name).To(Equal("web"))
+		Expect(data.OverrideHash).To(Equal(overrideHash(updatedCR)))
+		Expect(data.Replicas).To(Equal(int32(2)))
+		Expect(data.ReadyReplicas).To(Equal(int32(2)))
+		Expect(data.Labels).To(Equal(map[string]string{"team": "web"}))
+
+		Expect(fakeClient.Delete(ctx, updatedCR)).To(Succeed())
+		_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+		Expect(err).NotTo(HaveOccurred())
+		Eventually(func() []string { return receivedTypes("controller") }).Should(HaveLen(3))
+		Expect(receivedTypes("controller")[2]).To(Equal("com.example.experimentcontroller.experiment.deleted"))
+	})
+
+	It("should publish to the sink of the namespace instead", func() {
+		Expect(fakeClient.Create(ctx, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+			Name:        testNamespace,
+			Annotations: map[string]string{experimentcontrollercomv1alpha1.CloudEventsSinkAnnotation: namespaceSink.URL},
+		}})).To(Succeed())
+
+		reconcile()
+
+		Eventually(func() []string { return receivedTypes("namespace") }).Should(Equal([]string{"com.example.experimentcontroller.experiment.created"}))
+		Expect(receivedTypes("controller")).To(BeEmpty())
+	})
+
+	It("should not publish when the namespace disables the events", func() {
+		Expect(fakeClient.Create(ctx, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+			Name:        testNamespace,
+			Annotations: map[string]string{experimentcontrollercomv1alpha1.CloudEventsSinkAnnotation: ""},
+		}})).To(Succeed())
+
+		reconcile()
+
+		Consistently(func() []string { return receivedTypes("controller") }).WithTimeout(200 * time.Millisecond).Should(BeEmpty())
+	})
+
+	It("should not hold up the reconcile while the sink is slow", func() {
+		release := make(chan struct{})
+		slowSink := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			<-release
+		}))
+		DeferCleanup(slowSink.Close)
+		DeferCleanup(func() { close(release) })
+		reconciler.CloudEvents.Sink = slowSink.URL
+
+		started := time.Now()
+		reconcile()
+		Expect(time.Since(started)).To(BeNumerically("<", time.Second))
+	})
+
+	It("should hash the overrides independently of their formatting", func() {
+		experimentCR := &experimentcontrollercomv1alpha1.ExperimentDeployment{}
+		experimentCR.Spec.OverrideSpec.Raw = []byte(`{"b": 1, "a": {"c": "d"}}`)
+		hash := overrideHash(experimentCR)
+		experimentCR.Spec.OverrideSpec.Raw = []byte("{\"a\":{\"c\":\"d\"},\n \"b\":1}")
+		Expect(overrideHash(experimentCR)).To(Equal(hash))
+		experimentCR.Spec.OverrideSpec.Raw = []byte(`{"a": {"c": "e"}, "b": 1}`)
+		Expect(overrideHash(experimentCR)).NotTo(Equal(hash))
+	})
+})
@@ -0,0 +1,47 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"experimentcontroller.example.com/experiment-deployment/internal/config"
+)
+
+// Names of the labels and annotations the controller sets, prefixed with the configured label domain
+const (
+	labelCRName       = "cr-name"
+	labelManagedBy    = "managed-by"
+	labelRole         = "role"
+	labelOverrideHash = "override-hash"
+
+	// annotationSpecHash is the hash of the configuration last applied to an experiment workload
+	annotationSpecHash = "spec-hash"
+)
+
+// config returns the current configuration of the controller
+func (r *ExperimentDeploymentReconciler) config() *config.ControllerConfig {
+	return r.Config.Get()
+}
+
+// labelKey returns the key of a label or annotation set by the controller
+func (r *ExperimentDeploymentReconciler) labelKey(name string) string {
+	return r.config().LabelDomain + "/" + name
+}
+
+// finalizer returns the finalizer the controller adds to experiments
+func (r *ExperimentDeploymentReconciler) finalizer() string {
+	return r.config().Finalizer
+}
@@ -0,0 +1,119 @@
+package controller
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	experimentcontrollercomv1alpha1 "experimentcontroller.example.com/experiment-deployment/api/v1alpha1"
+	"experimentcontroller.example.com/experiment-deployment/internal/config"
+)
+
+var _ = Describe("Controller config", func() {
+	var (
+		ctx        context.Context
+		reconciler *ExperimentDeploymentReconciler
+		fakeClient client.Client
+		cfg        *config.ControllerConfig
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		scheme := runtime.NewScheme()
+		Expect(experimentcontrollercomv1alpha1.AddToScheme(scheme)).To(Succeed())
+		Expect(appsv1.AddToScheme(scheme)).To(Succeed())
+		Expect(corev1.AddToScheme(scheme)).To(Succeed())
+		fakeClient = fake.NewClientBuilder().WithScheme(scheme).
+			WithStatusSubresource(&experimentcontrollercomv1alpha1.ExperimentDeployment{}).
+			WithInterceptorFuncs(withServerSideApply(interceptor.Funcs{})).Build()
+
+		cfg = config.DefaultControllerConfig()
+		cfg.LabelDomain = "experiments.example.org"
+		cfg.Finalizer = "experiments.example.org/cleanup"
+		cfg.Defaults.Replicas = 2
+		cfg.Requeue.NotReadyInterval = metav1.Duration{Duration: 7 * time.Second}
+		cfg.Requeue.WaitingInterval = metav1.Duration{Duration: 2 * time.Minute}
+		cfg.Features = map[config.Feature]bool{config.FeatureRevisionHistory: false}
+		reconciler = &ExperimentDeploymentReconciler{
+			Client:   fakeClient,
+			Scheme:   scheme,
+			Recorder: record.NewFakeRecorder(100),
+			Config:   config.NewStore(cfg),
+		}
+
+		Expect(fakeClient.Create(ctx, &experimentcontrollercomv1alpha1.ExperimentDeployment{
+			ObjectMeta: metav1.ObjectMeta{Name: testExperimentCRName, Namespace: testNamespace},
+			Spec: experimentcontrollercomv1alpha1.ExperimentDeploymentSpec{
+				SourceRef: experimentcontrollercomv1alpha1.SourceRef{
+					Kind: experimentcontrollercomv1alpha1.SourceKindDeployment,
+					Name: "web",
+				},
+				OverrideSpec: apiextensionsv1.JSON{Raw: []byte(`{}`)},
+			},
+		})).To(Succeed())
+	})
+
+	reconcile := func() ctrl.Result {
+		result, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: types.NamespacedName{Name: testExperimentCRName, Namespace: testNamespace}})
+		Expect(err).NotTo(HaveOccurred())
+		return result
+	}
+
+	It("should add the configured finalizer and requeue experiments waiting for their source", func() {
+		Expect(reconcile().Requeue).To(BeTrue())
+		experimentCR := &experimentcontrollercomv1alpha1.ExperimentDeployment{}
+		Expect(fakeClient.Get(ctx, types.NamespacedName{Name: testExperimentCRName, Namespace: testNamespace}, experimentCR)).To(Succeed())
+		Expect(experimentCR.Finalizers).To(ConsistOf("experiments.example.org/cleanup"))
+
+		Expect(reconcile().RequeueAfter).To(Equal(2 * time.Minute))
+	})
+
+	It("should label, scale and requeue the experiment workload as configured", func() {
+		Expect(fakeClient.Create(ctx, &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: testNamespace},
+			Spec: appsv1.DeploymentSpec{
+				Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "web"}},
+				Template: corev1.PodTemplateSpec{
+					ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": "web"}},
+					Spec: corev1.PodSpec{
+						Containers: []corev1.Container{{Name: "web", Image: "nginx:1.27"}},
+					},
+				},
+			},
+		})).To(Succeed())
+
+		reconcile()
+		Expect(reconcile().RequeueAfter).To(Equal(7 * time.Second))
+
+		deployment := &appsv1.Deployment{}
+		Expect(fakeClient.Get(ctx, types.NamespacedName{Name: testExperimentCRName, Namespace: testNamespace}, deployment)).To(Succeed())
+		Expect(*deployment.Spec.Replicas).To(Equal(int32(2)))
+		Expect(deployment.Labels).To(Equal(map[string]string{
+			"experiments.example.org/managed-by": "experiment-controller",
+			"experiments.example.org/cr-name":    testExperimentCRName,
+		}))
+		Expect(deployment.Spec.Template.Labels).To(HaveKeyWithValue("experiments.example.org/role", ExperimentRoleValue))
+		Expect(deployment.Spec.Template.Labels).To(HaveKeyWithValue("experiments.example.org/source-deployment-name", "web"))
+
+		// The RevisionHistory feature is disabled
+		revisions := &appsv1.ControllerRevisionList{}
+		Expect(fakeClient.List(ctx, revisions)).To(Succeed())
+		Expect(revisions.Items).To(BeEmpty())
+		experimentCR := &experimentcontrollercomv1alpha1.ExperimentDeployment{}
+		Expect(fakeClient.Get(ctx, types.NamespacedName{Name: testExperimentCRName, Namespace: testNamespace}, experimentCR)).To(Succeed())
+		Expect(experimentCR.Status.History).To(BeEmpty())
+	})
+})
@@ -0,0 +1,257 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	experimentcontrollercomv1alpha1 "experimentcontroller.example.com/experiment-deployment/api/v1alpha1"
+)
+
+// ConcurrencyLimits limit the experiments running at the same time. Zero means unlimited.
+type ConcurrencyLimits struct {
+	// MaxExperimentsPerSource is the maximum number of running experiments of a source workload
+	MaxExperimentsPerSource int32
+	// MaxExperimentsPerNamespace is the maximum number of running experiments of a namespace
+	MaxExperimentsPerNamespace int32
+	// MaxTrafficSharePercent is the maximum share of the pods of a source and its Shared experiments
+	// that belong to the experiments
+	MaxTrafficSharePercent int32
+}
+
+// effectiveConcurrencyLimits returns the strictest of the controller limits and the limits of all
+// ClusterExperimentPolicies
+func (r *ExperimentDeploymentReconciler) effectiveConcurrencyLimits(ctx context.Context) (ConcurrencyLimits, error) {
+	limits := r.ConcurrencyLimits
+
+	policies := &experimentcontrollercomv1alpha1.ClusterExperimentPolicyList{}
+	if err := r.List(ctx, policies); err != nil {
+		if meta.IsNoMatchError(err) {
+			return limits, nil
+		}
+		return limits, err
+	}
+	for _, policy := range policies.Items {
+		limits.MaxExperimentsPerSource = stricterLimit(limits.MaxExperimentsPerSource, policy.Spec.MaxExperimentsPerSource)
+		limits.MaxExperimentsPerNamespace = stricterLimit(limits.MaxExperimentsPerNamespace, policy.Spec.MaxExperimentsPerNamespace)
+		limits.MaxTrafficSharePercent = stricterLimit(limits.MaxTrafficSharePercent, policy.Spec.MaxTrafficSharePercent)
+	}
+	return limits, nil
+}
+
+// stricterLimit returns the smaller of two limits, where zero and nil mean unlimited
+func stricterLimit(limit int32, policyLimit *int32) int32 {
+	if policyLimit == nil || *policyLimit <= 0 {
+		return limit
+	}
+	if limit == 0 || *policyLimit < limit {
+		return *policyLimit
+	}
+	return limit
+}
+
+// admitExperiment decides whether the experiment may start within the concurrency limits. Running
+// experiments are never preempted. Other experiments are queued behind the queued experiments of a
+// higher priority or, at equal priority, created earlier. Queued experiments get the Queued phase and
+// condition, and start when a slot frees up.
+func (r *ExperimentDeploymentReconciler) admitExperiment(
+	ctx context.Context,
+	experimentCR *experimentcontrollercomv1alpha1.ExperimentDeployment,
+	sourceNamespace string,
+	sourceReplicas int32) (bool, error) {
+
+	log := logf.FromContext(ctx)
+
+	limits, err := r.effectiveConcurrencyLimits(ctx)
+	if err != nil {
+		log.Error(err, "Failed to list ClusterExperimentPolicies for concurrency limits")
+		return false, err
+	}
+	if limits == (ConcurrencyLimits{}) || isExperimentRunning(experimentCR) {
+		r.dequeueExperiment(experimentCR)
+		return true, nil
+	}
+
+	experiments := &experimentcontrollercomv1alpha1.ExperimentDeploymentList{}
+	if err := r.concurrencyReader().List(ctx, experiments); err != nil {
+		log.Error(err, "Failed to list ExperimentDeployments for concurrency limits")
+		return false, err
+	}
+
+	source := experimentSourceKey(experimentCR)
+	var perSource, perNamespace, sharedReplicas int32
+	for i := range experiments.Items {
+		other := &experiments.Items[i]
+		if (other.Namespace == experimentCR.Namespace && other.Name == experimentCR.Name) || !other.DeletionTimestamp.IsZero() {
+			continue
+		}
+		// Aborted experiments keep their workload scaled down and free their slot
+		if isExperimentAborted(other) {
+			continue
+		}
+		sameSource := experimentSourceKey(other) == source
+		sameNamespace := other.Namespace == experimentCR.Namespace
+		if !sameSource && !sameNamespace {
+			continue
+		}
+		// Count the running experiments and the queued experiments ahead of this one
+		if !isExperimentRunning(other) && !(other.Status.Phase == experimentcontrollercomv1alpha1.ExperimentPhaseQueued && queuedBefore(other, experimentCR)) {
+			continue
+		}
+		if sameSource {
+			perSource++
+			if effectiveServiceMode(other) == experimentcontrollercomv1alpha1.ServiceModeShared {
+				sharedReplicas += r.experimentReplicas(other)
+			}
+		}
+		if sameNamespace {
+			perNamespace++
+		}
+	}
+
+	var reason string
+	switch {
+	case limits.MaxExperimentsPerSource > 0 && perSource >= limits.MaxExperimentsPerSource:
+		reason = fmt.Sprintf("%d experiments of %s %s are running or queued ahead, the limit is %d",
+			perSource, experimentCR.Spec.SourceRef.Kind, types.NamespacedName{Namespace: sourceNamespace, Name: experimentCR.Spec.SourceRef.Name}, limits.MaxExperimentsPerSource)
+	case limits.MaxExperimentsPerNamespace > 0 && perNamespace >= limits.MaxExperimentsPerNamespace:
+		reason = fmt.Sprintf("%d experiments of namespace %s are running or queued ahead, the limit is %d",
+			perNamespace, experimentCR.Namespace, limits.MaxExperimentsPerNamespace)
+	case limits.MaxTrafficSharePercent > 0 && effectiveServiceMode(experimentCR) == experimentcontrollercomv1alpha1.ServiceModeShared:
+		experimentPods := sharedReplicas + r.experimentReplicas(experimentCR)
+		if int64(experimentPods)*100 > int64(limits.MaxTrafficSharePercent)*int64(sourceReplicas+experimentPods) {
+			reason = fmt.Sprintf("%d experiment pods next to %d source pods would receive more than %d%% of the traffic of %s %s",
+				experimentPods, sourceReplicas, limits.MaxTrafficSharePercent,
+				experimentCR.Spec.SourceRef.Kind, types.NamespacedName{Namespace: sourceNamespace, Name: experimentCR.Spec.SourceRef.Name})
+		}
+	}
+	if reason == "" {
+		r.dequeueExperiment(experimentCR)
+		return true, nil
+	}
+
+	if experimentCR.Status.Phase != experimentcontrollercomv1alpha1.ExperimentPhaseQueued {
+		log.Info("ExperimentDeployment queued", "reason", reason)
+		r.Recorder.Event(experimentCR, corev1.EventTypeNormal, "Queued", reason)
+	}
+	experimentCR.Status.Phase = experimentcontrollercomv1alpha1.ExperimentPhaseQueued
+	r.updateStatusConditions(experimentCR, experimentcontrollercomv1alpha1.ReasonQueued, "Waiting for a slot: "+reason)
+	return false, nil
+}
+
+// concurrencyReader returns the reader of the experiments counted against the concurrency limits.
+// With an experiment selector the cache only holds the experiments of this replica, and with
+// sharding other replicas admit experiments of the same sources, so the experiments of all
+// replicas are then listed from the API server.
+func (r *ExperimentDeploymentReconciler) concurrencyReader() client.Reader {
+	if r.APIReader != nil && (r.ExperimentSelector != nil || r.Sharding.enabled()) {
+		return r.APIReader
+	}
+	return r.Client
+}
+
+// dequeueExperiment clears the Queued phase of an experiment allowed to start
+func (r *ExperimentDeploymentReconciler) dequeueExperiment(experimentCR *experimentcontrollercomv1alpha1.ExperimentDeployment) {
+	if experimentCR.Status.Phase != experimentcontrollercomv1alpha1.ExperimentPhaseQueued {
+		return
+	}
+	r.Recorder.Event(experimentCR, corev1.EventTypeNormal, "Dequeued", "A slot within the concurrency limits is free, starting the experiment")
+	experimentCR.Status.Phase = ""
+}
+
+// isExperimentRunning reports whether the workload of the experiment has been rendered
+func isExperimentRunning(experimentCR *experimentcontrollercomv1alpha1.ExperimentDeployment) bool {
+	return experimentCR.Status.ExperimentResourceRef != nil && experimentCR.Status.Phase != experimentcontrollercomv1alpha1.ExperimentPhaseQueued
+}
+
+// queuedBefore reports whether experiment a leaves the queue before experiment b
+func queuedBefore(a, b *experimentcontrollercomv1alpha1.ExperimentDeployment) bool {
+	if a.Spec.Priority != b.Spec.Priority {
+		return a.Spec.Priority > b.Spec.Priority
+	}
+	if !a.CreationTimestamp.Equal(&b.CreationTimestamp) {
+		return a.CreationTimestamp.Before(&b.CreationTimestamp)
+	}
+	if a.Namespace != b.Namespace {
+		return a.Namespace < b.Namespace
+	}
+	return a.Name < b.Name
+}
+
+// experimentSourceKey identifies the source workload of an experiment
+func experimentSourceKey(experimentCR *experimentcontrollercomv1alpha1.ExperimentDeployment) string {
+	sourceNamespace := experimentCR.Spec.SourceRef.Namespace
+	if sourceNamespace == "" {
+		sourceNamespace = experimentCR.Namespace
+	}
+	return sourceKey(experimentCR.Spec.SourceRef.Kind, sourceNamespace, experimentCR.Spec.SourceRef.Name)
+}
+
+// experimentReplicas returns the replicas an experiment runs, zero once it is aborted
+func (r *ExperimentDeploymentReconciler) experimentReplicas(experimentCR *experimentcontrollercomv1alpha1.ExperimentDeployment) int32 {
+	if isExperimentAborted(experimentCR) {
+		return 0
+	}
+	if experimentCR.Spec.Replicas != nil {
+		return *experimentCR.Spec.Replicas
+	}
+	return r.config().Defaults.Replicas
+}
+
+// sourceReplicas returns the replicas of a source workload, defaulting to 1
+func sourceReplicas(replicas *int32) int32 {
+	if replicas == nil {
+		return 1
+	}
+	return *replicas
+}
+
+// queuedExperimentsFor enqueues the queued experiments sharing the source or the namespace of a
+// changed experiment, so they start as soon as it frees up a slot. Only the experiments of this
+// replica are enqueued, the queued experiments of other replicas retry after the waiting interval.
+func (r *ExperimentDeploymentReconciler) queuedExperimentsFor(ctx context.Context, obj client.Object) []reconcile.Request {
+	changed, ok := obj.(*experimentcontrollercomv1alpha1.ExperimentDeployment)
+	if !ok {
+		return nil
+	}
+	experiments := &experimentcontrollercomv1alpha1.ExperimentDeploymentList{}
+	if err := r.List(ctx, experiments); err != nil {
+		logf.FromContext(ctx).Error(err, "Failed to list ExperimentDeployments for queued experiments")
+		return nil
+	}
+	source := experimentSourceKey(changed)
+	var requests []reconcile.Request
+	for i := range experiments.Items {
+		experiment := &experiments.Items[i]
+		if experiment.Status.Phase != experimentcontrollercomv1alpha1.ExperimentPhaseQueued ||
+			(experiment.Namespace == changed.Namespace && experiment.Name == changed.Name) {
+			continue
+		}
+		if experiment.Namespace == changed.Namespace || experimentSourceKey(experiment) == source {
+			requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: experiment.Name, Namespace: experiment.Namespace}})
+		}
+	}
+	return requests
+}
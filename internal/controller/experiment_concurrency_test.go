@@ -0,0 +1,253 @@
+package controller
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	experimentcontrollercomv1alpha1 "experimentcontroller.example.com/experiment-deployment/api/v1alpha1"
+)
+
+var _ = Describe("Experiment Concurrency Limits", func() {
+	var (
+		ctx              context.Context
+		reconciler       *ExperimentDeploymentReconciler
+		fakeClient       client.Client
+		sourceDeployment *appsv1.Deployment
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		scheme := runtime.NewScheme()
+		Expect(experimentcontrollercomv1alpha1.AddToScheme(scheme)).To(Succeed())
+		Expect(appsv1.AddToScheme(scheme)).To(Succeed())
+		Expect(corev1.AddToScheme(scheme)).To(Succeed())
+		fakeClient = fake.NewClientBuilder().WithScheme(scheme).WithInterceptorFuncs(withServerSideApply(interceptor.Funcs{})).WithStatusSubresource(&experimentcontrollercomv1alpha1.ExperimentDeployment{}).Build()
+		reconciler = &ExperimentDeploymentReconciler{
+			Client:   fakeClient,
+			Scheme:   scheme,
+			Recorder: record.NewFakeRecorder(100),
+		}
+
+		sourceDeployment = &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: testNamespace},
+			Spec: appsv1.DeploymentSpec{
+				Replicas: ptr.To(int32(4)),
+				Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "web"}},
+				Template: corev1.PodTemplateSpec{
+					ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": "web"}},
+					Spec: corev1.PodSpec{
+						Containers: []corev1.Container{{Name: "web", Image: "nginx:1.27"}},
+					},
+				},
+			},
+		}
+		Expect(fakeClient.Create(ctx, sourceDeployment)).To(Succeed())
+	})
+
+	createExperiment := func(name string, mutate func(*experimentcontrollercomv1alpha1.ExperimentDeployment)) {
+		experimentCR := &experimentcontrollercomv1alpha1.ExperimentDeployment{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:       name,
+				Namespace:  testNamespace,
+				Finalizers: []string{experimentDeploymentFinalizer},
+			},
+			Spec: experimentcontrollercomv1alpha1.ExperimentDeploymentSpec{
+				SourceRef: experimentcontrollercomv1alpha1.SourceRef{
+					Kind: experimentcontrollercomv1alpha1.SourceKindDeployment,
+					Name: "web",
+				},
+				OverrideSpec: apiextensionsv1.JSON{Raw: []byte(`{}`)},
+			},
+		}
+		if mutate != nil {
+			mutate(experimentCR)
+		}
+		Expect(fakeClient.Create(ctx, experimentCR)).To(Succeed())
+	}
+
+	reconcileExperiment := func(name string) {
+		_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: types.NamespacedName{Name: name, Namespace: testNamespace}})
+		Expect(err).NotTo(HaveOccurred())
+	}
+
+	getExperimentCR := func(name string) *experimentcontrollercomv1alpha1.ExperimentDeployment {
+		experimentCR := &experimentcontrollercomv1alpha1.ExperimentDeployment{}
+		Expect(fakeClient.Get(ctx, types.NamespacedName{Name: name, Namespace: testNamespace}, experimentCR)).To(Succeed())
+		return experimentCR
+	}
+
+	isRendered := func(name string) bool {
+		err := fakeClient.Get(ctx, types.NamespacedName{Name: name, Namespace: testNamespace}, &appsv1.Deployment{})
+		if k8serrors.IsNotFound(err) {
+			return false
+		}
+		Expect(err).NotTo(HaveOccurred())
+		return true
+	}
+
+	expectQueued := func(name, message string) {
+		experimentCR := getExperimentCR(name)
+		Expect(experimentCR.Status.Phase).To(Equal(experimentcontrollercomv1alpha1.ExperimentPhaseQueued))
+		readyCondition := meta.FindStatusCondition(experimentCR.Status.Conditions, ConditionTypeReady)
+		Expect(readyCondition).NotTo(BeNil())
+		Expect(readyCondition.Reason).To(Equal("Queued"))
+		Expect(readyCondition.Message).To(ContainSubstring(message))
+		Expect(isRendered(name)).To(BeFalse())
+	}
+
+	deleteExperiment := func(name string) {
+		Expect(fakeClient.Delete(ctx, getExperimentCR(name))).To(Succeed())
+		reconcileExperiment(name)
+	}
+
+	It("should queue experiments beyond the limit per source and start them when a slot frees up", func() {
+		reconciler.ConcurrencyLimits.MaxExperimentsPerSource = 1
+		createExperiment("exp-a", nil)
+		createExperiment("exp-b", nil)
+
+		reconcileExperiment("exp-a")
+		reconcileExperiment("exp-b")
+		Expect(isRendered("exp-a")).To(BeTrue())
+		expectQueued("exp-b", "1 experiments of Deployment test-namespace/web are running or queued ahead, the limit is 1")
+		Expect(reconciler.queuedExperimentsFor(ctx, getExperimentCR("exp-a"))).To(ConsistOf(
+			ctrl.Request{NamespacedName: types.NamespacedName{Name: "exp-b", Namespace: testNamespace}}))
+
+		// Running experiments are not preempted
+		reconcileExperiment("exp-a")
+		Expect(getExperimentCR("exp-a").Status.Phase).To(Equal(experimentcontrollercomv1alpha1.ExperimentPhasePending))
+
+		deleteExperiment("exp-a")
+		reconcileExperiment("exp-b")
+		Expect(isRendered("exp-b")).To(BeTrue())
+		Expect(getExperimentCR("exp-b").Status.Phase).To(Equal(experimentcontrollercomv1alpha1.ExperimentPhasePending))
+	})
+
+	It("should start queued experiments when a running experiment is aborted", func() {
+		reconciler.ConcurrencyLimits.MaxExperimentsPerSource = 1
+		createExperiment("exp-a", nil)
+		createExperiment("exp-b", nil)
+
+		reconcileExperiment("exp-a")
+		reconcileExperiment("exp-b")
+		expectQueued("exp-b", "running or queued ahead")
+
+		abortedCR := getExperimentCR("exp-a")
+		abortedCR.Status.Abort = &experimentcontrollercomv1alpha1.AbortStatus{
+			Trigger: experimentcontrollercomv1alpha1.AbortTriggerMaxRestarts,
+			Action:  experimentcontrollercomv1alpha1.AbortActionScaleToZero,
+			Time:    metav1.Now(),
+		}
+		Expect(fakeClient.Status().Update(ctx, abortedCR)).To(Succeed())
+		reconcileExperiment("exp-a")
+		Expect(isRendered("exp-a")).To(BeTrue())
+
+		reconcileExperiment("exp-b")
+		Expect(isRendered("exp-b")).To(BeTrue())
+		Expect(getExperimentCR("exp-b").Status.Phase).NotTo(Equal(experimentcontrollercomv1alpha1.ExperimentPhaseQueued))
+	})
+
+	It("should count the experiments of other replicas when the cache holds selected experiments only", func() {
+		reconciler.ConcurrencyLimits.MaxExperimentsPerSource = 1
+		createExperiment("exp-a", func(experimentCR *experimentcontrollercomv1alpha1.ExperimentDeployment) {
+			experimentCR.Labels = map[string]string{"controller-version": "stable"}
+		})
+		runningCR := getExperimentCR("exp-a")
+		runningCR.Status.ExperimentResourceRef = &experimentcontrollercomv1alpha1.ExperimentResourceRef{Kind: "Deployment", Name: "exp-a"}
+		Expect(fakeClient.Status().Update(ctx, runningCR)).To(Succeed())
+		createExperiment("exp-b", func(experimentCR *experimentcontrollercomv1alpha1.ExperimentDeployment) {
+			experimentCR.Labels = map[string]string{"controller-version": "canary"}
+		})
+
+		// The cache of this replica only holds the canary experiments
+		selector := labels.SelectorFromSet(labels.Set{"controller-version": "canary"})
+		reconciler.ExperimentSelector = selector
+		reconciler.APIReader = fakeClient
+		reconciler.Client = interceptor.NewClient(fakeClient.(client.WithWatch), interceptor.Funcs{
+			List: func(ctx context.Context, c client.WithWatch, list client.ObjectList, opts ...client.ListOption) error {
+				if _, ok := list.(*experimentcontrollercomv1alpha1.ExperimentDeploymentList); ok {
+					opts = append(opts, client.MatchingLabelsSelector{Selector: selector})
+				}
+				return c.List(ctx, list, opts...)
+			},
+		})
+
+		reconcileExperiment("exp-b")
+		expectQueued("exp-b", "1 experiments of Deployment test-namespace/web are running or queued ahead, the limit is 1")
+	})
+
+	It("should start queued experiments in priority order", func() {
+		reconciler.ConcurrencyLimits.MaxExperimentsPerSource = 1
+		createExperiment("exp-a", nil)
+		createExperiment("exp-b", nil)
+		createExperiment("exp-c", func(experimentCR *experimentcontrollercomv1alpha1.ExperimentDeployment) {
+			experimentCR.Spec.Priority = 10
+		})
+
+		reconcileExperiment("exp-a")
+		reconcileExperiment("exp-b")
+		reconcileExperiment("exp-c")
+		expectQueued("exp-b", "running or queued ahead")
+		expectQueued("exp-c", "running or queued ahead")
+
+		deleteExperiment("exp-a")
+		reconcileExperiment("exp-b")
+		expectQueued("exp-b", "running or queued ahead")
+		reconcileExperiment("exp-c")
+		Expect(isRendered("exp-c")).To(BeTrue())
+	})
+
+	It("should apply the strictest limit of the controller and the policies", func() {
+		reconciler.ConcurrencyLimits.MaxExperimentsPerNamespace = 5
+		Expect(fakeClient.Create(ctx, &experimentcontrollercomv1alpha1.ClusterExperimentPolicy{
+			ObjectMeta: metav1.ObjectMeta{Name: "limits"},
+			Spec: experimentcontrollercomv1alpha1.ClusterExperimentPolicySpec{
+				MaxExperimentsPerNamespace: ptr.To(int32(1)),
+			},
+		})).To(Succeed())
+		createExperiment("exp-a", nil)
+		createExperiment("exp-b", nil)
+
+		reconcileExperiment("exp-a")
+		reconcileExperiment("exp-b")
+		Expect(isRendered("exp-a")).To(BeTrue())
+		expectQueued("exp-b", "1 experiments of namespace test-namespace are running or queued ahead, the limit is 1")
+	})
+
+	It("should cap the traffic share of Shared experiments", func() {
+		reconciler.ConcurrencyLimits.MaxTrafficSharePercent = 50
+		createExperiment("exp-a", func(experimentCR *experimentcontrollercomv1alpha1.ExperimentDeployment) {
+			experimentCR.Spec.Replicas = ptr.To(int32(3))
+		})
+		createExperiment("exp-b", func(experimentCR *experimentcontrollercomv1alpha1.ExperimentDeployment) {
+			experimentCR.Spec.Replicas = ptr.To(int32(2))
+		})
+		createExperiment("exp-c", func(experimentCR *experimentcontrollercomv1alpha1.ExperimentDeployment) {
+			experimentCR.Spec.Replicas = ptr.To(int32(2))
+			experimentCR.Spec.ServiceMode = experimentcontrollercomv1alpha1.ServiceModeIsolated
+		})
+
+		reconcileExperiment("exp-a")
+		reconcileExperiment("exp-b")
+		reconcileExperiment("exp-c")
+		Expect(isRendered("exp-a")).To(BeTrue())
+		expectQueued("exp-b", "5 experiment pods next to 4 source pods would receive more than 50% of the traffic")
+		Expect(isRendered("exp-c")).To(BeTrue())
+	})
+})
@@ -0,0 +1,121 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	experimentcontrollercomv1alpha1 "experimentcontroller.example.com/experiment-deployment/api/v1alpha1"
+	rolloutsv1alpha1 "github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+)
+
+// stalledReasons are the reasons of a not ready experiment that needs a change of its spec or of
+// the cluster to make progress
+var stalledReasons = sets.New(
+	experimentcontrollercomv1alpha1.ReasonValidationFailed,
+	experimentcontrollercomv1alpha1.ReasonUnsupportedSourceKind,
+	experimentcontrollercomv1alpha1.ReasonConstructionFailed,
+	experimentcontrollercomv1alpha1.ReasonRolloutsNotSupported,
+	experimentcontrollercomv1alpha1.ReasonAnalysisNotSupported,
+	experimentcontrollercomv1alpha1.ReasonSnapshotNotSupported,
+	experimentcontrollercomv1alpha1.ReasonAnalysisFailed,
+	experimentcontrollercomv1alpha1.ReasonAborted,
+	experimentcontrollercomv1alpha1.ReasonPolicyViolation,
+	experimentcontrollercomv1alpha1.ReasonRefNotPermitted,
+	experimentcontrollercomv1alpha1.ReasonUnauthorized,
+	experimentcontrollercomv1alpha1.ReasonImmutableFieldConflict,
+	experimentcontrollercomv1alpha1.ReasonFieldManagerConflict,
+	experimentcontrollercomv1alpha1.ReasonRevisionNotFound,
+)
+
+// degradedReasons are the reasons of a not ready experiment failing in a way the controller retries
+var degradedReasons = sets.New(
+	experimentcontrollercomv1alpha1.ReasonReconcileError,
+	experimentcontrollercomv1alpha1.ReasonProgressDeadlineExceeded,
+	experimentcontrollercomv1alpha1.ReasonCrashLoopBackOff,
+	experimentcontrollercomv1alpha1.ReasonOOMKilled,
+	experimentcontrollercomv1alpha1.ReasonImagePullBackOff,
+	experimentcontrollercomv1alpha1.ReasonErrImagePull,
+	experimentcontrollercomv1alpha1.ReasonCreateContainerConfigError,
+	experimentcontrollercomv1alpha1.ReasonUnschedulable,
+	experimentcontrollercomv1alpha1.ReasonSourceNotFound,
+	experimentcontrollercomv1alpha1.ReasonWorkloadRefNotFound,
+	experimentcontrollercomv1alpha1.ReasonExperimentWorkloadNotFound,
+	experimentcontrollercomv1alpha1.ReasonUpsertFailed,
+	experimentcontrollercomv1alpha1.ReasonServiceIsolationFailed,
+	experimentcontrollercomv1alpha1.ReasonMirrorFailed,
+	experimentcontrollercomv1alpha1.ReasonNetworkIsolationFailed,
+	experimentcontrollercomv1alpha1.ReasonSnapshotFailed,
+)
+
+// setLifecycleStatus derives the Progressing, Degraded and Stalled conditions and the phase of the
+// experiment from its Ready condition. Every not ready experiment has exactly one of them True.
+func (r *ExperimentDeploymentReconciler) setLifecycleStatus(experimentCR *experimentcontrollercomv1alpha1.ExperimentDeployment) {
+	reason, message := experimentcontrollercomv1alpha1.ReasonNotReady, "Experiment is not reconciled yet"
+	ready := meta.FindStatusCondition(experimentCR.Status.Conditions, ConditionTypeReady)
+	if ready != nil {
+		reason, message = ready.Reason, ready.Message
+	}
+	isReady := ready != nil && ready.Status == metav1.ConditionTrue
+	stalled := !isReady && stalledReasons.Has(reason)
+	degraded := !isReady && degradedReasons.Has(reason)
+	progressing := !isReady && !stalled && !degraded
+
+	for conditionType, active := range map[string]bool{
+		ConditionTypeProgressing: progressing,
+		ConditionTypeDegraded:    degraded,
+		ConditionTypeStalled:     stalled,
+	} {
+		status := metav1.ConditionFalse
+		if active {
+			status = metav1.ConditionTrue
+		}
+		meta.SetStatusCondition(&experimentCR.Status.Conditions, metav1.Condition{
+			Type:    conditionType,
+			Status:  status,
+			Reason:  reason,
+			Message: message,
+		})
+	}
+	for i := range experimentCR.Status.Conditions {
+		experimentCR.Status.Conditions[i].ObservedGeneration = experimentCR.Generation
+	}
+
+	experimentCR.Status.Phase = experimentPhase(experimentCR, isReady, stalled)
+}
+
+// experimentPhase summarizes the lifecycle of the experiment
+func experimentPhase(experimentCR *experimentcontrollercomv1alpha1.ExperimentDeployment, isReady, stalled bool) experimentcontrollercomv1alpha1.ExperimentPhase {
+	switch {
+	case experimentCR.Status.Abort != nil:
+		return experimentcontrollercomv1alpha1.ExperimentPhaseAborted
+	case isExperimentAborted(experimentCR) || stalled:
+		return experimentcontrollercomv1alpha1.ExperimentPhaseFailed
+	case experimentCR.Status.Phase == experimentcontrollercomv1alpha1.ExperimentPhaseQueued:
+		return experimentcontrollercomv1alpha1.ExperimentPhaseQueued
+	case experimentCR.Spec.Replicas != nil && *experimentCR.Spec.Replicas == 0:
+		return experimentcontrollercomv1alpha1.ExperimentPhasePaused
+	case len(experimentCR.Status.AnalysisRuns) > 0 &&
+		experimentCR.Status.AnalysisPhase == string(rolloutsv1alpha1.AnalysisPhaseSuccessful):
+		return experimentcontrollercomv1alpha1.ExperimentPhaseSucceeded
+	case isReady:
+		return experimentcontrollercomv1alpha1.ExperimentPhaseRunning
+	}
+	return experimentcontrollercomv1alpha1.ExperimentPhasePending
+}
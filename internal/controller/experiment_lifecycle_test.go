@@ -0,0 +1,153 @@
+package controller
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	experimentcontrollercomv1alpha1 "experimentcontroller.example.com/experiment-deployment/api/v1alpha1"
+)
+
+var _ = Describe("Experiment Lifecycle", func() {
+	var (
+		reconciler   *ExperimentDeploymentReconciler
+		experimentCR *experimentcontrollercomv1alpha1.ExperimentDeployment
+	)
+
+	BeforeEach(func() {
+		scheme := runtime.NewScheme()
+		Expect(experimentcontrollercomv1alpha1.AddToScheme(scheme)).To(Succeed())
+		Expect(appsv1.AddToScheme(scheme)).To(Succeed())
+		Expect(corev1.AddToScheme(scheme)).To(Succeed())
+		reconciler = &ExperimentDeploymentReconciler{
+			Client: fake.NewClientBuilder().WithScheme(scheme).
+				WithStatusSubresource(&experimentcontrollercomv1alpha1.ExperimentDeployment{}).
+				WithInterceptorFuncs(withServerSideApply(interceptor.Funcs{})).Build(),
+			Scheme:   scheme,
+			Recorder: record.NewFakeRecorder(100),
+		}
+		experimentCR = &experimentcontrollercomv1alpha1.ExperimentDeployment{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:       testExperimentCRName,
+				Namespace:  testNamespace,
+				Generation: 3,
+				Finalizers: []string{experimentDeploymentFinalizer},
+			},
+			Spec: experimentcontrollercomv1alpha1.ExperimentDeploymentSpec{
+				SourceRef: experimentcontrollercomv1alpha1.SourceRef{
+					Kind: experimentcontrollercomv1alpha1.SourceKindDeployment,
+					Name: "web",
+				},
+				OverrideSpec: apiextensionsv1.JSON{Raw: []byte(`{}`)},
+			},
+		}
+	})
+
+	expectConditions := func(progressing, degraded, stalled metav1.ConditionStatus, reason string) {
+		for conditionType, status := range map[string]metav1.ConditionStatus{
+			ConditionTypeProgressing: progressing,
+			ConditionTypeDegraded:    degraded,
+			ConditionTypeStalled:     stalled,
+		} {
+			condition := meta.FindStatusCondition(experimentCR.Status.Conditions, conditionType)
+			Expect(condition).NotTo(BeNil(), conditionType)
+			Expect(condition.Status).To(Equal(status), conditionType)
+			Expect(condition.Reason).To(Equal(reason), conditionType)
+			Expect(condition.ObservedGeneration).To(Equal(int64(3)), conditionType)
+		}
+	}
+
+	It("should report ready experiments as running", func() {
+		reconciler.setReadyStatus(experimentCR, "Experiment Deployment is Ready")
+		reconciler.setLifecycleStatus(experimentCR)
+
+		expectConditions(metav1.ConditionFalse, metav1.ConditionFalse, metav1.ConditionFalse, ReasonReconcileSuccess)
+		Expect(experimentCR.Status.Phase).To(Equal(experimentcontrollercomv1alpha1.ExperimentPhaseRunning))
+	})
+
+	It("should report rolling out experiments as progressing", func() {
+		reconciler.setNotReadyStatus(experimentCR, experimentcontrollercomv1alpha1.ReasonNotReady, "Experiment Deployment is not yet ready.")
+		reconciler.setLifecycleStatus(experimentCR)
+
+		expectConditions(metav1.ConditionTrue, metav1.ConditionFalse, metav1.ConditionFalse, experimentcontrollercomv1alpha1.ReasonNotReady)
+		Expect(meta.IsStatusConditionTrue(experimentCR.Status.Conditions, ConditionTypeSynced)).To(BeTrue())
+		Expect(experimentCR.Status.Phase).To(Equal(experimentcontrollercomv1alpha1.ExperimentPhasePending))
+	})
+
+	It("should report workloads exceeding their progress deadline as degraded and out of sync", func() {
+		reconciler.setNotReadyStatus(experimentCR, experimentcontrollercomv1alpha1.ReasonProgressDeadlineExceeded, "ReplicaSet has timed out progressing.")
+		reconciler.setLifecycleStatus(experimentCR)
+
+		expectConditions(metav1.ConditionFalse, metav1.ConditionTrue, metav1.ConditionFalse, experimentcontrollercomv1alpha1.ReasonProgressDeadlineExceeded)
+		synced := meta.FindStatusCondition(experimentCR.Status.Conditions, ConditionTypeSynced)
+		Expect(synced.Status).To(Equal(metav1.ConditionFalse))
+		Expect(synced.Reason).To(Equal(experimentcontrollercomv1alpha1.ReasonProgressDeadlineExceeded))
+		Expect(experimentCR.Status.Phase).To(Equal(experimentcontrollercomv1alpha1.ExperimentPhasePending))
+	})
+
+	It("should report experiments needing a change as stalled and failed", func() {
+		reconciler.updateStatusConditions(experimentCR, experimentcontrollercomv1alpha1.ReasonPolicyViolation, "Replicas exceed the limit")
+		reconciler.setLifecycleStatus(experimentCR)
+
+		expectConditions(metav1.ConditionFalse, metav1.ConditionFalse, metav1.ConditionTrue, experimentcontrollercomv1alpha1.ReasonPolicyViolation)
+		Expect(experimentCR.Status.Phase).To(Equal(experimentcontrollercomv1alpha1.ExperimentPhaseFailed))
+	})
+
+	It("should report queued experiments as progressing", func() {
+		experimentCR.Status.Phase = experimentcontrollercomv1alpha1.ExperimentPhaseQueued
+		reconciler.updateStatusConditions(experimentCR, experimentcontrollercomv1alpha1.ReasonQueued, "The limit is 1")
+		reconciler.setLifecycleStatus(experimentCR)
+
+		expectConditions(metav1.ConditionTrue, metav1.ConditionFalse, metav1.ConditionFalse, experimentcontrollercomv1alpha1.ReasonQueued)
+		Expect(experimentCR.Status.Phase).To(Equal(experimentcontrollercomv1alpha1.ExperimentPhaseQueued))
+	})
+
+	It("should derive the phase from replicas and analysis", func() {
+		reconciler.setReadyStatus(experimentCR, "Experiment Deployment is Ready")
+		experimentCR.Spec.Replicas = ptr.To(int32(0))
+		reconciler.setLifecycleStatus(experimentCR)
+		Expect(experimentCR.Status.Phase).To(Equal(experimentcontrollercomv1alpha1.ExperimentPhasePaused))
+
+		experimentCR.Spec.Replicas = nil
+		experimentCR.Status.AnalysisRuns = []experimentcontrollercomv1alpha1.AnalysisRunStatus{{Name: "run", Phase: "Successful"}}
+		experimentCR.Status.AnalysisPhase = "Successful"
+		reconciler.setLifecycleStatus(experimentCR)
+		Expect(experimentCR.Status.Phase).To(Equal(experimentcontrollercomv1alpha1.ExperimentPhaseSucceeded))
+
+		experimentCR.Status.AnalysisPhase = "Failed"
+		reconciler.setAbortedStatus(experimentCR)
+		reconciler.setLifecycleStatus(experimentCR)
+		Expect(experimentCR.Status.Phase).To(Equal(experimentcontrollercomv1alpha1.ExperimentPhaseFailed))
+		Expect(meta.IsStatusConditionTrue(experimentCR.Status.Conditions, ConditionTypeStalled)).To(BeTrue())
+	})
+
+	It("should persist the lifecycle status when reconciling", func() {
+		ctx := context.Background()
+		Expect(reconciler.Create(ctx, experimentCR)).To(Succeed())
+
+		_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: types.NamespacedName{Name: testExperimentCRName, Namespace: testNamespace}})
+		Expect(err).NotTo(HaveOccurred())
+
+		updatedCR := &experimentcontrollercomv1alpha1.ExperimentDeployment{}
+		Expect(reconciler.Get(ctx, client.ObjectKeyFromObject(experimentCR), updatedCR)).To(Succeed())
+		Expect(updatedCR.Status.Phase).To(Equal(experimentcontrollercomv1alpha1.ExperimentPhasePending))
+		degraded := meta.FindStatusCondition(updatedCR.Status.Conditions, ConditionTypeDegraded)
+		Expect(degraded).NotTo(BeNil())
+		Expect(degraded.Status).To(Equal(metav1.ConditionTrue))
+		Expect(degraded.Reason).To(Equal(experimentcontrollercomv1alpha1.ReasonSourceNotFound))
+	})
+})
@@ -0,0 +1,94 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	experimentcontrollercomv1alpha1 "experimentcontroller.example.com/experiment-deployment/api/v1alpha1"
+	"experimentcontroller.example.com/experiment-deployment/internal/policy"
+)
+
+// enforceExperimentPolicies evaluates the experiment against all ClusterExperimentPolicies before
+// anything is rendered. It returns false and sets the PolicyViolation condition, naming the
+// offending paths, when the experiment violates a policy.
+func (r *ExperimentDeploymentReconciler) enforceExperimentPolicies(
+	ctx context.Context,
+	experimentCR *experimentcontrollercomv1alpha1.ExperimentDeployment,
+	sourceNamespace string,
+	sourceSpec interface{}) (bool, error) {
+
+	log := logf.FromContext(ctx)
+
+	policies := &experimentcontrollercomv1alpha1.ClusterExperimentPolicyList{}
+	if err := r.List(ctx, policies); err != nil {
+		if meta.IsNoMatchError(err) {
+			// The ClusterExperimentPolicy CRD is not installed, nothing to enforce
+			return true, nil
+		}
+		log.Error(err, "Failed to list ClusterExperimentPolicies")
+		return false, err
+	}
+	if len(policies.Items) == 0 {
+		return true, nil
+	}
+
+	input, err := policy.NewInput(experimentCR, sourceNamespace, sourceSpec)
+	if err != nil {
+		return false, err
+	}
+	violations, err := policy.Evaluate(policies.Items, input)
+	if err != nil {
+		return false, err
+	}
+	if len(violations) == 0 {
+		return true, nil
+	}
+
+	messages := make([]string, 0, len(violations))
+	for _, violation := range violations {
+		messages = append(messages, violation.String())
+	}
+	message := strings.Join(messages, "; ")
+	log.Info("ExperimentDeployment violates ClusterExperimentPolicies", "violations", message)
+	r.Recorder.Event(experimentCR, corev1.EventTypeWarning, "PolicyViolation", message)
+	r.updateStatusConditions(experimentCR, experimentcontrollercomv1alpha1.ReasonPolicyViolation, fmt.Sprintf("Experiment not rendered: %s", message))
+	return false, nil
+}
+
+// experimentsForPolicy re-evaluates all ExperimentDeployments when a ClusterExperimentPolicy changes
+func (r *ExperimentDeploymentReconciler) experimentsForPolicy(ctx context.Context, _ client.Object) []reconcile.Request {
+	experiments := &experimentcontrollercomv1alpha1.ExperimentDeploymentList{}
+	if err := r.List(ctx, experiments); err != nil {
+		logf.FromContext(ctx).Error(err, "Failed to list ExperimentDeployments for ClusterExperimentPolicy change")
+		return nil
+	}
+	requests := make([]reconcile.Request, 0, len(experiments.Items))
+	for _, experiment := range experiments.Items {
+		requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: experiment.Name, Namespace: experiment.Namespace}})
+	}
+	return requests
+}
@@ -0,0 +1,116 @@
+package controller
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	experimentcontrollercomv1alpha1 "experimentcontroller.example.com/experiment-deployment/api/v1alpha1"
+)
+
+var _ = Describe("Experiment Policies", func() {
+	var (
+		ctx              context.Context
+		reconciler       *ExperimentDeploymentReconciler
+		fakeClient       client.Client
+		sourceDeployment *appsv1.Deployment
+		experimentCR     *experimentcontrollercomv1alpha1.ExperimentDeployment
+		namespacedName   types.NamespacedName
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		scheme := runtime.NewScheme()
+		Expect(experimentcontrollercomv1alpha1.AddToScheme(scheme)).To(Succeed())
+		Expect(appsv1.AddToScheme(scheme)).To(Succeed())
+		Expect(corev1.AddToScheme(scheme)).To(Succeed())
+		fakeClient = fake.NewClientBuilder().WithScheme(scheme).WithInterceptorFuncs(withServerSideApply(interceptor.Funcs{})).WithStatusSubresource(&experimentcontrollercomv1alpha1.ExperimentDeployment{}).Build()
+		reconciler = &ExperimentDeploymentReconciler{
+			Client:   fakeClient,
+			Scheme:   scheme,
+			Recorder: record.NewFakeRecorder(100),
+		}
+
+		namespacedName = types.NamespacedName{Name: testExperimentCRName, Namespace: testNamespace}
+		sourceDeployment = &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: testNamespace},
+			Spec: appsv1.DeploymentSpec{
+				Replicas: ptr.To(int32(4)),
+				Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "web"}},
+				Template: corev1.PodTemplateSpec{
+					ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": "web"}},
+					Spec: corev1.PodSpec{
+						Containers: []corev1.Container{{Name: "web", Image: "nginx:1.27"}},
+					},
+				},
+			},
+		}
+		experimentCR = &experimentcontrollercomv1alpha1.ExperimentDeployment{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:       testExperimentCRName,
+				Namespace:  testNamespace,
+				Finalizers: []string{experimentDeploymentFinalizer},
+			},
+			Spec: experimentcontrollercomv1alpha1.ExperimentDeploymentSpec{
+				SourceRef: experimentcontrollercomv1alpha1.SourceRef{
+					Kind: experimentcontrollercomv1alpha1.SourceKindDeployment,
+					Name: "web",
+				},
+				OverrideSpec: apiextensionsv1.JSON{Raw: []byte(`{"template":{"spec":{"hostNetwork":true}}}`)},
+			},
+		}
+		Expect(fakeClient.Create(ctx, &experimentcontrollercomv1alpha1.ClusterExperimentPolicy{
+			ObjectMeta: metav1.ObjectMeta{Name: "baseline"},
+			Spec: experimentcontrollercomv1alpha1.ClusterExperimentPolicySpec{
+				DeniedPaths: []string{"template.spec.hostNetwork", "**.securityContext"},
+			},
+		})).To(Succeed())
+	})
+
+	It("should not render experiments violating a policy", func() {
+		Expect(fakeClient.Create(ctx, sourceDeployment)).To(Succeed())
+		Expect(fakeClient.Create(ctx, experimentCR)).To(Succeed())
+
+		_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+		Expect(err).NotTo(HaveOccurred())
+
+		err = fakeClient.Get(ctx, namespacedName, &appsv1.Deployment{})
+		Expect(k8serrors.IsNotFound(err)).To(BeTrue())
+
+		updatedCR := &experimentcontrollercomv1alpha1.ExperimentDeployment{}
+		Expect(fakeClient.Get(ctx, namespacedName, updatedCR)).To(Succeed())
+		readyCondition := meta.FindStatusCondition(updatedCR.Status.Conditions, ConditionTypeReady)
+		Expect(readyCondition).NotTo(BeNil())
+		Expect(readyCondition.Status).To(Equal(metav1.ConditionFalse))
+		Expect(readyCondition.Reason).To(Equal("PolicyViolation"))
+		Expect(readyCondition.Message).To(ContainSubstring("ClusterExperimentPolicy baseline: template.spec.hostNetwork"))
+	})
+
+	It("should render experiments respecting all policies", func() {
+		experimentCR.Spec.OverrideSpec = apiextensionsv1.JSON{Raw: []byte(`{"template":{"spec":{"containers":[{"image":"nginx:1.28"}]}}}`)}
+		Expect(fakeClient.Create(ctx, sourceDeployment)).To(Succeed())
+		Expect(fakeClient.Create(ctx, experimentCR)).To(Succeed())
+
+		_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+		Expect(err).NotTo(HaveOccurred())
+
+		deployment := &appsv1.Deployment{}
+		Expect(fakeClient.Get(ctx, namespacedName, deployment)).To(Succeed())
+		Expect(deployment.Spec.Template.Spec.Containers[0].Image).To(Equal("nginx:1.28"))
+	})
+})
@@ -0,0 +1,224 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	experimentcontrollercomv1alpha1 "experimentcontroller.example.com/experiment-deployment/api/v1alpha1"
+	rolloutsv1alpha1 "github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+)
+
+// blueGreenSuffix is appended to the name of the experiment workload replacing the current one
+// under the BlueGreen recreate policy, alternating with the plain name
+const blueGreenSuffix = "-green"
+
+// effectiveRecreatePolicy returns the recreate policy of the experiment, defaulting to Recreate
+func effectiveRecreatePolicy(experimentCR *experimentcontrollercomv1alpha1.ExperimentDeployment) experimentcontrollercomv1alpha1.RecreatePolicy {
+	if experimentCR.Spec.RecreatePolicy == "" {
+		return experimentcontrollercomv1alpha1.RecreatePolicyRecreate
+	}
+	return experimentCR.Spec.RecreatePolicy
+}
+
+// isRecreationInProgress reports whether the experiment workload is being replaced
+func isRecreationInProgress(experimentCR *experimentcontrollercomv1alpha1.ExperimentDeployment) bool {
+	return experimentCR.Status.Recreation != nil && experimentCR.Status.Recreation.CompletionTime == nil
+}
+
+// experimentWorkloadName returns the name of the experiment workload of the given kind. It is the
+// name of the experiment, unless a BlueGreen replacement gave the workload the alternate name.
+func experimentWorkloadName(experimentCR *experimentcontrollercomv1alpha1.ExperimentDeployment, kind string) string {
+	if isRecreationInProgress(experimentCR) {
+		return experimentCR.Status.Recreation.Name
+	}
+	if ref := experimentCR.Status.ExperimentResourceRef; ref != nil && ref.Kind == kind && ref.Name == experimentCR.Name+blueGreenSuffix {
+		return ref.Name
+	}
+	return experimentCR.Name
+}
+
+// isImmutableFieldError reports whether an update was rejected because it changes immutable fields
+func isImmutableFieldError(err error) bool {
+	if !k8serrors.IsInvalid(err) {
+		return false
+	}
+	var apiStatus k8serrors.APIStatus
+	if errors.As(err, &apiStatus) && apiStatus.Status().Details != nil {
+		for _, cause := range apiStatus.Status().Details.Causes {
+			if strings.Contains(cause.Message, "field is immutable") ||
+				strings.Contains(cause.Message, "updates to statefulset spec for fields other than") {
+				return true
+			}
+		}
+	}
+	return strings.Contains(err.Error(), "field is immutable")
+}
+
+// handleImmutableFieldConflict applies the recreate policy of the experiment after updating its
+// workload failed because immutable fields changed. upsert creates or updates a workload and is
+// called again for the replacement.
+func (r *ExperimentDeploymentReconciler) handleImmutableFieldConflict(
+	ctx context.Context,
+	experimentCR *experimentcontrollercomv1alpha1.ExperimentDeployment,
+	desired client.Object,
+	kind string,
+	conflict error,
+	upsert func(desired client.Object) (client.Object, error)) (client.Object, error) {
+
+	log := logf.FromContext(ctx)
+	policy := effectiveRecreatePolicy(experimentCR)
+	name := desired.GetName()
+
+	if policy == experimentcontrollercomv1alpha1.RecreatePolicyFail {
+		message := fmt.Sprintf("Experiment %s %s cannot be updated, the change modifies immutable fields and recreatePolicy is Fail: %s",
+			kind, name, conflict.Error())
+		log.Info("Immutable field conflict, not recreating the experiment workload", "kind", kind, "name", name)
+		r.Recorder.Event(experimentCR, corev1.EventTypeWarning, "ImmutableFieldConflict", message)
+		r.updateStatusConditions(experimentCR, experimentcontrollercomv1alpha1.ReasonImmutableFieldConflict, message)
+		return nil, nil
+	}
+
+	now := metav1.Now()
+	// Replacing the replacement of a BlueGreen recreation again would leave three workloads around,
+	// so a conflict during a recreation recreates the replacement in place
+	if policy == experimentcontrollercomv1alpha1.RecreatePolicyBlueGreen && !isRecreationInProgress(experimentCR) {
+		successor := experimentCR.Name + blueGreenSuffix
+		if name == successor {
+			successor = experimentCR.Name
+		}
+		message := fmt.Sprintf("Immutable fields of experiment %s %s changed, creating %s next to it: %s", kind, name, successor, conflict.Error())
+		log.Info("Immutable field conflict, creating a replacement of the experiment workload", "kind", kind, "name", name, "replacement", successor)
+		r.Recorder.Event(experimentCR, corev1.EventTypeNormal, "Recreating", message)
+		experimentCR.Status.Recreation = &experimentcontrollercomv1alpha1.RecreationStatus{
+			Policy:       policy,
+			Name:         successor,
+			PreviousName: name,
+			Message:      message,
+			StartTime:    &now,
+		}
+		replacement := desired.DeepCopyObject().(client.Object)
+		replacement.SetName(successor)
+		replacement.SetResourceVersion("")
+		return upsert(replacement)
+	}
+
+	message := fmt.Sprintf("Immutable fields of experiment %s %s changed, deleting it to create it again: %s", kind, name, conflict.Error())
+	log.Info("Immutable field conflict, recreating the experiment workload", "kind", kind, "name", name)
+	r.Recorder.Event(experimentCR, corev1.EventTypeNormal, "Recreating", message)
+	if isRecreationInProgress(experimentCR) {
+		// Keep the replaced workload of a BlueGreen recreation so it is deleted once this one is ready
+		experimentCR.Status.Recreation.Message = message
+	} else {
+		experimentCR.Status.Recreation = &experimentcontrollercomv1alpha1.RecreationStatus{
+			Policy:    policy,
+			Name:      name,
+			Message:   message,
+			StartTime: &now,
+		}
+	}
+	current, err := r.workloadReference(desired, name)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.Delete(ctx, current, client.PropagationPolicy(metav1.DeletePropagationBackground)); err != nil && !k8serrors.IsNotFound(err) {
+		log.Error(err, "Failed to delete experiment workload for recreation", "kind", kind, "name", name)
+		return nil, err
+	}
+	if err := r.Get(ctx, client.ObjectKeyFromObject(current), current); !k8serrors.IsNotFound(err) {
+		// Wait until the workload is gone before creating it again
+		r.updateStatusConditions(experimentCR, experimentcontrollercomv1alpha1.ReasonRecreating, message)
+		return nil, nil
+	}
+	recreated := desired.DeepCopyObject().(client.Object)
+	recreated.SetResourceVersion("")
+	return upsert(recreated)
+}
+
+// completeRecreation finishes the recreation of the experiment workload once the replacement exists
+// and, for BlueGreen, is ready. The replaced BlueGreen workload is deleted then.
+func (r *ExperimentDeploymentReconciler) completeRecreation(
+	ctx context.Context,
+	experimentCR *experimentcontrollercomv1alpha1.ExperimentDeployment,
+	workload client.Object,
+	kind string) error {
+
+	if !isRecreationInProgress(experimentCR) || experimentCR.Status.Recreation.Name != workload.GetName() {
+		return nil
+	}
+	recreation := experimentCR.Status.Recreation
+	message := fmt.Sprintf("Experiment %s %s recreated", kind, workload.GetName())
+
+	if recreation.PreviousName != "" {
+		if !isWorkloadReady(workload) {
+			return nil
+		}
+		previous, err := r.workloadReference(workload, recreation.PreviousName)
+		if err != nil {
+			return err
+		}
+		if err := r.Delete(ctx, previous, client.PropagationPolicy(metav1.DeletePropagationBackground)); err != nil && !k8serrors.IsNotFound(err) {
+			logf.FromContext(ctx).Error(err, "Failed to delete replaced experiment workload", "kind", kind, "name", recreation.PreviousName)
+			return err
+		}
+		message = fmt.Sprintf("Experiment %s %s is ready and replaced %s", kind, workload.GetName(), recreation.PreviousName)
+	}
+
+	now := metav1.Now()
+	recreation.CompletionTime = &now
+	r.Recorder.Event(experimentCR, corev1.EventTypeNormal, "Recreated", message)
+	return nil
+}
+
+// workloadReference returns an object referencing the workload of the same kind with the given name
+func (r *ExperimentDeploymentReconciler) workloadReference(workload client.Object, name string) (client.Object, error) {
+	gvk, err := apiutil.GVKForObject(workload, r.Scheme)
+	if err != nil {
+		return nil, err
+	}
+	reference := &unstructured.Unstructured{}
+	reference.SetGroupVersionKind(gvk)
+	reference.SetName(name)
+	reference.SetNamespace(workload.GetNamespace())
+	return reference, nil
+}
+
+// isWorkloadReady reports whether all replicas of the latest spec of a workload are ready
+func isWorkloadReady(workload client.Object) bool {
+	switch w := workload.(type) {
+	case *appsv1.Deployment:
+		return w.Status.ObservedGeneration >= w.Generation && w.Status.ReadyReplicas >= sourceReplicas(w.Spec.Replicas) &&
+			w.Status.UpdatedReplicas >= sourceReplicas(w.Spec.Replicas)
+	case *appsv1.StatefulSet:
+		return w.Status.ObservedGeneration >= w.Generation && w.Status.ReadyReplicas >= sourceReplicas(w.Spec.Replicas)
+	case *rolloutsv1alpha1.Rollout:
+		return w.Status.ObservedGeneration == fmt.Sprint(w.Generation) && w.Status.ReadyReplicas >= sourceReplicas(w.Spec.Replicas)
+	}
+	return false
+}
@@ -0,0 +1,231 @@
+package controller
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	experimentcontrollercomv1alpha1 "experimentcontroller.example.com/experiment-deployment/api/v1alpha1"
+)
+
+var _ = Describe("Experiment Recreation", func() {
+	var (
+		ctx              context.Context
+		reconciler       *ExperimentDeploymentReconciler
+		fakeClient       client.Client
+		recorder         *record.FakeRecorder
+		rejectUpdates    bool
+		deleted          []string
+		sourceDeployment *appsv1.Deployment
+		experimentCR     *experimentcontrollercomv1alpha1.ExperimentDeployment
+		namespacedName   types.NamespacedName
+	)
+
+	immutableFieldError := func(name string) error {
+		return k8serrors.NewInvalid(schema.GroupKind{Group: "apps", Kind: "Deployment"}, name, field.ErrorList{
+			field.Invalid(field.NewPath("spec", "selector"), nil, "field is immutable"),
+		})
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		scheme := runtime.NewScheme()
+		Expect(experimentcontrollercomv1alpha1.AddToScheme(scheme)).To(Succeed())
+		Expect(appsv1.AddToScheme(scheme)).To(Succeed())
+		Expect(corev1.AddToScheme(scheme)).To(Succeed())
+
+		// Reject applies changing the first experiment Deployment like the API server rejects selector changes
+		rejectUpdates = false
+		deleted = nil
+		fakeClient = fake.NewClientBuilder().
+			WithScheme(scheme).
+			WithStatusSubresource(&experimentcontrollercomv1alpha1.ExperimentDeployment{}).
+			WithInterceptorFuncs(interceptor.Funcs{
+				Patch: func(ctx context.Context, c client.WithWatch, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+					if patch.Type() == types.ApplyPatchType && rejectUpdates && obj.GetName() == testExperimentCRName &&
+						c.Get(ctx, client.ObjectKeyFromObject(obj), &appsv1.Deployment{}) == nil {
+						return immutableFieldError(obj.GetName())
+					}
+					return applyPatch(ctx, c, obj, patch, opts...)
+				},
+				Delete: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.DeleteOption) error {
+					deleted = append(deleted, obj.GetName())
+					return c.Delete(ctx, obj, opts...)
+				},
+			}).
+			Build()
+		recorder = record.NewFakeRecorder(100)
+		reconciler = &ExperimentDeploymentReconciler{
+			Client:   fakeClient,
+			Scheme:   scheme,
+			Recorder: recorder,
+		}
+
+		namespacedName = types.NamespacedName{Name: testExperimentCRName, Namespace: testNamespace}
+		sourceDeployment = &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: testNamespace},
+			Spec: appsv1.DeploymentSpec{
+				Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "web"}},
+				Template: corev1.PodTemplateSpec{
+					ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": "web"}},
+					Spec: corev1.PodSpec{
+						Containers: []corev1.Container{{Name: "web", Image: "nginx:1.27"}},
+					},
+				},
+			},
+		}
+		experimentCR = &experimentcontrollercomv1alpha1.ExperimentDeployment{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:       testExperimentCRName,
+				Namespace:  testNamespace,
+				Finalizers: []string{experimentDeploymentFinalizer},
+			},
+			Spec: experimentcontrollercomv1alpha1.ExperimentDeploymentSpec{
+				SourceRef: experimentcontrollercomv1alpha1.SourceRef{
+					Kind: experimentcontrollercomv1alpha1.SourceKindDeployment,
+					Name: "web",
+				},
+				OverrideSpec: apiextensionsv1.JSON{Raw: []byte(`{}`)},
+			},
+		}
+		Expect(fakeClient.Create(ctx, sourceDeployment)).To(Succeed())
+	})
+
+	getExperimentCR := func() *experimentcontrollercomv1alpha1.ExperimentDeployment {
+		updatedCR := &experimentcontrollercomv1alpha1.ExperimentDeployment{}
+		Expect(fakeClient.Get(ctx, namespacedName, updatedCR)).To(Succeed())
+		return updatedCR
+	}
+
+	getDeployment := func(name string) *appsv1.Deployment {
+		deployment := &appsv1.Deployment{}
+		Expect(fakeClient.Get(ctx, types.NamespacedName{Name: name, Namespace: testNamespace}, deployment)).To(Succeed())
+		return deployment
+	}
+
+	reconcile := func() {
+		_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+		Expect(err).NotTo(HaveOccurred())
+	}
+
+	events := func() []string {
+		var recorded []string
+		for len(recorder.Events) > 0 {
+			recorded = append(recorded, <-recorder.Events)
+		}
+		return recorded
+	}
+
+	// renderAndChangeSource renders the experiment, then changes the source so that updating the
+	// experiment Deployment is rejected
+	renderAndChangeSource := func(policy experimentcontrollercomv1alpha1.RecreatePolicy) {
+		experimentCR.Spec.RecreatePolicy = policy
+		Expect(fakeClient.Create(ctx, experimentCR)).To(Succeed())
+		reconcile()
+		getDeployment(testExperimentCRName)
+		events()
+
+		Expect(fakeClient.Get(ctx, client.ObjectKeyFromObject(sourceDeployment), sourceDeployment)).To(Succeed())
+		sourceDeployment.Spec.Template.Spec.Containers[0].Image = "nginx:1.28"
+		Expect(fakeClient.Update(ctx, sourceDeployment)).To(Succeed())
+		rejectUpdates = true
+	}
+
+	It("should recognize immutable field errors", func() {
+		Expect(isImmutableFieldError(immutableFieldError("web"))).To(BeTrue())
+		Expect(isImmutableFieldError(k8serrors.NewInvalid(schema.GroupKind{Group: "apps", Kind: "StatefulSet"}, "db", field.ErrorList{
+			field.Forbidden(field.NewPath("spec"), "updates to statefulset spec for fields other than 'replicas' are forbidden"),
+		}))).To(BeTrue())
+		Expect(isImmutableFieldError(k8serrors.NewInvalid(schema.GroupKind{Group: "apps", Kind: "Deployment"}, "web", field.ErrorList{
+			field.Required(field.NewPath("spec", "template"), ""),
+		}))).To(BeFalse())
+		Expect(isImmutableFieldError(k8serrors.NewNotFound(schema.GroupResource{Group: "apps", Resource: "deployments"}, "web"))).To(BeFalse())
+	})
+
+	It("should delete and recreate the workload by default", func() {
+		renderAndChangeSource("")
+
+		reconcile()
+
+		Expect(deleted).To(Equal([]string{testExperimentCRName}))
+		recreated := getDeployment(testExperimentCRName)
+		Expect(recreated.Spec.Template.Spec.Containers[0].Image).To(Equal("nginx:1.28"))
+		recreation := getExperimentCR().Status.Recreation
+		Expect(recreation).NotTo(BeNil())
+		Expect(recreation.Policy).To(Equal(experimentcontrollercomv1alpha1.RecreatePolicyRecreate))
+		Expect(recreation.Name).To(Equal(testExperimentCRName))
+		Expect(recreation.StartTime).NotTo(BeNil())
+		Expect(recreation.CompletionTime).NotTo(BeNil())
+		Expect(events()).To(ContainElements(ContainSubstring("Normal Recreating"), ContainSubstring("Normal Recreated")))
+	})
+
+	It("should leave the workload alone with the Fail policy", func() {
+		renderAndChangeSource(experimentcontrollercomv1alpha1.RecreatePolicyFail)
+
+		reconcile()
+
+		Expect(deleted).To(BeEmpty())
+		current := getDeployment(testExperimentCRName)
+		Expect(current.Spec.Template.Spec.Containers[0].Image).To(Equal("nginx:1.27"))
+		updatedCR := getExperimentCR()
+		Expect(updatedCR.Status.Recreation).To(BeNil())
+		readyCondition := meta.FindStatusCondition(updatedCR.Status.Conditions, ConditionTypeReady)
+		Expect(readyCondition).NotTo(BeNil())
+		Expect(readyCondition.Reason).To(Equal("ImmutableFieldConflict"))
+		Expect(events()).To(ContainElement(ContainSubstring("Warning ImmutableFieldConflict")))
+	})
+
+	It("should replace the workload once its successor is ready with the BlueGreen policy", func() {
+		renderAndChangeSource(experimentcontrollercomv1alpha1.RecreatePolicyBlueGreen)
+
+		reconcile()
+
+		green := getDeployment(testExperimentCRName + blueGreenSuffix)
+		Expect(green.Spec.Template.Spec.Containers[0].Image).To(Equal("nginx:1.28"))
+		Expect(getDeployment(testExperimentCRName).Spec.Template.Spec.Containers[0].Image).To(Equal("nginx:1.27"))
+		recreation := getExperimentCR().Status.Recreation
+		Expect(recreation).NotTo(BeNil())
+		Expect(recreation.Name).To(Equal(testExperimentCRName + blueGreenSuffix))
+		Expect(recreation.PreviousName).To(Equal(testExperimentCRName))
+		Expect(recreation.CompletionTime).To(BeNil())
+
+		// The replaced workload stays until its successor is ready
+		reconcile()
+		Expect(deleted).To(BeEmpty())
+
+		rejectUpdates = false
+		green = getDeployment(testExperimentCRName + blueGreenSuffix)
+		green.Status.ObservedGeneration = green.Generation
+		green.Status.ReadyReplicas = 1
+		green.Status.UpdatedReplicas = 1
+		Expect(fakeClient.Status().Update(ctx, green)).To(Succeed())
+
+		reconcile()
+
+		err := fakeClient.Get(ctx, namespacedName, &appsv1.Deployment{})
+		Expect(k8serrors.IsNotFound(err)).To(BeTrue())
+		updatedCR := getExperimentCR()
+		Expect(updatedCR.Status.Recreation.CompletionTime).NotTo(BeNil())
+		Expect(updatedCR.Status.ExperimentResourceRef.Name).To(Equal(testExperimentCRName + blueGreenSuffix))
+		Expect(events()).To(ContainElement(ContainSubstring("Normal Recreated")))
+
+		// Later changes keep updating the successor
+		Expect(experimentWorkloadName(updatedCR, "Deployment")).To(Equal(testExperimentCRName + blueGreenSuffix))
+	})
+})
@@ -0,0 +1,253 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	experimentcontrollercomv1alpha1 "experimentcontroller.example.com/experiment-deployment/api/v1alpha1"
+	"experimentcontroller.example.com/experiment-deployment/internal/config"
+)
+
+// defaultRevisionHistoryLimit is the number of revisions retained without spec.revisionHistoryLimit
+const defaultRevisionHistoryLimit = 10
+
+// revisionName returns the name of the ControllerRevision holding overrides with the given hash
+func revisionName(experimentCR *experimentcontrollercomv1alpha1.ExperimentDeployment, hash string) string {
+	return fmt.Sprintf("%s-%s", experimentCR.Name, hash[:10])
+}
+
+// listRevisions returns the ControllerRevisions of an experiment, oldest first
+func (r *ExperimentDeploymentReconciler) listRevisions(
+	ctx context.Context,
+	experimentCR *experimentcontrollercomv1alpha1.ExperimentDeployment) ([]appsv1.ControllerRevision, error) {
+
+	revisionList := &appsv1.ControllerRevisionList{}
+	if err := r.List(ctx, revisionList, client.InNamespace(experimentCR.Namespace), client.MatchingLabels{
+		r.labelKey(labelManagedBy): ManagedByValue,
+		r.labelKey(labelCRName):    experimentCR.Name,
+	}); err != nil {
+		return nil, fmt.Errorf("failed to list ControllerRevisions: %w", err)
+	}
+	revisions := slices.DeleteFunc(revisionList.Items, func(revision appsv1.ControllerRevision) bool {
+		return !metav1.IsControlledBy(&revision, experimentCR)
+	})
+	slices.SortFunc(revisions, func(a, b appsv1.ControllerRevision) int {
+		return cmp.Compare(a.Revision, b.Revision)
+	})
+	return revisions, nil
+}
+
+// applyRollback replaces the overrides of the experiment with those of the revision named by
+// spec.rollbackTo, for this reconcile only. It reports false if the revision is not retained.
+func (r *ExperimentDeploymentReconciler) applyRollback(ctx context.Context, experimentCR *experimentcontrollercomv1alpha1.ExperimentDeployment) (bool, error) {
+	if experimentCR.Spec.RollbackTo == nil {
+		return true, nil
+	}
+	revisions, err := r.listRevisions(ctx, experimentCR)
+	if err != nil {
+		return false, err
+	}
+	for _, revision := range revisions {
+		if revision.Revision == *experimentCR.Spec.RollbackTo {
+			experimentCR.Spec.OverrideSpec = apiextensionsv1.JSON{Raw: revision.Data.Raw}
+			return true, nil
+		}
+	}
+
+	message := fmt.Sprintf("Revision %d to roll back to is not retained", *experimentCR.Spec.RollbackTo)
+	logf.FromContext(ctx).Info(message)
+	r.Recorder.Event(experimentCR, corev1.EventTypeWarning, experimentcontrollercomv1alpha1.ReasonRevisionNotFound, message)
+	r.updateStatusConditions(experimentCR, experimentcontrollercomv1alpha1.ReasonRevisionNotFound, message)
+	return false, nil
+}
+
+// recordRevision records the overrides the experiment workload was rendered with in
+// status.history, storing them in a ControllerRevision when they were not rendered before, and
+// prunes the revisions beyond the history limit. Nothing is recorded with the RevisionHistory
+// feature disabled.
+func (r *ExperimentDeploymentReconciler) recordRevision(
+	ctx context.Context,
+	experimentCR *experimentcontrollercomv1alpha1.ExperimentDeployment,
+	sourceGeneration int64) error {
+
+	if !r.config().Enabled(config.FeatureRevisionHistory) {
+		return nil
+	}
+	hash := overrideHash(experimentCR)
+	now := metav1.Now()
+	if i := slices.IndexFunc(experimentCR.Status.History, func(entry experimentcontrollercomv1alpha1.ExperimentRevision) bool {
+		return entry.OverrideHash == hash
+	}); i >= 0 {
+		entry := &experimentCR.Status.History[i]
+		entry.SourceGeneration = sourceGeneration
+		if experimentCR.Status.CurrentRevision != entry.Revision {
+			entry.LastAppliedTime = now
+			entry.Outcome = experimentcontrollercomv1alpha1.RevisionOutcomePending
+			experimentCR.Status.CurrentRevision = entry.Revision
+		}
+		return nil
+	}
+
+	revisions, err := r.listRevisions(ctx, experimentCR)
+	if err != nil {
+		return err
+	}
+	var revision *appsv1.ControllerRevision
+	next := int64(1)
+	for i := range revisions {
+		if revisions[i].Labels[r.labelKey(labelOverrideHash)] == hash {
+			revision = &revisions[i]
+		}
+		next = max(next, revisions[i].Revision+1)
+	}
+	if revision == nil {
+		revision = &appsv1.ControllerRevision{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      revisionName(experimentCR, hash),
+				Namespace: experimentCR.Namespace,
+				Labels: map[string]string{
+					r.labelKey(labelManagedBy):    ManagedByValue,
+					r.labelKey(labelCRName):       experimentCR.Name,
+					r.labelKey(labelOverrideHash): hash,
+				},
+			},
+			Data:     runtime.RawExtension{Raw: experimentCR.Spec.OverrideSpec.Raw},
+			Revision: next,
+		}
+		if err := controllerutil.SetControllerReference(experimentCR, revision, r.Scheme); err != nil {
+			return err
+		}
+		if err := r.Create(ctx, revision); err != nil {
+			return fmt.Errorf("failed to create ControllerRevision %s: %w", revision.Name, err)
+		}
+		r.Recorder.Eventf(experimentCR, corev1.EventTypeNormal, "RevisionCreated", "Recorded revision %d of the overrides", revision.Revision)
+		revisions = append(revisions, *revision)
+	}
+
+	experimentCR.Status.History = append(experimentCR.Status.History, experimentcontrollercomv1alpha1.ExperimentRevision{
+		Revision:         revision.Revision,
+		OverrideHash:     hash,
+		SourceGeneration: sourceGeneration,
+		ChangedBy:        overrideFieldManager(experimentCR),
+		CreationTime:     now,
+		LastAppliedTime:  now,
+		Outcome:          experimentcontrollercomv1alpha1.RevisionOutcomePending,
+	})
+	slices.SortFunc(experimentCR.Status.History, func(a, b experimentcontrollercomv1alpha1.ExperimentRevision) int {
+		return cmp.Compare(a.Revision, b.Revision)
+	})
+	experimentCR.Status.CurrentRevision = revision.Revision
+	return r.pruneRevisions(ctx, experimentCR, revisions)
+}
+
+// pruneRevisions deletes the oldest revisions beyond the history limit, keeping the current
+// revision and the one rolled back to, and drops the deleted revisions from status.history
+func (r *ExperimentDeploymentReconciler) pruneRevisions(
+	ctx context.Context,
+	experimentCR *experimentcontrollercomv1alpha1.ExperimentDeployment,
+	revisions []appsv1.ControllerRevision) error {
+
+	limit := int32(defaultRevisionHistoryLimit)
+	if experimentCR.Spec.RevisionHistoryLimit != nil {
+		limit = *experimentCR.Spec.RevisionHistoryLimit
+	}
+
+	retained := make(map[int64]bool, len(revisions))
+	excess := len(revisions) - int(limit)
+	for i := range revisions {
+		revision := &revisions[i]
+		keep := revision.Revision == experimentCR.Status.CurrentRevision ||
+			(experimentCR.Spec.RollbackTo != nil && revision.Revision == *experimentCR.Spec.RollbackTo)
+		if excess <= 0 || keep {
+			retained[revision.Revision] = true
+			continue
+		}
+		if err := r.Delete(ctx, revision); err != nil && !k8serrors.IsNotFound(err) {
+			return fmt.Errorf("failed to delete ControllerRevision %s: %w", revision.Name, err)
+		}
+		excess--
+	}
+
+	experimentCR.Status.History = slices.DeleteFunc(experimentCR.Status.History, func(entry experimentcontrollercomv1alpha1.ExperimentRevision) bool {
+		return !retained[entry.Revision]
+	})
+	return nil
+}
+
+// setRevisionOutcome records whether the experiment workload became ready with the current revision
+func setRevisionOutcome(experimentCR *experimentcontrollercomv1alpha1.ExperimentDeployment) {
+	i := slices.IndexFunc(experimentCR.Status.History, func(entry experimentcontrollercomv1alpha1.ExperimentRevision) bool {
+		return entry.Revision == experimentCR.Status.CurrentRevision
+	})
+	if i < 0 {
+		return
+	}
+	switch experimentCR.Status.Phase {
+	case experimentcontrollercomv1alpha1.ExperimentPhaseRunning, experimentcontrollercomv1alpha1.ExperimentPhaseSucceeded:
+		experimentCR.Status.History[i].Outcome = experimentcontrollercomv1alpha1.RevisionOutcomeReady
+	case experimentcontrollercomv1alpha1.ExperimentPhaseFailed, experimentcontrollercomv1alpha1.ExperimentPhaseAborted:
+		experimentCR.Status.History[i].Outcome = experimentcontrollercomv1alpha1.RevisionOutcomeFailed
+	}
+}
+
+// overrideFieldManager returns the field manager that last changed the overrides of the
+// experiment, or its rollbackTo while it is rolled back
+func overrideFieldManager(experimentCR *experimentcontrollercomv1alpha1.ExperimentDeployment) string {
+	field := "f:overrideSpec"
+	if experimentCR.Spec.RollbackTo != nil {
+		field = "f:rollbackTo"
+	}
+
+	var manager string
+	var changed *metav1.Time
+	for _, entry := range experimentCR.ManagedFields {
+		if entry.FieldsV1 == nil || entry.Subresource != "" {
+			continue
+		}
+		var fields struct {
+			Spec map[string]json.RawMessage `json:"f:spec"`
+		}
+		if err := json.Unmarshal(entry.FieldsV1.Raw, &fields); err != nil {
+			continue
+		}
+		if _, ok := fields.Spec[field]; !ok {
+			continue
+		}
+		if changed == nil || (entry.Time != nil && changed.Before(entry.Time)) {
+			manager, changed = entry.Manager, entry.Time
+			if changed == nil {
+				changed = &metav1.Time{}
+			}
+		}
+	}
+	return manager
+}
@@ -0,0 +1,227 @@
+package controller
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	experimentcontrollercomv1alpha1 "experimentcontroller.example.com/experiment-deployment/api/v1alpha1"
+)
+
+var _ = Describe("Experiment revisions", func() {
+	var (
+		ctx            context.Context
+		reconciler     *ExperimentDeploymentReconciler
+		fakeClient     client.Client
+		namespacedName types.NamespacedName
+	)
+
+	imageOverride := func(image string) apiextensionsv1.JSON {
+		return apiextensionsv1.JSON{Raw: []byte(`{"template": {"spec": {"containers": [{"name": "web", "image": "` + image + `"}]}}}`)}
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		scheme := runtime.NewScheme()
+		Expect(experimentcontrollercomv1alpha1.AddToScheme(scheme)).To(Succeed())
+		Expect(appsv1.AddToScheme(scheme)).To(Succeed())
+		Expect(corev1.AddToScheme(scheme)).To(Succeed())
+		fakeClient = fake.NewClientBuilder().WithScheme(scheme).
+			WithStatusSubresource(&experimentcontrollercomv1alpha1.ExperimentDeployment{}).
+			WithInterceptorFuncs(withServerSideApply(interceptor.Funcs{})).Build()
+		reconciler = &ExperimentDeploymentReconciler{
+			Client:   fakeClient,
+			Scheme:   scheme,
+			Recorder: record.NewFakeRecorder(100),
+		}
+		namespacedName = types.NamespacedName{Name: testExperimentCRName, Namespace: testNamespace}
+
+		Expect(fakeClient.Create(ctx, &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: testNamespace, Generation: 3},
+			Spec: appsv1.DeploymentSpec{
+				Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "web"}},
+				Template: corev1.PodTemplateSpec{
+					ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": "web"}},
+					Spec: corev1.PodSpec{
+						Containers: []corev1.Container{{Name: "web", Image: "nginx:1.27"}},
+					},
+				},
+			},
+		})).To(Succeed())
+		Expect(fakeClient.Create(ctx, &experimentcontrollercomv1alpha1.ExperimentDeployment{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:       testExperimentCRName,
+				Namespace:  testNamespace,
+				Finalizers: []string{experimentDeploymentFinalizer},
+			},
+			Spec: experimentcontrollercomv1alpha1.ExperimentDeploymentSpec{
+				SourceRef: experimentcontrollercomv1alpha1.SourceRef{
+					Kind: experimentcontrollercomv1alpha1.SourceKindDeployment,
+					Name: "web",
+				},
+				OverrideSpec: imageOverride("nginx:1.28"),
+			},
+		})).To(Succeed())
+	})
+
+	reconcile := func() *experimentcontrollercomv1alpha1.ExperimentDeployment {
+		_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+		Expect(err).NotTo(HaveOccurred())
+		updatedCR := &experimentcontrollercomv1alpha1.ExperimentDeployment{}
+		Expect(fakeClient.Get(ctx, namespacedName, updatedCR)).To(Succeed())
+		return updatedCR
+	}
+
+	updateSpec := func(mutate func(spec *experimentcontrollercomv1alpha1.ExperimentDeploymentSpec)) {
+		experimentCR := &experimentcontrollercomv1alpha1.ExperimentDeployment{}
+		Expect(fakeClient.Get(ctx, namespacedName, experimentCR)).To(Succeed())
+		mutate(&experimentCR.Spec)
+		Expect(fakeClient.Update(ctx, experimentCR)).To(Succeed())
+	}
+
+	experimentImage := func() string {
+		experimentDeployment := &appsv1.Deployment{}
+		Expect(fakeClient.Get(ctx, namespacedName, experimentDeployment)).To(Succeed())
+		return experimentDeployment.Spec.Template.Spec.Containers[0].Image
+	}
+
+	listRevisions := func() []appsv1.ControllerRevision {
+		revisionList := &appsv1.ControllerRevisionList{}
+		Expect(fakeClient.List(ctx, revisionList, client.InNamespace(testNamespace))).To(Succeed())
+		return revisionList.Items
+	}
+
+	It("should record a revision per rendered override", func() {
+		updatedCR := reconcile()
+		Expect(updatedCR.Status.CurrentRevision).To(Equal(int64(1)))
+		Expect(updatedCR.Status.History).To(HaveLen(1))
+		first := updatedCR.Status.History[0]
+		Expect(first.OverrideHash).To(Equal(overrideHash(updatedCR)))
+		Expect(first.SourceGeneration).To(Equal(int64(3)))
+		Expect(first.Outcome).To(Equal(experimentcontrollercomv1alpha1.RevisionOutcomePending))
+
+		// Reconciling the same override records nothing new
+		reconcile()
+		Expect(listRevisions()).To(HaveLen(1))
+
+		updateSpec(func(spec *experimentcontrollercomv1alpha1.ExperimentDeploymentSpec) {
+			spec.OverrideSpec = imageOverride("nginx:1.29")
+		})
+		updatedCR = reconcile()
+		Expect(updatedCR.Status.CurrentRevision).To(Equal(int64(2)))
+		Expect(updatedCR.Status.History).To(HaveLen(2))
+		Expect(listRevisions()).To(HaveLen(2))
+		revision := &appsv1.ControllerRevision{}
+		Expect(fakeClient.Get(ctx, types.NamespacedName{
+			Name:      revisionName(updatedCR, updatedCR.Status.History[1].OverrideHash),
+			Namespace: testNamespace,
+		}, revision)).To(Succeed())
+		Expect(revision.Revision).To(Equal(int64(2)))
+		Expect(string(revision.Data.Raw)).To(ContainSubstring("nginx:1.29"))
+		Expect(metav1.IsControlledBy(revision, updatedCR)).To(BeTrue())
+	})
+
+	It("should record the readiness outcome of the current revision", func() {
+		reconcile()
+		experimentDeployment := &appsv1.Deployment{}
+		Expect(fakeClient.Get(ctx, namespacedName, experimentDeployment)).To(Succeed())
+		experimentDeployment.Status.Replicas = 1
+		experimentDeployment.Status.ReadyReplicas = 1
+		experimentDeployment.Status.UpdatedReplicas = 1
+		Expect(fakeClient.Status().Update(ctx, experimentDeployment)).To(Succeed())
+
+		updatedCR := reconcile()
+		Expect(updatedCR.Status.Phase).To(Equal(experimentcontrollercomv1alpha1.ExperimentPhaseRunning))
+		Expect(updatedCR.Status.History[0].Outcome).To(Equal(experimentcontrollercomv1alpha1.RevisionOutcomeReady))
+	})
+
+	It("should render the overrides of the revision rolled back to", func() {
+		reconcile()
+		updateSpec(func(spec *experimentcontrollercomv1alpha1.ExperimentDeploymentSpec) {
+			spec.OverrideSpec = imageOverride("nginx:1.29")
+		})
+		reconcile()
+		Expect(experimentImage()).To(Equal("nginx:1.29"))
+
+		updateSpec(func(spec *experimentcontrollercomv1alpha1.ExperimentDeploymentSpec) {
+			spec.RollbackTo = ptr.To(int64(1))
+		})
+		updatedCR := reconcile()
+
+		Expect(experimentImage()).To(Equal("nginx:1.28"))
+		Expect(updatedCR.Status.CurrentRevision).To(Equal(int64(1)))
+		Expect(updatedCR.Status.History).To(HaveLen(2))
+		Expect(string(updatedCR.Spec.OverrideSpec.Raw)).To(ContainSubstring("nginx:1.29"))
+
+		updateSpec(func(spec *experimentcontrollercomv1alpha1.ExperimentDeploymentSpec) {
+			spec.RollbackTo = nil
+		})
+		updatedCR = reconcile()
+		Expect(experimentImage()).To(Equal("nginx:1.29"))
+		Expect(updatedCR.Status.CurrentRevision).To(Equal(int64(2)))
+	})
+
+	It("should stall when the revision rolled back to is not retained", func() {
+		reconcile()
+		updateSpec(func(spec *experimentcontrollercomv1alpha1.ExperimentDeploymentSpec) {
+			spec.RollbackTo = ptr.To(int64(7))
+		})
+
+		updatedCR := reconcile()
+
+		readyCondition := meta.FindStatusCondition(updatedCR.Status.Conditions, ConditionTypeReady)
+		Expect(readyCondition.Reason).To(Equal(experimentcontrollercomv1alpha1.ReasonRevisionNotFound))
+		Expect(readyCondition.Message).To(Equal("Revision 7 to roll back to is not retained"))
+		Expect(meta.IsStatusConditionTrue(updatedCR.Status.Conditions, ConditionTypeStalled)).To(BeTrue())
+		Expect(experimentImage()).To(Equal("nginx:1.28"))
+	})
+
+	It("should prune the revisions beyond the history limit", func() {
+		updateSpec(func(spec *experimentcontrollercomv1alpha1.ExperimentDeploymentSpec) {
+			spec.RevisionHistoryLimit = ptr.To(int32(2))
+		})
+		for _, image := range []string{"nginx:1.28", "nginx:1.29", "nginx:1.30"} {
+			updateSpec(func(spec *experimentcontrollercomv1alpha1.ExperimentDeploymentSpec) {
+				spec.OverrideSpec = imageOverride(image)
+			})
+			reconcile()
+		}
+
+		updatedCR := reconcile()
+		Expect(listRevisions()).To(HaveLen(2))
+		Expect(updatedCR.Status.History).To(HaveLen(2))
+		Expect(updatedCR.Status.History[0].Revision).To(Equal(int64(2)))
+		Expect(updatedCR.Status.History[1].Revision).To(Equal(int64(3)))
+	})
+
+	It("should attribute the overrides to the field manager that changed them last", func() {
+		experimentCR := &experimentcontrollercomv1alpha1.ExperimentDeployment{}
+		earlier, later := metav1.Unix(1000, 0), metav1.Unix(2000, 0)
+		experimentCR.ManagedFields = []metav1.ManagedFieldsEntry{
+			{Manager: "helm", Time: &earlier, FieldsV1: &metav1.FieldsV1{Raw: []byte(`{"f:spec":{"f:overrideSpec":{},"f:sourceRef":{}}}`)}},
+			{Manager: "kubectl-edit", Time: &later, FieldsV1: &metav1.FieldsV1{Raw: []byte(`{"f:spec":{"f:overrideSpec":{}}}`)}},
+			{Manager: "experiment-controller", Time: &later, Subresource: "status", FieldsV1: &metav1.FieldsV1{Raw: []byte(`{"f:status":{}}`)}},
+		}
+		Expect(overrideFieldManager(experimentCR)).To(Equal("kubectl-edit"))
+
+		experimentCR.Spec.RollbackTo = ptr.To(int64(1))
+		experimentCR.ManagedFields = append(experimentCR.ManagedFields, metav1.ManagedFieldsEntry{
+			Manager: "kubectl-patch", Time: &earlier, FieldsV1: &metav1.FieldsV1{Raw: []byte(`{"f:spec":{"f:rollbackTo":{}}}`)},
+		})
+		Expect(overrideFieldManager(experimentCR)).To(Equal("kubectl-patch"))
+	})
+})
@@ -19,36 +19,52 @@ package controller
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"time"
 
 	"dario.cat/mergo"
 	rolloutsv1alpha1 "github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+	"go.opentelemetry.io/otel/trace"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	experimentcontrollercomv1alpha1 "experimentcontroller.example.com/experiment-deployment/api/v1alpha1"
+	"experimentcontroller.example.com/experiment-deployment/internal/config"
+	"experimentcontroller.example.com/experiment-deployment/internal/notification"
+	"experimentcontroller.example.com/experiment-deployment/internal/sharding"
 )
 
 const (
-	experimentDeploymentFinalizer = "experimentdeployments.experimentcontroller.example.com/finalizer"
+	// experimentDeploymentFinalizer is the default finalizer of experiments, see config.ControllerConfig
+	experimentDeploymentFinalizer = config.DefaultFinalizer
 	// Condition Types
-	ConditionTypeReady     = "Ready"
-	ConditionTypeSynced    = "Synced"
-	ReasonReconcileError   = "ReconcileError"
-	ReasonReconcileSuccess = "ReconcileSuccess"
+	ConditionTypeReady       = experimentcontrollercomv1alpha1.ConditionTypeReady
+	ConditionTypeSynced      = experimentcontrollercomv1alpha1.ConditionTypeSynced
+	ConditionTypeProgressing = experimentcontrollercomv1alpha1.ConditionTypeProgressing
+	ConditionTypeDegraded    = experimentcontrollercomv1alpha1.ConditionTypeDegraded
+	ConditionTypeStalled     = experimentcontrollercomv1alpha1.ConditionTypeStalled
+	ReasonReconcileError     = experimentcontrollercomv1alpha1.ReasonReconcileError
+	ReasonReconcileSuccess   = experimentcontrollercomv1alpha1.ReasonReconcileSuccess
 	// Label values
 	ExperimentRoleValue = "experiment"
+	ManagedByValue      = "experiment-controller"
 )
 
 // ExperimentDeploymentReconciler reconciles a ExperimentDeployment object
@@ -56,22 +72,84 @@ type ExperimentDeploymentReconciler struct {
 	client.Client
 	Scheme   *runtime.Scheme
 	Recorder record.EventRecorder
+	// AuthorizeAuthors checks with SubjectAccessReviews that the author recorded by the
+	// admission webhook has access to the source before rendering experiments
+	AuthorizeAuthors bool
+	// ConcurrencyLimits limit the experiments running at the same time, next to the limits of
+	// the ClusterExperimentPolicies
+	ConcurrencyLimits ConcurrencyLimits
+	// Notifier delivers lifecycle notifications to the NotificationProviders referenced by
+	// experiments. A default notifier is used if nil.
+	Notifier *notification.Notifier
+	// CloudEvents configures the CloudEvents published about experiment lifecycle transitions
+	CloudEvents CloudEventsConfig
+	// TracerProvider creates the tracer of the reconcile spans. The global provider is used if nil.
+	TracerProvider trace.TracerProvider
+	// Config holds the configuration file of the controller, reloaded when it changes. The
+	// defaults are used if nil.
+	Config *config.Store
+	// NamespaceSelector restricts the experiments handled to the namespaces whose labels match.
+	// All namespaces are handled if nil.
+	NamespaceSelector labels.Selector
+
+	// ExperimentSelector restricts the experiments handled to those whose labels match, e.g. to
+	// canary a new version of the controller. All experiments are handled if nil.
+	ExperimentSelector labels.Selector
+	// Sharding splits the namespaces among the replicas of the controller
+	Sharding ShardingConfig
+
+	// APIReader reads source workloads from the API server, so that they need not be cached in
+	// full. The cached client is used if nil.
+	APIReader client.Reader
+	// SourceCache caches the metadata of source workloads, to reconcile experiments when their
+	// source changes. Sources are not watched if nil.
+	SourceCache cache.Cache
+
+	// activeNamespaces are the namespaces currently matching the NamespaceSelector
+	activeNamespaces activeNamespaces
+	// shardEvents enqueues the experiments of the shards acquired by this replica
+	shardEvents chan event.GenericEvent
+	// publisher publishes the CloudEvents of lifecycle transitions in the background
+	publisher *notification.Publisher
 }
 
 // +kubebuilder:rbac:groups=experimentcontroller.example.com,resources=experimentdeployments,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=experimentcontroller.example.com,resources=experimentdeployments/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups=experimentcontroller.example.com,resources=experimentdeployments/finalizers,verbs=update
+// +kubebuilder:rbac:groups=experimentcontroller.example.com,resources=clusterexperimentpolicies,verbs=get;list;watch
+// +kubebuilder:rbac:groups=experimentcontroller.example.com,resources=experimentreferencegrants,verbs=get;list;watch
+// +kubebuilder:rbac:groups=experimentcontroller.example.com,resources=notificationproviders,verbs=get;list;watch
 // +kubebuilder:rbac:groups=apps,resources=deployments,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=apps,resources=statefulsets,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=apps,resources=controllerrevisions,verbs=get;list;watch;create;delete
 // +kubebuilder:rbac:groups=argoproj.io,resources=rollouts,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=argoproj.io,resources=analysistemplates;clusteranalysistemplates,verbs=get;list;watch
+// +kubebuilder:rbac:groups=argoproj.io,resources=analysisruns,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups="",resources=services,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=networking.istio.io,resources=virtualservices,verbs=get;list;watch;update;patch
+// +kubebuilder:rbac:groups=networking.k8s.io,resources=networkpolicies,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups="",resources=persistentvolumeclaims,verbs=get;list;watch;create
+// +kubebuilder:rbac:groups="",resources=secrets,verbs=get
+// +kubebuilder:rbac:groups="",resources=namespaces,verbs=get;list;watch
+// +kubebuilder:rbac:groups=snapshot.storage.k8s.io,resources=volumesnapshots,verbs=get;list;watch;create;delete
 // +kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+// +kubebuilder:rbac:groups=authorization.k8s.io,resources=subjectaccessreviews,verbs=create
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
 // move the current state of the cluster closer to the desired state.
-func (r *ExperimentDeploymentReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+func (r *ExperimentDeploymentReconciler) Reconcile(ctx context.Context, req ctrl.Request) (result ctrl.Result, err error) {
 	log := logf.FromContext(ctx)
 
 	experimentCR := &experimentcontrollercomv1alpha1.ExperimentDeployment{}
+	ctx, span := r.tracer().Start(ctx, spanReconcile, trace.WithAttributes(
+		attributeExperimentName.String(req.Name),
+		attributeExperimentNamespace.String(req.Namespace),
+	))
+	defer func() {
+		setOutcomeAttributes(span, experimentCR)
+		endSpan(span, err)
+	}()
+
 	if err := r.Get(ctx, req.NamespacedName, experimentCR); err != nil {
 		if k8serrors.IsNotFound(err) {
 			log.Info("ExperimentDeployment resource not found. Ignoring since object must be deleted.")
@@ -81,11 +159,31 @@ func (r *ExperimentDeploymentReconciler) Reconcile(ctx context.Context, req ctrl
 		return ctrl.Result{}, err
 	}
 
+	// Experiments of namespaces that are not selected are left alone. Deleted experiments are
+	// still cleaned up, so their deletion does not hang on the finalizer.
+	selected, err := r.namespaceSelected(ctx, req.Namespace)
+	if err != nil {
+		log.Error(err, "Failed to check whether the namespace is selected")
+		return ctrl.Result{}, err
+	}
+	if !selected && experimentCR.DeletionTimestamp.IsZero() {
+		log.V(1).Info("Namespace not selected, ignoring ExperimentDeployment")
+		return ctrl.Result{}, nil
+	}
+
+	// Experiments of other shards are handled by other replicas of the controller
+	if !r.ownsExperiment(experimentCR) {
+		log.V(1).Info("ExperimentDeployment belongs to another shard, ignoring")
+		return ctrl.Result{}, nil
+	}
+
 	// Validate the ExperimentDeployment before processing
 	if err := r.validateExperimentDeployment(experimentCR); err != nil {
 		log.Error(err, "ExperimentDeployment validation failed")
-		r.updateStatusConditions(experimentCR, "ValidationFailed", err.Error())
-		if updateErr := r.Status().Update(ctx, experimentCR); updateErr != nil {
+		r.updateStatusConditions(experimentCR, experimentcontrollercomv1alpha1.ReasonValidationFailed, err.Error())
+		r.setLifecycleStatus(experimentCR)
+		r.notifyTransition(ctx, experimentCR)
+		if updateErr := r.updateStatus(ctx, experimentCR); updateErr != nil {
 			log.Error(updateErr, "Failed to update status after validation failure")
 		}
 		return ctrl.Result{}, err
@@ -93,11 +191,11 @@ func (r *ExperimentDeploymentReconciler) Reconcile(ctx context.Context, req ctrl
 
 	// Handle deletion
 	if !experimentCR.ObjectMeta.DeletionTimestamp.IsZero() {
-		if controllerutil.ContainsFinalizer(experimentCR, experimentDeploymentFinalizer) {
+		if controllerutil.ContainsFinalizer(experimentCR, r.finalizer()) {
 			log.Info("Handling deletion of ExperimentDeployment", "name", experimentCR.Name)
 
 			// Delete the experiment Deployment
-			experimentDeploymentName := experimentCR.Name // Use the same naming as creation
+			experimentDeploymentName := experimentWorkloadName(experimentCR, "Deployment") // Use the same naming as creation
 			err := r.deleteExperimentDeployment(ctx, experimentCR.Namespace, experimentDeploymentName)
 			if err != nil {
 				log.Error(err, "Failed to delete experiment Deployment during finalization")
@@ -106,7 +204,15 @@ func (r *ExperimentDeploymentReconciler) Reconcile(ctx context.Context, req ctrl
 			}
 			log.Info("Successfully deleted experiment Deployment", "name", experimentDeploymentName)
 
-			controllerutil.RemoveFinalizer(experimentCR, experimentDeploymentFinalizer)
+			// Remove traffic mirrors configured for Shadow mode, the experiment Service is garbage collected
+			if err := r.removeServiceMirrors(ctx, experimentCR); err != nil {
+				log.Error(err, "Failed to remove traffic mirrors during finalization")
+				return ctrl.Result{}, err
+			}
+
+			r.notify(ctx, experimentCR, experimentcontrollercomv1alpha1.NotificationEventDeleted)
+
+			controllerutil.RemoveFinalizer(experimentCR, r.finalizer())
 			if err := r.Update(ctx, experimentCR); err != nil {
 				log.Error(err, "Failed to remove finalizer from ExperimentDeployment")
 				return ctrl.Result{}, err
@@ -117,9 +223,9 @@ func (r *ExperimentDeploymentReconciler) Reconcile(ctx context.Context, req ctrl
 	}
 
 	// Add finalizer if it doesn't exist
-	if !controllerutil.ContainsFinalizer(experimentCR, experimentDeploymentFinalizer) {
+	if !controllerutil.ContainsFinalizer(experimentCR, r.finalizer()) {
 		log.Info("Adding finalizer to ExperimentDeployment", "name", experimentCR.Name)
-		controllerutil.AddFinalizer(experimentCR, experimentDeploymentFinalizer)
+		controllerutil.AddFinalizer(experimentCR, r.finalizer())
 		if err := r.Update(ctx, experimentCR); err != nil {
 			log.Error(err, "Failed to add finalizer to ExperimentDeployment")
 			return ctrl.Result{}, err
@@ -146,13 +252,13 @@ func (r *ExperimentDeploymentReconciler) Reconcile(ctx context.Context, req ctrl
 		meta.SetStatusCondition(&experimentCR.Status.Conditions, metav1.Condition{
 			Type:    ConditionTypeSynced,
 			Status:  metav1.ConditionFalse,
-			Reason:  "UnsupportedSourceKind",
+			Reason:  experimentcontrollercomv1alpha1.ReasonUnsupportedSourceKind,
 			Message: err.Error(),
 		})
 		meta.SetStatusCondition(&experimentCR.Status.Conditions, metav1.Condition{
 			Type:    ConditionTypeReady,
 			Status:  metav1.ConditionFalse,
-			Reason:  "UnsupportedSourceKind",
+			Reason:  experimentcontrollercomv1alpha1.ReasonUnsupportedSourceKind,
 			Message: err.Error(),
 		})
 		// Use the safer status update method
@@ -163,8 +269,29 @@ func (r *ExperimentDeploymentReconciler) Reconcile(ctx context.Context, req ctrl
 		return ctrl.Result{}, nil // No requeue, wait for user to fix CR
 	}
 
+	// Analysis requires the Argo Rollouts CRDs
+	if len(experimentCR.Spec.AnalysisTemplates) > 0 && !r.isAnalysisSupported() {
+		err := fmt.Errorf("Argo Rollouts analysis is not installed in this cluster, cannot run analysisTemplates")
+		log.Error(err, "Analysis not supported")
+		r.Recorder.Event(experimentCR, corev1.EventTypeWarning, "AnalysisNotSupported", err.Error())
+		r.updateStatusConditions(experimentCR, experimentcontrollercomv1alpha1.ReasonAnalysisNotSupported, err.Error())
+		if _, updateErr := r.finalizeStatusUpdate(ctx, experimentCR); updateErr != nil {
+			log.Error(updateErr, "Failed to update ExperimentDeployment status for unsupported analysis")
+		}
+		return ctrl.Result{}, err
+	}
+
+	// Experiments aborted with the Delete action keep no workload
+	if isWorkloadDeletedOnAbort(experimentCR) {
+		r.setAbortedStatus(experimentCR)
+		if _, err := r.finalizeStatusUpdate(ctx, experimentCR); err != nil {
+			return ctrl.Result{}, err
+		}
+		return r.requeueNotifications(ctrl.Result{}, experimentCR), nil
+	}
+
 	// Reconcile experiment workload based on source kind
-	experimentWorkload, err := r.reconcileExperimentWorkload(ctx, experimentCR)
+	experimentWorkload, sourceWorkload, err := r.reconcileExperimentWorkload(ctx, experimentCR)
 	if err != nil {
 		return ctrl.Result{}, err
 	}
@@ -174,16 +301,36 @@ func (r *ExperimentDeploymentReconciler) Reconcile(ctx context.Context, req ctrl
 		if err != nil {
 			return result, err
 		}
-		// Override the result to use 30-second requeue for source not found scenarios
-		return ctrl.Result{RequeueAfter: 30 * time.Second}, nil
+		// Override the result to use the longer waiting requeue for source not found scenarios
+		return ctrl.Result{RequeueAfter: r.config().Requeue.WaitingInterval.Duration}, nil
+	}
+
+	// Abort broken experiments according to their abort policy
+	wasAborted := isExperimentAborted(experimentCR)
+	if err := r.enforceAbortPolicy(ctx, experimentCR, experimentWorkload, sourceWorkload); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	// Run the referenced AnalysisTemplates against the experiment pods
+	if err := r.reconcileAnalysisRuns(ctx, experimentCR); err != nil {
+		return ctrl.Result{}, err
+	}
+	if isExperimentAborted(experimentCR) {
+		r.setAbortedStatus(experimentCR)
+		if _, err := r.finalizeStatusUpdate(ctx, experimentCR); err != nil {
+			return ctrl.Result{}, err
+		}
+		// Requeue right away to scale down the workload of a newly aborted experiment
+		return r.requeueNotifications(ctrl.Result{Requeue: !wasAborted}, experimentCR), nil
 	}
 
 	// Update Status
-	return r.updateExperimentWorkloadStatus(ctx, experimentCR, experimentWorkload)
+	return r.updateExperimentWorkloadStatus(ctx, experimentCR, experimentWorkload, sourceWorkload)
 }
 
-// reconcileExperimentWorkload handles fetching source workload and creating experiment workload for all supported kinds
-func (r *ExperimentDeploymentReconciler) reconcileExperimentWorkload(ctx context.Context, experimentCR *experimentcontrollercomv1alpha1.ExperimentDeployment) (client.Object, error) {
+// reconcileExperimentWorkload handles fetching source workload and creating experiment workload for all supported kinds.
+// It returns the experiment workload and the source workload it was rendered from.
+func (r *ExperimentDeploymentReconciler) reconcileExperimentWorkload(ctx context.Context, experimentCR *experimentcontrollercomv1alpha1.ExperimentDeployment) (client.Object, client.Object, error) {
 	log := logf.FromContext(ctx)
 
 	sourceNamespace := experimentCR.Spec.SourceRef.Namespace
@@ -191,6 +338,33 @@ func (r *ExperimentDeploymentReconciler) reconcileExperimentWorkload(ctx context
 		sourceNamespace = experimentCR.Namespace
 	}
 
+	// Cloning a workload of another namespace requires an ExperimentReferenceGrant there
+	permitted, err := r.isSourceReferencePermitted(ctx, experimentCR, sourceNamespace)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !permitted {
+		return nil, nil, nil
+	}
+
+	// The author of the experiment must have access to the source themselves
+	authorized, err := r.isAuthorAuthorized(ctx, experimentCR, sourceNamespace)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !authorized {
+		return nil, nil, nil
+	}
+
+	// Render the overrides of the revision the experiment is rolled back to
+	rolledBack, err := r.applyRollback(ctx, experimentCR)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !rolledBack {
+		return nil, nil, nil
+	}
+
 	switch experimentCR.Spec.SourceRef.Kind {
 	case experimentcontrollercomv1alpha1.SourceKindDeployment:
 		return r.reconcileDeploymentExperiment(ctx, experimentCR, sourceNamespace)
@@ -202,108 +376,253 @@ func (r *ExperimentDeploymentReconciler) reconcileExperimentWorkload(ctx context
 			err := fmt.Errorf("Argo Rollouts are not installed in this cluster, cannot process Rollout source kind")
 			log.Error(err, "Rollouts not supported")
 			r.Recorder.Event(experimentCR, corev1.EventTypeWarning, "RolloutsNotSupported", err.Error())
-			r.updateStatusConditions(experimentCR, "RolloutsNotSupported", err.Error())
-			return nil, err
+			r.updateStatusConditions(experimentCR, experimentcontrollercomv1alpha1.ReasonRolloutsNotSupported, err.Error())
+			return nil, nil, err
 		}
 		return r.reconcileRolloutExperiment(ctx, experimentCR, sourceNamespace)
 	default:
 		err := fmt.Errorf("unsupported source kind: %s", experimentCR.Spec.SourceRef.Kind)
 		log.Error(err, "Invalid source kind")
-		return nil, err
+		return nil, nil, err
 	}
 }
 
 // reconcileDeploymentExperiment handles Deployment-based experiments
-func (r *ExperimentDeploymentReconciler) reconcileDeploymentExperiment(ctx context.Context, experimentCR *experimentcontrollercomv1alpha1.ExperimentDeployment, sourceNamespace string) (client.Object, error) {
+func (r *ExperimentDeploymentReconciler) reconcileDeploymentExperiment(ctx context.Context, experimentCR *experimentcontrollercomv1alpha1.ExperimentDeployment, sourceNamespace string) (client.Object, client.Object, error) {
 	log := logf.FromContext(ctx)
 
 	// Fetch source Deployment
 	sourceDeployment := &appsv1.Deployment{}
-	err := r.Get(ctx, types.NamespacedName{Name: experimentCR.Spec.SourceRef.Name, Namespace: sourceNamespace}, sourceDeployment)
+	fetchCtx, span := r.startSpan(ctx, spanFetchSource, experimentCR, attributeSourceName.String(experimentCR.Spec.SourceRef.Name))
+	err := r.sourceReader().Get(fetchCtx, types.NamespacedName{Name: experimentCR.Spec.SourceRef.Name, Namespace: sourceNamespace}, sourceDeployment)
+	endSpan(span, err)
 	if err != nil {
 		if k8serrors.IsNotFound(err) {
 			log.Error(err, "Source Deployment not found", "sourceName", experimentCR.Spec.SourceRef.Name, "sourceNamespace", sourceNamespace)
 			r.Recorder.Eventf(experimentCR, corev1.EventTypeWarning, "SourceNotFound", "Source Deployment %s/%s not found", sourceNamespace, experimentCR.Spec.SourceRef.Name)
-			r.updateStatusConditions(experimentCR, "SourceNotFound", fmt.Sprintf("Source Deployment %s/%s not found", sourceNamespace, experimentCR.Spec.SourceRef.Name))
-			return nil, nil // Return nil to indicate requeue needed
+			r.updateStatusConditions(experimentCR, experimentcontrollercomv1alpha1.ReasonSourceNotFound, fmt.Sprintf("Source Deployment %s/%s not found", sourceNamespace, experimentCR.Spec.SourceRef.Name))
+			return nil, nil, nil // Return nil to indicate requeue needed
 		}
 		log.Error(err, "Failed to get source Deployment")
-		return nil, err
+		return nil, nil, err
 	}
 
 	// Construct experiment Deployment
+	_, span = r.startSpan(ctx, spanRender, experimentCR)
 	desiredExperimentDeployment, err := r.constructExperimentDeployment(experimentCR, sourceDeployment)
+	endSpan(span, err)
 	if err != nil {
 		log.Error(err, "Failed to construct desired experiment Deployment")
 		r.Recorder.Eventf(experimentCR, corev1.EventTypeWarning, "ConstructionFailed", "Failed to construct experiment Deployment: %s", err.Error())
-		r.updateStatusConditions(experimentCR, "ConstructionFailed", fmt.Sprintf("Failed to construct experiment Deployment: %s", err.Error()))
-		return nil, err
+		r.updateStatusConditions(experimentCR, experimentcontrollercomv1alpha1.ReasonConstructionFailed, fmt.Sprintf("Failed to construct experiment Deployment: %s", err.Error()))
+		return nil, nil, err
+	}
+
+	// Render nothing for experiments violating a ClusterExperimentPolicy
+	allowed, err := r.enforceExperimentPolicies(ctx, experimentCR, sourceNamespace, sourceDeployment.Spec)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !allowed {
+		return nil, nil, nil // Requeue to re-evaluate the policies
+	}
+
+	// Wait for a slot within the concurrency limits
+	admitted, err := r.admitExperiment(ctx, experimentCR, sourceNamespace, sourceReplicas(sourceDeployment.Spec.Replicas))
+	if err != nil {
+		return nil, nil, err
+	}
+	if !admitted {
+		return nil, nil, nil // Requeue until a slot frees up
+	}
+
+	// Apply the service mode (Shared, Isolated, Shadow) to the experiment pods
+	if err := r.reconcileServiceIsolation(ctx, experimentCR, &desiredExperimentDeployment.Spec.Template, desiredExperimentDeployment.Spec.Selector); err != nil {
+		return nil, nil, err
+	}
+
+	// Restrict the network access of the experiment pods if requested
+	if err := r.reconcileNetworkIsolation(ctx, experimentCR, sourceNamespace, sourceDeployment.Spec.Template.Labels); err != nil {
+		return nil, nil, err
 	}
 
 	// Create or Update experiment Deployment
-	return r.createOrUpdateDeployment(ctx, experimentCR, desiredExperimentDeployment)
+	upsertCtx, span := r.startSpan(ctx, spanUpsert, experimentCR, attributeWorkloadName.String(desiredExperimentDeployment.Name))
+	experimentDeployment, err := r.createOrUpdateDeployment(upsertCtx, experimentCR, desiredExperimentDeployment)
+	endSpan(span, err)
+	if err != nil || experimentDeployment == nil {
+		return experimentDeployment, sourceDeployment, err
+	}
+	return experimentDeployment, sourceDeployment, r.recordRevision(ctx, experimentCR, sourceDeployment.Generation)
 }
 
 // reconcileStatefulSetExperiment handles StatefulSet-based experiments
-func (r *ExperimentDeploymentReconciler) reconcileStatefulSetExperiment(ctx context.Context, experimentCR *experimentcontrollercomv1alpha1.ExperimentDeployment, sourceNamespace string) (client.Object, error) {
+func (r *ExperimentDeploymentReconciler) reconcileStatefulSetExperiment(ctx context.Context, experimentCR *experimentcontrollercomv1alpha1.ExperimentDeployment, sourceNamespace string) (client.Object, client.Object, error) {
 	log := logf.FromContext(ctx)
 
 	// Fetch source StatefulSet
 	sourceStatefulSet := &appsv1.StatefulSet{}
-	err := r.Get(ctx, types.NamespacedName{Name: experimentCR.Spec.SourceRef.Name, Namespace: sourceNamespace}, sourceStatefulSet)
+	fetchCtx, span := r.startSpan(ctx, spanFetchSource, experimentCR, attributeSourceName.String(experimentCR.Spec.SourceRef.Name))
+	err := r.sourceReader().Get(fetchCtx, types.NamespacedName{Name: experimentCR.Spec.SourceRef.Name, Namespace: sourceNamespace}, sourceStatefulSet)
+	endSpan(span, err)
 	if err != nil {
 		if k8serrors.IsNotFound(err) {
 			log.Error(err, "Source StatefulSet not found", "sourceName", experimentCR.Spec.SourceRef.Name, "sourceNamespace", sourceNamespace)
 			r.Recorder.Eventf(experimentCR, corev1.EventTypeWarning, "SourceNotFound", "Source StatefulSet %s/%s not found", sourceNamespace, experimentCR.Spec.SourceRef.Name)
-			r.updateStatusConditions(experimentCR, "SourceNotFound", fmt.Sprintf("Source StatefulSet %s/%s not found", sourceNamespace, experimentCR.Spec.SourceRef.Name))
-			return nil, nil // Return nil to indicate requeue needed
+			r.updateStatusConditions(experimentCR, experimentcontrollercomv1alpha1.ReasonSourceNotFound, fmt.Sprintf("Source StatefulSet %s/%s not found", sourceNamespace, experimentCR.Spec.SourceRef.Name))
+			return nil, nil, nil // Return nil to indicate requeue needed
 		}
 		log.Error(err, "Failed to get source StatefulSet")
-		return nil, err
+		return nil, nil, err
 	}
 
 	// Construct experiment StatefulSet
+	_, span = r.startSpan(ctx, spanRender, experimentCR)
 	desiredExperimentStatefulSet, err := r.constructExperimentStatefulSet(experimentCR, sourceStatefulSet)
+	endSpan(span, err)
 	if err != nil {
 		log.Error(err, "Failed to construct desired experiment StatefulSet")
 		r.Recorder.Eventf(experimentCR, corev1.EventTypeWarning, "ConstructionFailed", "Failed to construct experiment StatefulSet: %s", err.Error())
-		r.updateStatusConditions(experimentCR, "ConstructionFailed", fmt.Sprintf("Failed to construct experiment StatefulSet: %s", err.Error()))
-		return nil, err
+		r.updateStatusConditions(experimentCR, experimentcontrollercomv1alpha1.ReasonConstructionFailed, fmt.Sprintf("Failed to construct experiment StatefulSet: %s", err.Error()))
+		return nil, nil, err
+	}
+
+	// Render nothing for experiments violating a ClusterExperimentPolicy
+	allowed, err := r.enforceExperimentPolicies(ctx, experimentCR, sourceNamespace, sourceStatefulSet.Spec)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !allowed {
+		return nil, nil, nil // Requeue to re-evaluate the policies
+	}
+
+	// Wait for a slot within the concurrency limits
+	admitted, err := r.admitExperiment(ctx, experimentCR, sourceNamespace, sourceReplicas(sourceStatefulSet.Spec.Replicas))
+	if err != nil {
+		return nil, nil, err
+	}
+	if !admitted {
+		return nil, nil, nil // Requeue until a slot frees up
+	}
+
+	// Apply the service mode (Shared, Isolated, Shadow) to the experiment pods
+	if err := r.reconcileServiceIsolation(ctx, experimentCR, &desiredExperimentStatefulSet.Spec.Template, desiredExperimentStatefulSet.Spec.Selector); err != nil {
+		return nil, nil, err
+	}
+
+	// Keep the experiment pods out of the peer group of the source pods unless allowed to join
+	if err := r.reconcileStatefulSetIdentity(ctx, experimentCR, sourceStatefulSet, desiredExperimentStatefulSet); err != nil {
+		return nil, nil, err
+	}
+
+	// Restrict the network access of the experiment pods if requested
+	if err := r.reconcileNetworkIsolation(ctx, experimentCR, sourceNamespace, sourceStatefulSet.Spec.Template.Labels); err != nil {
+		return nil, nil, err
+	}
+
+	// Clone the experiment PVCs from VolumeSnapshots before the StatefulSet provisions empty ones
+	volumesReady, err := r.reconcileStatefulSetDataSource(ctx, experimentCR, sourceStatefulSet, desiredExperimentStatefulSet)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !volumesReady {
+		return nil, nil, nil // Requeue until the VolumeSnapshots are ready to use
 	}
 
 	// Create or Update experiment StatefulSet
-	return r.createOrUpdateStatefulSet(ctx, experimentCR, desiredExperimentStatefulSet)
+	upsertCtx, span := r.startSpan(ctx, spanUpsert, experimentCR, attributeWorkloadName.String(desiredExperimentStatefulSet.Name))
+	experimentStatefulSet, err := r.createOrUpdateStatefulSet(upsertCtx, experimentCR, desiredExperimentStatefulSet)
+	endSpan(span, err)
+	if err != nil || experimentStatefulSet == nil {
+		return experimentStatefulSet, sourceStatefulSet, err
+	}
+	return experimentStatefulSet, sourceStatefulSet, r.recordRevision(ctx, experimentCR, sourceStatefulSet.Generation)
 }
 
 // reconcileRolloutExperiment handles Argo Rollout-based experiments
-func (r *ExperimentDeploymentReconciler) reconcileRolloutExperiment(ctx context.Context, experimentCR *experimentcontrollercomv1alpha1.ExperimentDeployment, sourceNamespace string) (client.Object, error) {
+func (r *ExperimentDeploymentReconciler) reconcileRolloutExperiment(ctx context.Context, experimentCR *experimentcontrollercomv1alpha1.ExperimentDeployment, sourceNamespace string) (client.Object, client.Object, error) {
 	log := logf.FromContext(ctx)
 
 	// Fetch source Rollout
 	sourceRollout := &rolloutsv1alpha1.Rollout{}
-	err := r.Get(ctx, types.NamespacedName{Name: experimentCR.Spec.SourceRef.Name, Namespace: sourceNamespace}, sourceRollout)
+	fetchCtx, span := r.startSpan(ctx, spanFetchSource, experimentCR, attributeSourceName.String(experimentCR.Spec.SourceRef.Name))
+	err := r.sourceReader().Get(fetchCtx, types.NamespacedName{Name: experimentCR.Spec.SourceRef.Name, Namespace: sourceNamespace}, sourceRollout)
+	endSpan(span, err)
 	if err != nil {
 		if k8serrors.IsNotFound(err) {
 			log.Error(err, "Source Rollout not found", "sourceName", experimentCR.Spec.SourceRef.Name, "sourceNamespace", sourceNamespace)
 			r.Recorder.Eventf(experimentCR, corev1.EventTypeWarning, "SourceNotFound", "Source Rollout %s/%s not found", sourceNamespace, experimentCR.Spec.SourceRef.Name)
-			r.updateStatusConditions(experimentCR, "SourceNotFound", fmt.Sprintf("Source Rollout %s/%s not found", sourceNamespace, experimentCR.Spec.SourceRef.Name))
-			return nil, nil // Return nil to indicate requeue needed
+			r.updateStatusConditions(experimentCR, experimentcontrollercomv1alpha1.ReasonSourceNotFound, fmt.Sprintf("Source Rollout %s/%s not found", sourceNamespace, experimentCR.Spec.SourceRef.Name))
+			return nil, nil, nil // Return nil to indicate requeue needed
 		}
 		log.Error(err, "Failed to get source Rollout")
-		return nil, err
+		return nil, nil, err
 	}
 
+	// Inline the pod template of a Deployment referenced through spec.workloadRef
+	resolvedRollout, err := r.resolveRolloutWorkloadRef(ctx, sourceRollout)
+	if err != nil {
+		if k8serrors.IsNotFound(err) {
+			workloadRefName := sourceRollout.Spec.WorkloadRef.Name
+			log.Error(err, "Deployment referenced by source Rollout not found", "workloadRefName", workloadRefName, "sourceNamespace", sourceNamespace)
+			r.Recorder.Eventf(experimentCR, corev1.EventTypeWarning, "WorkloadRefNotFound", "Deployment %s/%s referenced by source Rollout not found", sourceNamespace, workloadRefName)
+			r.updateStatusConditions(experimentCR, experimentcontrollercomv1alpha1.ReasonWorkloadRefNotFound, fmt.Sprintf("Deployment %s/%s referenced by source Rollout not found", sourceNamespace, workloadRefName))
+			return nil, nil, nil // Return nil to indicate requeue needed
+		}
+		log.Error(err, "Failed to resolve workloadRef of source Rollout")
+		r.Recorder.Eventf(experimentCR, corev1.EventTypeWarning, "ConstructionFailed", "Failed to resolve workloadRef of source Rollout: %s", err.Error())
+		r.updateStatusConditions(experimentCR, experimentcontrollercomv1alpha1.ReasonConstructionFailed, fmt.Sprintf("Failed to resolve workloadRef of source Rollout: %s", err.Error()))
+		return nil, nil, err
+	}
+	sourceRollout = resolvedRollout
+
 	// Construct experiment Rollout
+	_, span = r.startSpan(ctx, spanRender, experimentCR)
 	desiredExperimentRollout, err := r.constructExperimentRollout(experimentCR, sourceRollout)
+	endSpan(span, err)
 	if err != nil {
 		log.Error(err, "Failed to construct desired experiment Rollout")
 		r.Recorder.Eventf(experimentCR, corev1.EventTypeWarning, "ConstructionFailed", "Failed to construct experiment Rollout: %s", err.Error())
-		r.updateStatusConditions(experimentCR, "ConstructionFailed", fmt.Sprintf("Failed to construct experiment Rollout: %s", err.Error()))
-		return nil, err
+		r.updateStatusConditions(experimentCR, experimentcontrollercomv1alpha1.ReasonConstructionFailed, fmt.Sprintf("Failed to construct experiment Rollout: %s", err.Error()))
+		return nil, nil, err
+	}
+
+	// Render nothing for experiments violating a ClusterExperimentPolicy
+	allowed, err := r.enforceExperimentPolicies(ctx, experimentCR, sourceNamespace, sourceRollout.Spec)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !allowed {
+		return nil, nil, nil // Requeue to re-evaluate the policies
+	}
+
+	// Wait for a slot within the concurrency limits
+	admitted, err := r.admitExperiment(ctx, experimentCR, sourceNamespace, sourceReplicas(sourceRollout.Spec.Replicas))
+	if err != nil {
+		return nil, nil, err
+	}
+	if !admitted {
+		return nil, nil, nil // Requeue until a slot frees up
+	}
+
+	// Apply the service mode (Shared, Isolated, Shadow) to the experiment pods
+	if err := r.reconcileServiceIsolation(ctx, experimentCR, &desiredExperimentRollout.Spec.Template, desiredExperimentRollout.Spec.Selector); err != nil {
+		return nil, nil, err
+	}
+
+	// Restrict the network access of the experiment pods if requested
+	if err := r.reconcileNetworkIsolation(ctx, experimentCR, sourceNamespace, sourceRollout.Spec.Template.Labels); err != nil {
+		return nil, nil, err
 	}
 
 	// Create or Update experiment Rollout
-	return r.createOrUpdateRollout(ctx, experimentCR, desiredExperimentRollout)
+	upsertCtx, span := r.startSpan(ctx, spanUpsert, experimentCR, attributeWorkloadName.String(desiredExperimentRollout.Name))
+	experimentRollout, err := r.createOrUpdateRollout(upsertCtx, experimentCR, desiredExperimentRollout)
+	endSpan(span, err)
+	if err != nil || experimentRollout == nil {
+		return experimentRollout, sourceRollout, err
+	}
+	return experimentRollout, sourceRollout, r.recordRevision(ctx, experimentCR, sourceRollout.Generation)
 }
 
 // Helper function to update status conditions
@@ -349,41 +668,25 @@ func (r *ExperimentDeploymentReconciler) deleteExperimentDeployment(ctx context.
 	return nil
 }
 
-// createOrUpdateDeployment creates or updates an experiment Deployment
+// createOrUpdateDeployment creates or updates an experiment Deployment with server-side apply
 func (r *ExperimentDeploymentReconciler) createOrUpdateDeployment(ctx context.Context, experimentCR *experimentcontrollercomv1alpha1.ExperimentDeployment, desired *appsv1.Deployment) (client.Object, error) {
 	log := logf.FromContext(ctx)
 
-	experimentToManage := &appsv1.Deployment{
-		ObjectMeta: metav1.ObjectMeta{
-			Name:      desired.Name,
-			Namespace: desired.Namespace,
-		},
-	}
-
-	opResult, err := controllerutil.CreateOrUpdate(ctx, r.Client, experimentToManage, func() error {
-		if err := controllerutil.SetControllerReference(experimentCR, experimentToManage, r.Scheme); err != nil {
-			return err
-		}
-		experimentToManage.Spec = desired.Spec
-		if experimentToManage.Labels == nil {
-			experimentToManage.Labels = make(map[string]string)
-		}
-		for k, v := range desired.Labels {
-			experimentToManage.Labels[k] = v
-		}
-		if experimentToManage.Annotations == nil {
-			experimentToManage.Annotations = make(map[string]string)
-		}
-		for k, v := range desired.Annotations {
-			experimentToManage.Annotations[k] = v
-		}
-		return nil
-	})
+	experimentToManage := &appsv1.Deployment{}
+	opResult, err := r.applyExperimentWorkload(ctx, experimentCR, desired, experimentToManage, "Deployment")
 
+	if isImmutableFieldError(err) {
+		return r.handleImmutableFieldConflict(ctx, experimentCR, desired, "Deployment", err, func(replacement client.Object) (client.Object, error) {
+			return r.createOrUpdateDeployment(ctx, experimentCR, replacement.(*appsv1.Deployment))
+		})
+	}
+	if errors.Is(err, errFieldConflict) {
+		return nil, nil
+	}
 	if err != nil {
 		log.Error(err, "Failed to create or update experiment Deployment", "name", desired.Name)
 		r.Recorder.Eventf(experimentCR, corev1.EventTypeWarning, "UpsertFailed", "Failed to create/update experiment Deployment %s: %s", desired.Name, err.Error())
-		r.updateStatusConditions(experimentCR, "UpsertFailed", fmt.Sprintf("Failed to create/update experiment Deployment %s: %s", desired.Name, err.Error()))
+		r.updateStatusConditions(experimentCR, experimentcontrollercomv1alpha1.ReasonUpsertFailed, fmt.Sprintf("Failed to create/update experiment Deployment %s: %s", desired.Name, err.Error()))
 		return nil, err
 	}
 
@@ -392,44 +695,32 @@ func (r *ExperimentDeploymentReconciler) createOrUpdateDeployment(ctx context.Co
 		r.Recorder.Eventf(experimentCR, corev1.EventTypeNormal, string(opResult), "Experiment Deployment %s %s", desired.Name, opResult)
 	}
 
+	if err := r.completeRecreation(ctx, experimentCR, experimentToManage, "Deployment"); err != nil {
+		return nil, err
+	}
+
 	return experimentToManage, nil
 }
 
-// createOrUpdateStatefulSet creates or updates an experiment StatefulSet
+// createOrUpdateStatefulSet creates or updates an experiment StatefulSet with server-side apply
 func (r *ExperimentDeploymentReconciler) createOrUpdateStatefulSet(ctx context.Context, experimentCR *experimentcontrollercomv1alpha1.ExperimentDeployment, desired *appsv1.StatefulSet) (client.Object, error) {
 	log := logf.FromContext(ctx)
 
-	experimentToManage := &appsv1.StatefulSet{
-		ObjectMeta: metav1.ObjectMeta{
-			Name:      desired.Name,
-			Namespace: desired.Namespace,
-		},
-	}
-
-	opResult, err := controllerutil.CreateOrUpdate(ctx, r.Client, experimentToManage, func() error {
-		if err := controllerutil.SetControllerReference(experimentCR, experimentToManage, r.Scheme); err != nil {
-			return err
-		}
-		experimentToManage.Spec = desired.Spec
-		if experimentToManage.Labels == nil {
-			experimentToManage.Labels = make(map[string]string)
-		}
-		for k, v := range desired.Labels {
-			experimentToManage.Labels[k] = v
-		}
-		if experimentToManage.Annotations == nil {
-			experimentToManage.Annotations = make(map[string]string)
-		}
-		for k, v := range desired.Annotations {
-			experimentToManage.Annotations[k] = v
-		}
-		return nil
-	})
+	experimentToManage := &appsv1.StatefulSet{}
+	opResult, err := r.applyExperimentWorkload(ctx, experimentCR, desired, experimentToManage, "StatefulSet")
 
+	if isImmutableFieldError(err) {
+		return r.handleImmutableFieldConflict(ctx, experimentCR, desired, "StatefulSet", err, func(replacement client.Object) (client.Object, error) {
+			return r.createOrUpdateStatefulSet(ctx, experimentCR, replacement.(*appsv1.StatefulSet))
+		})
+	}
+	if errors.Is(err, errFieldConflict) {
+		return nil, nil
+	}
 	if err != nil {
 		log.Error(err, "Failed to create or update experiment StatefulSet", "name", desired.Name)
 		r.Recorder.Eventf(experimentCR, corev1.EventTypeWarning, "UpsertFailed", "Failed to create/update experiment StatefulSet %s: %s", desired.Name, err.Error())
-		r.updateStatusConditions(experimentCR, "UpsertFailed", fmt.Sprintf("Failed to create/update experiment StatefulSet %s: %s", desired.Name, err.Error()))
+		r.updateStatusConditions(experimentCR, experimentcontrollercomv1alpha1.ReasonUpsertFailed, fmt.Sprintf("Failed to create/update experiment StatefulSet %s: %s", desired.Name, err.Error()))
 		return nil, err
 	}
 
@@ -438,44 +729,32 @@ func (r *ExperimentDeploymentReconciler) createOrUpdateStatefulSet(ctx context.C
 		r.Recorder.Eventf(experimentCR, corev1.EventTypeNormal, string(opResult), "Experiment StatefulSet %s %s", desired.Name, opResult)
 	}
 
+	if err := r.completeRecreation(ctx, experimentCR, experimentToManage, "StatefulSet"); err != nil {
+		return nil, err
+	}
+
 	return experimentToManage, nil
 }
 
-// createOrUpdateRollout creates or updates an experiment Rollout
+// createOrUpdateRollout creates or updates an experiment Rollout with server-side apply
 func (r *ExperimentDeploymentReconciler) createOrUpdateRollout(ctx context.Context, experimentCR *experimentcontrollercomv1alpha1.ExperimentDeployment, desired *rolloutsv1alpha1.Rollout) (client.Object, error) {
 	log := logf.FromContext(ctx)
 
-	experimentToManage := &rolloutsv1alpha1.Rollout{
-		ObjectMeta: metav1.ObjectMeta{
-			Name:      desired.Name,
-			Namespace: desired.Namespace,
-		},
-	}
-
-	opResult, err := controllerutil.CreateOrUpdate(ctx, r.Client, experimentToManage, func() error {
-		if err := controllerutil.SetControllerReference(experimentCR, experimentToManage, r.Scheme); err != nil {
-			return err
-		}
-		experimentToManage.Spec = desired.Spec
-		if experimentToManage.Labels == nil {
-			experimentToManage.Labels = make(map[string]string)
-		}
-		for k, v := range desired.Labels {
-			experimentToManage.Labels[k] = v
-		}
-		if experimentToManage.Annotations == nil {
-			experimentToManage.Annotations = make(map[string]string)
-		}
-		for k, v := range desired.Annotations {
-			experimentToManage.Annotations[k] = v
-		}
-		return nil
-	})
+	experimentToManage := &rolloutsv1alpha1.Rollout{}
+	opResult, err := r.applyExperimentWorkload(ctx, experimentCR, desired, experimentToManage, "Rollout")
 
+	if isImmutableFieldError(err) {
+		return r.handleImmutableFieldConflict(ctx, experimentCR, desired, "Rollout", err, func(replacement client.Object) (client.Object, error) {
+			return r.createOrUpdateRollout(ctx, experimentCR, replacement.(*rolloutsv1alpha1.Rollout))
+		})
+	}
+	if errors.Is(err, errFieldConflict) {
+		return nil, nil
+	}
 	if err != nil {
 		log.Error(err, "Failed to create or update experiment Rollout", "name", desired.Name)
 		r.Recorder.Eventf(experimentCR, corev1.EventTypeWarning, "UpsertFailed", "Failed to create/update experiment Rollout %s: %s", desired.Name, err.Error())
-		r.updateStatusConditions(experimentCR, "UpsertFailed", fmt.Sprintf("Failed to create/update experiment Rollout %s: %s", desired.Name, err.Error()))
+		r.updateStatusConditions(experimentCR, experimentcontrollercomv1alpha1.ReasonUpsertFailed, fmt.Sprintf("Failed to create/update experiment Rollout %s: %s", desired.Name, err.Error()))
 		return nil, err
 	}
 
@@ -484,6 +763,10 @@ func (r *ExperimentDeploymentReconciler) createOrUpdateRollout(ctx context.Conte
 		r.Recorder.Eventf(experimentCR, corev1.EventTypeNormal, string(opResult), "Experiment Rollout %s %s", desired.Name, opResult)
 	}
 
+	if err := r.completeRecreation(ctx, experimentCR, experimentToManage, "Rollout"); err != nil {
+		return nil, err
+	}
+
 	return experimentToManage, nil
 }
 
@@ -493,7 +776,7 @@ func (r *ExperimentDeploymentReconciler) constructExperimentDeployment(
 
 	log := logf.FromContext(context.Background()) // Use a background context for logging in helpers
 
-	experimentDeploymentName := experimentCR.Name
+	experimentDeploymentName := experimentWorkloadName(experimentCR, "Deployment")
 	experimentDeploymentNamespace := experimentCR.Namespace // Create in CR's namespace
 
 	// Deep copy the source spec
@@ -534,15 +817,21 @@ func (r *ExperimentDeploymentReconciler) constructExperimentDeployment(
 		return nil, fmt.Errorf("failed to unmarshal merged spec map to DeploymentSpec: %w", err)
 	}
 
-	// Apply replicas from CR spec (takes precedence), otherwise default to 1
+	// Apply replicas from CR spec (takes precedence), otherwise use the configured default
 	if experimentCR.Spec.Replicas != nil {
 		finalExperimentSpec.Replicas = experimentCR.Spec.Replicas
 	} else {
-		// Default to 1 replica for experiments if not specified in CR
-		defaultReplicas := int32(1)
+		// Default replicas for experiments if not specified in CR
+		defaultReplicas := r.config().Defaults.Replicas
 		finalExperimentSpec.Replicas = &defaultReplicas
 	}
 
+	// Aborted experiments are kept scaled down
+	if isExperimentAborted(experimentCR) {
+		abortedReplicas := int32(0)
+		finalExperimentSpec.Replicas = &abortedReplicas
+	}
+
 	// Labels for the experiment deployment's pods
 	podLabels := make(map[string]string)
 	// Copy labels from source pod template to ensure service discovery
@@ -552,9 +841,9 @@ func (r *ExperimentDeploymentReconciler) constructExperimentDeployment(
 		}
 	}
 	// Add experiment-specific labels
-	podLabels["experiment-controller.example.com/cr-name"] = experimentCR.Name
-	podLabels["experiment-controller.example.com/role"] = ExperimentRoleValue
-	podLabels["experiment-controller.example.com/source-deployment-name"] = sourceDeployment.Name
+	podLabels[r.labelKey(labelCRName)] = experimentCR.Name
+	podLabels[r.labelKey(labelRole)] = ExperimentRoleValue
+	podLabels[r.labelKey("source-deployment-name")] = sourceDeployment.Name
 	// Ensure these labels are on the pod template
 	finalExperimentSpec.Template.ObjectMeta.Labels = podLabels
 
@@ -580,8 +869,8 @@ func (r *ExperimentDeploymentReconciler) constructExperimentDeployment(
 			Name:      experimentDeploymentName,
 			Namespace: experimentDeploymentNamespace,
 			Labels: map[string]string{ // Labels for the Deployment object itself
-				"experiment-controller.example.com/managed-by": "experiment-controller",
-				"experiment-controller.example.com/cr-name":    experimentCR.Name,
+				r.labelKey(labelManagedBy): ManagedByValue,
+				r.labelKey(labelCRName):    experimentCR.Name,
 			},
 			Annotations: make(map[string]string), // Add annotations if needed
 		},
@@ -602,7 +891,7 @@ func (r *ExperimentDeploymentReconciler) constructExperimentStatefulSet(
 
 	log := logf.FromContext(context.Background())
 
-	experimentStatefulSetName := experimentCR.Name
+	experimentStatefulSetName := experimentWorkloadName(experimentCR, "StatefulSet")
 	experimentStatefulSetNamespace := experimentCR.Namespace
 
 	// Deep copy the source spec
@@ -645,10 +934,16 @@ func (r *ExperimentDeploymentReconciler) constructExperimentStatefulSet(
 	if experimentCR.Spec.Replicas != nil {
 		finalExperimentSpec.Replicas = experimentCR.Spec.Replicas
 	} else if finalExperimentSpec.Replicas == nil {
-		defaultReplicas := int32(1)
+		defaultReplicas := r.config().Defaults.Replicas
 		finalExperimentSpec.Replicas = &defaultReplicas
 	}
 
+	// Aborted experiments are kept scaled down
+	if isExperimentAborted(experimentCR) {
+		abortedReplicas := int32(0)
+		finalExperimentSpec.Replicas = &abortedReplicas
+	}
+
 	// Labels for the experiment statefulset's pods
 	podLabels := make(map[string]string)
 	if sourceStatefulSet.Spec.Template.Labels != nil {
@@ -657,9 +952,9 @@ func (r *ExperimentDeploymentReconciler) constructExperimentStatefulSet(
 		}
 	}
 	// Add experiment-specific labels
-	podLabels["experiment-controller.example.com/cr-name"] = experimentCR.Name
-	podLabels["experiment-controller.example.com/role"] = "experiment"
-	podLabels["experiment-controller.example.com/source-statefulset-name"] = sourceStatefulSet.Name
+	podLabels[r.labelKey(labelCRName)] = experimentCR.Name
+	podLabels[r.labelKey(labelRole)] = "experiment"
+	podLabels[r.labelKey("source-statefulset-name")] = sourceStatefulSet.Name
 	finalExperimentSpec.Template.ObjectMeta.Labels = podLabels
 
 	// The StatefulSet's selector must match its pod template labels
@@ -682,8 +977,8 @@ func (r *ExperimentDeploymentReconciler) constructExperimentStatefulSet(
 			Name:      experimentStatefulSetName,
 			Namespace: experimentStatefulSetNamespace,
 			Labels: map[string]string{
-				"experiment-controller.example.com/managed-by": "experiment-controller",
-				"experiment-controller.example.com/cr-name":    experimentCR.Name,
+				r.labelKey(labelManagedBy): ManagedByValue,
+				r.labelKey(labelCRName):    experimentCR.Name,
 			},
 			Annotations: make(map[string]string),
 		},
@@ -700,12 +995,16 @@ func (r *ExperimentDeploymentReconciler) constructExperimentRollout(
 
 	log := logf.FromContext(context.Background())
 
-	experimentRolloutName := experimentCR.Name
+	experimentRolloutName := experimentWorkloadName(experimentCR, "Rollout")
 	experimentRolloutNamespace := experimentCR.Namespace
 
 	// Deep copy the source spec
 	experimentSpec := *sourceRollout.Spec.DeepCopy()
 
+	// Strip the strategy parts shared with the source Rollout before applying the overrides,
+	// so strategy fields set in overrideSpec are always honored
+	experimentSpec.Strategy = sanitizeRolloutStrategy(experimentSpec.Strategy, keptRolloutStrategyFields(experimentCR))
+
 	// Convert source spec to map for merging
 	sourceSpecMap := make(map[string]interface{})
 	sourceSpecJSON, err := json.Marshal(experimentSpec)
@@ -743,10 +1042,16 @@ func (r *ExperimentDeploymentReconciler) constructExperimentRollout(
 	if experimentCR.Spec.Replicas != nil {
 		finalExperimentSpec.Replicas = experimentCR.Spec.Replicas
 	} else if finalExperimentSpec.Replicas == nil {
-		defaultReplicas := int32(1)
+		defaultReplicas := r.config().Defaults.Replicas
 		finalExperimentSpec.Replicas = &defaultReplicas
 	}
 
+	// Aborted experiments are kept scaled down
+	if isExperimentAborted(experimentCR) {
+		abortedReplicas := int32(0)
+		finalExperimentSpec.Replicas = &abortedReplicas
+	}
+
 	// Labels for the experiment rollout's pods
 	podLabels := make(map[string]string)
 	if sourceRollout.Spec.Template.Labels != nil {
@@ -755,11 +1060,14 @@ func (r *ExperimentDeploymentReconciler) constructExperimentRollout(
 		}
 	}
 	// Add experiment-specific labels
-	podLabels["experiment-controller.example.com/cr-name"] = experimentCR.Name
-	podLabels["experiment-controller.example.com/role"] = "experiment"
-	podLabels["experiment-controller.example.com/source-rollout-name"] = sourceRollout.Name
+	podLabels[r.labelKey(labelCRName)] = experimentCR.Name
+	podLabels[r.labelKey(labelRole)] = "experiment"
+	podLabels[r.labelKey("source-rollout-name")] = sourceRollout.Name
 	finalExperimentSpec.Template.ObjectMeta.Labels = podLabels
 
+	// Experiment Rollouts always embed their pod template, use resolveRolloutWorkloadRef for workloadRef sources
+	finalExperimentSpec.WorkloadRef = nil
+
 	// The Rollout's selector must match its pod template labels
 	finalExperimentSpec.Selector = &metav1.LabelSelector{
 		MatchLabels: podLabels,
@@ -774,8 +1082,10 @@ func (r *ExperimentDeploymentReconciler) constructExperimentRollout(
 	}
 	finalExperimentSpec.Template.ObjectMeta.Annotations = podAnnotations
 
-	// For Rollouts, we may want to simplify the strategy for experiments
-	// Here we keep the original strategy but could override it in the overrideSpec
+	// A blue-green strategy from overrideSpec replaces the sanitized canary strategy
+	if finalExperimentSpec.Strategy.BlueGreen != nil {
+		finalExperimentSpec.Strategy.Canary = nil
+	}
 
 	// Construct the experiment Rollout object
 	desiredExperimentRollout := &rolloutsv1alpha1.Rollout{
@@ -783,8 +1093,8 @@ func (r *ExperimentDeploymentReconciler) constructExperimentRollout(
 			Name:      experimentRolloutName,
 			Namespace: experimentRolloutNamespace,
 			Labels: map[string]string{
-				"experiment-controller.example.com/managed-by": "experiment-controller",
-				"experiment-controller.example.com/cr-name":    experimentCR.Name,
+				r.labelKey(labelManagedBy): ManagedByValue,
+				r.labelKey(labelCRName):    experimentCR.Name,
 			},
 			Annotations: make(map[string]string),
 		},
@@ -798,10 +1108,18 @@ func (r *ExperimentDeploymentReconciler) constructExperimentRollout(
 func (r *ExperimentDeploymentReconciler) updateExperimentWorkloadStatus(
 	ctx context.Context,
 	experimentCR *experimentcontrollercomv1alpha1.ExperimentDeployment,
-	experimentWorkload client.Object) (ctrl.Result, error) {
+	experimentWorkload, sourceWorkload client.Object) (ctrl.Result, error) {
 
 	log := logf.FromContext(ctx)
 
+	// Summarize the experiment pods to diagnose experiments that do not become ready
+	podHealth, err := r.summarizePodHealth(ctx, experimentCR, sourceWorkload)
+	if err != nil {
+		log.Error(err, "Failed to summarize the health of the experiment pods")
+		return ctrl.Result{}, err
+	}
+	experimentCR.Status.PodHealth = podHealth
+
 	// Update status based on workload type
 	switch workload := experimentWorkload.(type) {
 	case *appsv1.Deployment:
@@ -852,6 +1170,7 @@ func (r *ExperimentDeploymentReconciler) updateDeploymentStatus(
 			r.setReadyStatus(experimentCR, "Experiment Deployment is Ready")
 		} else {
 			reason, message := r.getDeploymentNotReadyStatus(currentExpDeployment)
+			reason, message = podHealthNotReadyStatus(experimentCR.Status.PodHealth, reason, message)
 			r.setNotReadyStatus(experimentCR, reason, message)
 		}
 	}
@@ -893,7 +1212,9 @@ func (r *ExperimentDeploymentReconciler) updateStatefulSetStatus(
 		if isReady {
 			r.setReadyStatus(experimentCR, "Experiment StatefulSet is Ready")
 		} else {
-			r.setNotReadyStatus(experimentCR, "NotReady", "Experiment StatefulSet is not yet ready")
+			reason, message := podHealthNotReadyStatus(experimentCR.Status.PodHealth,
+				experimentcontrollercomv1alpha1.ReasonNotReady, "Experiment StatefulSet is not yet ready")
+			r.setNotReadyStatus(experimentCR, reason, message)
 		}
 	}
 
@@ -933,7 +1254,9 @@ func (r *ExperimentDeploymentReconciler) updateRolloutStatus(
 		if isReady {
 			r.setReadyStatus(experimentCR, "Experiment Rollout is Ready")
 		} else {
-			r.setNotReadyStatus(experimentCR, "NotReady", "Experiment Rollout is not yet ready")
+			reason, message := podHealthNotReadyStatus(experimentCR.Status.PodHealth,
+				experimentcontrollercomv1alpha1.ReasonNotReady, "Experiment Rollout is not yet ready")
+			r.setNotReadyStatus(experimentCR, reason, message)
 		}
 	}
 
@@ -945,13 +1268,13 @@ func (r *ExperimentDeploymentReconciler) setNotFoundStatus(experimentCR *experim
 	meta.SetStatusCondition(&experimentCR.Status.Conditions, metav1.Condition{
 		Type:    ConditionTypeReady,
 		Status:  metav1.ConditionFalse,
-		Reason:  "ExperimentWorkloadNotFound",
+		Reason:  experimentcontrollercomv1alpha1.ReasonExperimentWorkloadNotFound,
 		Message: fmt.Sprintf("Experiment %s %s/%s not found", kind, namespace, name),
 	})
 	meta.SetStatusCondition(&experimentCR.Status.Conditions, metav1.Condition{
 		Type:    ConditionTypeSynced,
 		Status:  metav1.ConditionFalse,
-		Reason:  "ExperimentWorkloadNotFound",
+		Reason:  experimentcontrollercomv1alpha1.ReasonExperimentWorkloadNotFound,
 		Message: fmt.Sprintf("Experiment %s %s/%s not found", kind, namespace, name),
 	})
 	experimentCR.Status.ExperimentResourceRef = nil
@@ -980,6 +1303,16 @@ func (r *ExperimentDeploymentReconciler) setNotReadyStatus(experimentCR *experim
 		Reason:  reason,
 		Message: message,
 	})
+	// A workload failing to roll out is rendered, but out of sync with the spec
+	if degradedReasons.Has(reason) {
+		meta.SetStatusCondition(&experimentCR.Status.Conditions, metav1.Condition{
+			Type:    ConditionTypeSynced,
+			Status:  metav1.ConditionFalse,
+			Reason:  reason,
+			Message: message,
+		})
+		return
+	}
 	meta.SetStatusCondition(&experimentCR.Status.Conditions, metav1.Condition{
 		Type:    ConditionTypeSynced,
 		Status:  metav1.ConditionTrue,
@@ -988,32 +1321,64 @@ func (r *ExperimentDeploymentReconciler) setNotReadyStatus(experimentCR *experim
 	})
 }
 
+func (r *ExperimentDeploymentReconciler) setAbortedStatus(experimentCR *experimentcontrollercomv1alpha1.ExperimentDeployment) {
+	reason := experimentcontrollercomv1alpha1.ReasonAnalysisFailed
+	message := fmt.Sprintf("Experiment aborted, analysis %s", experimentCR.Status.AnalysisPhase)
+	syncedMessage := "Experiment workload is Synced and scaled down"
+	if abort := experimentCR.Status.Abort; abort != nil {
+		reason = experimentcontrollercomv1alpha1.ReasonAborted
+		message = fmt.Sprintf("Experiment aborted, %s: %s", abort.Trigger, abort.Message)
+		if abort.Action == experimentcontrollercomv1alpha1.AbortActionDelete {
+			syncedMessage = "Experiment workload is deleted"
+		}
+	}
+	meta.SetStatusCondition(&experimentCR.Status.Conditions, metav1.Condition{
+		Type:    ConditionTypeReady,
+		Status:  metav1.ConditionFalse,
+		Reason:  reason,
+		Message: message,
+	})
+	meta.SetStatusCondition(&experimentCR.Status.Conditions, metav1.Condition{
+		Type:    ConditionTypeSynced,
+		Status:  metav1.ConditionTrue,
+		Reason:  ReasonReconcileSuccess,
+		Message: syncedMessage,
+	})
+}
+
 func (r *ExperimentDeploymentReconciler) getDeploymentNotReadyStatus(deployment *appsv1.Deployment) (string, string) {
 	progressingCondition := getDeploymentCondition(deployment.Status, appsv1.DeploymentProgressing)
-	reason := "NotReady"
+	reason := experimentcontrollercomv1alpha1.ReasonNotReady
 	message := "Experiment Deployment is not yet ready."
 	if progressingCondition != nil && progressingCondition.Status == corev1.ConditionFalse {
 		reason = progressingCondition.Reason
 		message = progressingCondition.Message
 	} else if progressingCondition != nil && progressingCondition.Status == corev1.ConditionTrue && progressingCondition.Reason == "NewReplicaSetAvailable" {
-		reason = "Progressing"
+		reason = experimentcontrollercomv1alpha1.ReasonProgressing
 		message = "Experiment Deployment is progressing."
 	}
 	return reason, message
 }
 
-func (r *ExperimentDeploymentReconciler) finalizeStatusUpdate(ctx context.Context, experimentCR *experimentcontrollercomv1alpha1.ExperimentDeployment) (ctrl.Result, error) {
+func (r *ExperimentDeploymentReconciler) finalizeStatusUpdate(ctx context.Context, experimentCR *experimentcontrollercomv1alpha1.ExperimentDeployment) (result ctrl.Result, err error) {
+	ctx, span := r.startSpan(ctx, spanUpdateStatus, experimentCR)
+	defer func() { endSpan(span, err) }()
 	log := logf.FromContext(ctx)
 
 	experimentCR.Status.ObservedGeneration = experimentCR.Generation
+	r.setLifecycleStatus(experimentCR)
+	setRevisionOutcome(experimentCR)
+	if !r.notifyTransition(ctx, experimentCR) {
+		r.retryNotifications(ctx, experimentCR)
+	}
 
-	if err := r.Status().Update(ctx, experimentCR); err != nil {
+	if err := r.updateStatus(ctx, experimentCR); err != nil {
 		if k8serrors.IsNotFound(err) {
 			log.Info("ExperimentDeployment not found during status update, possibly already deleted", "name", experimentCR.Name)
 			// Still return appropriate result based on current status
 			readyCond := meta.FindStatusCondition(experimentCR.Status.Conditions, ConditionTypeReady)
 			if readyCond == nil || readyCond.Status == metav1.ConditionFalse {
-				return ctrl.Result{RequeueAfter: 15 * time.Second}, nil
+				return ctrl.Result{RequeueAfter: r.config().Requeue.NotReadyInterval.Duration}, nil
 			}
 			return ctrl.Result{}, nil
 		}
@@ -1025,10 +1390,10 @@ func (r *ExperimentDeploymentReconciler) finalizeStatusUpdate(ctx context.Contex
 	readyCond := meta.FindStatusCondition(experimentCR.Status.Conditions, ConditionTypeReady)
 	if readyCond == nil || readyCond.Status == metav1.ConditionFalse {
 		log.Info("ExperimentDeployment not ready, requeueing for status check.", "name", experimentCR.Name)
-		return ctrl.Result{RequeueAfter: 15 * time.Second}, nil
+		return r.requeueNotifications(ctrl.Result{RequeueAfter: r.config().Requeue.NotReadyInterval.Duration}, experimentCR), nil
 	}
 
-	return ctrl.Result{}, nil
+	return r.requeueNotifications(ctrl.Result{}, experimentCR), nil
 }
 
 // getDeploymentCondition returns the condition with the provided type.
@@ -1078,6 +1443,12 @@ func (r *ExperimentDeploymentReconciler) validateExperimentDeployment(experiment
 		return fmt.Errorf("overrideSpec is not valid JSON: %v", err)
 	}
 
+	// NetworkPolicies are additive: in Shared mode the policies of the source pods also select the
+	// experiment pods, and their egress rules would allow what the experiment restricts
+	if restrictsEgress(experimentCR.Spec.NetworkIsolation) && effectiveServiceMode(experimentCR) == experimentcontrollercomv1alpha1.ServiceModeShared {
+		return fmt.Errorf("networkIsolation egress restrictions require serviceMode Isolated or Shadow, the NetworkPolicies of the source pods also select Shared experiment pods")
+	}
+
 	return nil
 }
 
@@ -1087,17 +1458,70 @@ func (r *ExperimentDeploymentReconciler) SetupWithManager(mgr ctrl.Manager) erro
 		r.Recorder = mgr.GetEventRecorderFor("experimentdeployment-controller")
 	}
 
+	// The concurrency and rate limits are read once, reloads of the config do not change them
+	cfg := r.config()
 	builder := ctrl.NewControllerManagedBy(mgr).
 		For(&experimentcontrollercomv1alpha1.ExperimentDeployment{}).
-		Owns(&appsv1.Deployment{}).  // Watch Deployments created by this controller
-		Owns(&appsv1.StatefulSet{}). // Watch StatefulSets created by this controller
+		Owns(&appsv1.Deployment{}).          // Watch Deployments created by this controller
+		Owns(&appsv1.StatefulSet{}).         // Watch StatefulSets created by this controller
+		Owns(&corev1.Service{}).             // Watch experiment Services created by this controller
+		Owns(&networkingv1.NetworkPolicy{}). // Watch experiment NetworkPolicies created by this controller
+		Watches(&experimentcontrollercomv1alpha1.ClusterExperimentPolicy{},
+			handler.EnqueueRequestsFromMapFunc(r.experimentsForPolicy)). // Re-evaluate experiments when policies change
+		Watches(&experimentcontrollercomv1alpha1.ExperimentReferenceGrant{},
+			handler.EnqueueRequestsFromMapFunc(r.experimentsForReferenceGrant)). // Re-evaluate cross-namespace experiments when grants change
+		Watches(&experimentcontrollercomv1alpha1.ExperimentDeployment{},
+			handler.EnqueueRequestsFromMapFunc(r.queuedExperimentsFor)). // Start queued experiments when a slot frees up
+		WithOptions(controller.Options{
+			MaxConcurrentReconciles: cfg.MaxConcurrentReconciles,
+			RateLimiter:             cfg.NewRateLimiter(),
+		}).
 		Named("experimentdeployment")
 
-	// Only add Rollout watching if Rollouts are available in the cluster
+	// Start and stop handling the experiments of namespaces as they gain or lose the selector labels
 	setupLog := ctrl.Log.WithName("setup")
-	if r.isRolloutAvailable(mgr) {
+	if r.NamespaceSelector != nil {
+		setupLog.Info("Handling the experiments of the namespaces matching the selector", "selector", r.NamespaceSelector.String())
+		builder = builder.Watches(&corev1.Namespace{}, r.namespaceEventHandler())
+	}
+
+	// Drop the events of the shards of other replicas, and enqueue the experiments of the shards
+	// acquired from them
+	if r.Sharding.enabled() {
+		setupLog.Info("Handling the experiments of the owned shards", "shardCount", r.Sharding.Count)
+		builder = builder.WithEventFilter(r.shardPredicate())
+		if owner, ok := r.Sharding.Owner.(*sharding.LeaseOwner); ok {
+			r.shardEvents = make(chan event.GenericEvent)
+			owner.OnAcquired = r.shardsAcquired
+			builder = builder.WatchesRawSource(source.Channel(r.shardEvents, &handler.EnqueueRequestForObject{}))
+		}
+	}
+
+	// Publish CloudEvents without holding up reconciles
+	r.publisher = r.newCloudEventsPublisher()
+	if err := mgr.Add(r.publisher); err != nil {
+		return err
+	}
+
+	// Reconcile the experiments cloning a source workload when it changes
+	rolloutsAvailable := r.isRolloutAvailable(mgr)
+	if r.SourceCache != nil {
+		if err := mgr.GetFieldIndexer().IndexField(context.Background(), &experimentcontrollercomv1alpha1.ExperimentDeployment{},
+			sourceRefIndex, indexSourceRef); err != nil {
+			return err
+		}
+		for kind, gv := range r.sourceKinds(rolloutsAvailable) {
+			builder = builder.WatchesRawSource(r.sourceWatch(kind, gv))
+		}
+	}
+
+	// Only add Rollout watching if Rollouts are available in the cluster
+	if rolloutsAvailable {
 		setupLog.Info("Argo Rollouts detected in cluster, enabling Rollout support")
 		builder = builder.Owns(&rolloutsv1alpha1.Rollout{}) // Watch Rollouts created by this controller
+		if r.isAnalysisRunCRDAvailable(mgr) {
+			builder = builder.Owns(&rolloutsv1alpha1.AnalysisRun{}) // Watch AnalysisRuns created for analysisTemplates
+		}
 	} else {
 		setupLog.Info("Argo Rollouts not available in cluster, Rollout support disabled")
 	}
@@ -1129,6 +1553,17 @@ func (r *ExperimentDeploymentReconciler) isRolloutCRDAvailable(mgr ctrl.Manager)
 	return true
 }
 
+// isAnalysisRunCRDAvailable checks if the AnalysisRun CRD exists in the cluster
+func (r *ExperimentDeploymentReconciler) isAnalysisRunCRDAvailable(mgr ctrl.Manager) bool {
+	_, err := mgr.GetRESTMapper().RESTMapping(analysisRunGVK.GroupKind(), analysisRunGVK.Version)
+	if err != nil {
+		setupLog := ctrl.Log.WithName("setup")
+		setupLog.Info("AnalysisRun CRD not found in cluster", "error", err)
+		return false
+	}
+	return true
+}
+
 // isRolloutSupported checks if Rollouts are supported at runtime
 func (r *ExperimentDeploymentReconciler) isRolloutSupported() bool {
 	// Check if the scheme has the Rollout type registered
@@ -19,6 +19,7 @@ import (
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
 
 	experimentcontrollercomv1alpha1 "experimentcontroller.example.com/experiment-deployment/api/v1alpha1"
 )
@@ -46,7 +47,7 @@ var _ = Describe("ExperimentDeployment Controller", func() {
 		Expect(corev1.AddToScheme(scheme)).To(Succeed())
 
 		recorder = record.NewFakeRecorder(100)
-		fakeClient = fake.NewClientBuilder().WithScheme(scheme).WithStatusSubresource(&experimentcontrollercomv1alpha1.ExperimentDeployment{}).Build()
+		fakeClient = fake.NewClientBuilder().WithScheme(scheme).WithInterceptorFuncs(withServerSideApply(interceptor.Funcs{})).WithStatusSubresource(&experimentcontrollercomv1alpha1.ExperimentDeployment{}).Build()
 		reconciler = &ExperimentDeploymentReconciler{
 			Client:   fakeClient,
 			Scheme:   scheme,
@@ -519,7 +520,19 @@ var _ = Describe("ExperimentDeployment Controller", func() {
 			}
 			experimentCR.Finalizers = []string{experimentDeploymentFinalizer}
 
+			grant := &experimentcontrollercomv1alpha1.ExperimentReferenceGrant{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "allow-experiments",
+					Namespace: sourceNamespace,
+				},
+				Spec: experimentcontrollercomv1alpha1.ExperimentReferenceGrantSpec{
+					From: []experimentcontrollercomv1alpha1.ReferenceGrantFrom{{Namespace: experimentNamespace}},
+					To:   []experimentcontrollercomv1alpha1.ReferenceGrantTo{{Kind: experimentcontrollercomv1alpha1.SourceKindDeployment}},
+				},
+			}
+
 			Expect(fakeClient.Create(ctx, sourceDeployment)).To(Succeed())
+			Expect(fakeClient.Create(ctx, grant)).To(Succeed())
 			Expect(fakeClient.Create(ctx, experimentCR)).To(Succeed())
 
 			namespacedName := types.NamespacedName{Name: testExperimentCRName, Namespace: experimentNamespace}
@@ -16,6 +16,7 @@ import (
 	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
 
 	experimentcontrollercomv1alpha1 "experimentcontroller.example.com/experiment-deployment/api/v1alpha1"
 )
@@ -38,7 +39,7 @@ var _ = Describe("Helper Functions", func() {
 		Expect(corev1.AddToScheme(scheme)).To(Succeed())
 		Expect(rolloutsv1alpha1.AddToScheme(scheme)).To(Succeed())
 
-		fakeClient := fake.NewClientBuilder().WithScheme(scheme).Build()
+		fakeClient := fake.NewClientBuilder().WithScheme(scheme).WithInterceptorFuncs(withServerSideApply(interceptor.Funcs{})).Build()
 		reconciler = &ExperimentDeploymentReconciler{
 			Client:   fakeClient,
 			Scheme:   scheme,
@@ -350,7 +351,7 @@ var _ = Describe("Helper Functions", func() {
 			experimentCR := &experimentcontrollercomv1alpha1.ExperimentDeployment{}
 			unsupportedWorkload := &corev1.Pod{}
 
-			result, err := reconciler.updateExperimentWorkloadStatus(ctx, experimentCR, unsupportedWorkload)
+			result, err := reconciler.updateExperimentWorkloadStatus(ctx, experimentCR, unsupportedWorkload, nil)
 			Expect(err).To(HaveOccurred())
 			Expect(err.Error()).To(ContainSubstring("unsupported workload type"))
 			Expect(result).To(Equal(ctrl.Result{}))
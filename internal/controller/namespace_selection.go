@@ -0,0 +1,155 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	experimentcontrollercomv1alpha1 "experimentcontroller.example.com/experiment-deployment/api/v1alpha1"
+)
+
+// activeNamespaceGauge reports the namespaces whose experiments the controller handles when it
+// selects namespaces by label
+var activeNamespaceGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Name: "experiment_controller_active_namespace",
+	Help: "Namespaces selected by --namespace-selector whose experiments the controller handles, 1 while selected",
+}, []string{"namespace"})
+
+func init() {
+	metrics.Registry.MustRegister(activeNamespaceGauge)
+}
+
+// activeNamespaces tracks the namespaces selected by the namespace selector
+type activeNamespaces struct {
+	mu    sync.Mutex
+	names map[string]bool
+}
+
+// set records whether a namespace is selected and reports whether that changed
+func (a *activeNamespaces) set(namespace string, selected bool) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.names == nil {
+		a.names = map[string]bool{}
+	}
+	if a.names[namespace] == selected {
+		return false
+	}
+	if selected {
+		a.names[namespace] = true
+		activeNamespaceGauge.WithLabelValues(namespace).Set(1)
+	} else {
+		delete(a.names, namespace)
+		activeNamespaceGauge.DeleteLabelValues(namespace)
+	}
+	return true
+}
+
+// list returns the selected namespaces, sorted
+func (a *activeNamespaces) list() []string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	names := make([]string, 0, len(a.names))
+	for name := range a.names {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// ActiveNamespaces returns the namespaces whose experiments are handled, nil without a namespace selector
+func (r *ExperimentDeploymentReconciler) ActiveNamespaces() []string {
+	if r.NamespaceSelector == nil {
+		return nil
+	}
+	return r.activeNamespaces.list()
+}
+
+// namespaceSelected reports whether the experiments of a namespace are handled
+func (r *ExperimentDeploymentReconciler) namespaceSelected(ctx context.Context, namespace string) (bool, error) {
+	if r.NamespaceSelector == nil {
+		return true, nil
+	}
+	ns := &corev1.Namespace{}
+	if err := r.Get(ctx, types.NamespacedName{Name: namespace}, ns); err != nil {
+		if k8serrors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to get namespace %s: %w", namespace, err)
+	}
+	return r.NamespaceSelector.Matches(labels.Set(ns.Labels)), nil
+}
+
+// namespaceEventHandler tracks the namespaces gaining and losing the selector labels, and
+// enqueues the experiments of the namespaces that become selected
+func (r *ExperimentDeploymentReconciler) namespaceEventHandler() handler.EventHandler {
+	return handler.Funcs{
+		CreateFunc: func(ctx context.Context, e event.CreateEvent, q workqueue.TypedRateLimitingInterface[reconcile.Request]) {
+			r.namespaceChanged(ctx, e.Object, true, q)
+		},
+		UpdateFunc: func(ctx context.Context, e event.UpdateEvent, q workqueue.TypedRateLimitingInterface[reconcile.Request]) {
+			r.namespaceChanged(ctx, e.ObjectNew, true, q)
+		},
+		DeleteFunc: func(ctx context.Context, e event.DeleteEvent, q workqueue.TypedRateLimitingInterface[reconcile.Request]) {
+			r.namespaceChanged(ctx, e.Object, false, q)
+		},
+	}
+}
+
+// namespaceChanged updates the active namespaces after an event of a namespace
+func (r *ExperimentDeploymentReconciler) namespaceChanged(
+	ctx context.Context,
+	ns client.Object,
+	exists bool,
+	q workqueue.TypedRateLimitingInterface[reconcile.Request]) {
+
+	log := logf.FromContext(ctx)
+	selected := exists && r.NamespaceSelector.Matches(labels.Set(ns.GetLabels()))
+	if !r.activeNamespaces.set(ns.GetName(), selected) {
+		return
+	}
+	if !selected {
+		log.Info("Namespace deselected, no longer handling its experiments", "namespace", ns.GetName(), "activeNamespaces", r.activeNamespaces.list())
+		return
+	}
+	log.Info("Namespace selected, handling its experiments", "namespace", ns.GetName(), "activeNamespaces", r.activeNamespaces.list())
+
+	experiments := &experimentcontrollercomv1alpha1.ExperimentDeploymentList{}
+	if err := r.List(ctx, experiments, client.InNamespace(ns.GetName())); err != nil {
+		log.Error(err, "Failed to list ExperimentDeployments of selected namespace", "namespace", ns.GetName())
+		return
+	}
+	for _, experiment := range experiments.Items {
+		q.Add(reconcile.Request{NamespacedName: types.NamespacedName{Name: experiment.Name, Namespace: experiment.Namespace}})
+	}
+}
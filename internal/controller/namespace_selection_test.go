@@ -0,0 +1,144 @@
+package controller
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/workqueue"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	experimentcontrollercomv1alpha1 "experimentcontroller.example.com/experiment-deployment/api/v1alpha1"
+)
+
+var _ = Describe("Namespace selection", func() {
+	const (
+		selectedNamespace   = "team-a"
+		unselectedNamespace = "team-b"
+	)
+
+	var (
+		ctx        context.Context
+		reconciler *ExperimentDeploymentReconciler
+		fakeClient client.Client
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		scheme := runtime.NewScheme()
+		Expect(experimentcontrollercomv1alpha1.AddToScheme(scheme)).To(Succeed())
+		Expect(appsv1.AddToScheme(scheme)).To(Succeed())
+		Expect(corev1.AddToScheme(scheme)).To(Succeed())
+		fakeClient = fake.NewClientBuilder().WithScheme(scheme).
+			WithStatusSubresource(&experimentcontrollercomv1alpha1.ExperimentDeployment{}).
+			WithInterceptorFuncs(withServerSideApply(interceptor.Funcs{})).Build()
+		selector, err := labels.Parse("experiments=enabled")
+		Expect(err).NotTo(HaveOccurred())
+		reconciler = &ExperimentDeploymentReconciler{
+			Client:            fakeClient,
+			Scheme:            scheme,
+			Recorder:          record.NewFakeRecorder(100),
+			NamespaceSelector: selector,
+		}
+
+		Expect(fakeClient.Create(ctx, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+			Name:   selectedNamespace,
+			Labels: map[string]string{"experiments": "enabled"},
+		}})).To(Succeed())
+		Expect(fakeClient.Create(ctx, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: unselectedNamespace}})).To(Succeed())
+		for _, namespace := range []string{selectedNamespace, unselectedNamespace} {
+			Expect(fakeClient.Create(ctx, &experimentcontrollercomv1alpha1.ExperimentDeployment{
+				ObjectMeta: metav1.ObjectMeta{Name: testExperimentCRName, Namespace: namespace},
+				Spec: experimentcontrollercomv1alpha1.ExperimentDeploymentSpec{
+					SourceRef: experimentcontrollercomv1alpha1.SourceRef{
+						Kind: experimentcontrollercomv1alpha1.SourceKindDeployment,
+						Name: "web",
+					},
+					OverrideSpec: apiextensionsv1.JSON{Raw: []byte(`{}`)},
+				},
+			})).To(Succeed())
+		}
+	})
+
+	reconcileIn := func(namespace string) {
+		_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: types.NamespacedName{Name: testExperimentCRName, Namespace: namespace}})
+		Expect(err).NotTo(HaveOccurred())
+	}
+
+	getExperiment := func(namespace string) *experimentcontrollercomv1alpha1.ExperimentDeployment {
+		experimentCR := &experimentcontrollercomv1alpha1.ExperimentDeployment{}
+		Expect(fakeClient.Get(ctx, types.NamespacedName{Name: testExperimentCRName, Namespace: namespace}, experimentCR)).To(Succeed())
+		return experimentCR
+	}
+
+	It("should only handle the experiments of selected namespaces", func() {
+		reconcileIn(selectedNamespace)
+		reconcileIn(unselectedNamespace)
+
+		Expect(getExperiment(selectedNamespace).Finalizers).To(ContainElement(experimentDeploymentFinalizer))
+		ignored := getExperiment(unselectedNamespace)
+		Expect(ignored.Finalizers).To(BeEmpty())
+		Expect(ignored.Status.Conditions).To(BeEmpty())
+	})
+
+	It("should still clean up deleted experiments of namespaces that are no longer selected", func() {
+		reconcileIn(selectedNamespace)
+		ns := &corev1.Namespace{}
+		Expect(fakeClient.Get(ctx, types.NamespacedName{Name: selectedNamespace}, ns)).To(Succeed())
+		ns.Labels = nil
+		Expect(fakeClient.Update(ctx, ns)).To(Succeed())
+
+		Expect(fakeClient.Delete(ctx, getExperiment(selectedNamespace))).To(Succeed())
+		reconcileIn(selectedNamespace)
+
+		err := fakeClient.Get(ctx, types.NamespacedName{Name: testExperimentCRName, Namespace: selectedNamespace},
+			&experimentcontrollercomv1alpha1.ExperimentDeployment{})
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should track the active namespaces and enqueue the experiments of newly selected ones", func() {
+		queue := workqueue.NewTypedRateLimitingQueue(workqueue.DefaultTypedControllerRateLimiter[reconcile.Request]())
+		defer queue.ShutDown()
+		eventHandler := reconciler.namespaceEventHandler()
+
+		ns := &corev1.Namespace{}
+		Expect(fakeClient.Get(ctx, types.NamespacedName{Name: selectedNamespace}, ns)).To(Succeed())
+		eventHandler.Create(ctx, event.CreateEvent{Object: ns}, queue)
+		other := &corev1.Namespace{}
+		Expect(fakeClient.Get(ctx, types.NamespacedName{Name: unselectedNamespace}, other)).To(Succeed())
+		eventHandler.Create(ctx, event.CreateEvent{Object: other}, queue)
+
+		Expect(reconciler.ActiveNamespaces()).To(Equal([]string{selectedNamespace}))
+		Expect(queue.Len()).To(Equal(1))
+		request, _ := queue.Get()
+		Expect(request.Namespace).To(Equal(selectedNamespace))
+		queue.Done(request)
+
+		// Relabeling a namespace selects it, updates that do not change the selection are ignored
+		labeled := other.DeepCopy()
+		labeled.Labels = map[string]string{"experiments": "enabled"}
+		eventHandler.Update(ctx, event.UpdateEvent{ObjectOld: other, ObjectNew: labeled}, queue)
+		eventHandler.Update(ctx, event.UpdateEvent{ObjectOld: labeled, ObjectNew: labeled}, queue)
+		Expect(reconciler.ActiveNamespaces()).To(Equal([]string{selectedNamespace, unselectedNamespace}))
+		Expect(queue.Len()).To(Equal(1))
+
+		unlabeled := ns.DeepCopy()
+		unlabeled.Labels = nil
+		eventHandler.Update(ctx, event.UpdateEvent{ObjectOld: ns, ObjectNew: unlabeled}, queue)
+		eventHandler.Delete(ctx, event.DeleteEvent{Object: labeled}, queue)
+		Expect(reconciler.ActiveNamespaces()).To(BeEmpty())
+	})
+})
@@ -0,0 +1,400 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	experimentcontrollercomv1alpha1 "experimentcontroller.example.com/experiment-deployment/api/v1alpha1"
+)
+
+const (
+	// allIPv4CIDR and allIPv6CIDR match every destination of their address family
+	allIPv4CIDR = "0.0.0.0/0"
+	allIPv6CIDR = "::/0"
+)
+
+// experimentNetworkPolicyName returns the name of the NetworkPolicy generated for the experiment pods
+func experimentNetworkPolicyName(experimentCR *experimentcontrollercomv1alpha1.ExperimentDeployment) string {
+	return experimentCR.Name + "-experiment"
+}
+
+// reconcileNetworkIsolation manages the NetworkPolicy selecting the experiment pods.
+// The policy is removed when spec.networkIsolation is unset.
+func (r *ExperimentDeploymentReconciler) reconcileNetworkIsolation(
+	ctx context.Context,
+	experimentCR *experimentcontrollercomv1alpha1.ExperimentDeployment,
+	sourceNamespace string,
+	sourcePodLabels map[string]string) error {
+
+	if experimentCR.Spec.NetworkIsolation == nil {
+		// Only clean up a policy previously generated for this experiment
+		if experimentCR.Status.NetworkPolicyRef == nil {
+			return nil
+		}
+		if err := r.deleteExperimentNetworkPolicy(ctx, experimentCR); err != nil {
+			return err
+		}
+		experimentCR.Status.NetworkPolicyRef = nil
+		return nil
+	}
+
+	desired, err := r.constructExperimentNetworkPolicy(ctx, experimentCR, sourceNamespace, sourcePodLabels)
+	if err != nil {
+		logf.FromContext(ctx).Error(err, "Failed to construct experiment NetworkPolicy")
+		r.Recorder.Eventf(experimentCR, corev1.EventTypeWarning, "NetworkIsolationFailed", "Failed to construct experiment NetworkPolicy: %s", err.Error())
+		r.updateStatusConditions(experimentCR, experimentcontrollercomv1alpha1.ReasonNetworkIsolationFailed, fmt.Sprintf("Failed to construct experiment NetworkPolicy: %s", err.Error()))
+		return err
+	}
+
+	return r.createOrUpdateExperimentNetworkPolicy(ctx, experimentCR, desired)
+}
+
+// constructExperimentNetworkPolicy builds the NetworkPolicy for the experiment pods from the
+// ingress mode and egress lists of spec.networkIsolation
+func (r *ExperimentDeploymentReconciler) constructExperimentNetworkPolicy(
+	ctx context.Context,
+	experimentCR *experimentcontrollercomv1alpha1.ExperimentDeployment,
+	sourceNamespace string,
+	sourcePodLabels map[string]string) (*networkingv1.NetworkPolicy, error) {
+
+	isolation := experimentCR.Spec.NetworkIsolation
+
+	policy := &networkingv1.NetworkPolicy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      experimentNetworkPolicyName(experimentCR),
+			Namespace: experimentCR.Namespace,
+			Labels: map[string]string{
+				r.labelKey(labelManagedBy): ManagedByValue,
+				r.labelKey(labelCRName):    experimentCR.Name,
+			},
+		},
+		Spec: networkingv1.NetworkPolicySpec{
+			PodSelector: metav1.LabelSelector{
+				MatchLabels: map[string]string{
+					r.labelKey(labelCRName): experimentCR.Name,
+				},
+			},
+			PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeIngress},
+		},
+	}
+
+	switch isolation.Ingress {
+	case experimentcontrollercomv1alpha1.NetworkIngressDenyAll:
+		// An Ingress policy without rules blocks all ingress
+	case experimentcontrollercomv1alpha1.NetworkIngressAllowAll:
+		policy.Spec.Ingress = []networkingv1.NetworkPolicyIngressRule{{}}
+	default:
+		ingress, err := r.sourceIngressRules(ctx, sourceNamespace, experimentCR.Namespace, sourcePodLabels)
+		if err != nil {
+			return nil, err
+		}
+		policy.Spec.Ingress = ingress
+	}
+
+	dnsPeer, err := r.dnsPeer()
+	if err != nil {
+		return nil, err
+	}
+	egress, err := constructEgressRules(isolation, dnsPeer)
+	if err != nil {
+		return nil, err
+	}
+	if egress != nil {
+		policy.Spec.PolicyTypes = append(policy.Spec.PolicyTypes, networkingv1.PolicyTypeEgress)
+		policy.Spec.Egress = egress
+	}
+
+	return policy, nil
+}
+
+// sourceIngressRules returns the effective ingress rules of the source pods: the union of the
+// ingress rules of every NetworkPolicy selecting them, or allow-all when none restricts ingress.
+// Peers relative to the source namespace are pinned to it when the experiment runs elsewhere.
+func (r *ExperimentDeploymentReconciler) sourceIngressRules(
+	ctx context.Context,
+	sourceNamespace, experimentNamespace string,
+	sourcePodLabels map[string]string) ([]networkingv1.NetworkPolicyIngressRule, error) {
+
+	policyList := &networkingv1.NetworkPolicyList{}
+	if err := r.List(ctx, policyList, client.InNamespace(sourceNamespace)); err != nil {
+		return nil, fmt.Errorf("failed to list NetworkPolicies in namespace %s: %w", sourceNamespace, err)
+	}
+	sort.Slice(policyList.Items, func(i, j int) bool { return policyList.Items[i].Name < policyList.Items[j].Name })
+
+	restricted := false
+	var rules []networkingv1.NetworkPolicyIngressRule
+	for _, policy := range policyList.Items {
+		if policy.Labels[r.labelKey(labelManagedBy)] == ManagedByValue {
+			continue
+		}
+		if !policyAffectsIngress(policy) {
+			continue
+		}
+		selector, err := metav1.LabelSelectorAsSelector(&policy.Spec.PodSelector)
+		if err != nil || !selector.Matches(labels.Set(sourcePodLabels)) {
+			continue
+		}
+		restricted = true
+		for _, rule := range policy.Spec.Ingress {
+			rule := *rule.DeepCopy()
+			if sourceNamespace != experimentNamespace {
+				for i := range rule.From {
+					pinPeerNamespace(&rule.From[i], sourceNamespace)
+				}
+			}
+			rules = append(rules, rule)
+		}
+	}
+
+	if !restricted {
+		return []networkingv1.NetworkPolicyIngressRule{{}}, nil
+	}
+	return rules, nil
+}
+
+// policyAffectsIngress reports whether a NetworkPolicy restricts ingress to the pods it selects
+func policyAffectsIngress(policy networkingv1.NetworkPolicy) bool {
+	if len(policy.Spec.PolicyTypes) == 0 {
+		return true
+	}
+	for _, policyType := range policy.Spec.PolicyTypes {
+		if policyType == networkingv1.PolicyTypeIngress {
+			return true
+		}
+	}
+	return false
+}
+
+// pinPeerNamespace restricts a pod selector peer, implicitly relative to the policy namespace,
+// to the given namespace
+func pinPeerNamespace(peer *networkingv1.NetworkPolicyPeer, namespace string) {
+	if peer.PodSelector == nil || peer.NamespaceSelector != nil {
+		return
+	}
+	peer.NamespaceSelector = &metav1.LabelSelector{
+		MatchLabels: map[string]string{corev1.LabelMetadataName: namespace},
+	}
+}
+
+// dnsPeer returns the peer selecting the cluster DNS pods of the controller config
+func (r *ExperimentDeploymentReconciler) dnsPeer() (networkingv1.NetworkPolicyPeer, error) {
+	networkIsolation := r.config().NetworkIsolation
+	podSelector, err := metav1.ParseToLabelSelector(networkIsolation.DNSPodSelector)
+	if err != nil {
+		return networkingv1.NetworkPolicyPeer{}, fmt.Errorf("invalid DNS pod selector %q: %w", networkIsolation.DNSPodSelector, err)
+	}
+	return networkingv1.NetworkPolicyPeer{
+		NamespaceSelector: &metav1.LabelSelector{
+			MatchLabels: map[string]string{corev1.LabelMetadataName: networkIsolation.DNSNamespace},
+		},
+		PodSelector: podSelector,
+	}, nil
+}
+
+// restrictsEgress reports whether the network isolation restricts the egress of the experiment pods
+func restrictsEgress(isolation *experimentcontrollercomv1alpha1.NetworkIsolation) bool {
+	return isolation != nil && (len(isolation.EgressAllow) > 0 || len(isolation.EgressDenyCIDRs) > 0)
+}
+
+// constructEgressRules builds the egress rules from the allow and deny lists, and allows DNS
+// queries to the given peer. It returns nil when egress is not restricted.
+// Denied CIDRs only except addresses from the ipBlock peers; pods selected by podSelector or
+// namespaceSelector peers stay reachable whatever their IP.
+func constructEgressRules(
+	isolation *experimentcontrollercomv1alpha1.NetworkIsolation,
+	dnsPeer networkingv1.NetworkPolicyPeer) ([]networkingv1.NetworkPolicyEgressRule, error) {
+
+	if !restrictsEgress(isolation) {
+		return nil, nil
+	}
+
+	denied := make([]*net.IPNet, 0, len(isolation.EgressDenyCIDRs))
+	for _, cidr := range isolation.EgressDenyCIDRs {
+		_, ipNet, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid egressDenyCIDRs entry %q: %w", cidr, err)
+		}
+		denied = append(denied, ipNet)
+	}
+
+	var rules []networkingv1.NetworkPolicyEgressRule
+	if len(isolation.EgressAllow) == 0 {
+		// Everything but the denied CIDRs
+		rules = append(rules, networkingv1.NetworkPolicyEgressRule{To: allDestinationPeers()})
+	}
+	for _, allowed := range isolation.EgressAllow {
+		rule := *allowed.DeepCopy()
+		if len(rule.To) == 0 {
+			rule.To = allDestinationPeers()
+		}
+		rules = append(rules, rule)
+	}
+
+	for i := range rules {
+		for j := range rules[i].To {
+			if err := exceptDeniedCIDRs(rules[i].To[j].IPBlock, denied); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if isolation.AllowDNS == nil || *isolation.AllowDNS {
+		udp := corev1.ProtocolUDP
+		tcp := corev1.ProtocolTCP
+		dnsPort := intstr.FromInt32(53)
+		rules = append(rules, networkingv1.NetworkPolicyEgressRule{
+			Ports: []networkingv1.NetworkPolicyPort{
+				{Protocol: &udp, Port: &dnsPort},
+				{Protocol: &tcp, Port: &dnsPort},
+			},
+			To: []networkingv1.NetworkPolicyPeer{dnsPeer},
+		})
+	}
+
+	return rules, nil
+}
+
+// allDestinationPeers returns peers matching every IPv4 and IPv6 destination
+func allDestinationPeers() []networkingv1.NetworkPolicyPeer {
+	return []networkingv1.NetworkPolicyPeer{
+		{IPBlock: &networkingv1.IPBlock{CIDR: allIPv4CIDR}},
+		{IPBlock: &networkingv1.IPBlock{CIDR: allIPv6CIDR}},
+	}
+}
+
+// exceptDeniedCIDRs adds the denied CIDRs contained in an allowed IP block to its exceptions.
+// Allowed blocks lying entirely within a denied CIDR are rejected.
+func exceptDeniedCIDRs(block *networkingv1.IPBlock, denied []*net.IPNet) error {
+	if block == nil {
+		return nil
+	}
+	_, allowed, err := net.ParseCIDR(block.CIDR)
+	if err != nil {
+		return fmt.Errorf("invalid egressAllow ipBlock %q: %w", block.CIDR, err)
+	}
+	allowedOnes, allowedBits := allowed.Mask.Size()
+
+	for _, deny := range denied {
+		denyOnes, denyBits := deny.Mask.Size()
+		if denyBits != allowedBits {
+			continue
+		}
+		if denyOnes <= allowedOnes && deny.Contains(allowed.IP) {
+			return fmt.Errorf("egressAllow ipBlock %s is within denied CIDR %s", block.CIDR, deny.String())
+		}
+		if allowed.Contains(deny.IP) && !containsString(block.Except, deny.String()) {
+			block.Except = append(block.Except, deny.String())
+		}
+	}
+	return nil
+}
+
+// containsString reports whether a slice contains the given string
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// createOrUpdateExperimentNetworkPolicy creates or updates the NetworkPolicy for the experiment pods
+func (r *ExperimentDeploymentReconciler) createOrUpdateExperimentNetworkPolicy(
+	ctx context.Context,
+	experimentCR *experimentcontrollercomv1alpha1.ExperimentDeployment,
+	desired *networkingv1.NetworkPolicy) error {
+
+	log := logf.FromContext(ctx)
+
+	policyToManage := &networkingv1.NetworkPolicy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      desired.Name,
+			Namespace: desired.Namespace,
+		},
+	}
+
+	opResult, err := controllerutil.CreateOrUpdate(ctx, r.Client, policyToManage, func() error {
+		if err := controllerutil.SetControllerReference(experimentCR, policyToManage, r.Scheme); err != nil {
+			return err
+		}
+		if policyToManage.Labels == nil {
+			policyToManage.Labels = make(map[string]string)
+		}
+		for k, v := range desired.Labels {
+			policyToManage.Labels[k] = v
+		}
+		policyToManage.Spec = desired.Spec
+		return nil
+	})
+	if err != nil {
+		log.Error(err, "Failed to create or update experiment NetworkPolicy", "name", desired.Name)
+		r.Recorder.Eventf(experimentCR, corev1.EventTypeWarning, "NetworkIsolationFailed", "Failed to create/update experiment NetworkPolicy %s: %s", desired.Name, err.Error())
+		r.updateStatusConditions(experimentCR, experimentcontrollercomv1alpha1.ReasonNetworkIsolationFailed, fmt.Sprintf("Failed to create/update experiment NetworkPolicy %s: %s", desired.Name, err.Error()))
+		return err
+	}
+
+	if opResult != controllerutil.OperationResultNone {
+		log.Info("Experiment NetworkPolicy successfully reconciled", "operation", opResult, "name", desired.Name)
+		r.Recorder.Eventf(experimentCR, corev1.EventTypeNormal, string(opResult), "Experiment NetworkPolicy %s %s", desired.Name, opResult)
+	}
+
+	experimentCR.Status.NetworkPolicyRef = &experimentcontrollercomv1alpha1.ExperimentResourceRef{
+		Kind:      "NetworkPolicy",
+		Name:      desired.Name,
+		Namespace: desired.Namespace,
+	}
+	return nil
+}
+
+// deleteExperimentNetworkPolicy removes the NetworkPolicy generated for the experiment pods if it exists
+func (r *ExperimentDeploymentReconciler) deleteExperimentNetworkPolicy(ctx context.Context, experimentCR *experimentcontrollercomv1alpha1.ExperimentDeployment) error {
+	log := logf.FromContext(ctx)
+
+	policy := &networkingv1.NetworkPolicy{}
+	err := r.Get(ctx, client.ObjectKey{Name: experimentNetworkPolicyName(experimentCR), Namespace: experimentCR.Namespace}, policy)
+	if err != nil {
+		if k8serrors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	// Never delete a NetworkPolicy this experiment does not own
+	if !metav1.IsControlledBy(policy, experimentCR) {
+		return nil
+	}
+	if err := r.Delete(ctx, policy); err != nil && !k8serrors.IsNotFound(err) {
+		log.Error(err, "Failed to delete experiment NetworkPolicy", "name", policy.Name)
+		return err
+	}
+	log.Info("Deleted experiment NetworkPolicy", "name", policy.Name)
+	return nil
+}
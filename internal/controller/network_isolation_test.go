@@ -0,0 +1,241 @@
+package controller
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	experimentcontrollercomv1alpha1 "experimentcontroller.example.com/experiment-deployment/api/v1alpha1"
+)
+
+var _ = Describe("Network Isolation", func() {
+	var (
+		ctx              context.Context
+		reconciler       *ExperimentDeploymentReconciler
+		fakeClient       client.Client
+		scheme           *runtime.Scheme
+		sourceDeployment *appsv1.Deployment
+		experimentCR     *experimentcontrollercomv1alpha1.ExperimentDeployment
+		namespacedName   types.NamespacedName
+		policyName       types.NamespacedName
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		scheme = runtime.NewScheme()
+		Expect(experimentcontrollercomv1alpha1.AddToScheme(scheme)).To(Succeed())
+		Expect(appsv1.AddToScheme(scheme)).To(Succeed())
+		Expect(corev1.AddToScheme(scheme)).To(Succeed())
+		Expect(networkingv1.AddToScheme(scheme)).To(Succeed())
+		fakeClient = fake.NewClientBuilder().WithScheme(scheme).WithInterceptorFuncs(withServerSideApply(interceptor.Funcs{})).WithStatusSubresource(&experimentcontrollercomv1alpha1.ExperimentDeployment{}).Build()
+		reconciler = &ExperimentDeploymentReconciler{
+			Client:   fakeClient,
+			Scheme:   scheme,
+			Recorder: record.NewFakeRecorder(100),
+		}
+
+		namespacedName = types.NamespacedName{Name: testExperimentCRName, Namespace: testNamespace}
+		policyName = types.NamespacedName{Name: testExperimentCRName + "-experiment", Namespace: testNamespace}
+		sourceDeployment = &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "source-deployment", Namespace: testNamespace},
+			Spec: appsv1.DeploymentSpec{
+				Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "source-app"}},
+				Template: corev1.PodTemplateSpec{
+					ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": "source-app"}},
+					Spec: corev1.PodSpec{
+						Containers: []corev1.Container{{Name: "test-container", Image: "nginx:1.14"}},
+					},
+				},
+			},
+		}
+		experimentCR = &experimentcontrollercomv1alpha1.ExperimentDeployment{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:       testExperimentCRName,
+				Namespace:  testNamespace,
+				Finalizers: []string{experimentDeploymentFinalizer},
+			},
+			Spec: experimentcontrollercomv1alpha1.ExperimentDeploymentSpec{
+				SourceRef: experimentcontrollercomv1alpha1.SourceRef{
+					Kind: experimentcontrollercomv1alpha1.SourceKindDeployment,
+					Name: "source-deployment",
+				},
+				OverrideSpec:     apiextensionsv1.JSON{Raw: []byte("{}")},
+				NetworkIsolation: &experimentcontrollercomv1alpha1.NetworkIsolation{},
+			},
+		}
+	})
+
+	reconcileAndGetPolicy := func() *networkingv1.NetworkPolicy {
+		_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+		Expect(err).NotTo(HaveOccurred())
+
+		policy := &networkingv1.NetworkPolicy{}
+		Expect(fakeClient.Get(ctx, policyName, policy)).To(Succeed())
+		return policy
+	}
+
+	It("should select the experiment pods and allow all ingress when the source is not restricted", func() {
+		Expect(fakeClient.Create(ctx, sourceDeployment)).To(Succeed())
+		Expect(fakeClient.Create(ctx, experimentCR)).To(Succeed())
+
+		policy := reconcileAndGetPolicy()
+		Expect(policy.Spec.PodSelector.MatchLabels).To(Equal(map[string]string{
+			"experiment-controller.example.com/cr-name": testExperimentCRName,
+		}))
+		Expect(policy.Spec.PolicyTypes).To(ConsistOf(networkingv1.PolicyTypeIngress))
+		Expect(policy.Spec.Ingress).To(Equal([]networkingv1.NetworkPolicyIngressRule{{}}))
+		Expect(policy.Spec.Egress).To(BeEmpty())
+		Expect(metav1.IsControlledBy(policy, experimentCR)).To(BeTrue())
+
+		updatedCR := &experimentcontrollercomv1alpha1.ExperimentDeployment{}
+		Expect(fakeClient.Get(ctx, namespacedName, updatedCR)).To(Succeed())
+		Expect(updatedCR.Status.NetworkPolicyRef).NotTo(BeNil())
+		Expect(updatedCR.Status.NetworkPolicyRef.Kind).To(Equal("NetworkPolicy"))
+		Expect(updatedCR.Status.NetworkPolicyRef.Name).To(Equal(policyName.Name))
+	})
+
+	It("should copy the ingress rules of the policies selecting the source pods", func() {
+		port := intstr.FromInt32(8080)
+		sourcePolicy := &networkingv1.NetworkPolicy{
+			ObjectMeta: metav1.ObjectMeta{Name: "source-policy", Namespace: testNamespace},
+			Spec: networkingv1.NetworkPolicySpec{
+				PodSelector: metav1.LabelSelector{MatchLabels: map[string]string{"app": "source-app"}},
+				Ingress: []networkingv1.NetworkPolicyIngressRule{{
+					From:  []networkingv1.NetworkPolicyPeer{{PodSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "frontend"}}}},
+					Ports: []networkingv1.NetworkPolicyPort{{Port: &port}},
+				}},
+			},
+		}
+		unrelatedPolicy := &networkingv1.NetworkPolicy{
+			ObjectMeta: metav1.ObjectMeta{Name: "unrelated-policy", Namespace: testNamespace},
+			Spec: networkingv1.NetworkPolicySpec{
+				PodSelector: metav1.LabelSelector{MatchLabels: map[string]string{"app": "other-app"}},
+			},
+		}
+		Expect(fakeClient.Create(ctx, sourceDeployment)).To(Succeed())
+		Expect(fakeClient.Create(ctx, sourcePolicy)).To(Succeed())
+		Expect(fakeClient.Create(ctx, unrelatedPolicy)).To(Succeed())
+		Expect(fakeClient.Create(ctx, experimentCR)).To(Succeed())
+
+		policy := reconcileAndGetPolicy()
+		Expect(policy.Spec.Ingress).To(Equal(sourcePolicy.Spec.Ingress))
+	})
+
+	It("should block all ingress in DenyAll mode", func() {
+		experimentCR.Spec.NetworkIsolation.Ingress = experimentcontrollercomv1alpha1.NetworkIngressDenyAll
+		Expect(fakeClient.Create(ctx, sourceDeployment)).To(Succeed())
+		Expect(fakeClient.Create(ctx, experimentCR)).To(Succeed())
+
+		policy := reconcileAndGetPolicy()
+		Expect(policy.Spec.PolicyTypes).To(ConsistOf(networkingv1.PolicyTypeIngress))
+		Expect(policy.Spec.Ingress).To(BeEmpty())
+	})
+
+	It("should deny egress to the denied CIDRs and keep DNS to the cluster DNS open", func() {
+		experimentCR.Spec.ServiceMode = experimentcontrollercomv1alpha1.ServiceModeIsolated
+		experimentCR.Spec.NetworkIsolation.EgressDenyCIDRs = []string{"10.20.0.0/16"}
+		Expect(fakeClient.Create(ctx, sourceDeployment)).To(Succeed())
+		Expect(fakeClient.Create(ctx, experimentCR)).To(Succeed())
+
+		policy := reconcileAndGetPolicy()
+		Expect(policy.Spec.PolicyTypes).To(ConsistOf(networkingv1.PolicyTypeIngress, networkingv1.PolicyTypeEgress))
+		Expect(policy.Spec.Egress).To(HaveLen(2))
+		Expect(policy.Spec.Egress[0].To).To(Equal([]networkingv1.NetworkPolicyPeer{
+			{IPBlock: &networkingv1.IPBlock{CIDR: "0.0.0.0/0", Except: []string{"10.20.0.0/16"}}},
+			{IPBlock: &networkingv1.IPBlock{CIDR: "::/0"}},
+		}))
+		Expect(policy.Spec.Egress[1].To).To(Equal([]networkingv1.NetworkPolicyPeer{{
+			NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"kubernetes.io/metadata.name": "kube-system"}},
+			PodSelector:       &metav1.LabelSelector{MatchLabels: map[string]string{"k8s-app": "kube-dns"}},
+		}}))
+		Expect(policy.Spec.Egress[1].Ports).To(HaveLen(2))
+		Expect(policy.Spec.Egress[1].Ports[0].Port.IntValue()).To(Equal(53))
+	})
+
+	It("should restrict egress to the allow list minus the denied CIDRs", func() {
+		experimentCR.Spec.ServiceMode = experimentcontrollercomv1alpha1.ServiceModeIsolated
+		allowDNS := false
+		experimentCR.Spec.NetworkIsolation.AllowDNS = &allowDNS
+		experimentCR.Spec.NetworkIsolation.EgressAllow = []networkingv1.NetworkPolicyEgressRule{
+			{To: []networkingv1.NetworkPolicyPeer{{IPBlock: &networkingv1.IPBlock{CIDR: "10.0.0.0/8"}}}},
+		}
+		experimentCR.Spec.NetworkIsolation.EgressDenyCIDRs = []string{"10.20.0.0/16", "192.168.1.0/24"}
+		Expect(fakeClient.Create(ctx, sourceDeployment)).To(Succeed())
+		Expect(fakeClient.Create(ctx, experimentCR)).To(Succeed())
+
+		policy := reconcileAndGetPolicy()
+		Expect(policy.Spec.Egress).To(Equal([]networkingv1.NetworkPolicyEgressRule{
+			{To: []networkingv1.NetworkPolicyPeer{{IPBlock: &networkingv1.IPBlock{CIDR: "10.0.0.0/8", Except: []string{"10.20.0.0/16"}}}}},
+		}))
+	})
+
+	It("should reject egress restrictions of Shared experiments", func() {
+		experimentCR.Spec.NetworkIsolation.EgressDenyCIDRs = []string{"10.20.0.0/16"}
+		Expect(fakeClient.Create(ctx, sourceDeployment)).To(Succeed())
+		Expect(fakeClient.Create(ctx, experimentCR)).To(Succeed())
+
+		_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+		Expect(err).To(MatchError(ContainSubstring("require serviceMode Isolated or Shadow")))
+		Expect(errors.IsNotFound(fakeClient.Get(ctx, policyName, &networkingv1.NetworkPolicy{}))).To(BeTrue())
+		Expect(errors.IsNotFound(fakeClient.Get(ctx, namespacedName, &appsv1.Deployment{}))).To(BeTrue())
+	})
+
+	It("should reject allowed blocks inside a denied CIDR", func() {
+		experimentCR.Spec.ServiceMode = experimentcontrollercomv1alpha1.ServiceModeIsolated
+		experimentCR.Spec.NetworkIsolation.EgressAllow = []networkingv1.NetworkPolicyEgressRule{
+			{To: []networkingv1.NetworkPolicyPeer{{IPBlock: &networkingv1.IPBlock{CIDR: "10.20.1.0/24"}}}},
+		}
+		experimentCR.Spec.NetworkIsolation.EgressDenyCIDRs = []string{"10.20.0.0/16"}
+		Expect(fakeClient.Create(ctx, sourceDeployment)).To(Succeed())
+		Expect(fakeClient.Create(ctx, experimentCR)).To(Succeed())
+
+		_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("within denied CIDR"))
+	})
+
+	It("should delete the policy when network isolation is removed", func() {
+		Expect(fakeClient.Create(ctx, sourceDeployment)).To(Succeed())
+		Expect(fakeClient.Create(ctx, experimentCR)).To(Succeed())
+		reconcileAndGetPolicy()
+
+		updatedCR := &experimentcontrollercomv1alpha1.ExperimentDeployment{}
+		Expect(fakeClient.Get(ctx, namespacedName, updatedCR)).To(Succeed())
+		updatedCR.Spec.NetworkIsolation = nil
+		Expect(fakeClient.Update(ctx, updatedCR)).To(Succeed())
+
+		_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+		Expect(err).NotTo(HaveOccurred())
+
+		err = fakeClient.Get(ctx, policyName, &networkingv1.NetworkPolicy{})
+		Expect(errors.IsNotFound(err)).To(BeTrue())
+		Expect(fakeClient.Get(ctx, namespacedName, updatedCR)).To(Succeed())
+		Expect(updatedCR.Status.NetworkPolicyRef).To(BeNil())
+	})
+
+	It("should pin pod selector peers to the source namespace for cross-namespace sources", func() {
+		peer := networkingv1.NetworkPolicyPeer{PodSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "frontend"}}}
+		pinPeerNamespace(&peer, "source-ns")
+		Expect(peer.NamespaceSelector).NotTo(BeNil())
+		Expect(peer.NamespaceSelector.MatchLabels).To(HaveKeyWithValue(corev1.LabelMetadataName, "source-ns"))
+
+		ipPeer := networkingv1.NetworkPolicyPeer{IPBlock: &networkingv1.IPBlock{CIDR: "10.0.0.0/8"}}
+		pinPeerNamespace(&ipPeer, "source-ns")
+		Expect(ipPeer.NamespaceSelector).To(BeNil())
+	})
+})
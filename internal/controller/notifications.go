@@ -0,0 +1,268 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	experimentcontrollercomv1alpha1 "experimentcontroller.example.com/experiment-deployment/api/v1alpha1"
+	"experimentcontroller.example.com/experiment-deployment/internal/config"
+	"experimentcontroller.example.com/experiment-deployment/internal/notification"
+)
+
+// defaultNotificationRetries is the number of retries of providers without spec.retries
+const defaultNotificationRetries = 3
+
+// notificationEvent returns the lifecycle transition of an experiment that moved from the
+// previous phase to its current phase, if notifications are sent for it
+func notificationEvent(
+	experimentCR *experimentcontrollercomv1alpha1.ExperimentDeployment,
+	previousPhase experimentcontrollercomv1alpha1.ExperimentPhase) (experimentcontrollercomv1alpha1.NotificationEventType, bool) {
+
+	phase := experimentCR.Status.Phase
+	if phase == previousPhase {
+		return "", false
+	}
+	switch {
+	case previousPhase == "":
+		return experimentcontrollercomv1alpha1.NotificationEventCreated, true
+	case phase == experimentcontrollercomv1alpha1.ExperimentPhaseRunning:
+		return experimentcontrollercomv1alpha1.NotificationEventReady, true
+	case phase == experimentcontrollercomv1alpha1.ExperimentPhaseSucceeded,
+		phase == experimentcontrollercomv1alpha1.ExperimentPhaseFailed && isAnalysisFailed(experimentCR):
+		return experimentcontrollercomv1alpha1.NotificationEventAnalysisCompleted, true
+	case phase == experimentcontrollercomv1alpha1.ExperimentPhaseFailed:
+		return experimentcontrollercomv1alpha1.NotificationEventFailed, true
+	case phase == experimentcontrollercomv1alpha1.ExperimentPhaseAborted:
+		return experimentcontrollercomv1alpha1.NotificationEventAborted, true
+	case phase == experimentcontrollercomv1alpha1.ExperimentPhaseExpired:
+		return experimentcontrollercomv1alpha1.NotificationEventExpired, true
+	case phase == experimentcontrollercomv1alpha1.ExperimentPhasePromoted:
+		return experimentcontrollercomv1alpha1.NotificationEventPromoted, true
+	}
+	return "", false
+}
+
+// notifyTransition notifies the providers of the experiment and the CloudEvents sink when its
+// lifecycle status moved it to another phase than the stored one, and reports whether it did.
+// Notifications are delivered at least once: they are sent again if storing the new phase fails.
+func (r *ExperimentDeploymentReconciler) notifyTransition(ctx context.Context, experimentCR *experimentcontrollercomv1alpha1.ExperimentDeployment) bool {
+	stored := &experimentcontrollercomv1alpha1.ExperimentDeployment{}
+	if err := r.Get(ctx, client.ObjectKeyFromObject(experimentCR), stored); err != nil {
+		logf.FromContext(ctx).Error(err, "Failed to get the stored ExperimentDeployment to detect lifecycle transitions")
+		return false
+	}
+	eventType, ok := notificationEvent(experimentCR, stored.Status.Phase)
+	if ok {
+		r.notify(ctx, experimentCR, eventType)
+	}
+	return ok
+}
+
+// newNotificationEvent describes a lifecycle transition of the experiment
+func newNotificationEvent(
+	experimentCR *experimentcontrollercomv1alpha1.ExperimentDeployment,
+	eventType experimentcontrollercomv1alpha1.NotificationEventType) notification.Event {
+
+	event := notification.Event{
+		Type:       eventType,
+		Experiment: experimentCR.Name,
+		Namespace:  experimentCR.Namespace,
+		SourceKind: experimentCR.Spec.SourceRef.Kind,
+		SourceName: experimentCR.Spec.SourceRef.Name,
+		Phase:      experimentCR.Status.Phase,
+		Time:       time.Now().UTC(),
+	}
+	if ready := meta.FindStatusCondition(experimentCR.Status.Conditions, ConditionTypeReady); ready != nil {
+		event.Reason, event.Message = ready.Reason, ready.Message
+	}
+	if eventType == experimentcontrollercomv1alpha1.NotificationEventAnalysisCompleted {
+		event.Message = fmt.Sprintf("Analysis %s", experimentCR.Status.AnalysisPhase)
+	}
+	return event
+}
+
+// notify publishes a lifecycle transition as a CloudEvent, attempts to deliver it once to the
+// NotificationProviders referenced by the experiment and reports the deliveries in its status.
+// Failed deliveries do not fail the reconcile, they are retried by retryNotifications.
+func (r *ExperimentDeploymentReconciler) notify(
+	ctx context.Context,
+	experimentCR *experimentcontrollercomv1alpha1.ExperimentDeployment,
+	eventType experimentcontrollercomv1alpha1.NotificationEventType) {
+
+	event := newNotificationEvent(experimentCR, eventType)
+	if r.config().Enabled(config.FeatureCloudEvents) {
+		r.publishCloudEvent(ctx, experimentCR, event)
+	}
+	if len(experimentCR.Spec.NotificationProviderRefs) == 0 || !r.config().Enabled(config.FeatureNotifications) {
+		return
+	}
+
+	var statuses []experimentcontrollercomv1alpha1.NotificationStatus
+	for _, ref := range experimentCR.Spec.NotificationProviderRefs {
+		status := experimentcontrollercomv1alpha1.NotificationStatus{Provider: ref.Name}
+		if i := slices.IndexFunc(experimentCR.Status.Notifications, func(s experimentcontrollercomv1alpha1.NotificationStatus) bool {
+			return s.Provider == ref.Name
+		}); i >= 0 {
+			status = experimentCR.Status.Notifications[i]
+		}
+		// A new transition replaces the pending one and gets its own retries
+		status.Failures = 0
+		r.deliverNotification(ctx, experimentCR, &status, event)
+		statuses = append(statuses, status)
+	}
+	experimentCR.Status.Notifications = statuses
+}
+
+// retryNotifications attempts once more the pending deliveries whose backoff has elapsed
+func (r *ExperimentDeploymentReconciler) retryNotifications(ctx context.Context, experimentCR *experimentcontrollercomv1alpha1.ExperimentDeployment) {
+	if !r.config().Enabled(config.FeatureNotifications) {
+		return
+	}
+	for i := range experimentCR.Status.Notifications {
+		status := &experimentCR.Status.Notifications[i]
+		if status.PendingEvent == "" || r.notificationRetryDelay(status) > 0 {
+			continue
+		}
+		if !slices.ContainsFunc(experimentCR.Spec.NotificationProviderRefs, func(ref experimentcontrollercomv1alpha1.LocalObjectReference) bool {
+			return ref.Name == status.Provider
+		}) {
+			status.PendingEvent, status.LastAttemptTime = "", nil
+			continue
+		}
+		r.deliverNotification(ctx, experimentCR, status, newNotificationEvent(experimentCR, status.PendingEvent))
+	}
+}
+
+// notificationRetryDelay returns how long the pending delivery of a provider waits for its next
+// attempt, growing with the failed attempts
+func (r *ExperimentDeploymentReconciler) notificationRetryDelay(status *experimentcontrollercomv1alpha1.NotificationStatus) time.Duration {
+	if status.PendingEvent == "" || status.LastAttemptTime == nil {
+		return 0
+	}
+	return time.Until(status.LastAttemptTime.Add(r.notifier().RetryDelay(status.Failures)))
+}
+
+// requeueNotifications shortens the requeue of the result to the next retry of a pending delivery
+func (r *ExperimentDeploymentReconciler) requeueNotifications(
+	result ctrl.Result,
+	experimentCR *experimentcontrollercomv1alpha1.ExperimentDeployment) ctrl.Result {
+
+	for i := range experimentCR.Status.Notifications {
+		status := &experimentCR.Status.Notifications[i]
+		if status.PendingEvent == "" {
+			continue
+		}
+		// Attempt times are stored with a precision of seconds
+		delay := max(r.notificationRetryDelay(status), time.Second)
+		if result.RequeueAfter == 0 || delay < result.RequeueAfter {
+			result.RequeueAfter = delay
+		}
+	}
+	return result
+}
+
+// deliverNotification attempts once to deliver the event to a NotificationProvider and records
+// the outcome in its status. Failed deliveries stay pending until the retries of the provider are
+// exhausted, unless the webhook rejected the notification.
+func (r *ExperimentDeploymentReconciler) deliverNotification(
+	ctx context.Context,
+	experimentCR *experimentcontrollercomv1alpha1.ExperimentDeployment,
+	status *experimentcontrollercomv1alpha1.NotificationStatus,
+	event notification.Event) {
+
+	now := metav1.Now()
+	delivered, retries, err := r.postNotification(ctx, experimentCR, status.Provider, event)
+	switch {
+	case err != nil:
+		logf.FromContext(ctx).Error(err, "Failed to deliver notification", "provider", status.Provider, "event", event.Type)
+		r.Recorder.Eventf(experimentCR, corev1.EventTypeWarning, "NotificationFailed",
+			"Failed to notify %s about %s: %s", status.Provider, event.Type, err.Error())
+		status.Failures++
+		status.LastError = err.Error()
+		status.PendingEvent, status.LastAttemptTime = event.Type, &now
+		if notification.IsPermanent(err) || status.Failures > retries {
+			status.PendingEvent, status.LastAttemptTime = "", nil
+		}
+	case delivered:
+		status.LastEvent = event.Type
+		status.LastDeliveryTime = &now
+		status.Failures = 0
+		status.LastError = ""
+		status.PendingEvent, status.LastAttemptTime = "", nil
+	default:
+		status.PendingEvent, status.LastAttemptTime = "", nil
+	}
+}
+
+// postNotification posts the event to a NotificationProvider and returns the retries of the
+// provider. It reports false without an error when the provider does not subscribe to the event.
+func (r *ExperimentDeploymentReconciler) postNotification(
+	ctx context.Context,
+	experimentCR *experimentcontrollercomv1alpha1.ExperimentDeployment,
+	providerName string,
+	event notification.Event) (bool, int32, error) {
+
+	retries := int32(defaultNotificationRetries)
+	provider := &experimentcontrollercomv1alpha1.NotificationProvider{}
+	if err := r.Get(ctx, types.NamespacedName{Name: providerName, Namespace: experimentCR.Namespace}, provider); err != nil {
+		return false, retries, fmt.Errorf("failed to get NotificationProvider: %w", err)
+	}
+	if provider.Spec.Retries != nil {
+		retries = *provider.Spec.Retries
+	}
+	if len(provider.Spec.EventTypes) > 0 && !slices.Contains(provider.Spec.EventTypes, event.Type) {
+		return false, retries, nil
+	}
+
+	address := provider.Spec.Address
+	if ref := provider.Spec.SecretRef; ref != nil {
+		secret := &corev1.Secret{}
+		if err := r.Get(ctx, types.NamespacedName{Name: ref.Name, Namespace: provider.Namespace}, secret); err != nil {
+			return false, retries, fmt.Errorf("failed to get Secret %s: %w", ref.Name, err)
+		}
+		address = string(secret.Data[experimentcontrollercomv1alpha1.NotificationSecretAddressKey])
+		if address == "" {
+			return false, retries, fmt.Errorf("secret %s has no %q key", ref.Name, experimentcontrollercomv1alpha1.NotificationSecretAddressKey)
+		}
+	}
+
+	timeout := notification.DefaultTimeout
+	if provider.Spec.Timeout != nil {
+		timeout = provider.Spec.Timeout.Duration
+	}
+	return true, retries, r.notifier().Deliver(ctx, address, provider.Spec.Type, event, timeout)
+}
+
+// notifier returns the Notifier of the reconciler, or one with the default backoff
+func (r *ExperimentDeploymentReconciler) notifier() *notification.Notifier {
+	if r.Notifier == nil {
+		return notification.NewNotifier()
+	}
+	return r.Notifier
+}
@@ -0,0 +1,305 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	experimentcontrollercomv1alpha1 "experimentcontroller.example.com/experiment-deployment/api/v1alpha1"
+	"experimentcontroller.example.com/experiment-deployment/internal/notification"
+)
+
+var _ = Describe("Notifications", func() {
+	var (
+		ctx            context.Context
+		reconciler     *ExperimentDeploymentReconciler
+		recorder       *record.FakeRecorder
+		fakeClient     client.Client
+		namespacedName types.NamespacedName
+		server         *httptest.Server
+		mu             sync.Mutex
+		received       []notification.Event
+		failRequests   bool
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		received = nil
+		failRequests = false
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			defer mu.Unlock()
+			if failRequests {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			body, _ := io.ReadAll(r.Body)
+			event := notification.Event{}
+			Expect(json.Unmarshal(body, &event)).To(Succeed())
+			received = append(received, event)
+		}))
+		DeferCleanup(server.Close)
+
+		scheme := runtime.NewScheme()
+		Expect(experimentcontrollercomv1alpha1.AddToScheme(scheme)).To(Succeed())
+		Expect(appsv1.AddToScheme(scheme)).To(Succeed())
+		Expect(corev1.AddToScheme(scheme)).To(Succeed())
+		fakeClient = fake.NewClientBuilder().WithScheme(scheme).
+			WithStatusSubresource(&experimentcontrollercomv1alpha1.ExperimentDeployment{}).
+			WithInterceptorFuncs(withServerSideApply(interceptor.Funcs{})).Build()
+		recorder = record.NewFakeRecorder(100)
+		reconciler = &ExperimentDeploymentReconciler{
+			Client:   fakeClient,
+			Scheme:   scheme,
+			Recorder: recorder,
+			Notifier: &notification.Notifier{Client: server.Client(), Backoff: wait.Backoff{Duration: time.Millisecond}},
+		}
+		namespacedName = types.NamespacedName{Name: testExperimentCRName, Namespace: testNamespace}
+
+		Expect(fakeClient.Create(ctx, &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: testNamespace},
+			Spec: appsv1.DeploymentSpec{
+				Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "web"}},
+				Template: corev1.PodTemplateSpec{
+					ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": "web"}},
+					Spec: corev1.PodSpec{
+						Containers: []corev1.Container{{Name: "web", Image: "nginx:1.27"}},
+					},
+				},
+			},
+		})).To(Succeed())
+		Expect(fakeClient.Create(ctx, &experimentcontrollercomv1alpha1.ExperimentDeployment{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:       testExperimentCRName,
+				Namespace:  testNamespace,
+				Finalizers: []string{experimentDeploymentFinalizer},
+			},
+			Spec: experimentcontrollercomv1alpha1.ExperimentDeploymentSpec{
+				SourceRef: experimentcontrollercomv1alpha1.SourceRef{
+					Kind: experimentcontrollercomv1alpha1.SourceKindDeployment,
+					Name: "web",
+				},
+				OverrideSpec:             apiextensionsv1.JSON{Raw: []byte(`{}`)},
+				NotificationProviderRefs: []experimentcontrollercomv1alpha1.LocalObjectReference{{Name: "team-hook"}},
+			},
+		})).To(Succeed())
+	})
+
+	createProvider := func(spec experimentcontrollercomv1alpha1.NotificationProviderSpec) {
+		Expect(fakeClient.Create(ctx, &experimentcontrollercomv1alpha1.NotificationProvider{
+			ObjectMeta: metav1.ObjectMeta{Name: "team-hook", Namespace: testNamespace},
+			Spec:       spec,
+		})).To(Succeed())
+	}
+
+	receivedTypes := func() []experimentcontrollercomv1alpha1.NotificationEventType {
+		mu.Lock()
+		defer mu.Unlock()
+		var types []experimentcontrollercomv1alpha1.NotificationEventType
+		for _, event := range received {
+			types = append(types, event.Type)
+		}
+		return types
+	}
+
+	reconcile := func() *experimentcontrollercomv1alpha1.ExperimentDeployment {
+		_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+		Expect(err).NotTo(HaveOccurred())
+		updatedCR := &experimentcontrollercomv1alpha1.ExperimentDeployment{}
+		Expect(fakeClient.Get(ctx, namespacedName, updatedCR)).To(Succeed())
+		return updatedCR
+	}
+
+	makeWorkloadReady := func() {
+		experimentDeployment := &appsv1.Deployment{}
+		Expect(fakeClient.Get(ctx, namespacedName, experimentDeployment)).To(Succeed())
+		experimentDeployment.Status.Replicas = 1
+		experimentDeployment.Status.ReadyReplicas = 1
+		experimentDeployment.Status.UpdatedReplicas = 1
+		experimentDeployment.Status.ObservedGeneration = experimentDeployment.Generation
+		Expect(fakeClient.Status().Update(ctx, experimentDeployment)).To(Succeed())
+	}
+
+	It("should notify the lifecycle transitions once", func() {
+		createProvider(experimentcontrollercomv1alpha1.NotificationProviderSpec{
+			Type:    experimentcontrollercomv1alpha1.NotificationProviderGeneric,
+			Address: server.URL,
+		})
+
+		updatedCR := reconcile()
+		Expect(receivedTypes()).To(Equal([]experimentcontrollercomv1alpha1.NotificationEventType{
+			experimentcontrollercomv1alpha1.NotificationEventCreated,
+		}))
+		Expect(received[0].Experiment).To(Equal(testExperimentCRName))
+		Expect(received[0].SourceName).To(Equal("web"))
+		Expect(received[0].Phase).To(Equal(experimentcontrollercomv1alpha1.ExperimentPhasePending))
+		Expect(updatedCR.Status.Notifications).To(HaveLen(1))
+		Expect(updatedCR.Status.Notifications[0].LastEvent).To(Equal(experimentcontrollercomv1alpha1.NotificationEventCreated))
+		Expect(updatedCR.Status.Notifications[0].LastDeliveryTime).NotTo(BeNil())
+
+		// Reconciling without a transition sends nothing
+		reconcile()
+		Expect(receivedTypes()).To(HaveLen(1))
+
+		makeWorkloadReady()
+		updatedCR = reconcile()
+		Expect(receivedTypes()).To(Equal([]experimentcontrollercomv1alpha1.NotificationEventType{
+			experimentcontrollercomv1alpha1.NotificationEventCreated,
+			experimentcontrollercomv1alpha1.NotificationEventReady,
+		}))
+		Expect(updatedCR.Status.Notifications[0].LastEvent).To(Equal(experimentcontrollercomv1alpha1.NotificationEventReady))
+
+		Expect(fakeClient.Delete(ctx, updatedCR)).To(Succeed())
+		_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(receivedTypes()).To(HaveLen(3))
+		Expect(receivedTypes()[2]).To(Equal(experimentcontrollercomv1alpha1.NotificationEventDeleted))
+	})
+
+	It("should only send the subscribed transitions", func() {
+		createProvider(experimentcontrollercomv1alpha1.NotificationProviderSpec{
+			Type:       experimentcontrollercomv1alpha1.NotificationProviderGeneric,
+			Address:    server.URL,
+			EventTypes: []experimentcontrollercomv1alpha1.NotificationEventType{experimentcontrollercomv1alpha1.NotificationEventReady},
+		})
+
+		updatedCR := reconcile()
+		Expect(receivedTypes()).To(BeEmpty())
+		Expect(updatedCR.Status.Notifications[0].LastDeliveryTime).To(BeNil())
+
+		makeWorkloadReady()
+		reconcile()
+		Expect(receivedTypes()).To(Equal([]experimentcontrollercomv1alpha1.NotificationEventType{
+			experimentcontrollercomv1alpha1.NotificationEventReady,
+		}))
+	})
+
+	It("should read the webhook address from a Secret", func() {
+		Expect(fakeClient.Create(ctx, &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "team-hook-url", Namespace: testNamespace},
+			Data:       map[string][]byte{experimentcontrollercomv1alpha1.NotificationSecretAddressKey: []byte(server.URL + "/hooks/secret-token")},
+		})).To(Succeed())
+		createProvider(experimentcontrollercomv1alpha1.NotificationProviderSpec{
+			Type:      experimentcontrollercomv1alpha1.NotificationProviderGeneric,
+			SecretRef: &experimentcontrollercomv1alpha1.LocalObjectReference{Name: "team-hook-url"},
+		})
+
+		reconcile()
+		Expect(receivedTypes()).To(HaveLen(1))
+	})
+
+	failDeliveries := func(fail bool) {
+		mu.Lock()
+		defer mu.Unlock()
+		failRequests = fail
+	}
+
+	// backdateAttempt makes the pending delivery due for a retry
+	backdateAttempt := func() {
+		updatedCR := &experimentcontrollercomv1alpha1.ExperimentDeployment{}
+		Expect(fakeClient.Get(ctx, namespacedName, updatedCR)).To(Succeed())
+		updatedCR.Status.Notifications[0].LastAttemptTime = &metav1.Time{Time: time.Now().Add(-time.Hour)}
+		Expect(fakeClient.Status().Update(ctx, updatedCR)).To(Succeed())
+	}
+
+	It("should report failed deliveries in status without failing the reconcile", func() {
+		createProvider(experimentcontrollercomv1alpha1.NotificationProviderSpec{
+			Type:    experimentcontrollercomv1alpha1.NotificationProviderSlack,
+			Address: server.URL,
+			Retries: ptr.To(int32(1)),
+		})
+		failDeliveries(true)
+
+		updatedCR := reconcile()
+
+		Expect(updatedCR.Status.Notifications).To(HaveLen(1))
+		status := updatedCR.Status.Notifications[0]
+		Expect(status.Provider).To(Equal("team-hook"))
+		Expect(status.Failures).To(Equal(int32(1)))
+		Expect(status.LastError).To(Equal("webhook responded 500 Internal Server Error"))
+		Expect(status.LastDeliveryTime).To(BeNil())
+		Expect(status.PendingEvent).To(Equal(experimentcontrollercomv1alpha1.NotificationEventCreated))
+		var events []string
+		for len(recorder.Events) > 0 {
+			events = append(events, <-recorder.Events)
+		}
+		Expect(events).To(ContainElement(HavePrefix("Warning NotificationFailed Failed to notify team-hook about Created")))
+
+		// The retries of the provider are exhausted by the next attempt
+		backdateAttempt()
+		updatedCR = reconcile()
+		Expect(updatedCR.Status.Notifications[0].Failures).To(Equal(int32(2)))
+		Expect(updatedCR.Status.Notifications[0].PendingEvent).To(BeEmpty())
+	})
+
+	It("should retry failed deliveries in later reconciles with backoff", func() {
+		createProvider(experimentcontrollercomv1alpha1.NotificationProviderSpec{
+			Type:    experimentcontrollercomv1alpha1.NotificationProviderGeneric,
+			Address: server.URL,
+		})
+		reconciler.Notifier.Backoff = wait.Backoff{Duration: time.Hour}
+		failDeliveries(true)
+
+		result, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.RequeueAfter).To(BeNumerically(">", 0))
+		failDeliveries(false)
+
+		// The delivery waits for its backoff
+		updatedCR := reconcile()
+		Expect(receivedTypes()).To(BeEmpty())
+		Expect(updatedCR.Status.Notifications[0].Failures).To(Equal(int32(1)))
+
+		backdateAttempt()
+		updatedCR = reconcile()
+		Expect(receivedTypes()).To(Equal([]experimentcontrollercomv1alpha1.NotificationEventType{
+			experimentcontrollercomv1alpha1.NotificationEventCreated,
+		}))
+		status := updatedCR.Status.Notifications[0]
+		Expect(status.LastEvent).To(Equal(experimentcontrollercomv1alpha1.NotificationEventCreated))
+		Expect(status.Failures).To(BeZero())
+		Expect(status.PendingEvent).To(BeEmpty())
+		Expect(status.LastAttemptTime).To(BeNil())
+	})
+
+	It("should report missing providers", func() {
+		updatedCR := reconcile()
+
+		Expect(updatedCR.Status.Notifications).To(HaveLen(1))
+		Expect(updatedCR.Status.Notifications[0].LastError).To(ContainSubstring("failed to get NotificationProvider"))
+	})
+
+	It("should map the ended phases to their transitions", func() {
+		experimentCR := &experimentcontrollercomv1alpha1.ExperimentDeployment{}
+		for phase, event := range map[experimentcontrollercomv1alpha1.ExperimentPhase]experimentcontrollercomv1alpha1.NotificationEventType{
+			experimentcontrollercomv1alpha1.ExperimentPhaseAborted:  experimentcontrollercomv1alpha1.NotificationEventAborted,
+			experimentcontrollercomv1alpha1.ExperimentPhaseExpired:  experimentcontrollercomv1alpha1.NotificationEventExpired,
+			experimentcontrollercomv1alpha1.ExperimentPhasePromoted: experimentcontrollercomv1alpha1.NotificationEventPromoted,
+		} {
+			experimentCR.Status.Phase = phase
+			eventType, ok := notificationEvent(experimentCR, experimentcontrollercomv1alpha1.ExperimentPhaseRunning)
+			Expect(ok).To(BeTrue())
+			Expect(eventType).To(Equal(event))
+		}
+	})
+})
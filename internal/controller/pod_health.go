@@ -0,0 +1,225 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	experimentcontrollercomv1alpha1 "experimentcontroller.example.com/experiment-deployment/api/v1alpha1"
+	rolloutsv1alpha1 "github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+)
+
+// degradingPodIssues are the pod issues degrading an experiment, most severe first
+var degradingPodIssues = []string{
+	experimentcontrollercomv1alpha1.ReasonOOMKilled,
+	experimentcontrollercomv1alpha1.ReasonCrashLoopBackOff,
+	experimentcontrollercomv1alpha1.ReasonImagePullBackOff,
+	experimentcontrollercomv1alpha1.ReasonErrImagePull,
+	experimentcontrollercomv1alpha1.ReasonCreateContainerConfigError,
+	experimentcontrollercomv1alpha1.ReasonUnschedulable,
+}
+
+// transientWaitingReasons are waiting reasons of containers starting normally
+var transientWaitingReasons = []string{"ContainerCreating", "PodInitializing"}
+
+// ExperimentPodSelector selects the experiment pods, labeled with the experiment role of the label
+// domain. Only the pods it selects need to be cached.
+func ExperimentPodSelector(labelDomain string) labels.Selector {
+	return labels.SelectorFromSet(labels.Set{labelDomain + "/" + labelRole: ExperimentRoleValue})
+}
+
+// summarizePodHealth summarizes the restarts, problems and startup times of the experiment pods
+// and compares their startup time with the one of the pods of the source workload
+func (r *ExperimentDeploymentReconciler) summarizePodHealth(
+	ctx context.Context,
+	experimentCR *experimentcontrollercomv1alpha1.ExperimentDeployment,
+	source client.Object) (*experimentcontrollercomv1alpha1.PodHealthStatus, error) {
+
+	pods := &corev1.PodList{}
+	if err := r.List(ctx, pods, client.InNamespace(experimentCR.Namespace), client.MatchingLabels{
+		r.labelKey(labelCRName): experimentCR.Name,
+		r.labelKey(labelRole):   ExperimentRoleValue,
+	}); err != nil {
+		return nil, err
+	}
+
+	health := &experimentcontrollercomv1alpha1.PodHealthStatus{}
+	issues := map[string]*experimentcontrollercomv1alpha1.PodIssue{}
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		health.Pods++
+		if _, ready := podReadyTime(pod); ready {
+			health.ReadyPods++
+		}
+		for reason, message := range podIssues(pod) {
+			if issue, found := issues[reason]; found {
+				issue.Pods++
+				continue
+			}
+			issues[reason] = &experimentcontrollercomv1alpha1.PodIssue{Reason: reason, Pods: 1, Message: message}
+		}
+		for _, status := range append(pod.Status.InitContainerStatuses, pod.Status.ContainerStatuses...) {
+			health.Restarts += status.RestartCount
+		}
+	}
+	for _, issue := range issues {
+		health.Issues = append(health.Issues, *issue)
+	}
+	slices.SortFunc(health.Issues, comparePodIssues)
+	health.TimeToReady = averageTimeToReady(pods.Items)
+
+	sourcePods, err := r.listSourcePods(ctx, source)
+	if err != nil {
+		return nil, err
+	}
+	health.SourceTimeToReady = averageTimeToReady(sourcePods)
+	return health, nil
+}
+
+// podIssues returns the problems of a pod by reason, with a message of each
+func podIssues(pod *corev1.Pod) map[string]string {
+	issues := map[string]string{}
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.PodScheduled && condition.Status == corev1.ConditionFalse &&
+			condition.Reason == corev1.PodReasonUnschedulable {
+			issues[experimentcontrollercomv1alpha1.ReasonUnschedulable] = condition.Message
+		}
+	}
+	for _, status := range append(pod.Status.InitContainerStatuses, pod.Status.ContainerStatuses...) {
+		if waiting := status.State.Waiting; waiting != nil && waiting.Reason != "" &&
+			!slices.Contains(transientWaitingReasons, waiting.Reason) {
+			issues[waiting.Reason] = fmt.Sprintf("container %s: %s", status.Name, waiting.Message)
+		}
+		for _, terminated := range []*corev1.ContainerStateTerminated{status.State.Terminated, status.LastTerminationState.Terminated} {
+			if terminated != nil && terminated.Reason == experimentcontrollercomv1alpha1.ReasonOOMKilled {
+				issues[experimentcontrollercomv1alpha1.ReasonOOMKilled] = fmt.Sprintf("container %s exceeded its memory limit", status.Name)
+			}
+		}
+	}
+	return issues
+}
+
+// comparePodIssues orders degrading issues by severity before the others, then by affected pods
+func comparePodIssues(a, b experimentcontrollercomv1alpha1.PodIssue) int {
+	severity := func(issue experimentcontrollercomv1alpha1.PodIssue) int {
+		if i := slices.Index(degradingPodIssues, issue.Reason); i >= 0 {
+			return i
+		}
+		return len(degradingPodIssues)
+	}
+	if severityA, severityB := severity(a), severity(b); severityA != severityB {
+		return severityA - severityB
+	}
+	if a.Pods != b.Pods {
+		return int(b.Pods - a.Pods)
+	}
+	if a.Reason < b.Reason {
+		return -1
+	}
+	if a.Reason > b.Reason {
+		return 1
+	}
+	return 0
+}
+
+// podHealthNotReadyStatus replaces the reason and message of a not ready experiment with the most
+// severe degrading issue of its pods
+func podHealthNotReadyStatus(podHealth *experimentcontrollercomv1alpha1.PodHealthStatus, reason, message string) (string, string) {
+	if podHealth == nil {
+		return reason, message
+	}
+	for _, issue := range podHealth.Issues {
+		if slices.Contains(degradingPodIssues, issue.Reason) {
+			return issue.Reason, fmt.Sprintf("%d of %d experiment pods are %s: %s", issue.Pods, podHealth.Pods, issue.Reason, issue.Message)
+		}
+	}
+	return reason, message
+}
+
+// podReadyTime returns when a pod became ready
+func podReadyTime(pod *corev1.Pod) (metav1.Time, bool) {
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.PodReady && condition.Status == corev1.ConditionTrue {
+			return condition.LastTransitionTime, true
+		}
+	}
+	return metav1.Time{}, false
+}
+
+// averageTimeToReady returns the average time the ready pods took from creation to readiness
+func averageTimeToReady(pods []corev1.Pod) *metav1.Duration {
+	var total time.Duration
+	var ready int64
+	for i := range pods {
+		readyTime, isReady := podReadyTime(&pods[i])
+		if !isReady || readyTime.IsZero() || pods[i].CreationTimestamp.IsZero() {
+			continue
+		}
+		total += readyTime.Sub(pods[i].CreationTimestamp.Time)
+		ready++
+	}
+	if ready == 0 {
+		return nil
+	}
+	return &metav1.Duration{Duration: (total / time.Duration(ready)).Round(time.Second)}
+}
+
+// listSourcePods lists the pods of the source workload, leaving out experiment pods sharing its
+// labels. The pods are read from the API server, only the experiment pods are cached.
+func (r *ExperimentDeploymentReconciler) listSourcePods(ctx context.Context, source client.Object) ([]corev1.Pod, error) {
+	var labelSelector *metav1.LabelSelector
+	switch source := source.(type) {
+	case *appsv1.Deployment:
+		labelSelector = source.Spec.Selector
+	case *appsv1.StatefulSet:
+		labelSelector = source.Spec.Selector
+	case *rolloutsv1alpha1.Rollout:
+		labelSelector = source.Spec.Selector
+	}
+	if labelSelector == nil {
+		return nil, nil
+	}
+
+	selector, err := metav1.LabelSelectorAsSelector(labelSelector)
+	if err != nil || selector.Empty() {
+		return nil, nil
+	}
+	notExperiment, err := labels.NewRequirement(r.labelKey(labelRole), selection.NotEquals, []string{ExperimentRoleValue})
+	if err != nil {
+		return nil, err
+	}
+	pods := &corev1.PodList{}
+	if err := r.sourceReader().List(ctx, pods, client.InNamespace(source.GetNamespace()), client.MatchingLabelsSelector{Selector: selector.Add(*notExperiment)}); err != nil {
+		if k8serrors.IsForbidden(err) {
+			// Namespace-scoped installations cannot list pods of other namespaces
+			return nil, nil
+		}
+		return nil, err
+	}
+	return pods.Items, nil
+}
@@ -0,0 +1,215 @@
+package controller
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	experimentcontrollercomv1alpha1 "experimentcontroller.example.com/experiment-deployment/api/v1alpha1"
+)
+
+var _ = Describe("Pod Health", func() {
+	var (
+		ctx            context.Context
+		reconciler     *ExperimentDeploymentReconciler
+		fakeClient     client.Client
+		namespacedName types.NamespacedName
+		created        time.Time
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		scheme := runtime.NewScheme()
+		Expect(experimentcontrollercomv1alpha1.AddToScheme(scheme)).To(Succeed())
+		Expect(appsv1.AddToScheme(scheme)).To(Succeed())
+		Expect(corev1.AddToScheme(scheme)).To(Succeed())
+		fakeClient = fake.NewClientBuilder().WithScheme(scheme).
+			WithStatusSubresource(&experimentcontrollercomv1alpha1.ExperimentDeployment{}).
+			WithInterceptorFuncs(withServerSideApply(interceptor.Funcs{})).Build()
+		reconciler = &ExperimentDeploymentReconciler{
+			Client:   fakeClient,
+			Scheme:   scheme,
+			Recorder: record.NewFakeRecorder(100),
+		}
+		namespacedName = types.NamespacedName{Name: testExperimentCRName, Namespace: testNamespace}
+		created = time.Now().Add(-time.Hour).Truncate(time.Second)
+
+		Expect(fakeClient.Create(ctx, &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: testNamespace},
+			Spec: appsv1.DeploymentSpec{
+				Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "web"}},
+				Template: corev1.PodTemplateSpec{
+					ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": "web"}},
+					Spec: corev1.PodSpec{
+						Containers: []corev1.Container{{Name: "web", Image: "nginx:1.27"}},
+					},
+				},
+			},
+		})).To(Succeed())
+		Expect(fakeClient.Create(ctx, &experimentcontrollercomv1alpha1.ExperimentDeployment{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:       testExperimentCRName,
+				Namespace:  testNamespace,
+				Finalizers: []string{experimentDeploymentFinalizer},
+			},
+			Spec: experimentcontrollercomv1alpha1.ExperimentDeploymentSpec{
+				SourceRef: experimentcontrollercomv1alpha1.SourceRef{
+					Kind: experimentcontrollercomv1alpha1.SourceKindDeployment,
+					Name: "web",
+				},
+				OverrideSpec: apiextensionsv1.JSON{Raw: []byte(`{"template":{"spec":{"containers":[{"name":"web","image":"nginx:broken"}]}}}`)},
+			},
+		})).To(Succeed())
+	})
+
+	readyCondition := func(readyAfter time.Duration) corev1.PodCondition {
+		return corev1.PodCondition{
+			Type:               corev1.PodReady,
+			Status:             corev1.ConditionTrue,
+			LastTransitionTime: metav1.NewTime(created.Add(readyAfter)),
+		}
+	}
+
+	createPod := func(name string, experiment bool, status corev1.PodStatus) {
+		podLabels := map[string]string{"app": "web"}
+		if experiment {
+			podLabels["experiment-controller.example.com/cr-name"] = testExperimentCRName
+			podLabels["experiment-controller.example.com/role"] = ExperimentRoleValue
+		}
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              name,
+				Namespace:         testNamespace,
+				Labels:            podLabels,
+				CreationTimestamp: metav1.NewTime(created),
+			},
+			Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "web", Image: "nginx"}}},
+		}
+		Expect(fakeClient.Create(ctx, pod)).To(Succeed())
+		pod.Status = status
+		Expect(fakeClient.Status().Update(ctx, pod)).To(Succeed())
+	}
+
+	reconcile := func() *experimentcontrollercomv1alpha1.ExperimentDeployment {
+		_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+		Expect(err).NotTo(HaveOccurred())
+		updatedCR := &experimentcontrollercomv1alpha1.ExperimentDeployment{}
+		Expect(fakeClient.Get(ctx, namespacedName, updatedCR)).To(Succeed())
+		return updatedCR
+	}
+
+	It("should summarize the experiment pods and degrade on crash loops", func() {
+		createPod("web-source", false, corev1.PodStatus{Conditions: []corev1.PodCondition{readyCondition(10 * time.Second)}})
+		createPod("exp-ready", true, corev1.PodStatus{
+			Conditions:        []corev1.PodCondition{readyCondition(30 * time.Second)},
+			ContainerStatuses: []corev1.ContainerStatus{{Name: "web", RestartCount: 1}},
+		})
+		createPod("exp-crashing", true, corev1.PodStatus{
+			ContainerStatuses: []corev1.ContainerStatus{{
+				Name:                 "web",
+				RestartCount:         4,
+				State:                corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "CrashLoopBackOff", Message: "back-off 40s restarting failed container"}},
+				LastTerminationState: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{Reason: "Error", ExitCode: 1}},
+			}},
+		})
+		createPod("exp-pending", true, corev1.PodStatus{
+			Conditions: []corev1.PodCondition{{
+				Type:    corev1.PodScheduled,
+				Status:  corev1.ConditionFalse,
+				Reason:  corev1.PodReasonUnschedulable,
+				Message: "0/3 nodes are available: 3 Insufficient memory.",
+			}},
+		})
+
+		updatedCR := reconcile()
+
+		podHealth := updatedCR.Status.PodHealth
+		Expect(podHealth).NotTo(BeNil())
+		Expect(podHealth.Pods).To(Equal(int32(3)))
+		Expect(podHealth.ReadyPods).To(Equal(int32(1)))
+		Expect(podHealth.Restarts).To(Equal(int32(5)))
+		Expect(podHealth.Issues).To(Equal([]experimentcontrollercomv1alpha1.PodIssue{
+			{Reason: "CrashLoopBackOff", Pods: 1, Message: "container web: back-off 40s restarting failed container"},
+			{Reason: "Unschedulable", Pods: 1, Message: "0/3 nodes are available: 3 Insufficient memory."},
+		}))
+		Expect(podHealth.TimeToReady.Duration).To(Equal(30 * time.Second))
+		Expect(podHealth.SourceTimeToReady.Duration).To(Equal(10 * time.Second))
+
+		readyCondition := meta.FindStatusCondition(updatedCR.Status.Conditions, ConditionTypeReady)
+		Expect(readyCondition.Reason).To(Equal(experimentcontrollercomv1alpha1.ReasonCrashLoopBackOff))
+		Expect(readyCondition.Message).To(Equal("1 of 3 experiment pods are CrashLoopBackOff: container web: back-off 40s restarting failed container"))
+		Expect(meta.IsStatusConditionTrue(updatedCR.Status.Conditions, ConditionTypeDegraded)).To(BeTrue())
+	})
+
+	It("should only list experiment pods from the cache", func() {
+		var cachedSelectors []string
+		reconciler.APIReader = fakeClient
+		reconciler.Client = interceptor.NewClient(fakeClient.(client.WithWatch), interceptor.Funcs{
+			List: func(ctx context.Context, c client.WithWatch, list client.ObjectList, opts ...client.ListOption) error {
+				if _, ok := list.(*corev1.PodList); ok {
+					listOpts := &client.ListOptions{}
+					listOpts.ApplyOptions(opts)
+					cachedSelectors = append(cachedSelectors, listOpts.LabelSelector.String())
+				}
+				return c.List(ctx, list, opts...)
+			},
+		})
+		createPod("web-source", false, corev1.PodStatus{Conditions: []corev1.PodCondition{readyCondition(10 * time.Second)}})
+		createPod("exp-ready", true, corev1.PodStatus{Conditions: []corev1.PodCondition{readyCondition(30 * time.Second)}})
+
+		updatedCR := reconcile()
+
+		Expect(updatedCR.Status.PodHealth.SourceTimeToReady.Duration).To(Equal(10 * time.Second))
+		Expect(cachedSelectors).NotTo(BeEmpty())
+		for _, selector := range cachedSelectors {
+			Expect(selector).To(ContainSubstring("experiment-controller.example.com/role=experiment"))
+		}
+	})
+
+	It("should report OOMKilled containers before other issues", func() {
+		createPod("exp-oom", true, corev1.PodStatus{
+			ContainerStatuses: []corev1.ContainerStatus{{
+				Name:                 "web",
+				RestartCount:         2,
+				State:                corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "CrashLoopBackOff"}},
+				LastTerminationState: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{Reason: "OOMKilled", ExitCode: 137}},
+			}},
+		})
+
+		updatedCR := reconcile()
+
+		Expect(updatedCR.Status.PodHealth.Issues).To(HaveLen(2))
+		Expect(updatedCR.Status.PodHealth.Issues[0].Reason).To(Equal(experimentcontrollercomv1alpha1.ReasonOOMKilled))
+		Expect(meta.FindStatusCondition(updatedCR.Status.Conditions, ConditionTypeReady).Reason).To(
+			Equal(experimentcontrollercomv1alpha1.ReasonOOMKilled))
+	})
+
+	It("should keep progressing while pods start normally", func() {
+		createPod("exp-starting", true, corev1.PodStatus{
+			ContainerStatuses: []corev1.ContainerStatus{{
+				Name:  "web",
+				State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "ContainerCreating"}},
+			}},
+		})
+
+		updatedCR := reconcile()
+
+		Expect(updatedCR.Status.PodHealth.Issues).To(BeEmpty())
+		Expect(updatedCR.Status.PodHealth.TimeToReady).To(BeNil())
+		Expect(meta.IsStatusConditionTrue(updatedCR.Status.Conditions, ConditionTypeProgressing)).To(BeTrue())
+	})
+})
@@ -0,0 +1,145 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	experimentcontrollercomv1alpha1 "experimentcontroller.example.com/experiment-deployment/api/v1alpha1"
+)
+
+// isSourceReferencePermitted checks that an experiment referencing a workload of another namespace
+// is allowed to by an ExperimentReferenceGrant in the source namespace. Without a grant it sets the
+// RefNotPermitted condition and returns false, so nothing is rendered, and deletes the experiment
+// workload cloned while a revoked grant still allowed it.
+func (r *ExperimentDeploymentReconciler) isSourceReferencePermitted(
+	ctx context.Context,
+	experimentCR *experimentcontrollercomv1alpha1.ExperimentDeployment,
+	sourceNamespace string) (bool, error) {
+
+	if sourceNamespace == experimentCR.Namespace {
+		return true, nil
+	}
+	log := logf.FromContext(ctx)
+
+	grants := &experimentcontrollercomv1alpha1.ExperimentReferenceGrantList{}
+	if err := r.List(ctx, grants, client.InNamespace(sourceNamespace)); err != nil && !meta.IsNoMatchError(err) {
+		log.Error(err, "Failed to list ExperimentReferenceGrants", "sourceNamespace", sourceNamespace)
+		return false, err
+	}
+	for _, grant := range grants.Items {
+		if referenceGrantPermits(grant, experimentCR.Namespace, experimentCR.Spec.SourceRef) {
+			return true, nil
+		}
+	}
+
+	message := fmt.Sprintf("No ExperimentReferenceGrant in namespace %s allows namespace %s to reference %s %s",
+		sourceNamespace, experimentCR.Namespace, experimentCR.Spec.SourceRef.Kind, experimentCR.Spec.SourceRef.Name)
+	log.Info("Cross-namespace source reference not permitted", "sourceNamespace", sourceNamespace, "sourceName", experimentCR.Spec.SourceRef.Name)
+	r.Recorder.Event(experimentCR, corev1.EventTypeWarning, "RefNotPermitted", message)
+	r.updateStatusConditions(experimentCR, experimentcontrollercomv1alpha1.ReasonRefNotPermitted, message)
+	if err := r.deleteUnpermittedWorkload(ctx, experimentCR); err != nil {
+		return false, err
+	}
+	return false, nil
+}
+
+// deleteUnpermittedWorkload deletes the experiment workload of an experiment whose source reference
+// is no longer permitted, so the clone stops running
+func (r *ExperimentDeploymentReconciler) deleteUnpermittedWorkload(
+	ctx context.Context,
+	experimentCR *experimentcontrollercomv1alpha1.ExperimentDeployment) error {
+
+	ref := experimentCR.Status.ExperimentResourceRef
+	if ref == nil {
+		return nil
+	}
+	gv, ok := r.sourceKinds(true)[experimentcontrollercomv1alpha1.SourceKind(ref.Kind)]
+	if !ok {
+		return fmt.Errorf("unsupported experiment workload kind: %s", ref.Kind)
+	}
+	workload := &unstructured.Unstructured{}
+	workload.SetGroupVersionKind(gv.WithKind(ref.Kind))
+	workload.SetName(ref.Name)
+	workload.SetNamespace(ref.Namespace)
+
+	log := logf.FromContext(ctx)
+	log.Info("Deleting the experiment workload, its source reference is no longer permitted", "kind", ref.Kind, "name", ref.Name)
+	if err := r.Delete(ctx, workload, client.PropagationPolicy(metav1.DeletePropagationBackground)); err != nil && !k8serrors.IsNotFound(err) {
+		log.Error(err, "Failed to delete the experiment workload", "kind", ref.Kind, "name", ref.Name)
+		return err
+	}
+	r.Recorder.Eventf(experimentCR, corev1.EventTypeNormal, "WorkloadDeleted",
+		"Deleted experiment %s %s, its source reference is no longer permitted", ref.Kind, ref.Name)
+	experimentCR.Status.ExperimentResourceRef = nil
+	experimentCR.Status.ReadyReplicas = 0
+	experimentCR.Status.WorkloadGeneration = 0
+	return nil
+}
+
+// referenceGrantPermits reports whether the grant allows experiments of the namespace to reference the source
+func referenceGrantPermits(
+	grant experimentcontrollercomv1alpha1.ExperimentReferenceGrant,
+	namespace string,
+	sourceRef experimentcontrollercomv1alpha1.SourceRef) bool {
+
+	fromPermitted := false
+	for _, from := range grant.Spec.From {
+		if from.Namespace == namespace {
+			fromPermitted = true
+			break
+		}
+	}
+	if !fromPermitted {
+		return false
+	}
+	for _, to := range grant.Spec.To {
+		if to.Kind == sourceRef.Kind && (to.Name == "" || to.Name == sourceRef.Name) {
+			return true
+		}
+	}
+	return false
+}
+
+// experimentsForReferenceGrant re-evaluates the ExperimentDeployments referencing workloads of the
+// namespace of a changed ExperimentReferenceGrant
+func (r *ExperimentDeploymentReconciler) experimentsForReferenceGrant(ctx context.Context, obj client.Object) []reconcile.Request {
+	experiments := &experimentcontrollercomv1alpha1.ExperimentDeploymentList{}
+	if err := r.List(ctx, experiments); err != nil {
+		logf.FromContext(ctx).Error(err, "Failed to list ExperimentDeployments for ExperimentReferenceGrant change")
+		return nil
+	}
+	var requests []reconcile.Request
+	for _, experiment := range experiments.Items {
+		if experiment.Spec.SourceRef.Namespace != obj.GetNamespace() || experiment.Namespace == obj.GetNamespace() {
+			continue
+		}
+		requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: experiment.Name, Namespace: experiment.Namespace}})
+	}
+	return requests
+}
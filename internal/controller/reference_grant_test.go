@@ -0,0 +1,161 @@
+package controller
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	experimentcontrollercomv1alpha1 "experimentcontroller.example.com/experiment-deployment/api/v1alpha1"
+)
+
+var _ = Describe("Experiment Reference Grants", func() {
+	const sourceNamespace = "production"
+
+	var (
+		ctx              context.Context
+		reconciler       *ExperimentDeploymentReconciler
+		fakeClient       client.Client
+		sourceDeployment *appsv1.Deployment
+		experimentCR     *experimentcontrollercomv1alpha1.ExperimentDeployment
+		grant            *experimentcontrollercomv1alpha1.ExperimentReferenceGrant
+		namespacedName   types.NamespacedName
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		scheme := runtime.NewScheme()
+		Expect(experimentcontrollercomv1alpha1.AddToScheme(scheme)).To(Succeed())
+		Expect(appsv1.AddToScheme(scheme)).To(Succeed())
+		Expect(corev1.AddToScheme(scheme)).To(Succeed())
+		fakeClient = fake.NewClientBuilder().WithScheme(scheme).WithInterceptorFuncs(withServerSideApply(interceptor.Funcs{})).WithStatusSubresource(&experimentcontrollercomv1alpha1.ExperimentDeployment{}).Build()
+		reconciler = &ExperimentDeploymentReconciler{
+			Client:   fakeClient,
+			Scheme:   scheme,
+			Recorder: record.NewFakeRecorder(100),
+		}
+
+		namespacedName = types.NamespacedName{Name: testExperimentCRName, Namespace: testNamespace}
+		sourceDeployment = &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: sourceNamespace},
+			Spec: appsv1.DeploymentSpec{
+				Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "web"}},
+				Template: corev1.PodTemplateSpec{
+					ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": "web"}},
+					Spec: corev1.PodSpec{
+						Containers: []corev1.Container{{Name: "web", Image: "nginx:1.27"}},
+					},
+				},
+			},
+		}
+		experimentCR = &experimentcontrollercomv1alpha1.ExperimentDeployment{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:       testExperimentCRName,
+				Namespace:  testNamespace,
+				Finalizers: []string{experimentDeploymentFinalizer},
+			},
+			Spec: experimentcontrollercomv1alpha1.ExperimentDeploymentSpec{
+				SourceRef: experimentcontrollercomv1alpha1.SourceRef{
+					Kind:      experimentcontrollercomv1alpha1.SourceKindDeployment,
+					Name:      "web",
+					Namespace: sourceNamespace,
+				},
+				OverrideSpec: apiextensionsv1.JSON{Raw: []byte(`{}`)},
+			},
+		}
+		grant = &experimentcontrollercomv1alpha1.ExperimentReferenceGrant{
+			ObjectMeta: metav1.ObjectMeta{Name: "allow-experiments", Namespace: sourceNamespace},
+			Spec: experimentcontrollercomv1alpha1.ExperimentReferenceGrantSpec{
+				From: []experimentcontrollercomv1alpha1.ReferenceGrantFrom{{Namespace: testNamespace}},
+				To:   []experimentcontrollercomv1alpha1.ReferenceGrantTo{{Kind: experimentcontrollercomv1alpha1.SourceKindDeployment, Name: "web"}},
+			},
+		}
+		Expect(fakeClient.Create(ctx, sourceDeployment)).To(Succeed())
+	})
+
+	expectNotPermitted := func() {
+		_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+		Expect(err).NotTo(HaveOccurred())
+
+		err = fakeClient.Get(ctx, namespacedName, &appsv1.Deployment{})
+		Expect(k8serrors.IsNotFound(err)).To(BeTrue())
+
+		updatedCR := &experimentcontrollercomv1alpha1.ExperimentDeployment{}
+		Expect(fakeClient.Get(ctx, namespacedName, updatedCR)).To(Succeed())
+		readyCondition := meta.FindStatusCondition(updatedCR.Status.Conditions, ConditionTypeReady)
+		Expect(readyCondition).NotTo(BeNil())
+		Expect(readyCondition.Reason).To(Equal("RefNotPermitted"))
+	}
+
+	It("should not render experiments of other namespaces without a grant", func() {
+		Expect(fakeClient.Create(ctx, experimentCR)).To(Succeed())
+		expectNotPermitted()
+	})
+
+	It("should not render experiments when the grant is for another namespace or workload", func() {
+		grant.Spec.From = []experimentcontrollercomv1alpha1.ReferenceGrantFrom{{Namespace: "other"}}
+		Expect(fakeClient.Create(ctx, grant)).To(Succeed())
+		Expect(fakeClient.Create(ctx, experimentCR)).To(Succeed())
+		expectNotPermitted()
+
+		Expect(fakeClient.Get(ctx, client.ObjectKeyFromObject(grant), grant)).To(Succeed())
+		grant.Spec.From = []experimentcontrollercomv1alpha1.ReferenceGrantFrom{{Namespace: testNamespace}}
+		grant.Spec.To = []experimentcontrollercomv1alpha1.ReferenceGrantTo{{Kind: experimentcontrollercomv1alpha1.SourceKindDeployment, Name: "api"}}
+		Expect(fakeClient.Update(ctx, grant)).To(Succeed())
+		expectNotPermitted()
+	})
+
+	It("should render experiments permitted by a grant in the source namespace", func() {
+		Expect(fakeClient.Create(ctx, grant)).To(Succeed())
+		Expect(fakeClient.Create(ctx, experimentCR)).To(Succeed())
+
+		_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(fakeClient.Get(ctx, namespacedName, &appsv1.Deployment{})).To(Succeed())
+	})
+
+	It("should delete the experiment workload once the grant is revoked", func() {
+		Expect(fakeClient.Create(ctx, grant)).To(Succeed())
+		Expect(fakeClient.Create(ctx, experimentCR)).To(Succeed())
+		_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(fakeClient.Get(ctx, namespacedName, &appsv1.Deployment{})).To(Succeed())
+
+		Expect(fakeClient.Delete(ctx, grant)).To(Succeed())
+		expectNotPermitted()
+
+		updatedCR := &experimentcontrollercomv1alpha1.ExperimentDeployment{}
+		Expect(fakeClient.Get(ctx, namespacedName, updatedCR)).To(Succeed())
+		Expect(updatedCR.Status.ExperimentResourceRef).To(BeNil())
+		Expect(updatedCR.Status.ReadyReplicas).To(BeZero())
+	})
+
+	It("should grant all workloads of a kind when the name is empty", func() {
+		grant.Spec.To = []experimentcontrollercomv1alpha1.ReferenceGrantTo{{Kind: experimentcontrollercomv1alpha1.SourceKindDeployment}}
+		Expect(referenceGrantPermits(*grant, testNamespace, experimentCR.Spec.SourceRef)).To(BeTrue())
+
+		experimentCR.Spec.SourceRef.Kind = experimentcontrollercomv1alpha1.SourceKindStatefulSet
+		Expect(referenceGrantPermits(*grant, testNamespace, experimentCR.Spec.SourceRef)).To(BeFalse())
+	})
+
+	It("should enqueue the experiments referencing the namespace of a grant", func() {
+		Expect(fakeClient.Create(ctx, experimentCR)).To(Succeed())
+		Expect(reconciler.experimentsForReferenceGrant(ctx, grant)).To(ConsistOf(ctrl.Request{NamespacedName: namespacedName}))
+
+		grant.Namespace = "staging"
+		Expect(reconciler.experimentsForReferenceGrant(ctx, grant)).To(BeEmpty())
+	})
+})
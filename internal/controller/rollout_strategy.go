@@ -0,0 +1,104 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	rolloutsv1alpha1 "github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+
+	experimentcontrollercomv1alpha1 "experimentcontroller.example.com/experiment-deployment/api/v1alpha1"
+)
+
+// keptRolloutStrategyFields returns the source strategy fields the experiment opted back in to
+func keptRolloutStrategyFields(experimentCR *experimentcontrollercomv1alpha1.ExperimentDeployment) map[experimentcontrollercomv1alpha1.RolloutStrategyField]bool {
+	kept := make(map[experimentcontrollercomv1alpha1.RolloutStrategyField]bool)
+	if experimentCR.Spec.RolloutStrategy == nil {
+		return kept
+	}
+	for _, field := range experimentCR.Spec.RolloutStrategy.Keep {
+		kept[field] = true
+	}
+	return kept
+}
+
+// sanitizeRolloutStrategy converts the source Rollout strategy to a simple canary strategy
+// for the experiment Rollout. Services, traffic routing, analysis and steps shared with the
+// source Rollout are dropped unless kept. Blue-green strategies always become canary, as
+// their active and preview Services would be shared with the source Rollout.
+func sanitizeRolloutStrategy(
+	source rolloutsv1alpha1.RolloutStrategy,
+	kept map[experimentcontrollercomv1alpha1.RolloutStrategyField]bool) rolloutsv1alpha1.RolloutStrategy {
+
+	sanitized := &rolloutsv1alpha1.CanaryStrategy{}
+
+	if source.BlueGreen != nil {
+		if kept[experimentcontrollercomv1alpha1.RolloutStrategyFieldAntiAffinity] {
+			sanitized.AntiAffinity = source.BlueGreen.AntiAffinity.DeepCopy()
+		}
+		return rolloutsv1alpha1.RolloutStrategy{Canary: sanitized}
+	}
+	if source.Canary == nil {
+		return rolloutsv1alpha1.RolloutStrategy{Canary: sanitized}
+	}
+
+	canary := source.Canary.DeepCopy()
+	sanitized.MaxSurge = canary.MaxSurge
+	sanitized.MaxUnavailable = canary.MaxUnavailable
+
+	if kept[experimentcontrollercomv1alpha1.RolloutStrategyFieldCanaryService] {
+		sanitized.CanaryService = canary.CanaryService
+		sanitized.CanaryMetadata = canary.CanaryMetadata
+	}
+	if kept[experimentcontrollercomv1alpha1.RolloutStrategyFieldStableService] {
+		sanitized.StableService = canary.StableService
+		sanitized.StableMetadata = canary.StableMetadata
+	}
+	if kept[experimentcontrollercomv1alpha1.RolloutStrategyFieldTrafficRouting] {
+		sanitized.TrafficRouting = canary.TrafficRouting
+		sanitized.PingPong = canary.PingPong
+		sanitized.DynamicStableScale = canary.DynamicStableScale
+		sanitized.AbortScaleDownDelaySeconds = canary.AbortScaleDownDelaySeconds
+	}
+	if kept[experimentcontrollercomv1alpha1.RolloutStrategyFieldAnalysis] {
+		sanitized.Analysis = canary.Analysis
+	}
+	if kept[experimentcontrollercomv1alpha1.RolloutStrategyFieldAntiAffinity] {
+		sanitized.AntiAffinity = canary.AntiAffinity
+	}
+	if kept[experimentcontrollercomv1alpha1.RolloutStrategyFieldSteps] {
+		sanitized.Steps = sanitizeCanarySteps(canary.Steps, kept)
+	}
+
+	return rolloutsv1alpha1.RolloutStrategy{Canary: sanitized}
+}
+
+// sanitizeCanarySteps drops the steps depending on strategy fields that were not kept
+func sanitizeCanarySteps(
+	steps []rolloutsv1alpha1.CanaryStep,
+	kept map[experimentcontrollercomv1alpha1.RolloutStrategyField]bool) []rolloutsv1alpha1.CanaryStep {
+
+	var sanitized []rolloutsv1alpha1.CanaryStep
+	for _, step := range steps {
+		if (step.Analysis != nil || step.Experiment != nil) && !kept[experimentcontrollercomv1alpha1.RolloutStrategyFieldAnalysis] {
+			continue
+		}
+		if (step.SetHeaderRoute != nil || step.SetMirrorRoute != nil || step.Plugin != nil) && !kept[experimentcontrollercomv1alpha1.RolloutStrategyFieldTrafficRouting] {
+			continue
+		}
+		sanitized = append(sanitized, step)
+	}
+	return sanitized
+}
@@ -0,0 +1,168 @@
+package controller
+
+import (
+	rolloutsv1alpha1 "github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	experimentcontrollercomv1alpha1 "experimentcontroller.example.com/experiment-deployment/api/v1alpha1"
+)
+
+var _ = Describe("Rollout Strategy Sanitizer", func() {
+	var (
+		reconciler    *ExperimentDeploymentReconciler
+		sourceRollout *rolloutsv1alpha1.Rollout
+		experimentCR  *experimentcontrollercomv1alpha1.ExperimentDeployment
+	)
+
+	BeforeEach(func() {
+		reconciler = &ExperimentDeploymentReconciler{}
+
+		maxSurge := intstr.FromString("25%")
+		weight := int32(20)
+		sourceRollout = &rolloutsv1alpha1.Rollout{
+			ObjectMeta: metav1.ObjectMeta{Name: "source-rollout", Namespace: testNamespace},
+			Spec: rolloutsv1alpha1.RolloutSpec{
+				Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "source-app"}},
+				Template: corev1.PodTemplateSpec{
+					ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": "source-app"}},
+					Spec: corev1.PodSpec{
+						Containers: []corev1.Container{{Name: "test-container", Image: "nginx:1.14"}},
+					},
+				},
+				Strategy: rolloutsv1alpha1.RolloutStrategy{
+					Canary: &rolloutsv1alpha1.CanaryStrategy{
+						CanaryService: "source-canary",
+						StableService: "source-stable",
+						MaxSurge:      &maxSurge,
+						TrafficRouting: &rolloutsv1alpha1.RolloutTrafficRouting{
+							Istio: &rolloutsv1alpha1.IstioTrafficRouting{
+								VirtualService: &rolloutsv1alpha1.IstioVirtualService{Name: "source-vs"},
+							},
+						},
+						Analysis: &rolloutsv1alpha1.RolloutAnalysisBackground{
+							RolloutAnalysis: rolloutsv1alpha1.RolloutAnalysis{
+								Templates: []rolloutsv1alpha1.AnalysisTemplateRef{{TemplateName: "success-rate"}},
+							},
+						},
+						AntiAffinity: &rolloutsv1alpha1.AntiAffinity{
+							PreferredDuringSchedulingIgnoredDuringExecution: &rolloutsv1alpha1.PreferredDuringSchedulingIgnoredDuringExecution{Weight: 1},
+						},
+						Steps: []rolloutsv1alpha1.CanaryStep{
+							{SetWeight: &weight},
+							{Analysis: &rolloutsv1alpha1.RolloutAnalysis{
+								Templates: []rolloutsv1alpha1.AnalysisTemplateRef{{TemplateName: "success-rate"}},
+							}},
+							{SetMirrorRoute: &rolloutsv1alpha1.SetMirrorRoute{Name: "mirror"}},
+							{Pause: &rolloutsv1alpha1.RolloutPause{}},
+						},
+					},
+				},
+			},
+		}
+		experimentCR = &experimentcontrollercomv1alpha1.ExperimentDeployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "experiment-cr", Namespace: testNamespace},
+			Spec: experimentcontrollercomv1alpha1.ExperimentDeploymentSpec{
+				SourceRef: experimentcontrollercomv1alpha1.SourceRef{
+					Kind: experimentcontrollercomv1alpha1.SourceKindRollout,
+					Name: "source-rollout",
+				},
+			},
+		}
+	})
+
+	It("should convert the strategy to a simple canary by default", func() {
+		result, err := reconciler.constructExperimentRollout(experimentCR, sourceRollout)
+		Expect(err).NotTo(HaveOccurred())
+
+		canary := result.Spec.Strategy.Canary
+		Expect(canary).NotTo(BeNil())
+		Expect(canary.CanaryService).To(BeEmpty())
+		Expect(canary.StableService).To(BeEmpty())
+		Expect(canary.TrafficRouting).To(BeNil())
+		Expect(canary.Analysis).To(BeNil())
+		Expect(canary.AntiAffinity).To(BeNil())
+		Expect(canary.Steps).To(BeEmpty())
+		Expect(canary.MaxSurge.String()).To(Equal("25%"))
+
+		// The source Rollout must not be modified
+		Expect(sourceRollout.Spec.Strategy.Canary.CanaryService).To(Equal("source-canary"))
+	})
+
+	It("should keep the opted-in fields and drop steps depending on stripped fields", func() {
+		experimentCR.Spec.RolloutStrategy = &experimentcontrollercomv1alpha1.RolloutStrategyPolicy{
+			Keep: []experimentcontrollercomv1alpha1.RolloutStrategyField{
+				experimentcontrollercomv1alpha1.RolloutStrategyFieldAntiAffinity,
+				experimentcontrollercomv1alpha1.RolloutStrategyFieldSteps,
+			},
+		}
+
+		result, err := reconciler.constructExperimentRollout(experimentCR, sourceRollout)
+		Expect(err).NotTo(HaveOccurred())
+
+		canary := result.Spec.Strategy.Canary
+		Expect(canary.AntiAffinity).NotTo(BeNil())
+		Expect(canary.CanaryService).To(BeEmpty())
+		Expect(canary.Steps).To(HaveLen(2))
+		Expect(canary.Steps[0].SetWeight).NotTo(BeNil())
+		Expect(canary.Steps[1].Pause).NotTo(BeNil())
+	})
+
+	It("should keep traffic routing and analysis when opted in", func() {
+		experimentCR.Spec.RolloutStrategy = &experimentcontrollercomv1alpha1.RolloutStrategyPolicy{
+			Keep: []experimentcontrollercomv1alpha1.RolloutStrategyField{
+				experimentcontrollercomv1alpha1.RolloutStrategyFieldCanaryService,
+				experimentcontrollercomv1alpha1.RolloutStrategyFieldStableService,
+				experimentcontrollercomv1alpha1.RolloutStrategyFieldTrafficRouting,
+				experimentcontrollercomv1alpha1.RolloutStrategyFieldAnalysis,
+				experimentcontrollercomv1alpha1.RolloutStrategyFieldSteps,
+			},
+		}
+
+		result, err := reconciler.constructExperimentRollout(experimentCR, sourceRollout)
+		Expect(err).NotTo(HaveOccurred())
+
+		canary := result.Spec.Strategy.Canary
+		Expect(canary.CanaryService).To(Equal("source-canary"))
+		Expect(canary.StableService).To(Equal("source-stable"))
+		Expect(canary.TrafficRouting).NotTo(BeNil())
+		Expect(canary.Analysis).NotTo(BeNil())
+		Expect(canary.Steps).To(HaveLen(4))
+	})
+
+	It("should apply strategy fields from overrideSpec", func() {
+		experimentCR.Spec.OverrideSpec = apiextensionsv1.JSON{Raw: []byte(`{"strategy":{"canary":{"canaryService":"experiment-canary"}}}`)}
+
+		result, err := reconciler.constructExperimentRollout(experimentCR, sourceRollout)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.Spec.Strategy.Canary.CanaryService).To(Equal("experiment-canary"))
+		Expect(result.Spec.Strategy.Canary.StableService).To(BeEmpty())
+	})
+
+	It("should convert blue-green strategies to canary", func() {
+		sourceRollout.Spec.Strategy = rolloutsv1alpha1.RolloutStrategy{
+			BlueGreen: &rolloutsv1alpha1.BlueGreenStrategy{
+				ActiveService:  "source-active",
+				PreviewService: "source-preview",
+			},
+		}
+
+		result, err := reconciler.constructExperimentRollout(experimentCR, sourceRollout)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.Spec.Strategy.BlueGreen).To(BeNil())
+		Expect(result.Spec.Strategy.Canary).NotTo(BeNil())
+	})
+
+	It("should let a blue-green strategy from overrideSpec replace the canary", func() {
+		experimentCR.Spec.OverrideSpec = apiextensionsv1.JSON{Raw: []byte(`{"strategy":{"blueGreen":{"activeService":"experiment-active"}}}`)}
+
+		result, err := reconciler.constructExperimentRollout(experimentCR, sourceRollout)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.Spec.Strategy.Canary).To(BeNil())
+		Expect(result.Spec.Strategy.BlueGreen.ActiveService).To(Equal("experiment-active"))
+	})
+})
@@ -0,0 +1,56 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	rolloutsv1alpha1 "github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// resolveRolloutWorkloadRef returns the source Rollout with the pod template of the Deployment
+// referenced by spec.workloadRef inlined, so the experiment Rollout embeds its own template and
+// never depends on the source Deployment. Rollouts without workloadRef are returned unchanged.
+func (r *ExperimentDeploymentReconciler) resolveRolloutWorkloadRef(ctx context.Context, sourceRollout *rolloutsv1alpha1.Rollout) (*rolloutsv1alpha1.Rollout, error) {
+	workloadRef := sourceRollout.Spec.WorkloadRef
+	if workloadRef == nil {
+		return sourceRollout, nil
+	}
+
+	// Argo Rollouts only supports referencing apps/v1 Deployments
+	gv, err := schema.ParseGroupVersion(workloadRef.APIVersion)
+	if err != nil {
+		return nil, fmt.Errorf("invalid workloadRef apiVersion %q: %w", workloadRef.APIVersion, err)
+	}
+	if gv.Group != appsv1.GroupName || workloadRef.Kind != "Deployment" {
+		return nil, fmt.Errorf("unsupported workloadRef %s %s, only Deployments are supported", workloadRef.APIVersion, workloadRef.Kind)
+	}
+
+	referencedDeployment := &appsv1.Deployment{}
+	if err := r.sourceReader().Get(ctx, types.NamespacedName{Name: workloadRef.Name, Namespace: sourceRollout.Namespace}, referencedDeployment); err != nil {
+		return nil, err
+	}
+
+	resolvedRollout := sourceRollout.DeepCopy()
+	resolvedRollout.Spec.Template = *referencedDeployment.Spec.Template.DeepCopy()
+	resolvedRollout.Spec.WorkloadRef = nil
+	return resolvedRollout, nil
+}
@@ -0,0 +1,157 @@
+package controller
+
+import (
+	"context"
+
+	rolloutsv1alpha1 "github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	experimentcontrollercomv1alpha1 "experimentcontroller.example.com/experiment-deployment/api/v1alpha1"
+)
+
+var _ = Describe("Rollout workloadRef", func() {
+	var (
+		ctx                  context.Context
+		reconciler           *ExperimentDeploymentReconciler
+		fakeClient           client.Client
+		referencedDeployment *appsv1.Deployment
+		sourceRollout        *rolloutsv1alpha1.Rollout
+		experimentCR         *experimentcontrollercomv1alpha1.ExperimentDeployment
+		namespacedName       types.NamespacedName
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		scheme := runtime.NewScheme()
+		Expect(experimentcontrollercomv1alpha1.AddToScheme(scheme)).To(Succeed())
+		Expect(appsv1.AddToScheme(scheme)).To(Succeed())
+		Expect(corev1.AddToScheme(scheme)).To(Succeed())
+		Expect(rolloutsv1alpha1.AddToScheme(scheme)).To(Succeed())
+		fakeClient = fake.NewClientBuilder().WithScheme(scheme).WithInterceptorFuncs(withServerSideApply(interceptor.Funcs{})).WithStatusSubresource(&experimentcontrollercomv1alpha1.ExperimentDeployment{}).Build()
+		reconciler = &ExperimentDeploymentReconciler{
+			Client:   fakeClient,
+			Scheme:   scheme,
+			Recorder: record.NewFakeRecorder(100),
+		}
+
+		namespacedName = types.NamespacedName{Name: testExperimentCRName, Namespace: testNamespace}
+		referencedDeployment = &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "referenced-deployment", Namespace: testNamespace},
+			Spec: appsv1.DeploymentSpec{
+				Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "source-app"}},
+				Template: corev1.PodTemplateSpec{
+					ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": "source-app", "tier": "web"}},
+					Spec: corev1.PodSpec{
+						Containers: []corev1.Container{{Name: "test-container", Image: "nginx:1.14"}},
+					},
+				},
+			},
+		}
+		sourceRollout = &rolloutsv1alpha1.Rollout{
+			ObjectMeta: metav1.ObjectMeta{Name: "source-rollout", Namespace: testNamespace},
+			Spec: rolloutsv1alpha1.RolloutSpec{
+				Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "source-app"}},
+				WorkloadRef: &rolloutsv1alpha1.ObjectRef{
+					APIVersion: "apps/v1",
+					Kind:       "Deployment",
+					Name:       "referenced-deployment",
+				},
+			},
+		}
+		experimentCR = &experimentcontrollercomv1alpha1.ExperimentDeployment{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:       testExperimentCRName,
+				Namespace:  testNamespace,
+				Finalizers: []string{experimentDeploymentFinalizer},
+			},
+			Spec: experimentcontrollercomv1alpha1.ExperimentDeploymentSpec{
+				SourceRef: experimentcontrollercomv1alpha1.SourceRef{
+					Kind: experimentcontrollercomv1alpha1.SourceKindRollout,
+					Name: "source-rollout",
+				},
+				OverrideSpec: apiextensionsv1.JSON{Raw: []byte(`{"template":{"spec":{"containers":[{"name":"test-container","image":"nginx:1.15"}]}}}`)},
+			},
+		}
+	})
+
+	getExperimentRollout := func() *rolloutsv1alpha1.Rollout {
+		rollout := &rolloutsv1alpha1.Rollout{}
+		Expect(fakeClient.Get(ctx, namespacedName, rollout)).To(Succeed())
+		return rollout
+	}
+
+	It("should create a Rollout embedding the template of the referenced Deployment", func() {
+		Expect(fakeClient.Create(ctx, referencedDeployment)).To(Succeed())
+		Expect(fakeClient.Create(ctx, sourceRollout)).To(Succeed())
+		Expect(fakeClient.Create(ctx, experimentCR)).To(Succeed())
+
+		_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+		Expect(err).NotTo(HaveOccurred())
+
+		experimentRollout := getExperimentRollout()
+		Expect(experimentRollout.Spec.WorkloadRef).To(BeNil())
+		Expect(experimentRollout.Spec.Template.Spec.Containers).To(HaveLen(1))
+		Expect(experimentRollout.Spec.Template.Spec.Containers[0].Image).To(Equal("nginx:1.15"))
+		Expect(experimentRollout.Spec.Template.Labels).To(HaveKeyWithValue("tier", "web"))
+		Expect(experimentRollout.Spec.Template.Labels).To(HaveKeyWithValue("experiment-controller.example.com/cr-name", testExperimentCRName))
+		Expect(experimentRollout.Spec.Selector.MatchLabels).To(Equal(experimentRollout.Spec.Template.Labels))
+
+		// The referenced Deployment is left untouched
+		deployment := &appsv1.Deployment{}
+		Expect(fakeClient.Get(ctx, types.NamespacedName{Name: "referenced-deployment", Namespace: testNamespace}, deployment)).To(Succeed())
+		Expect(deployment.Spec.Template.Spec.Containers[0].Image).To(Equal("nginx:1.14"))
+	})
+
+	It("should keep using the embedded template of Rollouts without workloadRef", func() {
+		sourceRollout.Spec.WorkloadRef = nil
+		sourceRollout.Spec.Template = *referencedDeployment.Spec.Template.DeepCopy()
+		sourceRollout.Spec.Template.Labels["tier"] = "embedded"
+		Expect(fakeClient.Create(ctx, sourceRollout)).To(Succeed())
+		Expect(fakeClient.Create(ctx, experimentCR)).To(Succeed())
+
+		_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+		Expect(err).NotTo(HaveOccurred())
+
+		experimentRollout := getExperimentRollout()
+		Expect(experimentRollout.Spec.WorkloadRef).To(BeNil())
+		Expect(experimentRollout.Spec.Template.Spec.Containers[0].Image).To(Equal("nginx:1.15"))
+		Expect(experimentRollout.Spec.Template.Labels).To(HaveKeyWithValue("tier", "embedded"))
+	})
+
+	It("should report a missing referenced Deployment", func() {
+		Expect(fakeClient.Create(ctx, sourceRollout)).To(Succeed())
+		Expect(fakeClient.Create(ctx, experimentCR)).To(Succeed())
+
+		result, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.RequeueAfter).NotTo(BeZero())
+
+		updatedCR := &experimentcontrollercomv1alpha1.ExperimentDeployment{}
+		Expect(fakeClient.Get(ctx, namespacedName, updatedCR)).To(Succeed())
+		readyCondition := meta.FindStatusCondition(updatedCR.Status.Conditions, ConditionTypeReady)
+		Expect(readyCondition.Reason).To(Equal("WorkloadRefNotFound"))
+	})
+
+	It("should reject workloadRefs to kinds other than Deployment", func() {
+		sourceRollout.Spec.WorkloadRef.Kind = "ReplicaSet"
+		Expect(fakeClient.Create(ctx, sourceRollout)).To(Succeed())
+		Expect(fakeClient.Create(ctx, experimentCR)).To(Succeed())
+
+		_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("only Deployments are supported"))
+	})
+})
@@ -0,0 +1,250 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/util/csaupgrade"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	experimentcontrollercomv1alpha1 "experimentcontroller.example.com/experiment-deployment/api/v1alpha1"
+)
+
+const (
+	// FieldManager is the field manager owning the fields of experiment workloads set by the controller
+	FieldManager = "experiment-controller"
+	// ConditionTypeFieldConflict reports fields of the experiment workload owned by other field managers
+	ConditionTypeFieldConflict = experimentcontrollercomv1alpha1.ConditionTypeFieldConflict
+	// ReasonFieldManagerConflict is the reason of conditions reporting field manager conflicts
+	ReasonFieldManagerConflict = experimentcontrollercomv1alpha1.ReasonFieldManagerConflict
+)
+
+// legacyFieldManagers are the field managers of the updates made to experiment workloads before they
+// were applied, the default field manager of the controller binary
+var legacyFieldManagers = sets.New("manager")
+
+// errFieldConflict is returned when fields of the experiment workload owned by other field managers
+// cannot be left out of the applied configuration. The conflict is reported in the conditions.
+var errFieldConflict = errors.New("fields of the experiment workload are owned by other field managers")
+
+// applyExperimentWorkload applies the desired experiment workload with server-side apply and reads
+// the result into current. Fields owned by other field managers, like replicas managed by an
+// HorizontalPodAutoscaler, are not taken over: they are left out of the applied configuration and
+// reported in the FieldConflict condition.
+func (r *ExperimentDeploymentReconciler) applyExperimentWorkload(
+	ctx context.Context,
+	experimentCR *experimentcontrollercomv1alpha1.ExperimentDeployment,
+	desired client.Object,
+	current client.Object,
+	kind string) (controllerutil.OperationResult, error) {
+
+	previousVersion := ""
+	err := r.Get(ctx, client.ObjectKeyFromObject(desired), current)
+	switch {
+	case err == nil:
+		previousVersion = current.GetResourceVersion()
+		if err := r.upgradeLegacyManagedFields(ctx, current); err != nil {
+			return controllerutil.OperationResultNone, err
+		}
+	case !k8serrors.IsNotFound(err):
+		return controllerutil.OperationResultNone, err
+	}
+
+	applied, err := r.workloadApplyConfiguration(experimentCR, desired)
+	if err != nil {
+		return controllerutil.OperationResultNone, err
+	}
+	// Applying the same configuration again is a no-op for the API server but still a write.
+	// Reported conflicts are applied again, to find out whether they were resolved, and so are
+	// workloads whose spec was changed since the last apply, like with kubectl edit.
+	hash, err := specHash(applied)
+	if err != nil {
+		return controllerutil.OperationResultNone, err
+	}
+	if previousVersion != "" && current.GetAnnotations()[r.labelKey(annotationSpecHash)] == hash &&
+		current.GetGeneration() == experimentCR.Status.WorkloadGeneration &&
+		meta.FindStatusCondition(experimentCR.Status.Conditions, ConditionTypeFieldConflict) == nil {
+		return controllerutil.OperationResultNone, nil
+	}
+	annotations := applied.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[r.labelKey(annotationSpecHash)] = hash
+	applied.SetAnnotations(annotations)
+
+	err = r.Patch(ctx, applied, client.Apply, client.FieldOwner(FieldManager))
+	var conflicts []metav1.StatusCause
+	if k8serrors.IsConflict(err) {
+		conflicts = fieldManagerConflicts(err)
+		unresolved := leaveOutConflictingFields(applied, conflicts)
+		if len(conflicts) == 0 || len(unresolved) > 0 {
+			message := fmt.Sprintf("Experiment %s %s cannot be applied, fields are owned by other field managers: %s",
+				kind, desired.GetName(), describeConflicts(unresolved, err))
+			r.setFieldConflictStatus(experimentCR, message)
+			r.Recorder.Event(experimentCR, corev1.EventTypeWarning, ReasonFieldManagerConflict, message)
+			r.updateStatusConditions(experimentCR, ReasonFieldManagerConflict, message)
+			return controllerutil.OperationResultNone, errFieldConflict
+		}
+		err = r.Patch(ctx, applied, client.Apply, client.FieldOwner(FieldManager))
+	}
+	if err != nil {
+		return controllerutil.OperationResultNone, err
+	}
+
+	if len(conflicts) > 0 {
+		r.setFieldConflictStatus(experimentCR, fmt.Sprintf("Fields of experiment %s %s owned by other field managers were left unchanged: %s",
+			kind, desired.GetName(), describeConflicts(conflicts, nil)))
+	} else {
+		meta.RemoveStatusCondition(&experimentCR.Status.Conditions, ConditionTypeFieldConflict)
+	}
+
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(applied.Object, current); err != nil {
+		return controllerutil.OperationResultNone, err
+	}
+	experimentCR.Status.WorkloadGeneration = current.GetGeneration()
+	switch {
+	case previousVersion == "":
+		return controllerutil.OperationResultCreated, nil
+	case previousVersion != current.GetResourceVersion():
+		return controllerutil.OperationResultUpdated, nil
+	}
+	return controllerutil.OperationResultNone, nil
+}
+
+// workloadApplyConfiguration returns the fields of the experiment workload set by the controller
+func (r *ExperimentDeploymentReconciler) workloadApplyConfiguration(
+	experimentCR *experimentcontrollercomv1alpha1.ExperimentDeployment,
+	desired client.Object) (*unstructured.Unstructured, error) {
+
+	workload := desired.DeepCopyObject().(client.Object)
+	if err := controllerutil.SetControllerReference(experimentCR, workload, r.Scheme); err != nil {
+		return nil, err
+	}
+	gvk, err := apiutil.GVKForObject(workload, r.Scheme)
+	if err != nil {
+		return nil, err
+	}
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(workload)
+	if err != nil {
+		return nil, err
+	}
+
+	applied := &unstructured.Unstructured{Object: content}
+	applied.SetGroupVersionKind(gvk)
+	// Leave out the status and the metadata set by the API server, applying their zero values
+	// would claim them
+	delete(applied.Object, "status")
+	applied.SetResourceVersion("")
+	applied.SetUID("")
+	applied.SetManagedFields(nil)
+	unstructured.RemoveNestedField(applied.Object, "metadata", "creationTimestamp")
+	unstructured.RemoveNestedField(applied.Object, "spec", "template", "metadata", "creationTimestamp")
+	return applied, nil
+}
+
+// upgradeLegacyManagedFields transfers the fields of an experiment workload updated by earlier
+// versions of the controller to its field manager, so that applying does not conflict with them
+func (r *ExperimentDeploymentReconciler) upgradeLegacyManagedFields(ctx context.Context, workload client.Object) error {
+	patch, err := csaupgrade.UpgradeManagedFieldsPatch(workload, legacyFieldManagers, FieldManager)
+	if err != nil || patch == nil {
+		return err
+	}
+	return r.Patch(ctx, workload, client.RawPatch(types.JSONPatchType, patch))
+}
+
+// setFieldConflictStatus reports fields of the experiment workload owned by other field managers
+func (r *ExperimentDeploymentReconciler) setFieldConflictStatus(experimentCR *experimentcontrollercomv1alpha1.ExperimentDeployment, message string) {
+	meta.SetStatusCondition(&experimentCR.Status.Conditions, metav1.Condition{
+		Type:    ConditionTypeFieldConflict,
+		Status:  metav1.ConditionTrue,
+		Reason:  ReasonFieldManagerConflict,
+		Message: message,
+	})
+}
+
+// fieldManagerConflicts returns the conflicting fields of a rejected apply
+func fieldManagerConflicts(err error) []metav1.StatusCause {
+	var apiStatus k8serrors.APIStatus
+	if !errors.As(err, &apiStatus) || apiStatus.Status().Details == nil {
+		return nil
+	}
+	var conflicts []metav1.StatusCause
+	for _, cause := range apiStatus.Status().Details.Causes {
+		if cause.Type == metav1.CauseTypeFieldManagerConflict {
+			conflicts = append(conflicts, cause)
+		}
+	}
+	return conflicts
+}
+
+// leaveOutConflictingFields removes the conflicting fields from the applied configuration and returns
+// the conflicts it could not remove, fields inside lists
+func leaveOutConflictingFields(applied *unstructured.Unstructured, conflicts []metav1.StatusCause) []metav1.StatusCause {
+	var unresolved []metav1.StatusCause
+	for _, conflict := range conflicts {
+		path, ok := conflictFieldPath(conflict.Field)
+		if !ok {
+			unresolved = append(unresolved, conflict)
+			continue
+		}
+		unstructured.RemoveNestedField(applied.Object, path...)
+	}
+	return unresolved
+}
+
+// conflictFieldPath splits the path of a conflicting field, like .spec.replicas, into its fields.
+// Paths into lists, like .spec.template.spec.containers[name="app"].image, are not supported.
+func conflictFieldPath(field string) ([]string, bool) {
+	if field == "" || strings.ContainsAny(field, "[]") {
+		return nil, false
+	}
+	fields := strings.Split(strings.TrimPrefix(field, "."), ".")
+	// Label and annotation keys contain dots themselves
+	for i := 1; i < len(fields)-1; i++ {
+		if fields[i-1] == "metadata" && (fields[i] == "labels" || fields[i] == "annotations") {
+			return append(fields[:i+1], strings.Join(fields[i+1:], ".")), true
+		}
+	}
+	return fields, true
+}
+
+// describeConflicts lists conflicting fields and their field managers, falling back to the error
+func describeConflicts(conflicts []metav1.StatusCause, err error) string {
+	if len(conflicts) == 0 && err != nil {
+		return err.Error()
+	}
+	descriptions := make([]string, 0, len(conflicts))
+	for _, conflict := range conflicts {
+		descriptions = append(descriptions, fmt.Sprintf("%s (%s)", conflict.Field, conflict.Message))
+	}
+	return strings.Join(descriptions, ", ")
+}
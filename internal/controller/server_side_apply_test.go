@@ -0,0 +1,273 @@
+package controller
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	experimentcontrollercomv1alpha1 "experimentcontroller.example.com/experiment-deployment/api/v1alpha1"
+	"experimentcontroller.example.com/experiment-deployment/internal/config"
+)
+
+// applyPatch emulates server-side apply, which the fake client does not support, by creating the
+// object or merging the applied configuration into it
+func applyPatch(ctx context.Context, c client.WithWatch, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+	if patch.Type() != types.ApplyPatchType {
+		return c.Patch(ctx, obj, patch, opts...)
+	}
+	data, err := patch.Data(obj)
+	if err != nil {
+		return err
+	}
+	existing := &unstructured.Unstructured{}
+	existing.SetGroupVersionKind(obj.GetObjectKind().GroupVersionKind())
+	if err := c.Get(ctx, client.ObjectKeyFromObject(obj), existing); k8serrors.IsNotFound(err) {
+		return c.Create(ctx, obj)
+	} else if err != nil {
+		return err
+	}
+	return c.Patch(ctx, obj, client.RawPatch(types.MergePatchType, data))
+}
+
+// withServerSideApply adds the emulation of server-side apply to the interceptors of a fake client
+func withServerSideApply(funcs interceptor.Funcs) interceptor.Funcs {
+	if funcs.Patch == nil {
+		funcs.Patch = applyPatch
+	}
+	return funcs
+}
+
+var _ = Describe("Server-Side Apply", func() {
+	var (
+		ctx              context.Context
+		reconciler       *ExperimentDeploymentReconciler
+		fakeClient       client.Client
+		applies          []client.PatchOptions
+		conflictingField string
+		sourceDeployment *appsv1.Deployment
+		experimentCR     *experimentcontrollercomv1alpha1.ExperimentDeployment
+		namespacedName   types.NamespacedName
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		scheme := runtime.NewScheme()
+		Expect(experimentcontrollercomv1alpha1.AddToScheme(scheme)).To(Succeed())
+		Expect(appsv1.AddToScheme(scheme)).To(Succeed())
+		Expect(corev1.AddToScheme(scheme)).To(Succeed())
+
+		// Record applies and reject those setting conflictingField like the API server does when
+		// another field manager owns it
+		applies = nil
+		conflictingField = ""
+		fakeClient = fake.NewClientBuilder().
+			WithScheme(scheme).
+			WithStatusSubresource(&experimentcontrollercomv1alpha1.ExperimentDeployment{}).
+			WithInterceptorFuncs(interceptor.Funcs{
+				Patch: func(ctx context.Context, c client.WithWatch, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+					if patch.Type() != types.ApplyPatchType {
+						return applyPatch(ctx, c, obj, patch, opts...)
+					}
+					patchOptions := client.PatchOptions{}
+					patchOptions.ApplyOptions(opts)
+					applies = append(applies, patchOptions)
+					if conflictingField != "" {
+						// Fields inside lists are taken to be always set
+						path, ok := conflictFieldPath(conflictingField)
+						if _, found, _ := unstructured.NestedFieldNoCopy(obj.(*unstructured.Unstructured).Object, path...); found || !ok {
+							return k8serrors.NewApplyConflict([]metav1.StatusCause{{
+								Type:    metav1.CauseTypeFieldManagerConflict,
+								Message: `conflict with "kube-controller-manager" using apps/v1`,
+								Field:   conflictingField,
+							}}, "Apply failed with 1 conflict")
+						}
+					}
+					return applyPatch(ctx, c, obj, patch, opts...)
+				},
+			}).
+			Build()
+		reconciler = &ExperimentDeploymentReconciler{
+			Client:   fakeClient,
+			Scheme:   scheme,
+			Recorder: record.NewFakeRecorder(100),
+		}
+
+		namespacedName = types.NamespacedName{Name: testExperimentCRName, Namespace: testNamespace}
+		sourceDeployment = &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: testNamespace},
+			Spec: appsv1.DeploymentSpec{
+				Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "web"}},
+				Template: corev1.PodTemplateSpec{
+					ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": "web"}},
+					Spec: corev1.PodSpec{
+						Containers: []corev1.Container{{Name: "web", Image: "nginx:1.27"}},
+					},
+				},
+			},
+		}
+		experimentCR = &experimentcontrollercomv1alpha1.ExperimentDeployment{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:       testExperimentCRName,
+				Namespace:  testNamespace,
+				Finalizers: []string{experimentDeploymentFinalizer},
+			},
+			Spec: experimentcontrollercomv1alpha1.ExperimentDeploymentSpec{
+				SourceRef: experimentcontrollercomv1alpha1.SourceRef{
+					Kind: experimentcontrollercomv1alpha1.SourceKindDeployment,
+					Name: "web",
+				},
+				OverrideSpec: apiextensionsv1.JSON{Raw: []byte(`{}`)},
+			},
+		}
+		Expect(fakeClient.Create(ctx, sourceDeployment)).To(Succeed())
+		Expect(fakeClient.Create(ctx, experimentCR)).To(Succeed())
+	})
+
+	getExperimentCR := func() *experimentcontrollercomv1alpha1.ExperimentDeployment {
+		updatedCR := &experimentcontrollercomv1alpha1.ExperimentDeployment{}
+		Expect(fakeClient.Get(ctx, namespacedName, updatedCR)).To(Succeed())
+		return updatedCR
+	}
+
+	getDeployment := func() *appsv1.Deployment {
+		deployment := &appsv1.Deployment{}
+		Expect(fakeClient.Get(ctx, namespacedName, deployment)).To(Succeed())
+		return deployment
+	}
+
+	reconcile := func() {
+		_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+		Expect(err).NotTo(HaveOccurred())
+	}
+
+	It("should apply experiment workloads with the field manager of the controller", func() {
+		reconcile()
+
+		Expect(applies).To(HaveLen(1))
+		Expect(applies[0].FieldManager).To(Equal(FieldManager))
+		Expect(applies[0].Force).To(BeNil())
+		deployment := getDeployment()
+		Expect(deployment.OwnerReferences).To(HaveLen(1))
+		Expect(deployment.OwnerReferences[0].Name).To(Equal(testExperimentCRName))
+		Expect(meta.FindStatusCondition(getExperimentCR().Status.Conditions, ConditionTypeFieldConflict)).To(BeNil())
+	})
+
+	It("should not apply unchanged configurations again", func() {
+		reconcile()
+		Expect(applies).To(HaveLen(1))
+		Expect(getDeployment().Annotations).To(HaveKey(config.DefaultLabelDomain + "/spec-hash"))
+
+		reconcile()
+		Expect(applies).To(HaveLen(1))
+
+		Expect(fakeClient.Get(ctx, client.ObjectKeyFromObject(sourceDeployment), sourceDeployment)).To(Succeed())
+		sourceDeployment.Spec.Template.Spec.Containers[0].Image = "nginx:1.28"
+		Expect(fakeClient.Update(ctx, sourceDeployment)).To(Succeed())
+		reconcile()
+		Expect(applies).To(HaveLen(2))
+		Expect(getDeployment().Spec.Template.Spec.Containers[0].Image).To(Equal("nginx:1.28"))
+	})
+
+	It("should apply workloads changed by others again", func() {
+		reconcile()
+		Expect(applies).To(HaveLen(1))
+		deployment := getDeployment()
+		Expect(getExperimentCR().Status.WorkloadGeneration).To(Equal(deployment.Generation))
+
+		// The API server bumps the generation on spec changes, the fake client does not
+		deployment.Spec.Template.Spec.Containers[0].Image = "nginx:edited"
+		deployment.Generation++
+		Expect(fakeClient.Update(ctx, deployment)).To(Succeed())
+		reconcile()
+
+		Expect(applies).To(HaveLen(2))
+		Expect(getDeployment().Spec.Template.Spec.Containers[0].Image).To(Equal("nginx:1.27"))
+		Expect(getExperimentCR().Status.WorkloadGeneration).To(Equal(getDeployment().Generation))
+		reconcile()
+		Expect(applies).To(HaveLen(2))
+	})
+
+	It("should keep fields set by others", func() {
+		reconcile()
+		deployment := getDeployment()
+		deployment.Annotations = map[string]string{"sidecar.example.com/injected": "true"}
+		Expect(fakeClient.Update(ctx, deployment)).To(Succeed())
+
+		reconcile()
+
+		Expect(getDeployment().Annotations).To(HaveKeyWithValue("sidecar.example.com/injected", "true"))
+	})
+
+	It("should leave fields owned by other field managers to them and report them", func() {
+		reconcile()
+		deployment := getDeployment()
+		deployment.Spec.Replicas = ptr.To(int32(3))
+		Expect(fakeClient.Update(ctx, deployment)).To(Succeed())
+		conflictingField = ".spec.replicas"
+		// Unchanged configurations are not applied again
+		Expect(fakeClient.Get(ctx, client.ObjectKeyFromObject(sourceDeployment), sourceDeployment)).To(Succeed())
+		sourceDeployment.Spec.Template.Spec.Containers[0].Image = "nginx:1.28"
+		Expect(fakeClient.Update(ctx, sourceDeployment)).To(Succeed())
+
+		reconcile()
+
+		Expect(*getDeployment().Spec.Replicas).To(Equal(int32(3)))
+		conflictCondition := meta.FindStatusCondition(getExperimentCR().Status.Conditions, ConditionTypeFieldConflict)
+		Expect(conflictCondition).NotTo(BeNil())
+		Expect(conflictCondition.Status).To(Equal(metav1.ConditionTrue))
+		Expect(conflictCondition.Reason).To(Equal(ReasonFieldManagerConflict))
+		Expect(conflictCondition.Message).To(ContainSubstring(`.spec.replicas (conflict with "kube-controller-manager" using apps/v1)`))
+
+		conflictingField = ""
+		reconcile()
+		Expect(meta.FindStatusCondition(getExperimentCR().Status.Conditions, ConditionTypeFieldConflict)).To(BeNil())
+	})
+
+	It("should not apply when fields inside lists conflict", func() {
+		reconcile()
+		conflictingField = `.spec.template.spec.containers[name="web"].image`
+		Expect(fakeClient.Get(ctx, client.ObjectKeyFromObject(sourceDeployment), sourceDeployment)).To(Succeed())
+		sourceDeployment.Spec.Template.Spec.Containers[0].Image = "nginx:1.28"
+		Expect(fakeClient.Update(ctx, sourceDeployment)).To(Succeed())
+
+		reconcile()
+
+		Expect(getDeployment().Spec.Template.Spec.Containers[0].Image).To(Equal("nginx:1.27"))
+		updatedCR := getExperimentCR()
+		readyCondition := meta.FindStatusCondition(updatedCR.Status.Conditions, ConditionTypeReady)
+		Expect(readyCondition).NotTo(BeNil())
+		Expect(readyCondition.Reason).To(Equal(ReasonFieldManagerConflict))
+		Expect(meta.IsStatusConditionTrue(updatedCR.Status.Conditions, ConditionTypeFieldConflict)).To(BeTrue())
+	})
+
+	It("should split conflicting field paths", func() {
+		fieldPath := func(field string) []string {
+			path, ok := conflictFieldPath(field)
+			Expect(ok).To(BeTrue())
+			return path
+		}
+		Expect(fieldPath(".spec.replicas")).To(Equal([]string{"spec", "replicas"}))
+		Expect(fieldPath(".metadata.annotations.sidecar.example.com/injected")).To(
+			Equal([]string{"metadata", "annotations", "sidecar.example.com/injected"}))
+		Expect(fieldPath(".spec.template.metadata.labels.app.kubernetes.io/name")).To(
+			Equal([]string{"spec", "template", "metadata", "labels", "app.kubernetes.io/name"}))
+		_, ok := conflictFieldPath(`.spec.template.spec.containers[name="web"].image`)
+		Expect(ok).To(BeFalse())
+	})
+})
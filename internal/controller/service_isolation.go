@@ -0,0 +1,477 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	experimentcontrollercomv1alpha1 "experimentcontroller.example.com/experiment-deployment/api/v1alpha1"
+)
+
+// virtualServiceGVK is the Istio VirtualService kind used to mirror traffic in Shadow mode
+var virtualServiceGVK = schema.GroupVersionKind{Group: "networking.istio.io", Version: "v1beta1", Kind: "VirtualService"}
+
+// experimentServiceName returns the name of the dedicated Service for isolated experiments
+func experimentServiceName(experimentCR *experimentcontrollercomv1alpha1.ExperimentDeployment) string {
+	return experimentCR.Name + "-experiment"
+}
+
+// effectiveServiceMode returns the service mode of the experiment, defaulting to Shared
+func effectiveServiceMode(experimentCR *experimentcontrollercomv1alpha1.ExperimentDeployment) experimentcontrollercomv1alpha1.ServiceMode {
+	if experimentCR.Spec.ServiceMode == "" {
+		return experimentcontrollercomv1alpha1.ServiceModeShared
+	}
+	return experimentCR.Spec.ServiceMode
+}
+
+// reconcileServiceIsolation applies the service mode of the experiment to the desired pod template.
+// In Isolated and Shadow modes it strips the labels selected by the source Services from the
+// pod template and selector, and manages the dedicated experiment Service. In Shared mode it
+// removes any leftovers from a previous isolated configuration.
+func (r *ExperimentDeploymentReconciler) reconcileServiceIsolation(
+	ctx context.Context,
+	experimentCR *experimentcontrollercomv1alpha1.ExperimentDeployment,
+	template *corev1.PodTemplateSpec,
+	selector *metav1.LabelSelector) error {
+
+	log := logf.FromContext(ctx)
+	mode := effectiveServiceMode(experimentCR)
+
+	if mode == experimentcontrollercomv1alpha1.ServiceModeShared {
+		if err := r.deleteExperimentService(ctx, experimentCR); err != nil {
+			return err
+		}
+		if err := r.removeServiceMirrors(ctx, experimentCR); err != nil {
+			return err
+		}
+		experimentCR.Status.ExperimentServiceRef = nil
+		return nil
+	}
+
+	sourceServices, err := r.findSourceServices(ctx, experimentCR.Namespace, template.Labels)
+	if err != nil {
+		log.Error(err, "Failed to list source Services for service isolation")
+		r.Recorder.Eventf(experimentCR, corev1.EventTypeWarning, "ServiceIsolationFailed", "Failed to list Services: %s", err.Error())
+		r.updateStatusConditions(experimentCR, experimentcontrollercomv1alpha1.ReasonServiceIsolationFailed, fmt.Sprintf("Failed to list Services: %s", err.Error()))
+		return err
+	}
+
+	// Remove the labels the source Services select on, so the experiment pods receive no production traffic
+	for _, svc := range sourceServices {
+		for key := range svc.Spec.Selector {
+			if strings.HasPrefix(key, r.config().LabelDomain+"/") {
+				continue
+			}
+			delete(template.Labels, key)
+			if selector != nil {
+				delete(selector.MatchLabels, key)
+			}
+		}
+	}
+
+	desiredService := r.constructExperimentService(experimentCR, sourceServices)
+	if len(desiredService.Spec.Ports) == 0 {
+		log.Info("No Services with ports select the experiment pods, skipping dedicated experiment Service", "mode", mode)
+		if err := r.deleteExperimentService(ctx, experimentCR); err != nil {
+			return err
+		}
+		experimentCR.Status.ExperimentServiceRef = nil
+		return r.removeServiceMirrors(ctx, experimentCR)
+	}
+
+	if err := r.createOrUpdateExperimentService(ctx, experimentCR, desiredService); err != nil {
+		return err
+	}
+
+	if mode == experimentcontrollercomv1alpha1.ServiceModeShadow {
+		return r.reconcileServiceMirrors(ctx, experimentCR, sourceServices)
+	}
+	return r.removeServiceMirrors(ctx, experimentCR)
+}
+
+// findSourceServices returns the Services in the namespace whose selectors match the given pod labels.
+// Services managed by this controller are ignored.
+func (r *ExperimentDeploymentReconciler) findSourceServices(ctx context.Context, namespace string, podLabels map[string]string) ([]corev1.Service, error) {
+	serviceList := &corev1.ServiceList{}
+	if err := r.List(ctx, serviceList, client.InNamespace(namespace)); err != nil {
+		return nil, err
+	}
+
+	var matched []corev1.Service
+	for _, svc := range serviceList.Items {
+		if svc.Labels[r.labelKey(labelManagedBy)] == ManagedByValue {
+			continue
+		}
+		if len(svc.Spec.Selector) == 0 {
+			continue
+		}
+		if labels.SelectorFromSet(svc.Spec.Selector).Matches(labels.Set(podLabels)) {
+			matched = append(matched, svc)
+		}
+	}
+	sort.Slice(matched, func(i, j int) bool { return matched[i].Name < matched[j].Name })
+	return matched, nil
+}
+
+// constructExperimentService builds the dedicated experiment Service exposing the ports of the source Services
+func (r *ExperimentDeploymentReconciler) constructExperimentService(
+	experimentCR *experimentcontrollercomv1alpha1.ExperimentDeployment,
+	sourceServices []corev1.Service) *corev1.Service {
+
+	var ports []corev1.ServicePort
+	seenPorts := make(map[string]bool)
+	seenNames := make(map[string]bool)
+	for _, svc := range sourceServices {
+		for _, port := range svc.Spec.Ports {
+			key := fmt.Sprintf("%d/%s", port.Port, port.Protocol)
+			if seenPorts[key] {
+				continue
+			}
+			seenPorts[key] = true
+			// Port names must be unique within a Service
+			if port.Name != "" && seenNames[port.Name] {
+				port.Name = fmt.Sprintf("%s-%d", port.Name, port.Port)
+			}
+			seenNames[port.Name] = true
+			port.NodePort = 0
+			ports = append(ports, port)
+		}
+	}
+
+	return &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      experimentServiceName(experimentCR),
+			Namespace: experimentCR.Namespace,
+			Labels: map[string]string{
+				r.labelKey(labelManagedBy): ManagedByValue,
+				r.labelKey(labelCRName):    experimentCR.Name,
+			},
+		},
+		Spec: corev1.ServiceSpec{
+			Type:  corev1.ServiceTypeClusterIP,
+			Ports: ports,
+			Selector: map[string]string{
+				r.labelKey(labelCRName): experimentCR.Name,
+				r.labelKey(labelRole):   ExperimentRoleValue,
+			},
+		},
+	}
+}
+
+// createOrUpdateExperimentService creates or updates the dedicated experiment Service
+func (r *ExperimentDeploymentReconciler) createOrUpdateExperimentService(
+	ctx context.Context,
+	experimentCR *experimentcontrollercomv1alpha1.ExperimentDeployment,
+	desired *corev1.Service) error {
+
+	log := logf.FromContext(ctx)
+
+	serviceToManage := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      desired.Name,
+			Namespace: desired.Namespace,
+		},
+	}
+
+	opResult, err := controllerutil.CreateOrUpdate(ctx, r.Client, serviceToManage, func() error {
+		if err := controllerutil.SetControllerReference(experimentCR, serviceToManage, r.Scheme); err != nil {
+			return err
+		}
+		if serviceToManage.Labels == nil {
+			serviceToManage.Labels = make(map[string]string)
+		}
+		for k, v := range desired.Labels {
+			serviceToManage.Labels[k] = v
+		}
+		// Keep the allocated ClusterIP, it is immutable once set
+		serviceToManage.Spec.Type = desired.Spec.Type
+		serviceToManage.Spec.Ports = desired.Spec.Ports
+		serviceToManage.Spec.Selector = desired.Spec.Selector
+		return nil
+	})
+	if err != nil {
+		log.Error(err, "Failed to create or update experiment Service", "name", desired.Name)
+		r.Recorder.Eventf(experimentCR, corev1.EventTypeWarning, "ServiceIsolationFailed", "Failed to create/update experiment Service %s: %s", desired.Name, err.Error())
+		r.updateStatusConditions(experimentCR, experimentcontrollercomv1alpha1.ReasonServiceIsolationFailed, fmt.Sprintf("Failed to create/update experiment Service %s: %s", desired.Name, err.Error()))
+		return err
+	}
+
+	if opResult != controllerutil.OperationResultNone {
+		log.Info("Experiment Service successfully reconciled", "operation", opResult, "name", desired.Name)
+		r.Recorder.Eventf(experimentCR, corev1.EventTypeNormal, string(opResult), "Experiment Service %s %s", desired.Name, opResult)
+	}
+
+	experimentCR.Status.ExperimentServiceRef = &experimentcontrollercomv1alpha1.ExperimentResourceRef{
+		Kind:      "Service",
+		Name:      desired.Name,
+		Namespace: desired.Namespace,
+	}
+	return nil
+}
+
+// deleteExperimentService removes the dedicated experiment Service if it exists
+func (r *ExperimentDeploymentReconciler) deleteExperimentService(ctx context.Context, experimentCR *experimentcontrollercomv1alpha1.ExperimentDeployment) error {
+	log := logf.FromContext(ctx)
+
+	service := &corev1.Service{}
+	err := r.Get(ctx, client.ObjectKey{Name: experimentServiceName(experimentCR), Namespace: experimentCR.Namespace}, service)
+	if err != nil {
+		if k8serrors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	// Never delete a Service this experiment does not own
+	if !metav1.IsControlledBy(service, experimentCR) {
+		return nil
+	}
+	if err := r.Delete(ctx, service); err != nil && !k8serrors.IsNotFound(err) {
+		log.Error(err, "Failed to delete experiment Service", "name", service.Name)
+		return err
+	}
+	log.Info("Deleted experiment Service", "name", service.Name)
+	return nil
+}
+
+// experimentServiceHost returns the cluster-local DNS name of the dedicated experiment Service
+func experimentServiceHost(experimentCR *experimentcontrollercomv1alpha1.ExperimentDeployment) string {
+	return fmt.Sprintf("%s.%s.svc.cluster.local", experimentServiceName(experimentCR), experimentCR.Namespace)
+}
+
+// serviceHostMatches reports whether a mesh destination host refers to the given Service
+func serviceHostMatches(host string, service corev1.Service) bool {
+	switch host {
+	case service.Name,
+		service.Name + "." + service.Namespace,
+		service.Name + "." + service.Namespace + ".svc",
+		service.Name + "." + service.Namespace + ".svc.cluster.local":
+		return true
+	}
+	return false
+}
+
+// isVirtualServiceAvailable checks if the Istio VirtualService CRD exists in the cluster
+func (r *ExperimentDeploymentReconciler) isVirtualServiceAvailable() bool {
+	_, err := r.RESTMapper().RESTMapping(virtualServiceGVK.GroupKind(), virtualServiceGVK.Version)
+	return err == nil
+}
+
+// reconcileServiceMirrors mirrors the traffic of the source Services to the experiment Service
+// in every Istio VirtualService routing to them
+func (r *ExperimentDeploymentReconciler) reconcileServiceMirrors(
+	ctx context.Context,
+	experimentCR *experimentcontrollercomv1alpha1.ExperimentDeployment,
+	sourceServices []corev1.Service) error {
+
+	log := logf.FromContext(ctx)
+
+	if !r.isVirtualServiceAvailable() {
+		log.Info("No service mesh detected, Shadow experiment runs isolated without mirrored traffic")
+		r.Recorder.Event(experimentCR, corev1.EventTypeNormal, "MeshNotFound", "No service mesh detected, Shadow experiment runs isolated without mirrored traffic")
+		experimentCR.Status.MirroredVirtualServices = nil
+		return nil
+	}
+
+	virtualServices := &unstructured.UnstructuredList{}
+	virtualServices.SetGroupVersionKind(virtualServiceGVK.GroupVersion().WithKind(virtualServiceGVK.Kind + "List"))
+	if err := r.List(ctx, virtualServices, client.InNamespace(experimentCR.Namespace)); err != nil {
+		log.Error(err, "Failed to list VirtualServices for traffic mirroring")
+		r.Recorder.Eventf(experimentCR, corev1.EventTypeWarning, "MirrorFailed", "Failed to list VirtualServices: %s", err.Error())
+		r.updateStatusConditions(experimentCR, experimentcontrollercomv1alpha1.ReasonMirrorFailed, fmt.Sprintf("Failed to list VirtualServices: %s", err.Error()))
+		return err
+	}
+
+	mirrorHost := experimentServiceHost(experimentCR)
+	var mirrored []string
+	for i := range virtualServices.Items {
+		vs := &virtualServices.Items[i]
+		httpRoutes, found, err := unstructured.NestedSlice(vs.Object, "spec", "http")
+		if err != nil || !found {
+			continue
+		}
+
+		changed := false
+		mirrorsExperiment := false
+		for j, rawRoute := range httpRoutes {
+			route, ok := rawRoute.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			// Routes mirroring to the experiment are recorded even when they no longer target a
+			// source Service, so their mirror is still removed on cleanup
+			existingHost, _, _ := unstructured.NestedString(route, "mirror", "host")
+			if existingHost == mirrorHost {
+				mirrorsExperiment = true
+				continue
+			}
+			if !routeTargetsServices(route, sourceServices) {
+				continue
+			}
+			if existingHost != "" {
+				log.Info("VirtualService route already mirrors to another host, leaving it untouched", "virtualService", vs.GetName(), "mirrorHost", existingHost)
+				continue
+			}
+			route["mirror"] = map[string]interface{}{"host": mirrorHost}
+			route["mirrorPercentage"] = map[string]interface{}{"value": float64(100)}
+			httpRoutes[j] = route
+			changed = true
+			mirrorsExperiment = true
+		}
+
+		if changed {
+			if err := unstructured.SetNestedSlice(vs.Object, httpRoutes, "spec", "http"); err != nil {
+				return err
+			}
+			if err := r.recordMirroredVirtualService(ctx, experimentCR, vs.GetNamespace()+"/"+vs.GetName()); err != nil {
+				log.Error(err, "Failed to record the VirtualService before mirroring traffic", "virtualService", vs.GetName())
+				return err
+			}
+			if err := r.Update(ctx, vs); err != nil {
+				log.Error(err, "Failed to add traffic mirror to VirtualService", "virtualService", vs.GetName())
+				r.Recorder.Eventf(experimentCR, corev1.EventTypeWarning, "MirrorFailed", "Failed to mirror traffic in VirtualService %s: %s", vs.GetName(), err.Error())
+				r.updateStatusConditions(experimentCR, experimentcontrollercomv1alpha1.ReasonMirrorFailed, fmt.Sprintf("Failed to mirror traffic in VirtualService %s: %s", vs.GetName(), err.Error()))
+				return err
+			}
+			log.Info("Mirroring traffic to experiment Service", "virtualService", vs.GetName(), "host", mirrorHost)
+			r.Recorder.Eventf(experimentCR, corev1.EventTypeNormal, "MirrorConfigured", "VirtualService %s mirrors traffic to %s", vs.GetName(), mirrorHost)
+		}
+		if mirrorsExperiment {
+			mirrored = append(mirrored, vs.GetNamespace()+"/"+vs.GetName())
+		}
+	}
+
+	experimentCR.Status.MirroredVirtualServices = mirrored
+	return nil
+}
+
+// recordMirroredVirtualService stores a VirtualService in status.mirroredVirtualServices before
+// the mirror is added to it, so the mirror is removed on cleanup even if the status written at the
+// end of the reconcile is lost
+func (r *ExperimentDeploymentReconciler) recordMirroredVirtualService(ctx context.Context, experimentCR *experimentcontrollercomv1alpha1.ExperimentDeployment, ref string) error {
+	if slices.Contains(experimentCR.Status.MirroredVirtualServices, ref) {
+		return nil
+	}
+	experimentCR.Status.MirroredVirtualServices = append(experimentCR.Status.MirroredVirtualServices, ref)
+	patch, err := json.Marshal(map[string]interface{}{
+		"status": map[string]interface{}{"mirroredVirtualServices": experimentCR.Status.MirroredVirtualServices},
+	})
+	if err != nil {
+		return err
+	}
+	// Patch a copy, the response would replace the status computed so far
+	return r.Status().Patch(ctx, experimentCR.DeepCopy(), client.RawPatch(types.MergePatchType, patch))
+}
+
+// routeTargetsServices reports whether any destination of an Istio HTTP route is one of the given Services
+func routeTargetsServices(route map[string]interface{}, services []corev1.Service) bool {
+	destinations, found, err := unstructured.NestedSlice(route, "route")
+	if err != nil || !found {
+		return false
+	}
+	for _, rawDestination := range destinations {
+		destination, ok := rawDestination.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		host, _, _ := unstructured.NestedString(destination, "destination", "host")
+		for _, svc := range services {
+			if serviceHostMatches(host, svc) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// removeServiceMirrors removes the traffic mirrors pointing to the experiment Service from the
+// VirtualServices recorded in the status. Experiments that never mirrored traffic skip the
+// VirtualService lookups entirely.
+func (r *ExperimentDeploymentReconciler) removeServiceMirrors(ctx context.Context, experimentCR *experimentcontrollercomv1alpha1.ExperimentDeployment) error {
+	log := logf.FromContext(ctx)
+
+	if len(experimentCR.Status.MirroredVirtualServices) == 0 || !r.isVirtualServiceAvailable() {
+		experimentCR.Status.MirroredVirtualServices = nil
+		return nil
+	}
+
+	mirrorHost := experimentServiceHost(experimentCR)
+	for _, ref := range experimentCR.Status.MirroredVirtualServices {
+		namespace, name, found := strings.Cut(ref, "/")
+		if !found {
+			continue
+		}
+		vs := &unstructured.Unstructured{}
+		vs.SetGroupVersionKind(virtualServiceGVK)
+		if err := r.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, vs); err != nil {
+			if k8serrors.IsNotFound(err) {
+				continue
+			}
+			log.Error(err, "Failed to get VirtualService for mirror cleanup", "virtualService", ref)
+			return err
+		}
+		httpRoutes, found, err := unstructured.NestedSlice(vs.Object, "spec", "http")
+		if err != nil || !found {
+			continue
+		}
+
+		changed := false
+		for j, rawRoute := range httpRoutes {
+			route, ok := rawRoute.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			if host, _, _ := unstructured.NestedString(route, "mirror", "host"); host != mirrorHost {
+				continue
+			}
+			delete(route, "mirror")
+			delete(route, "mirrorPercentage")
+			httpRoutes[j] = route
+			changed = true
+		}
+
+		if !changed {
+			continue
+		}
+		if err := unstructured.SetNestedSlice(vs.Object, httpRoutes, "spec", "http"); err != nil {
+			return err
+		}
+		if err := r.Update(ctx, vs); err != nil && !k8serrors.IsNotFound(err) {
+			log.Error(err, "Failed to remove traffic mirror from VirtualService", "virtualService", vs.GetName())
+			return err
+		}
+		log.Info("Removed traffic mirror from VirtualService", "virtualService", vs.GetName())
+	}
+
+	experimentCR.Status.MirroredVirtualServices = nil
+	return nil
+}
@@ -0,0 +1,381 @@
+package controller
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	experimentcontrollercomv1alpha1 "experimentcontroller.example.com/experiment-deployment/api/v1alpha1"
+)
+
+var _ = Describe("Service Isolation", func() {
+	var (
+		ctx              context.Context
+		reconciler       *ExperimentDeploymentReconciler
+		fakeClient       client.Client
+		scheme           *runtime.Scheme
+		sourceDeployment *appsv1.Deployment
+		sourceService    *corev1.Service
+		experimentCR     *experimentcontrollercomv1alpha1.ExperimentDeployment
+		namespacedName   types.NamespacedName
+	)
+
+	newReconciler := func(mapper meta.RESTMapper) {
+		builder := fake.NewClientBuilder().WithScheme(scheme).WithInterceptorFuncs(withServerSideApply(interceptor.Funcs{})).WithStatusSubresource(&experimentcontrollercomv1alpha1.ExperimentDeployment{})
+		if mapper != nil {
+			builder = builder.WithRESTMapper(mapper)
+		}
+		fakeClient = builder.Build()
+		reconciler = &ExperimentDeploymentReconciler{
+			Client:   fakeClient,
+			Scheme:   scheme,
+			Recorder: record.NewFakeRecorder(100),
+		}
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		scheme = runtime.NewScheme()
+		Expect(experimentcontrollercomv1alpha1.AddToScheme(scheme)).To(Succeed())
+		Expect(appsv1.AddToScheme(scheme)).To(Succeed())
+		Expect(corev1.AddToScheme(scheme)).To(Succeed())
+		newReconciler(nil)
+
+		namespacedName = types.NamespacedName{Name: testExperimentCRName, Namespace: testNamespace}
+		sourceDeployment = &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "source-deployment", Namespace: testNamespace},
+			Spec: appsv1.DeploymentSpec{
+				Replicas: func() *int32 { r := int32(3); return &r }(),
+				Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "source-app"}},
+				Template: corev1.PodTemplateSpec{
+					ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": "source-app", "tier": "web"}},
+					Spec: corev1.PodSpec{
+						Containers: []corev1.Container{{Name: "test-container", Image: "nginx:1.14"}},
+					},
+				},
+			},
+		}
+		sourceService = &corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: "source-service", Namespace: testNamespace},
+			Spec: corev1.ServiceSpec{
+				Selector: map[string]string{"app": "source-app"},
+				Ports: []corev1.ServicePort{
+					{Name: "http", Port: 80, TargetPort: intstr.FromInt(8080), Protocol: corev1.ProtocolTCP, NodePort: 30080},
+				},
+			},
+		}
+		experimentCR = &experimentcontrollercomv1alpha1.ExperimentDeployment{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:       testExperimentCRName,
+				Namespace:  testNamespace,
+				Finalizers: []string{experimentDeploymentFinalizer},
+			},
+			Spec: experimentcontrollercomv1alpha1.ExperimentDeploymentSpec{
+				SourceRef: experimentcontrollercomv1alpha1.SourceRef{
+					Kind: experimentcontrollercomv1alpha1.SourceKindDeployment,
+					Name: "source-deployment",
+				},
+				OverrideSpec: apiextensionsv1.JSON{Raw: []byte("{}")},
+			},
+		}
+	})
+
+	getExperimentDeployment := func() *appsv1.Deployment {
+		deployment := &appsv1.Deployment{}
+		Expect(fakeClient.Get(ctx, namespacedName, deployment)).To(Succeed())
+		return deployment
+	}
+
+	Context("Shared mode", func() {
+		It("should keep the source labels and not create an experiment Service", func() {
+			Expect(fakeClient.Create(ctx, sourceDeployment)).To(Succeed())
+			Expect(fakeClient.Create(ctx, sourceService)).To(Succeed())
+			Expect(fakeClient.Create(ctx, experimentCR)).To(Succeed())
+
+			_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(getExperimentDeployment().Spec.Template.Labels).To(HaveKeyWithValue("app", "source-app"))
+
+			service := &corev1.Service{}
+			err = fakeClient.Get(ctx, types.NamespacedName{Name: experimentServiceName(experimentCR), Namespace: testNamespace}, service)
+			Expect(errors.IsNotFound(err)).To(BeTrue())
+		})
+	})
+
+	Context("Isolated mode", func() {
+		BeforeEach(func() {
+			experimentCR.Spec.ServiceMode = experimentcontrollercomv1alpha1.ServiceModeIsolated
+		})
+
+		It("should strip the source Service selector labels and create a dedicated Service", func() {
+			Expect(fakeClient.Create(ctx, sourceDeployment)).To(Succeed())
+			Expect(fakeClient.Create(ctx, sourceService)).To(Succeed())
+			Expect(fakeClient.Create(ctx, experimentCR)).To(Succeed())
+
+			_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+			Expect(err).NotTo(HaveOccurred())
+
+			experimentDeployment := getExperimentDeployment()
+			Expect(experimentDeployment.Spec.Template.Labels).NotTo(HaveKey("app"))
+			Expect(experimentDeployment.Spec.Template.Labels).To(HaveKeyWithValue("tier", "web"))
+			Expect(experimentDeployment.Spec.Selector.MatchLabels).NotTo(HaveKey("app"))
+
+			service := &corev1.Service{}
+			Expect(fakeClient.Get(ctx, types.NamespacedName{Name: testExperimentCRName + "-experiment", Namespace: testNamespace}, service)).To(Succeed())
+			Expect(service.Spec.Selector).To(HaveKeyWithValue("experiment-controller.example.com/cr-name", testExperimentCRName))
+			Expect(service.Spec.Ports).To(HaveLen(1))
+			Expect(service.Spec.Ports[0].Port).To(Equal(int32(80)))
+			Expect(service.Spec.Ports[0].TargetPort).To(Equal(intstr.FromInt(8080)))
+			Expect(service.Spec.Ports[0].NodePort).To(BeZero())
+			Expect(service.OwnerReferences).To(HaveLen(1))
+
+			updatedCR := &experimentcontrollercomv1alpha1.ExperimentDeployment{}
+			Expect(fakeClient.Get(ctx, namespacedName, updatedCR)).To(Succeed())
+			Expect(updatedCR.Status.ExperimentServiceRef).NotTo(BeNil())
+			Expect(updatedCR.Status.ExperimentServiceRef.Name).To(Equal(testExperimentCRName + "-experiment"))
+		})
+
+		It("should delete the experiment Service when switching back to Shared", func() {
+			Expect(fakeClient.Create(ctx, sourceDeployment)).To(Succeed())
+			Expect(fakeClient.Create(ctx, sourceService)).To(Succeed())
+			Expect(fakeClient.Create(ctx, experimentCR)).To(Succeed())
+
+			_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+			Expect(err).NotTo(HaveOccurred())
+
+			updatedCR := &experimentcontrollercomv1alpha1.ExperimentDeployment{}
+			Expect(fakeClient.Get(ctx, namespacedName, updatedCR)).To(Succeed())
+			updatedCR.Spec.ServiceMode = experimentcontrollercomv1alpha1.ServiceModeShared
+			Expect(fakeClient.Update(ctx, updatedCR)).To(Succeed())
+
+			_, err = reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+			Expect(err).NotTo(HaveOccurred())
+
+			service := &corev1.Service{}
+			err = fakeClient.Get(ctx, types.NamespacedName{Name: experimentServiceName(experimentCR), Namespace: testNamespace}, service)
+			Expect(errors.IsNotFound(err)).To(BeTrue())
+			Expect(fakeClient.Get(ctx, namespacedName, updatedCR)).To(Succeed())
+			Expect(updatedCR.Status.ExperimentServiceRef).To(BeNil())
+		})
+
+		It("should merge ports of several source Services", func() {
+			metricsService := &corev1.Service{
+				ObjectMeta: metav1.ObjectMeta{Name: "source-metrics", Namespace: testNamespace},
+				Spec: corev1.ServiceSpec{
+					Selector: map[string]string{"tier": "web"},
+					Ports: []corev1.ServicePort{
+						{Name: "http", Port: 9090, Protocol: corev1.ProtocolTCP},
+						{Name: "http", Port: 80, Protocol: corev1.ProtocolTCP},
+					},
+				},
+			}
+
+			service := reconciler.constructExperimentService(experimentCR, []corev1.Service{*metricsService, *sourceService})
+			Expect(service.Spec.Ports).To(HaveLen(2))
+			Expect(service.Spec.Ports[0].Name).To(Equal("http"))
+			Expect(service.Spec.Ports[1].Name).To(Equal("http-80"))
+		})
+	})
+
+	Context("Shadow mode", func() {
+		var virtualService *unstructured.Unstructured
+
+		BeforeEach(func() {
+			experimentCR.Spec.ServiceMode = experimentcontrollercomv1alpha1.ServiceModeShadow
+			virtualService = &unstructured.Unstructured{}
+			virtualService.SetGroupVersionKind(virtualServiceGVK)
+			virtualService.SetName("source-routes")
+			virtualService.SetNamespace(testNamespace)
+			Expect(unstructured.SetNestedSlice(virtualService.Object, []interface{}{
+				map[string]interface{}{
+					"route": []interface{}{
+						map[string]interface{}{"destination": map[string]interface{}{"host": "source-service"}},
+					},
+				},
+				map[string]interface{}{
+					"route": []interface{}{
+						map[string]interface{}{"destination": map[string]interface{}{"host": "other-service"}},
+					},
+				},
+			}, "spec", "http")).To(Succeed())
+		})
+
+		It("should behave like Isolated when no mesh is installed", func() {
+			Expect(fakeClient.Create(ctx, sourceDeployment)).To(Succeed())
+			Expect(fakeClient.Create(ctx, sourceService)).To(Succeed())
+			Expect(fakeClient.Create(ctx, experimentCR)).To(Succeed())
+
+			_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(getExperimentDeployment().Spec.Template.Labels).NotTo(HaveKey("app"))
+			updatedCR := &experimentcontrollercomv1alpha1.ExperimentDeployment{}
+			Expect(fakeClient.Get(ctx, namespacedName, updatedCR)).To(Succeed())
+			Expect(updatedCR.Status.ExperimentServiceRef).NotTo(BeNil())
+			Expect(updatedCR.Status.MirroredVirtualServices).To(BeEmpty())
+		})
+
+		It("should mirror source traffic through Istio VirtualServices and clean up on deletion", func() {
+			mapper := meta.NewDefaultRESTMapper(nil)
+			for gvk := range scheme.AllKnownTypes() {
+				mapper.Add(gvk, meta.RESTScopeNamespace)
+			}
+			mapper.Add(virtualServiceGVK, meta.RESTScopeNamespace)
+			newReconciler(mapper)
+
+			Expect(fakeClient.Create(ctx, sourceDeployment)).To(Succeed())
+			Expect(fakeClient.Create(ctx, sourceService)).To(Succeed())
+			Expect(fakeClient.Create(ctx, virtualService)).To(Succeed())
+			Expect(fakeClient.Create(ctx, experimentCR)).To(Succeed())
+
+			_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+			Expect(err).NotTo(HaveOccurred())
+
+			updatedVS := &unstructured.Unstructured{}
+			updatedVS.SetGroupVersionKind(virtualServiceGVK)
+			Expect(fakeClient.Get(ctx, types.NamespacedName{Name: "source-routes", Namespace: testNamespace}, updatedVS)).To(Succeed())
+			routes, _, _ := unstructured.NestedSlice(updatedVS.Object, "spec", "http")
+			mirrorHost, _, _ := unstructured.NestedString(routes[0].(map[string]interface{}), "mirror", "host")
+			Expect(mirrorHost).To(Equal("experiment-cr-experiment.test-namespace.svc.cluster.local"))
+			Expect(routes[1].(map[string]interface{})).NotTo(HaveKey("mirror"))
+
+			updatedCR := &experimentcontrollercomv1alpha1.ExperimentDeployment{}
+			Expect(fakeClient.Get(ctx, namespacedName, updatedCR)).To(Succeed())
+			Expect(updatedCR.Status.MirroredVirtualServices).To(ConsistOf("test-namespace/source-routes"))
+
+			Expect(fakeClient.Delete(ctx, updatedCR)).To(Succeed())
+			_, err = reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fakeClient.Get(ctx, types.NamespacedName{Name: "source-routes", Namespace: testNamespace}, updatedVS)).To(Succeed())
+			routes, _, _ = unstructured.NestedSlice(updatedVS.Object, "spec", "http")
+			Expect(routes[0].(map[string]interface{})).NotTo(HaveKey("mirror"))
+			Expect(routes[0].(map[string]interface{})).NotTo(HaveKey("mirrorPercentage"))
+		})
+
+		It("should record the VirtualService in status before mirroring traffic", func() {
+			mapper := meta.NewDefaultRESTMapper(nil)
+			for gvk := range scheme.AllKnownTypes() {
+				mapper.Add(gvk, meta.RESTScopeNamespace)
+			}
+			mapper.Add(virtualServiceGVK, meta.RESTScopeNamespace)
+			newReconciler(mapper)
+			var recordedBeforeUpdate []string
+			reconciler.Client = interceptor.NewClient(fakeClient.(client.WithWatch), interceptor.Funcs{
+				Update: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.UpdateOption) error {
+					if obj.GetObjectKind().GroupVersionKind() == virtualServiceGVK {
+						stored := &experimentcontrollercomv1alpha1.ExperimentDeployment{}
+						Expect(c.Get(ctx, namespacedName, stored)).To(Succeed())
+						recordedBeforeUpdate = stored.Status.MirroredVirtualServices
+					}
+					return c.Update(ctx, obj, opts...)
+				},
+			})
+
+			Expect(fakeClient.Create(ctx, sourceDeployment)).To(Succeed())
+			Expect(fakeClient.Create(ctx, sourceService)).To(Succeed())
+			Expect(fakeClient.Create(ctx, virtualService)).To(Succeed())
+			Expect(fakeClient.Create(ctx, experimentCR)).To(Succeed())
+
+			_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(recordedBeforeUpdate).To(ConsistOf("test-namespace/source-routes"))
+		})
+
+		It("should keep recording mirrors of routes that no longer target a source Service", func() {
+			mapper := meta.NewDefaultRESTMapper(nil)
+			for gvk := range scheme.AllKnownTypes() {
+				mapper.Add(gvk, meta.RESTScopeNamespace)
+			}
+			mapper.Add(virtualServiceGVK, meta.RESTScopeNamespace)
+			newReconciler(mapper)
+
+			Expect(fakeClient.Create(ctx, sourceDeployment)).To(Succeed())
+			Expect(fakeClient.Create(ctx, sourceService)).To(Succeed())
+			Expect(fakeClient.Create(ctx, virtualService)).To(Succeed())
+			Expect(fakeClient.Create(ctx, experimentCR)).To(Succeed())
+
+			_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+			Expect(err).NotTo(HaveOccurred())
+
+			// Route the mirrored route elsewhere, its mirror stays recorded
+			updatedVS := &unstructured.Unstructured{}
+			updatedVS.SetGroupVersionKind(virtualServiceGVK)
+			Expect(fakeClient.Get(ctx, types.NamespacedName{Name: "source-routes", Namespace: testNamespace}, updatedVS)).To(Succeed())
+			routes, _, _ := unstructured.NestedSlice(updatedVS.Object, "spec", "http")
+			Expect(unstructured.SetNestedSlice(routes[0].(map[string]interface{}), []interface{}{
+				map[string]interface{}{"destination": map[string]interface{}{"host": "other-service"}},
+			}, "route")).To(Succeed())
+			Expect(unstructured.SetNestedSlice(updatedVS.Object, routes, "spec", "http")).To(Succeed())
+			Expect(fakeClient.Update(ctx, updatedVS)).To(Succeed())
+
+			_, err = reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+			Expect(err).NotTo(HaveOccurred())
+			updatedCR := &experimentcontrollercomv1alpha1.ExperimentDeployment{}
+			Expect(fakeClient.Get(ctx, namespacedName, updatedCR)).To(Succeed())
+			Expect(updatedCR.Status.MirroredVirtualServices).To(ConsistOf("test-namespace/source-routes"))
+
+			updatedCR.Spec.ServiceMode = experimentcontrollercomv1alpha1.ServiceModeIsolated
+			Expect(fakeClient.Update(ctx, updatedCR)).To(Succeed())
+			_, err = reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fakeClient.Get(ctx, types.NamespacedName{Name: "source-routes", Namespace: testNamespace}, updatedVS)).To(Succeed())
+			routes, _, _ = unstructured.NestedSlice(updatedVS.Object, "spec", "http")
+			Expect(routes[0].(map[string]interface{})).NotTo(HaveKey("mirror"))
+			Expect(fakeClient.Get(ctx, namespacedName, updatedCR)).To(Succeed())
+			Expect(updatedCR.Status.MirroredVirtualServices).To(BeEmpty())
+		})
+
+		It("should not look up VirtualServices for experiments that never mirrored traffic", func() {
+			mapper := meta.NewDefaultRESTMapper(nil)
+			for gvk := range scheme.AllKnownTypes() {
+				mapper.Add(gvk, meta.RESTScopeNamespace)
+			}
+			mapper.Add(virtualServiceGVK, meta.RESTScopeNamespace)
+			newReconciler(mapper)
+			var lookups int
+			reconciler.Client = interceptor.NewClient(fakeClient.(client.WithWatch), interceptor.Funcs{
+				Get: func(ctx context.Context, c client.WithWatch, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+					if obj.GetObjectKind().GroupVersionKind() == virtualServiceGVK {
+						lookups++
+					}
+					return c.Get(ctx, key, obj, opts...)
+				},
+				List: func(ctx context.Context, c client.WithWatch, list client.ObjectList, opts ...client.ListOption) error {
+					if list.GetObjectKind().GroupVersionKind().Kind == virtualServiceGVK.Kind+"List" {
+						lookups++
+					}
+					return c.List(ctx, list, opts...)
+				},
+			})
+			experimentCR.Spec.ServiceMode = experimentcontrollercomv1alpha1.ServiceModeShared
+
+			Expect(fakeClient.Create(ctx, sourceDeployment)).To(Succeed())
+			Expect(fakeClient.Create(ctx, sourceService)).To(Succeed())
+			Expect(fakeClient.Create(ctx, virtualService)).To(Succeed())
+			Expect(fakeClient.Create(ctx, experimentCR)).To(Succeed())
+
+			_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(lookups).To(BeZero())
+		})
+	})
+})
@@ -0,0 +1,89 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"slices"
+
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	experimentcontrollercomv1alpha1 "experimentcontroller.example.com/experiment-deployment/api/v1alpha1"
+	"experimentcontroller.example.com/experiment-deployment/internal/sharding"
+)
+
+// ShardingConfig splits the namespaces among the replicas of the controller
+type ShardingConfig struct {
+	// Count is the number of shards the namespaces are hashed to. Sharding is disabled below 2.
+	Count int
+	// Owner reports the shards handled by this replica
+	Owner sharding.Owner
+}
+
+// enabled reports whether the namespaces are split among shards
+func (s ShardingConfig) enabled() bool {
+	return s.Count > 1 && s.Owner != nil
+}
+
+// ownsNamespace reports whether the experiments of a namespace belong to a shard of this replica
+func (r *ExperimentDeploymentReconciler) ownsNamespace(namespace string) bool {
+	return !r.Sharding.enabled() || r.Sharding.Owner.Owns(sharding.ShardFor(namespace, r.Sharding.Count))
+}
+
+// ownsExperiment reports whether this replica handles an experiment: it matches the experiment
+// selector and its namespace belongs to a shard of this replica
+func (r *ExperimentDeploymentReconciler) ownsExperiment(experimentCR *experimentcontrollercomv1alpha1.ExperimentDeployment) bool {
+	if r.ExperimentSelector != nil && !r.ExperimentSelector.Matches(labels.Set(experimentCR.Labels)) {
+		return false
+	}
+	return r.ownsNamespace(experimentCR.Namespace)
+}
+
+// shardPredicate drops the events of namespaced objects of the shards of other replicas.
+// Cluster-scoped objects map to experiments of all shards and are passed on.
+func (r *ExperimentDeploymentReconciler) shardPredicate() predicate.Predicate {
+	return predicate.NewPredicateFuncs(func(obj client.Object) bool {
+		return obj.GetNamespace() == "" || r.ownsNamespace(obj.GetNamespace())
+	})
+}
+
+// shardsAcquired enqueues the experiments of the shards this replica took over, whose events
+// were dropped while other replicas owned them
+func (r *ExperimentDeploymentReconciler) shardsAcquired(ctx context.Context, shards []int) {
+	go func() {
+		experiments := &experimentcontrollercomv1alpha1.ExperimentDeploymentList{}
+		if err := r.List(ctx, experiments); err != nil {
+			logf.FromContext(ctx).Error(err, "Failed to list ExperimentDeployments of acquired shards", "shards", shards)
+			return
+		}
+		for i := range experiments.Items {
+			experimentCR := &experiments.Items[i]
+			if !slices.Contains(shards, sharding.ShardFor(experimentCR.Namespace, r.Sharding.Count)) {
+				continue
+			}
+			select {
+			case r.shardEvents <- event.GenericEvent{Object: experimentCR}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
@@ -0,0 +1,126 @@
+package controller
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+
+	experimentcontrollercomv1alpha1 "experimentcontroller.example.com/experiment-deployment/api/v1alpha1"
+	"experimentcontroller.example.com/experiment-deployment/internal/sharding"
+)
+
+var _ = Describe("Sharding", func() {
+	const shardCount = 4
+
+	var (
+		ctx        context.Context
+		reconciler *ExperimentDeploymentReconciler
+		fakeClient client.Client
+		// ownedNamespace and otherNamespace hash to different shards
+		ownedNamespace, otherNamespace string
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		scheme := runtime.NewScheme()
+		Expect(experimentcontrollercomv1alpha1.AddToScheme(scheme)).To(Succeed())
+		Expect(appsv1.AddToScheme(scheme)).To(Succeed())
+		Expect(corev1.AddToScheme(scheme)).To(Succeed())
+		fakeClient = fake.NewClientBuilder().WithScheme(scheme).
+			WithStatusSubresource(&experimentcontrollercomv1alpha1.ExperimentDeployment{}).
+			WithInterceptorFuncs(withServerSideApply(interceptor.Funcs{})).Build()
+
+		ownedNamespace, otherNamespace = "team-a", ""
+		owned := sharding.ShardFor(ownedNamespace, shardCount)
+		for _, namespace := range []string{"team-b", "team-c", "team-d", "team-e", "team-f"} {
+			if sharding.ShardFor(namespace, shardCount) != owned {
+				otherNamespace = namespace
+				break
+			}
+		}
+		Expect(otherNamespace).NotTo(BeEmpty())
+
+		reconciler = &ExperimentDeploymentReconciler{
+			Client:   fakeClient,
+			Scheme:   scheme,
+			Recorder: record.NewFakeRecorder(100),
+			Sharding: ShardingConfig{Count: shardCount, Owner: sharding.Static(owned)},
+		}
+
+		for _, namespace := range []string{ownedNamespace, otherNamespace} {
+			Expect(fakeClient.Create(ctx, &experimentcontrollercomv1alpha1.ExperimentDeployment{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      testExperimentCRName,
+					Namespace: namespace,
+					Labels:    map[string]string{"controller-version": "canary"},
+				},
+				Spec: experimentcontrollercomv1alpha1.ExperimentDeploymentSpec{
+					SourceRef: experimentcontrollercomv1alpha1.SourceRef{
+						Kind: experimentcontrollercomv1alpha1.SourceKindDeployment,
+						Name: "web",
+					},
+					OverrideSpec: apiextensionsv1.JSON{Raw: []byte(`{}`)},
+				},
+			})).To(Succeed())
+		}
+	})
+
+	reconcileIn := func(namespace string) {
+		_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: types.NamespacedName{Name: testExperimentCRName, Namespace: namespace}})
+		Expect(err).NotTo(HaveOccurred())
+	}
+
+	finalizersIn := func(namespace string) []string {
+		experimentCR := &experimentcontrollercomv1alpha1.ExperimentDeployment{}
+		Expect(fakeClient.Get(ctx, types.NamespacedName{Name: testExperimentCRName, Namespace: namespace}, experimentCR)).To(Succeed())
+		return experimentCR.Finalizers
+	}
+
+	It("should only handle the experiments of the owned shards", func() {
+		reconcileIn(ownedNamespace)
+		reconcileIn(otherNamespace)
+
+		Expect(finalizersIn(ownedNamespace)).To(ContainElement(experimentDeploymentFinalizer))
+		Expect(finalizersIn(otherNamespace)).To(BeEmpty())
+	})
+
+	It("should only handle the experiments matching the experiment selector", func() {
+		selector, err := labels.Parse("controller-version!=canary")
+		Expect(err).NotTo(HaveOccurred())
+		reconciler.ExperimentSelector = selector
+		reconcileIn(ownedNamespace)
+		Expect(finalizersIn(ownedNamespace)).To(BeEmpty())
+
+		reconciler.ExperimentSelector = labels.SelectorFromSet(labels.Set{"controller-version": "canary"})
+		reconcileIn(ownedNamespace)
+		Expect(finalizersIn(ownedNamespace)).To(ContainElement(experimentDeploymentFinalizer))
+	})
+
+	It("should drop the events of namespaced objects of other shards", func() {
+		filter := reconciler.shardPredicate()
+		pod := func(namespace string) *corev1.Pod {
+			return &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: namespace}}
+		}
+
+		Expect(filter.Generic(event.GenericEvent{Object: pod(ownedNamespace)})).To(BeTrue())
+		Expect(filter.Generic(event.GenericEvent{Object: pod(otherNamespace)})).To(BeFalse())
+		Expect(filter.Generic(event.GenericEvent{Object: &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: otherNamespace}}})).To(BeTrue())
+
+		reconciler.Sharding = ShardingConfig{Count: 1, Owner: sharding.Static(0)}
+		Expect(filter.Generic(event.GenericEvent{Object: pod(otherNamespace)})).To(BeTrue())
+	})
+})
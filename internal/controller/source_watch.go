@@ -0,0 +1,112 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	rolloutsv1alpha1 "github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+
+	experimentcontrollercomv1alpha1 "experimentcontroller.example.com/experiment-deployment/api/v1alpha1"
+)
+
+// sourceRefIndex indexes ExperimentDeployments by the key of their source workload
+const sourceRefIndex = "spec.sourceRef"
+
+// ManagedBySelector selects the objects created by the controller, labeled with the managed-by
+// label of the label domain. Only the workloads it selects need to be cached.
+func ManagedBySelector(labelDomain string) labels.Selector {
+	return labels.SelectorFromSet(labels.Set{labelDomain + "/" + labelManagedBy: ManagedByValue})
+}
+
+// sourceKey identifies a source workload by kind, namespace and name
+func sourceKey(kind experimentcontrollercomv1alpha1.SourceKind, namespace, name string) string {
+	return fmt.Sprintf("%s/%s/%s", kind, namespace, name)
+}
+
+// sourceReader returns the reader of source workloads, the API server when set, so that the
+// sources need not be cached in full
+func (r *ExperimentDeploymentReconciler) sourceReader() client.Reader {
+	if r.APIReader != nil {
+		return r.APIReader
+	}
+	return r.Client
+}
+
+// sourceKinds are the kinds of source workloads watched, with their group version
+func (r *ExperimentDeploymentReconciler) sourceKinds(rolloutsAvailable bool) map[experimentcontrollercomv1alpha1.SourceKind]schema.GroupVersion {
+	kinds := map[experimentcontrollercomv1alpha1.SourceKind]schema.GroupVersion{
+		experimentcontrollercomv1alpha1.SourceKindDeployment:  appsv1.SchemeGroupVersion,
+		experimentcontrollercomv1alpha1.SourceKindStatefulSet: appsv1.SchemeGroupVersion,
+	}
+	if rolloutsAvailable {
+		kinds[experimentcontrollercomv1alpha1.SourceKindRollout] = rolloutsv1alpha1.SchemeGroupVersion
+	}
+	return kinds
+}
+
+// sourceWatch watches the metadata of the source workloads of a kind in the SourceCache. Changes
+// of their spec bump their generation and reconcile the experiments cloning them.
+func (r *ExperimentDeploymentReconciler) sourceWatch(kind experimentcontrollercomv1alpha1.SourceKind, gv schema.GroupVersion) source.Source {
+	sourceMetadata := &metav1.PartialObjectMetadata{}
+	sourceMetadata.SetGroupVersionKind(gv.WithKind(string(kind)))
+	return source.Kind(r.SourceCache, sourceMetadata,
+		handler.TypedEnqueueRequestsFromMapFunc(func(ctx context.Context, obj *metav1.PartialObjectMetadata) []reconcile.Request {
+			return r.experimentsForSource(ctx, kind, obj)
+		}),
+		predicate.TypedGenerationChangedPredicate[*metav1.PartialObjectMetadata]{})
+}
+
+// experimentsForSource enqueues the ExperimentDeployments cloning a changed source workload
+func (r *ExperimentDeploymentReconciler) experimentsForSource(
+	ctx context.Context,
+	kind experimentcontrollercomv1alpha1.SourceKind,
+	obj client.Object) []reconcile.Request {
+
+	experiments := &experimentcontrollercomv1alpha1.ExperimentDeploymentList{}
+	if err := r.List(ctx, experiments, client.MatchingFields{sourceRefIndex: sourceKey(kind, obj.GetNamespace(), obj.GetName())}); err != nil {
+		logf.FromContext(ctx).Error(err, "Failed to list ExperimentDeployments for source change", "kind", kind, "name", obj.GetName())
+		return nil
+	}
+	requests := make([]reconcile.Request, 0, len(experiments.Items))
+	for _, experiment := range experiments.Items {
+		requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: experiment.Name, Namespace: experiment.Namespace}})
+	}
+	return requests
+}
+
+// indexSourceRef returns the key of the source workload of an ExperimentDeployment for the sourceRefIndex
+func indexSourceRef(obj client.Object) []string {
+	experimentCR, ok := obj.(*experimentcontrollercomv1alpha1.ExperimentDeployment)
+	if !ok {
+		return nil
+	}
+	return []string{experimentSourceKey(experimentCR)}
+}
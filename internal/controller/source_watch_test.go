@@ -0,0 +1,109 @@
+package controller
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	experimentcontrollercomv1alpha1 "experimentcontroller.example.com/experiment-deployment/api/v1alpha1"
+	"experimentcontroller.example.com/experiment-deployment/internal/config"
+)
+
+var _ = Describe("Source watches", func() {
+	var (
+		ctx        context.Context
+		scheme     *runtime.Scheme
+		reconciler *ExperimentDeploymentReconciler
+		// cachedClient only holds the objects of the label-filtered cache, apiReader the source
+		// workloads read from the API server
+		cachedClient client.Client
+		apiReader    client.Client
+	)
+
+	newExperiment := func(name, namespace, sourceName string) *experimentcontrollercomv1alpha1.ExperimentDeployment {
+		return &experimentcontrollercomv1alpha1.ExperimentDeployment{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace, Finalizers: []string{experimentDeploymentFinalizer}},
+			Spec: experimentcontrollercomv1alpha1.ExperimentDeploymentSpec{
+				SourceRef: experimentcontrollercomv1alpha1.SourceRef{
+					Kind: experimentcontrollercomv1alpha1.SourceKindDeployment,
+					Name: sourceName,
+				},
+				OverrideSpec: apiextensionsv1.JSON{Raw: []byte(`{}`)},
+			},
+		}
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		scheme = runtime.NewScheme()
+		Expect(experimentcontrollercomv1alpha1.AddToScheme(scheme)).To(Succeed())
+		Expect(appsv1.AddToScheme(scheme)).To(Succeed())
+		Expect(corev1.AddToScheme(scheme)).To(Succeed())
+
+		cachedClient = fake.NewClientBuilder().WithScheme(scheme).
+			WithStatusSubresource(&experimentcontrollercomv1alpha1.ExperimentDeployment{}).
+			WithIndex(&experimentcontrollercomv1alpha1.ExperimentDeployment{}, sourceRefIndex, indexSourceRef).
+			WithInterceptorFuncs(withServerSideApply(interceptor.Funcs{})).
+			WithObjects(
+				newExperiment("web-canary", testNamespace, "web"),
+				newExperiment("web-debug", testNamespace, "web"),
+				newExperiment("api-canary", testNamespace, "api"),
+			).Build()
+		apiReader = fake.NewClientBuilder().WithScheme(scheme).WithObjects(&appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: testNamespace},
+			Spec: appsv1.DeploymentSpec{
+				Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "web"}},
+				Template: corev1.PodTemplateSpec{
+					ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": "web"}},
+					Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "web", Image: "nginx:1.27"}}},
+				},
+			},
+		}).Build()
+		reconciler = &ExperimentDeploymentReconciler{
+			Client:    cachedClient,
+			APIReader: apiReader,
+			Scheme:    scheme,
+			Recorder:  record.NewFakeRecorder(100),
+		}
+	})
+
+	It("should read source workloads from the API server", func() {
+		_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: types.NamespacedName{Name: "web-canary", Namespace: testNamespace}})
+		Expect(err).NotTo(HaveOccurred())
+
+		experimentDeployment := &appsv1.Deployment{}
+		Expect(cachedClient.Get(ctx, types.NamespacedName{Name: "web-canary", Namespace: testNamespace}, experimentDeployment)).To(Succeed())
+		Expect(experimentDeployment.Spec.Template.Spec.Containers[0].Image).To(Equal("nginx:1.27"))
+		// The experiment workload is selected by the cache filter
+		Expect(ManagedBySelector(config.DefaultLabelDomain).Matches(labels.Set(experimentDeployment.Labels))).To(BeTrue())
+	})
+
+	It("should enqueue the experiments cloning a changed source", func() {
+		source := &metav1.PartialObjectMetadata{ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: testNamespace}}
+
+		Expect(reconciler.experimentsForSource(ctx, experimentcontrollercomv1alpha1.SourceKindDeployment, source)).To(ConsistOf(
+			reconcile.Request{NamespacedName: types.NamespacedName{Name: "web-canary", Namespace: testNamespace}},
+			reconcile.Request{NamespacedName: types.NamespacedName{Name: "web-debug", Namespace: testNamespace}},
+		))
+		Expect(reconciler.experimentsForSource(ctx, experimentcontrollercomv1alpha1.SourceKindStatefulSet, source)).To(BeEmpty())
+	})
+
+	It("should only watch Rollouts when they are installed", func() {
+		Expect(reconciler.sourceKinds(false)).NotTo(HaveKey(experimentcontrollercomv1alpha1.SourceKindRollout))
+		Expect(reconciler.sourceKinds(true)).To(HaveKey(experimentcontrollercomv1alpha1.SourceKindRollout))
+	})
+})
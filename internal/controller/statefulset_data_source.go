@@ -0,0 +1,344 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	experimentcontrollercomv1alpha1 "experimentcontroller.example.com/experiment-deployment/api/v1alpha1"
+)
+
+// volumeSnapshotGVK is the CSI VolumeSnapshot kind used to clone the source PVCs
+var volumeSnapshotGVK = schema.GroupVersionKind{Group: "snapshot.storage.k8s.io", Version: "v1", Kind: "VolumeSnapshot"}
+
+// Phases of the experiment PVC seeding
+const (
+	DataSourcePhaseSnapshotting = "Snapshotting"
+	DataSourcePhaseReady        = "Ready"
+	DataSourcePhaseFailed       = "Failed"
+)
+
+// statefulSetClaimName returns the name the StatefulSet controller gives to the PVC of a
+// volumeClaimTemplate for the pod with the given ordinal
+func statefulSetClaimName(claimTemplate, statefulSetName string, ordinal int32) string {
+	return fmt.Sprintf("%s-%s-%d", claimTemplate, statefulSetName, ordinal)
+}
+
+// experimentSnapshotName returns the name of the VolumeSnapshot taken of a source PVC
+func experimentSnapshotName(experimentCR *experimentcontrollercomv1alpha1.ExperimentDeployment, claimTemplate string, ordinal int32) string {
+	return fmt.Sprintf("%s-%s-%d", experimentCR.Name, claimTemplate, ordinal)
+}
+
+// isVolumeSnapshotAvailable checks if the CSI VolumeSnapshot CRD exists in the cluster
+func (r *ExperimentDeploymentReconciler) isVolumeSnapshotAvailable() bool {
+	_, err := r.RESTMapper().RESTMapping(volumeSnapshotGVK.GroupKind(), volumeSnapshotGVK.Version)
+	return err == nil
+}
+
+// reconcileStatefulSetDataSource pre-creates the experiment PVCs cloned from VolumeSnapshots of
+// the source PVCs, or from existing VolumeSnapshots, so the experiment StatefulSet adopts them
+// instead of provisioning empty volumes. It returns false while the experiment StatefulSet must
+// wait for VolumeSnapshots to become ready to use. PVCs that already exist are left untouched,
+// and ordinals without a source PVC start with an empty volume.
+func (r *ExperimentDeploymentReconciler) reconcileStatefulSetDataSource(
+	ctx context.Context,
+	experimentCR *experimentcontrollercomv1alpha1.ExperimentDeployment,
+	sourceStatefulSet *appsv1.StatefulSet,
+	desired *appsv1.StatefulSet) (bool, error) {
+
+	log := logf.FromContext(ctx)
+
+	if experimentCR.Spec.StatefulSet == nil || experimentCR.Spec.StatefulSet.DataSource == nil {
+		experimentCR.Status.DataSource = nil
+		return true, nil
+	}
+	dataSource := experimentCR.Spec.StatefulSet.DataSource
+
+	// VolumeSnapshots can only be restored to PVCs in their own namespace
+	if sourceStatefulSet.Namespace != experimentCR.Namespace {
+		err := fmt.Errorf("statefulSet.dataSource requires the source StatefulSet to be in the experiment namespace %s", experimentCR.Namespace)
+		log.Error(err, "Cannot seed experiment volumes across namespaces")
+		r.Recorder.Event(experimentCR, corev1.EventTypeWarning, "SnapshotFailed", err.Error())
+		r.updateStatusConditions(experimentCR, experimentcontrollercomv1alpha1.ReasonSnapshotFailed, err.Error())
+		return false, err
+	}
+
+	if !r.isVolumeSnapshotAvailable() {
+		err := fmt.Errorf("the VolumeSnapshot API is not installed in this cluster, cannot seed statefulSet.dataSource")
+		log.Error(err, "VolumeSnapshots not supported")
+		r.Recorder.Event(experimentCR, corev1.EventTypeWarning, "SnapshotNotSupported", err.Error())
+		r.updateStatusConditions(experimentCR, experimentcontrollercomv1alpha1.ReasonSnapshotNotSupported, err.Error())
+		return false, err
+	}
+
+	existingSnapshots := make(map[string]string)
+	for _, existing := range dataSource.ExistingSnapshots {
+		existingSnapshots[existing.ClaimTemplate] = existing.SnapshotName
+	}
+
+	replicas := int32(0)
+	if desired.Spec.Replicas != nil {
+		replicas = *desired.Spec.Replicas
+	}
+	// Experiment pods are seeded from the source pod at the same position, whatever their ordinals.start
+	sourceStart := statefulSetOrdinalStart(sourceStatefulSet)
+	experimentStart := statefulSetOrdinalStart(desired)
+
+	status := &experimentcontrollercomv1alpha1.DataSourceStatus{}
+	for _, claimTemplate := range desired.Spec.VolumeClaimTemplates {
+		for i := int32(0); i < replicas; i++ {
+			volumeStatus, err := r.seedExperimentClaim(ctx, experimentCR, sourceStatefulSet, desired, claimTemplate, sourceStart+i, experimentStart+i, existingSnapshots)
+			if err != nil {
+				return false, err
+			}
+			if volumeStatus != nil {
+				status.Volumes = append(status.Volumes, *volumeStatus)
+			}
+		}
+	}
+
+	status.Phase = DataSourcePhaseReady
+	for _, volumeStatus := range status.Volumes {
+		if volumeStatus.Message != "" {
+			status.Phase = DataSourcePhaseFailed
+			break
+		}
+		if !volumeStatus.Created {
+			status.Phase = DataSourcePhaseSnapshotting
+		}
+	}
+	experimentCR.Status.DataSource = status
+
+	switch status.Phase {
+	case DataSourcePhaseFailed:
+		message := "VolumeSnapshot failed, experiment StatefulSet is waiting for its volumes"
+		for _, volumeStatus := range status.Volumes {
+			if volumeStatus.Message != "" {
+				message = fmt.Sprintf("VolumeSnapshot %s failed: %s", volumeStatus.SnapshotName, volumeStatus.Message)
+				break
+			}
+		}
+		r.Recorder.Event(experimentCR, corev1.EventTypeWarning, "SnapshotFailed", message)
+		r.updateStatusConditions(experimentCR, experimentcontrollercomv1alpha1.ReasonSnapshotFailed, message)
+		return false, nil
+	case DataSourcePhaseSnapshotting:
+		r.updateStatusConditions(experimentCR, experimentcontrollercomv1alpha1.ReasonSnapshotInProgress, "Waiting for VolumeSnapshots to be ready to use before starting the experiment StatefulSet")
+		return false, nil
+	}
+	return true, nil
+}
+
+// seedExperimentClaim makes sure the VolumeSnapshot of a single experiment PVC exists and creates the
+// PVC once the snapshot is ready to use. It returns nil when the ordinal has no data to clone.
+func (r *ExperimentDeploymentReconciler) seedExperimentClaim(
+	ctx context.Context,
+	experimentCR *experimentcontrollercomv1alpha1.ExperimentDeployment,
+	sourceStatefulSet *appsv1.StatefulSet,
+	desired *appsv1.StatefulSet,
+	claimTemplate corev1.PersistentVolumeClaim,
+	sourceOrdinal, experimentOrdinal int32,
+	existingSnapshots map[string]string) (*experimentcontrollercomv1alpha1.VolumeSeedStatus, error) {
+
+	log := logf.FromContext(ctx)
+
+	claimName := statefulSetClaimName(claimTemplate.Name, desired.Name, experimentOrdinal)
+	snapshotName, reuseSnapshot := existingSnapshots[claimTemplate.Name]
+	if !reuseSnapshot {
+		snapshotName = experimentSnapshotName(experimentCR, claimTemplate.Name, experimentOrdinal)
+	}
+	volumeStatus := &experimentcontrollercomv1alpha1.VolumeSeedStatus{ClaimName: claimName, SnapshotName: snapshotName}
+
+	// PVCs that already exist are bound to the experiment StatefulSet and never re-seeded
+	existingClaim := &corev1.PersistentVolumeClaim{}
+	err := r.Get(ctx, types.NamespacedName{Name: claimName, Namespace: desired.Namespace}, existingClaim)
+	if err == nil {
+		volumeStatus.ReadyToUse = true
+		volumeStatus.Created = true
+		return volumeStatus, nil
+	}
+	if !k8serrors.IsNotFound(err) {
+		log.Error(err, "Failed to get experiment PVC", "claim", claimName)
+		return nil, err
+	}
+
+	snapshot := &unstructured.Unstructured{}
+	snapshot.SetGroupVersionKind(volumeSnapshotGVK)
+	err = r.Get(ctx, types.NamespacedName{Name: snapshotName, Namespace: experimentCR.Namespace}, snapshot)
+	if err != nil && !k8serrors.IsNotFound(err) {
+		log.Error(err, "Failed to get VolumeSnapshot", "snapshot", snapshotName)
+		return nil, err
+	}
+	if k8serrors.IsNotFound(err) {
+		if reuseSnapshot {
+			volumeStatus.Message = fmt.Sprintf("VolumeSnapshot %s not found", snapshotName)
+			return volumeStatus, nil
+		}
+		sourceClaimName := statefulSetClaimName(claimTemplate.Name, sourceStatefulSet.Name, sourceOrdinal)
+		created, err := r.createSourceClaimSnapshot(ctx, experimentCR, sourceClaimName, snapshotName)
+		if err != nil {
+			return nil, err
+		}
+		if !created {
+			log.Info("Source PVC not found, experiment volume starts empty", "sourceClaim", sourceClaimName, "claim", claimName)
+			return nil, nil
+		}
+		return volumeStatus, nil
+	}
+
+	if message, found, _ := unstructured.NestedString(snapshot.Object, "status", "error", "message"); found && message != "" {
+		volumeStatus.Message = message
+		return volumeStatus, nil
+	}
+	readyToUse, _, _ := unstructured.NestedBool(snapshot.Object, "status", "readyToUse")
+	volumeStatus.ReadyToUse = readyToUse
+	if !readyToUse {
+		return volumeStatus, nil
+	}
+
+	restoreSize, _, _ := unstructured.NestedString(snapshot.Object, "status", "restoreSize")
+	claim, err := r.constructSeededClaim(experimentCR, desired, claimTemplate, claimName, snapshotName, restoreSize)
+	if err != nil {
+		return nil, err
+	}
+	if err := controllerutil.SetOwnerReference(experimentCR, claim, r.Scheme); err != nil {
+		return nil, err
+	}
+	if err := r.Create(ctx, claim); err != nil && !k8serrors.IsAlreadyExists(err) {
+		log.Error(err, "Failed to create experiment PVC", "claim", claimName)
+		r.Recorder.Eventf(experimentCR, corev1.EventTypeWarning, "UpsertFailed", "Failed to create experiment PVC %s: %s", claimName, err.Error())
+		r.updateStatusConditions(experimentCR, experimentcontrollercomv1alpha1.ReasonUpsertFailed, fmt.Sprintf("Failed to create experiment PVC %s: %s", claimName, err.Error()))
+		return nil, err
+	}
+	log.Info("Experiment PVC cloned from VolumeSnapshot", "claim", claimName, "snapshot", snapshotName)
+	r.Recorder.Eventf(experimentCR, corev1.EventTypeNormal, "VolumeSeeded", "Experiment PVC %s cloned from VolumeSnapshot %s", claimName, snapshotName)
+	volumeStatus.Created = true
+	return volumeStatus, nil
+}
+
+// createSourceClaimSnapshot takes a VolumeSnapshot of a source PVC. It returns false when the source
+// PVC does not exist, e.g. for experiments running more replicas than the source StatefulSet.
+func (r *ExperimentDeploymentReconciler) createSourceClaimSnapshot(
+	ctx context.Context,
+	experimentCR *experimentcontrollercomv1alpha1.ExperimentDeployment,
+	sourceClaimName, snapshotName string) (bool, error) {
+
+	log := logf.FromContext(ctx)
+
+	sourceClaim := &corev1.PersistentVolumeClaim{}
+	if err := r.Get(ctx, types.NamespacedName{Name: sourceClaimName, Namespace: experimentCR.Namespace}, sourceClaim); err != nil {
+		if k8serrors.IsNotFound(err) {
+			return false, nil
+		}
+		log.Error(err, "Failed to get source PVC", "claim", sourceClaimName)
+		return false, err
+	}
+
+	snapshot := &unstructured.Unstructured{}
+	snapshot.SetGroupVersionKind(volumeSnapshotGVK)
+	snapshot.SetName(snapshotName)
+	snapshot.SetNamespace(experimentCR.Namespace)
+	snapshot.SetLabels(map[string]string{
+		r.labelKey(labelManagedBy): ManagedByValue,
+		r.labelKey(labelCRName):    experimentCR.Name,
+	})
+	spec := map[string]interface{}{
+		"source": map[string]interface{}{"persistentVolumeClaimName": sourceClaimName},
+	}
+	if className := experimentCR.Spec.StatefulSet.DataSource.VolumeSnapshotClassName; className != "" {
+		spec["volumeSnapshotClassName"] = className
+	}
+	snapshot.Object["spec"] = spec
+	if err := controllerutil.SetControllerReference(experimentCR, snapshot, r.Scheme); err != nil {
+		return false, err
+	}
+
+	if err := r.Create(ctx, snapshot); err != nil && !k8serrors.IsAlreadyExists(err) {
+		log.Error(err, "Failed to create VolumeSnapshot", "snapshot", snapshotName, "sourceClaim", sourceClaimName)
+		r.Recorder.Eventf(experimentCR, corev1.EventTypeWarning, "SnapshotFailed", "Failed to snapshot source PVC %s: %s", sourceClaimName, err.Error())
+		r.updateStatusConditions(experimentCR, experimentcontrollercomv1alpha1.ReasonSnapshotFailed, fmt.Sprintf("Failed to snapshot source PVC %s: %s", sourceClaimName, err.Error()))
+		return false, err
+	}
+	log.Info("Created VolumeSnapshot of source PVC", "snapshot", snapshotName, "sourceClaim", sourceClaimName)
+	r.Recorder.Eventf(experimentCR, corev1.EventTypeNormal, "SnapshotCreated", "Created VolumeSnapshot %s of source PVC %s", snapshotName, sourceClaimName)
+	return true, nil
+}
+
+// constructSeededClaim builds an experiment PVC from a volumeClaimTemplate, restored from a VolumeSnapshot.
+// The storage request is raised to the restore size of the snapshot when the template asks for less.
+func (r *ExperimentDeploymentReconciler) constructSeededClaim(
+	experimentCR *experimentcontrollercomv1alpha1.ExperimentDeployment,
+	desired *appsv1.StatefulSet,
+	claimTemplate corev1.PersistentVolumeClaim,
+	claimName, snapshotName, restoreSize string) (*corev1.PersistentVolumeClaim, error) {
+
+	// The StatefulSet controller labels its PVCs with the template and selector labels
+	claimLabels := make(map[string]string)
+	for k, v := range claimTemplate.Labels {
+		claimLabels[k] = v
+	}
+	if desired.Spec.Selector != nil {
+		for k, v := range desired.Spec.Selector.MatchLabels {
+			claimLabels[k] = v
+		}
+	}
+	claimLabels[r.labelKey(labelManagedBy)] = ManagedByValue
+	claimLabels[r.labelKey(labelCRName)] = experimentCR.Name
+
+	spec := *claimTemplate.Spec.DeepCopy()
+	snapshotAPIGroup := volumeSnapshotGVK.Group
+	spec.DataSource = &corev1.TypedLocalObjectReference{
+		APIGroup: &snapshotAPIGroup,
+		Kind:     volumeSnapshotGVK.Kind,
+		Name:     snapshotName,
+	}
+	spec.DataSourceRef = nil
+
+	if restoreSize != "" {
+		size, err := resource.ParseQuantity(restoreSize)
+		if err != nil {
+			return nil, fmt.Errorf("invalid restoreSize %q of VolumeSnapshot %s: %w", restoreSize, snapshotName, err)
+		}
+		if spec.Resources.Requests == nil {
+			spec.Resources.Requests = corev1.ResourceList{}
+		}
+		if requested, ok := spec.Resources.Requests[corev1.ResourceStorage]; !ok || requested.Cmp(size) < 0 {
+			spec.Resources.Requests[corev1.ResourceStorage] = size
+		}
+	}
+
+	return &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        claimName,
+			Namespace:   desired.Namespace,
+			Labels:      claimLabels,
+			Annotations: claimTemplate.Annotations,
+		},
+		Spec: spec,
+	}, nil
+}
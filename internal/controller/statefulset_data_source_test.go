@@ -0,0 +1,268 @@
+package controller
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	experimentcontrollercomv1alpha1 "experimentcontroller.example.com/experiment-deployment/api/v1alpha1"
+)
+
+var _ = Describe("StatefulSet Data Source", func() {
+	var (
+		ctx               context.Context
+		reconciler        *ExperimentDeploymentReconciler
+		fakeClient        client.Client
+		scheme            *runtime.Scheme
+		sourceStatefulSet *appsv1.StatefulSet
+		sourceClaims      []*corev1.PersistentVolumeClaim
+		experimentCR      *experimentcontrollercomv1alpha1.ExperimentDeployment
+		namespacedName    types.NamespacedName
+	)
+
+	newReconciler := func(withSnapshots bool) {
+		builder := fake.NewClientBuilder().WithScheme(scheme).WithInterceptorFuncs(withServerSideApply(interceptor.Funcs{})).WithStatusSubresource(&experimentcontrollercomv1alpha1.ExperimentDeployment{})
+		if withSnapshots {
+			mapper := meta.NewDefaultRESTMapper(nil)
+			for gvk := range scheme.AllKnownTypes() {
+				mapper.Add(gvk, meta.RESTScopeNamespace)
+			}
+			mapper.Add(volumeSnapshotGVK, meta.RESTScopeNamespace)
+			builder = builder.WithRESTMapper(mapper)
+		}
+		fakeClient = builder.Build()
+		reconciler = &ExperimentDeploymentReconciler{
+			Client:   fakeClient,
+			Scheme:   scheme,
+			Recorder: record.NewFakeRecorder(100),
+		}
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		scheme = runtime.NewScheme()
+		Expect(experimentcontrollercomv1alpha1.AddToScheme(scheme)).To(Succeed())
+		Expect(appsv1.AddToScheme(scheme)).To(Succeed())
+		Expect(corev1.AddToScheme(scheme)).To(Succeed())
+		newReconciler(true)
+
+		namespacedName = types.NamespacedName{Name: testExperimentCRName, Namespace: testNamespace}
+		replicas := int32(2)
+		sourceStatefulSet = &appsv1.StatefulSet{
+			ObjectMeta: metav1.ObjectMeta{Name: "source-db", Namespace: testNamespace},
+			Spec: appsv1.StatefulSetSpec{
+				Replicas: &replicas,
+				Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "db"}},
+				Template: corev1.PodTemplateSpec{
+					ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": "db"}},
+					Spec: corev1.PodSpec{
+						Containers: []corev1.Container{{Name: "db", Image: "postgres:15"}},
+					},
+				},
+				VolumeClaimTemplates: []corev1.PersistentVolumeClaim{{
+					ObjectMeta: metav1.ObjectMeta{Name: "data"},
+					Spec: corev1.PersistentVolumeClaimSpec{
+						AccessModes: []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+						Resources: corev1.VolumeResourceRequirements{
+							Requests: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse("1Gi")},
+						},
+					},
+				}},
+			},
+		}
+		sourceClaims = nil
+		for _, name := range []string{"data-source-db-0", "data-source-db-1"} {
+			sourceClaims = append(sourceClaims, &corev1.PersistentVolumeClaim{
+				ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: testNamespace},
+				Spec:       sourceStatefulSet.Spec.VolumeClaimTemplates[0].Spec,
+			})
+		}
+		experimentCR = &experimentcontrollercomv1alpha1.ExperimentDeployment{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:       testExperimentCRName,
+				Namespace:  testNamespace,
+				Finalizers: []string{experimentDeploymentFinalizer},
+			},
+			Spec: experimentcontrollercomv1alpha1.ExperimentDeploymentSpec{
+				SourceRef: experimentcontrollercomv1alpha1.SourceRef{
+					Kind: experimentcontrollercomv1alpha1.SourceKindStatefulSet,
+					Name: "source-db",
+				},
+				OverrideSpec: apiextensionsv1.JSON{Raw: []byte(`{"template":{"spec":{"containers":[{"name":"db","image":"postgres:16"}]}}}`)},
+				StatefulSet: &experimentcontrollercomv1alpha1.StatefulSetOptions{
+					DataSource: &experimentcontrollercomv1alpha1.StatefulSetDataSource{
+						VolumeSnapshotClassName: "csi-snapclass",
+					},
+				},
+			},
+		}
+	})
+
+	getSnapshot := func(name string) *unstructured.Unstructured {
+		snapshot := &unstructured.Unstructured{}
+		snapshot.SetGroupVersionKind(volumeSnapshotGVK)
+		Expect(fakeClient.Get(ctx, types.NamespacedName{Name: name, Namespace: testNamespace}, snapshot)).To(Succeed())
+		return snapshot
+	}
+
+	markSnapshotReady := func(name, restoreSize string) {
+		snapshot := getSnapshot(name)
+		Expect(unstructured.SetNestedField(snapshot.Object, true, "status", "readyToUse")).To(Succeed())
+		Expect(unstructured.SetNestedField(snapshot.Object, restoreSize, "status", "restoreSize")).To(Succeed())
+		Expect(fakeClient.Update(ctx, snapshot)).To(Succeed())
+	}
+
+	getUpdatedCR := func() *experimentcontrollercomv1alpha1.ExperimentDeployment {
+		updatedCR := &experimentcontrollercomv1alpha1.ExperimentDeployment{}
+		Expect(fakeClient.Get(ctx, namespacedName, updatedCR)).To(Succeed())
+		return updatedCR
+	}
+
+	It("should snapshot the source PVCs and clone them before creating the StatefulSet", func() {
+		Expect(fakeClient.Create(ctx, sourceStatefulSet)).To(Succeed())
+		for _, claim := range sourceClaims {
+			Expect(fakeClient.Create(ctx, claim)).To(Succeed())
+		}
+		Expect(fakeClient.Create(ctx, experimentCR)).To(Succeed())
+
+		result, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.RequeueAfter).NotTo(BeZero())
+
+		snapshot := getSnapshot("experiment-cr-data-0")
+		sourceClaimName, _, _ := unstructured.NestedString(snapshot.Object, "spec", "source", "persistentVolumeClaimName")
+		Expect(sourceClaimName).To(Equal("data-source-db-0"))
+		className, _, _ := unstructured.NestedString(snapshot.Object, "spec", "volumeSnapshotClassName")
+		Expect(className).To(Equal("csi-snapclass"))
+		Expect(snapshot.GetOwnerReferences()).To(HaveLen(1))
+		getSnapshot("experiment-cr-data-1")
+
+		// The StatefulSet waits for the snapshots
+		err = fakeClient.Get(ctx, namespacedName, &appsv1.StatefulSet{})
+		Expect(err).To(HaveOccurred())
+		updatedCR := getUpdatedCR()
+		Expect(updatedCR.Status.DataSource.Phase).To(Equal(DataSourcePhaseSnapshotting))
+		Expect(updatedCR.Status.DataSource.Volumes).To(HaveLen(2))
+		readyCondition := meta.FindStatusCondition(updatedCR.Status.Conditions, ConditionTypeReady)
+		Expect(readyCondition.Reason).To(Equal("SnapshotInProgress"))
+
+		markSnapshotReady("experiment-cr-data-0", "2Gi")
+		markSnapshotReady("experiment-cr-data-1", "512Mi")
+
+		_, err = reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+		Expect(err).NotTo(HaveOccurred())
+
+		claim := &corev1.PersistentVolumeClaim{}
+		Expect(fakeClient.Get(ctx, types.NamespacedName{Name: "data-experiment-cr-0", Namespace: testNamespace}, claim)).To(Succeed())
+		Expect(claim.Spec.DataSource.Kind).To(Equal("VolumeSnapshot"))
+		Expect(claim.Spec.DataSource.Name).To(Equal("experiment-cr-data-0"))
+		Expect(claim.Labels).To(HaveKeyWithValue("experiment-controller.example.com/cr-name", testExperimentCRName))
+		requested := claim.Spec.Resources.Requests[corev1.ResourceStorage]
+		Expect(requested.String()).To(Equal("2Gi"))
+
+		Expect(fakeClient.Get(ctx, types.NamespacedName{Name: "data-experiment-cr-1", Namespace: testNamespace}, claim)).To(Succeed())
+		requested = claim.Spec.Resources.Requests[corev1.ResourceStorage]
+		Expect(requested.String()).To(Equal("1Gi"))
+
+		Expect(fakeClient.Get(ctx, namespacedName, &appsv1.StatefulSet{})).To(Succeed())
+		Expect(getUpdatedCR().Status.DataSource.Phase).To(Equal(DataSourcePhaseReady))
+	})
+
+	It("should clone every ordinal from an existing snapshot", func() {
+		experimentCR.Spec.StatefulSet.DataSource.ExistingSnapshots = []experimentcontrollercomv1alpha1.ExistingVolumeSnapshot{
+			{ClaimTemplate: "data", SnapshotName: "nightly-backup"},
+		}
+		existing := &unstructured.Unstructured{}
+		existing.SetGroupVersionKind(volumeSnapshotGVK)
+		existing.SetName("nightly-backup")
+		existing.SetNamespace(testNamespace)
+		Expect(unstructured.SetNestedField(existing.Object, true, "status", "readyToUse")).To(Succeed())
+		Expect(fakeClient.Create(ctx, existing)).To(Succeed())
+		Expect(fakeClient.Create(ctx, sourceStatefulSet)).To(Succeed())
+		Expect(fakeClient.Create(ctx, experimentCR)).To(Succeed())
+
+		_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+		Expect(err).NotTo(HaveOccurred())
+
+		for _, claimName := range []string{"data-experiment-cr-0", "data-experiment-cr-1"} {
+			claim := &corev1.PersistentVolumeClaim{}
+			Expect(fakeClient.Get(ctx, types.NamespacedName{Name: claimName, Namespace: testNamespace}, claim)).To(Succeed())
+			Expect(claim.Spec.DataSource.Name).To(Equal("nightly-backup"))
+		}
+		Expect(fakeClient.Get(ctx, namespacedName, &appsv1.StatefulSet{})).To(Succeed())
+	})
+
+	It("should report failed snapshots", func() {
+		Expect(fakeClient.Create(ctx, sourceStatefulSet)).To(Succeed())
+		for _, claim := range sourceClaims {
+			Expect(fakeClient.Create(ctx, claim)).To(Succeed())
+		}
+		Expect(fakeClient.Create(ctx, experimentCR)).To(Succeed())
+
+		_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+		Expect(err).NotTo(HaveOccurred())
+
+		snapshot := getSnapshot("experiment-cr-data-0")
+		Expect(unstructured.SetNestedField(snapshot.Object, "snapshot controller failed", "status", "error", "message")).To(Succeed())
+		Expect(fakeClient.Update(ctx, snapshot)).To(Succeed())
+
+		_, err = reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+		Expect(err).NotTo(HaveOccurred())
+
+		updatedCR := getUpdatedCR()
+		Expect(updatedCR.Status.DataSource.Phase).To(Equal(DataSourcePhaseFailed))
+		readyCondition := meta.FindStatusCondition(updatedCR.Status.Conditions, ConditionTypeReady)
+		Expect(readyCondition.Reason).To(Equal("SnapshotFailed"))
+		Expect(readyCondition.Message).To(ContainSubstring("snapshot controller failed"))
+	})
+
+	It("should seed experiment ordinals from the source pods at the same position", func() {
+		experimentCR.Spec.OverrideSpec = apiextensionsv1.JSON{Raw: []byte(`{"ordinals":{"start":10}}`)}
+		Expect(fakeClient.Create(ctx, sourceStatefulSet)).To(Succeed())
+		for _, claim := range sourceClaims {
+			Expect(fakeClient.Create(ctx, claim)).To(Succeed())
+		}
+		Expect(fakeClient.Create(ctx, experimentCR)).To(Succeed())
+
+		_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+		Expect(err).NotTo(HaveOccurred())
+
+		snapshot := getSnapshot("experiment-cr-data-11")
+		sourceClaimName, _, _ := unstructured.NestedString(snapshot.Object, "spec", "source", "persistentVolumeClaimName")
+		Expect(sourceClaimName).To(Equal("data-source-db-1"))
+		markSnapshotReady("experiment-cr-data-10", "1Gi")
+		markSnapshotReady("experiment-cr-data-11", "1Gi")
+
+		_, err = reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+		Expect(err).NotTo(HaveOccurred())
+
+		claim := &corev1.PersistentVolumeClaim{}
+		Expect(fakeClient.Get(ctx, types.NamespacedName{Name: "data-experiment-cr-11", Namespace: testNamespace}, claim)).To(Succeed())
+		Expect(claim.Spec.DataSource.Name).To(Equal("experiment-cr-data-11"))
+	})
+
+	It("should fail when the VolumeSnapshot API is not installed", func() {
+		newReconciler(false)
+		Expect(fakeClient.Create(ctx, sourceStatefulSet)).To(Succeed())
+		Expect(fakeClient.Create(ctx, experimentCR)).To(Succeed())
+
+		_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("VolumeSnapshot API is not installed"))
+	})
+})
@@ -0,0 +1,268 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	experimentcontrollercomv1alpha1 "experimentcontroller.example.com/experiment-deployment/api/v1alpha1"
+)
+
+// ConditionTypePeerIsolationIncomplete reports experiment pods left in the DNS records of the source headless Service
+const ConditionTypePeerIsolationIncomplete = experimentcontrollercomv1alpha1.ConditionTypePeerIsolationIncomplete
+
+// experimentHeadlessServiceName returns the name of the dedicated headless Service of isolated StatefulSet experiments
+func experimentHeadlessServiceName(experimentCR *experimentcontrollercomv1alpha1.ExperimentDeployment) string {
+	return experimentCR.Name + "-headless"
+}
+
+// effectivePeerPolicy returns the peer policy of the experiment, defaulting to Join, which keeps the
+// serviceName and labels of the source like experiments created before peer policies existed
+func effectivePeerPolicy(experimentCR *experimentcontrollercomv1alpha1.ExperimentDeployment) experimentcontrollercomv1alpha1.StatefulSetPeerPolicy {
+	if experimentCR.Spec.StatefulSet == nil || experimentCR.Spec.StatefulSet.PeerPolicy == "" {
+		return experimentcontrollercomv1alpha1.StatefulSetPeerPolicyJoin
+	}
+	return experimentCR.Spec.StatefulSet.PeerPolicy
+}
+
+// statefulSetOrdinalStart returns the ordinal of the first pod of a StatefulSet
+func statefulSetOrdinalStart(statefulSet *appsv1.StatefulSet) int32 {
+	if statefulSet.Spec.Ordinals == nil {
+		return 0
+	}
+	return statefulSet.Spec.Ordinals.Start
+}
+
+// reconcileStatefulSetIdentity applies the peer policy of the experiment to the desired StatefulSet.
+// With the Isolated policy the experiment pods get a dedicated headless Service, serviceName is
+// rewritten to it, and the labels selected by the source headless Service are stripped from the
+// pod template and selector so peer-discovering systems never see the experiment pods as members
+// of the source cluster. In the Shared service mode the labels selected by production Services are
+// kept, so the experiment pods keep receiving production traffic. The Join policy keeps the source
+// serviceName and labels.
+func (r *ExperimentDeploymentReconciler) reconcileStatefulSetIdentity(
+	ctx context.Context,
+	experimentCR *experimentcontrollercomv1alpha1.ExperimentDeployment,
+	sourceStatefulSet *appsv1.StatefulSet,
+	desired *appsv1.StatefulSet) error {
+
+	log := logf.FromContext(ctx)
+
+	if effectivePeerPolicy(experimentCR) == experimentcontrollercomv1alpha1.StatefulSetPeerPolicyJoin || sourceStatefulSet.Spec.ServiceName == "" {
+		if experimentCR.Status.HeadlessServiceRef != nil {
+			if err := r.deleteExperimentHeadlessService(ctx, experimentCR); err != nil {
+				return err
+			}
+		}
+		experimentCR.Status.HeadlessServiceRef = nil
+		meta.RemoveStatusCondition(&experimentCR.Status.Conditions, ConditionTypePeerIsolationIncomplete)
+		return nil
+	}
+
+	var sourceService *corev1.Service
+	existingService := &corev1.Service{}
+	err := r.Get(ctx, types.NamespacedName{Name: sourceStatefulSet.Spec.ServiceName, Namespace: sourceStatefulSet.Namespace}, existingService)
+	switch {
+	case err == nil:
+		sourceService = existingService
+	case k8serrors.IsNotFound(err):
+		log.Info("Source headless Service not found, creating the experiment headless Service without ports", "service", sourceStatefulSet.Spec.ServiceName)
+	default:
+		log.Error(err, "Failed to get source headless Service", "service", sourceStatefulSet.Spec.ServiceName)
+		r.Recorder.Eventf(experimentCR, corev1.EventTypeWarning, "ServiceIsolationFailed", "Failed to get source headless Service %s: %s", sourceStatefulSet.Spec.ServiceName, err.Error())
+		r.updateStatusConditions(experimentCR, experimentcontrollercomv1alpha1.ReasonServiceIsolationFailed, fmt.Sprintf("Failed to get source headless Service %s: %s", sourceStatefulSet.Spec.ServiceName, err.Error()))
+		return err
+	}
+
+	// Remove the labels the source headless Service selects on, so its DNS records never list experiment pods
+	incomplete := false
+	if sourceService != nil {
+		// In the Shared service mode the experiment pods stay behind the production Services
+		needed := map[string]bool{}
+		if effectiveServiceMode(experimentCR) == experimentcontrollercomv1alpha1.ServiceModeShared {
+			productionServices, err := r.findSourceServices(ctx, experimentCR.Namespace, desired.Spec.Template.Labels)
+			if err != nil {
+				log.Error(err, "Failed to list production Services for peer isolation")
+				return err
+			}
+			for _, svc := range productionServices {
+				if svc.Name == sourceService.Name || svc.Spec.ClusterIP == corev1.ClusterIPNone {
+					continue
+				}
+				for key := range svc.Spec.Selector {
+					needed[key] = true
+				}
+			}
+		}
+		for key := range sourceService.Spec.Selector {
+			if strings.HasPrefix(key, r.config().LabelDomain+"/") || needed[key] {
+				continue
+			}
+			delete(desired.Spec.Template.Labels, key)
+			if desired.Spec.Selector != nil {
+				delete(desired.Spec.Selector.MatchLabels, key)
+			}
+		}
+		if labels.SelectorFromSet(sourceService.Spec.Selector).Matches(labels.Set(desired.Spec.Template.Labels)) {
+			message := fmt.Sprintf("The source headless Service %s selects the same labels as the production Services, "+
+				"the experiment pods stay in its DNS records in the Shared service mode", sourceService.Name)
+			// The warning is recorded once, the condition reports it until the labels differ
+			if meta.FindStatusCondition(experimentCR.Status.Conditions, ConditionTypePeerIsolationIncomplete) == nil {
+				log.Info(message)
+				r.Recorder.Event(experimentCR, corev1.EventTypeWarning, "PeerIsolationIncomplete", message)
+			}
+			meta.SetStatusCondition(&experimentCR.Status.Conditions, metav1.Condition{
+				Type:    ConditionTypePeerIsolationIncomplete,
+				Status:  metav1.ConditionTrue,
+				Reason:  experimentcontrollercomv1alpha1.ReasonHeadlessServiceShared,
+				Message: message,
+			})
+			incomplete = true
+		}
+	}
+	if !incomplete {
+		meta.RemoveStatusCondition(&experimentCR.Status.Conditions, ConditionTypePeerIsolationIncomplete)
+	}
+
+	desiredService := r.constructExperimentHeadlessService(experimentCR, sourceService)
+	desired.Spec.ServiceName = desiredService.Name
+	return r.createOrUpdateExperimentHeadlessService(ctx, experimentCR, desiredService)
+}
+
+// constructExperimentHeadlessService builds the dedicated headless Service of the experiment pods,
+// exposing the ports of the source headless Service when it exists
+func (r *ExperimentDeploymentReconciler) constructExperimentHeadlessService(
+	experimentCR *experimentcontrollercomv1alpha1.ExperimentDeployment,
+	sourceService *corev1.Service) *corev1.Service {
+
+	service := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      experimentHeadlessServiceName(experimentCR),
+			Namespace: experimentCR.Namespace,
+			Labels: map[string]string{
+				r.labelKey(labelManagedBy): ManagedByValue,
+				r.labelKey(labelCRName):    experimentCR.Name,
+			},
+		},
+		Spec: corev1.ServiceSpec{
+			ClusterIP: corev1.ClusterIPNone,
+			Selector: map[string]string{
+				r.labelKey(labelCRName): experimentCR.Name,
+				r.labelKey(labelRole):   ExperimentRoleValue,
+			},
+		},
+	}
+	if sourceService != nil {
+		for _, port := range sourceService.Spec.Ports {
+			port.NodePort = 0
+			service.Spec.Ports = append(service.Spec.Ports, port)
+		}
+		service.Spec.PublishNotReadyAddresses = sourceService.Spec.PublishNotReadyAddresses
+	}
+	return service
+}
+
+// createOrUpdateExperimentHeadlessService creates or updates the dedicated headless Service
+func (r *ExperimentDeploymentReconciler) createOrUpdateExperimentHeadlessService(
+	ctx context.Context,
+	experimentCR *experimentcontrollercomv1alpha1.ExperimentDeployment,
+	desired *corev1.Service) error {
+
+	log := logf.FromContext(ctx)
+
+	serviceToManage := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      desired.Name,
+			Namespace: desired.Namespace,
+		},
+	}
+
+	opResult, err := controllerutil.CreateOrUpdate(ctx, r.Client, serviceToManage, func() error {
+		if err := controllerutil.SetControllerReference(experimentCR, serviceToManage, r.Scheme); err != nil {
+			return err
+		}
+		if serviceToManage.Labels == nil {
+			serviceToManage.Labels = make(map[string]string)
+		}
+		for k, v := range desired.Labels {
+			serviceToManage.Labels[k] = v
+		}
+		// The ClusterIP is immutable, it can only be set to None on creation
+		if serviceToManage.CreationTimestamp.IsZero() {
+			serviceToManage.Spec.ClusterIP = desired.Spec.ClusterIP
+		}
+		serviceToManage.Spec.Ports = desired.Spec.Ports
+		serviceToManage.Spec.Selector = desired.Spec.Selector
+		serviceToManage.Spec.PublishNotReadyAddresses = desired.Spec.PublishNotReadyAddresses
+		return nil
+	})
+	if err != nil {
+		log.Error(err, "Failed to create or update experiment headless Service", "name", desired.Name)
+		r.Recorder.Eventf(experimentCR, corev1.EventTypeWarning, "ServiceIsolationFailed", "Failed to create/update experiment headless Service %s: %s", desired.Name, err.Error())
+		r.updateStatusConditions(experimentCR, experimentcontrollercomv1alpha1.ReasonServiceIsolationFailed, fmt.Sprintf("Failed to create/update experiment headless Service %s: %s", desired.Name, err.Error()))
+		return err
+	}
+
+	if opResult != controllerutil.OperationResultNone {
+		log.Info("Experiment headless Service successfully reconciled", "operation", opResult, "name", desired.Name)
+		r.Recorder.Eventf(experimentCR, corev1.EventTypeNormal, string(opResult), "Experiment headless Service %s %s", desired.Name, opResult)
+	}
+
+	experimentCR.Status.HeadlessServiceRef = &experimentcontrollercomv1alpha1.ExperimentResourceRef{
+		Kind:      "Service",
+		Name:      desired.Name,
+		Namespace: desired.Namespace,
+	}
+	return nil
+}
+
+// deleteExperimentHeadlessService removes the dedicated headless Service if it exists
+func (r *ExperimentDeploymentReconciler) deleteExperimentHeadlessService(ctx context.Context, experimentCR *experimentcontrollercomv1alpha1.ExperimentDeployment) error {
+	log := logf.FromContext(ctx)
+
+	service := &corev1.Service{}
+	err := r.Get(ctx, client.ObjectKey{Name: experimentHeadlessServiceName(experimentCR), Namespace: experimentCR.Namespace}, service)
+	if err != nil {
+		if k8serrors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	// Never delete a Service this experiment does not own
+	if !metav1.IsControlledBy(service, experimentCR) {
+		return nil
+	}
+	if err := r.Delete(ctx, service); err != nil && !k8serrors.IsNotFound(err) {
+		log.Error(err, "Failed to delete experiment headless Service", "name", service.Name)
+		return err
+	}
+	log.Info("Deleted experiment headless Service", "name", service.Name)
+	return nil
+}
@@ -0,0 +1,223 @@
+package controller
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	experimentcontrollercomv1alpha1 "experimentcontroller.example.com/experiment-deployment/api/v1alpha1"
+)
+
+var _ = Describe("StatefulSet Identity", func() {
+	var (
+		ctx               context.Context
+		reconciler        *ExperimentDeploymentReconciler
+		fakeClient        client.Client
+		sourceStatefulSet *appsv1.StatefulSet
+		sourceHeadless    *corev1.Service
+		experimentCR      *experimentcontrollercomv1alpha1.ExperimentDeployment
+		namespacedName    types.NamespacedName
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		scheme := runtime.NewScheme()
+		Expect(experimentcontrollercomv1alpha1.AddToScheme(scheme)).To(Succeed())
+		Expect(appsv1.AddToScheme(scheme)).To(Succeed())
+		Expect(corev1.AddToScheme(scheme)).To(Succeed())
+		fakeClient = fake.NewClientBuilder().WithScheme(scheme).WithInterceptorFuncs(withServerSideApply(interceptor.Funcs{})).WithStatusSubresource(&experimentcontrollercomv1alpha1.ExperimentDeployment{}).Build()
+		reconciler = &ExperimentDeploymentReconciler{
+			Client:   fakeClient,
+			Scheme:   scheme,
+			Recorder: record.NewFakeRecorder(100),
+		}
+
+		namespacedName = types.NamespacedName{Name: testExperimentCRName, Namespace: testNamespace}
+		sourceStatefulSet = &appsv1.StatefulSet{
+			ObjectMeta: metav1.ObjectMeta{Name: "kafka", Namespace: testNamespace},
+			Spec: appsv1.StatefulSetSpec{
+				ServiceName: "kafka-headless",
+				Selector:    &metav1.LabelSelector{MatchLabels: map[string]string{"app": "kafka"}},
+				Template: corev1.PodTemplateSpec{
+					ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": "kafka", "tier": "data"}},
+					Spec: corev1.PodSpec{
+						Containers: []corev1.Container{{Name: "kafka", Image: "kafka:3.6"}},
+					},
+				},
+			},
+		}
+		sourceHeadless = &corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: "kafka-headless", Namespace: testNamespace},
+			Spec: corev1.ServiceSpec{
+				ClusterIP:                corev1.ClusterIPNone,
+				Selector:                 map[string]string{"app": "kafka"},
+				Ports:                    []corev1.ServicePort{{Name: "broker", Port: 9092}},
+				PublishNotReadyAddresses: true,
+			},
+		}
+		experimentCR = &experimentcontrollercomv1alpha1.ExperimentDeployment{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:       testExperimentCRName,
+				Namespace:  testNamespace,
+				Finalizers: []string{experimentDeploymentFinalizer},
+			},
+			Spec: experimentcontrollercomv1alpha1.ExperimentDeploymentSpec{
+				SourceRef: experimentcontrollercomv1alpha1.SourceRef{
+					Kind: experimentcontrollercomv1alpha1.SourceKindStatefulSet,
+					Name: "kafka",
+				},
+				OverrideSpec: apiextensionsv1.JSON{Raw: []byte(`{"ordinals":{"start":100}}`)},
+			},
+		}
+	})
+
+	getExperimentStatefulSet := func() *appsv1.StatefulSet {
+		statefulSet := &appsv1.StatefulSet{}
+		Expect(fakeClient.Get(ctx, namespacedName, statefulSet)).To(Succeed())
+		return statefulSet
+	}
+
+	isolatePeers := func() {
+		experimentCR.Spec.StatefulSet = &experimentcontrollercomv1alpha1.StatefulSetOptions{
+			PeerPolicy: experimentcontrollercomv1alpha1.StatefulSetPeerPolicyIsolated,
+		}
+	}
+
+	It("should keep the source serviceName and labels by default", func() {
+		Expect(fakeClient.Create(ctx, sourceStatefulSet)).To(Succeed())
+		Expect(fakeClient.Create(ctx, sourceHeadless)).To(Succeed())
+		Expect(fakeClient.Create(ctx, experimentCR)).To(Succeed())
+
+		_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+		Expect(err).NotTo(HaveOccurred())
+
+		statefulSet := getExperimentStatefulSet()
+		Expect(statefulSet.Spec.ServiceName).To(Equal("kafka-headless"))
+		Expect(statefulSet.Spec.Template.Labels).To(HaveKeyWithValue("app", "kafka"))
+	})
+
+	It("should register isolated experiment pods in a dedicated headless Service", func() {
+		isolatePeers()
+		Expect(fakeClient.Create(ctx, sourceStatefulSet)).To(Succeed())
+		Expect(fakeClient.Create(ctx, sourceHeadless)).To(Succeed())
+		Expect(fakeClient.Create(ctx, experimentCR)).To(Succeed())
+
+		_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+		Expect(err).NotTo(HaveOccurred())
+
+		statefulSet := getExperimentStatefulSet()
+		Expect(statefulSet.Spec.ServiceName).To(Equal("experiment-cr-headless"))
+		Expect(statefulSet.Spec.Template.Labels).NotTo(HaveKey("app"))
+		Expect(statefulSet.Spec.Template.Labels).To(HaveKeyWithValue("tier", "data"))
+		Expect(statefulSet.Spec.Selector.MatchLabels).To(Equal(statefulSet.Spec.Template.Labels))
+		Expect(statefulSet.Spec.Ordinals.Start).To(Equal(int32(100)))
+
+		headless := &corev1.Service{}
+		Expect(fakeClient.Get(ctx, types.NamespacedName{Name: "experiment-cr-headless", Namespace: testNamespace}, headless)).To(Succeed())
+		Expect(headless.Spec.ClusterIP).To(Equal(corev1.ClusterIPNone))
+		Expect(headless.Spec.Ports).To(HaveLen(1))
+		Expect(headless.Spec.PublishNotReadyAddresses).To(BeTrue())
+		Expect(headless.Spec.Selector).To(HaveKeyWithValue("experiment-controller.example.com/cr-name", testExperimentCRName))
+
+		updatedCR := &experimentcontrollercomv1alpha1.ExperimentDeployment{}
+		Expect(fakeClient.Get(ctx, namespacedName, updatedCR)).To(Succeed())
+		Expect(updatedCR.Status.HeadlessServiceRef.Name).To(Equal("experiment-cr-headless"))
+	})
+
+	It("should keep the source headless Service when experiment pods may join the peer group", func() {
+		experimentCR.Spec.StatefulSet = &experimentcontrollercomv1alpha1.StatefulSetOptions{
+			PeerPolicy: experimentcontrollercomv1alpha1.StatefulSetPeerPolicyJoin,
+		}
+		Expect(fakeClient.Create(ctx, sourceStatefulSet)).To(Succeed())
+		Expect(fakeClient.Create(ctx, sourceHeadless)).To(Succeed())
+		Expect(fakeClient.Create(ctx, experimentCR)).To(Succeed())
+
+		_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+		Expect(err).NotTo(HaveOccurred())
+
+		statefulSet := getExperimentStatefulSet()
+		Expect(statefulSet.Spec.ServiceName).To(Equal("kafka-headless"))
+		Expect(statefulSet.Spec.Template.Labels).To(HaveKeyWithValue("app", "kafka"))
+
+		err = fakeClient.Get(ctx, types.NamespacedName{Name: "experiment-cr-headless", Namespace: testNamespace}, &corev1.Service{})
+		Expect(k8serrors.IsNotFound(err)).To(BeTrue())
+	})
+
+	It("should keep the labels of production Services in the Shared service mode", func() {
+		isolatePeers()
+		Expect(fakeClient.Create(ctx, sourceStatefulSet)).To(Succeed())
+		Expect(fakeClient.Create(ctx, sourceHeadless)).To(Succeed())
+		Expect(fakeClient.Create(ctx, &corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: "kafka", Namespace: testNamespace},
+			Spec: corev1.ServiceSpec{
+				Selector: map[string]string{"app": "kafka"},
+				Ports:    []corev1.ServicePort{{Name: "broker", Port: 9092}},
+			},
+		})).To(Succeed())
+		Expect(fakeClient.Create(ctx, experimentCR)).To(Succeed())
+
+		_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+		Expect(err).NotTo(HaveOccurred())
+
+		statefulSet := getExperimentStatefulSet()
+		Expect(statefulSet.Spec.ServiceName).To(Equal("experiment-cr-headless"))
+		Expect(statefulSet.Spec.Template.Labels).To(HaveKeyWithValue("app", "kafka"))
+		events := reconciler.Recorder.(*record.FakeRecorder).Events
+		Expect(events).To(Receive(ContainSubstring("PeerIsolationIncomplete")))
+		updatedCR := &experimentcontrollercomv1alpha1.ExperimentDeployment{}
+		Expect(fakeClient.Get(ctx, namespacedName, updatedCR)).To(Succeed())
+		condition := meta.FindStatusCondition(updatedCR.Status.Conditions, ConditionTypePeerIsolationIncomplete)
+		Expect(condition).NotTo(BeNil())
+		Expect(condition.Status).To(Equal(metav1.ConditionTrue))
+		Expect(condition.Message).To(ContainSubstring("the experiment pods stay in its DNS records"))
+
+		// Later reconciles keep the condition without recording the warning again
+		for len(events) > 0 {
+			<-events
+		}
+		_, err = reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(events).NotTo(Receive(ContainSubstring("PeerIsolationIncomplete")))
+		Expect(fakeClient.Get(ctx, namespacedName, updatedCR)).To(Succeed())
+		Expect(meta.FindStatusCondition(updatedCR.Status.Conditions, ConditionTypePeerIsolationIncomplete)).NotTo(BeNil())
+	})
+
+	It("should remove the dedicated headless Service when switching to Join", func() {
+		isolatePeers()
+		Expect(fakeClient.Create(ctx, sourceStatefulSet)).To(Succeed())
+		Expect(fakeClient.Create(ctx, sourceHeadless)).To(Succeed())
+		Expect(fakeClient.Create(ctx, experimentCR)).To(Succeed())
+
+		_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+		Expect(err).NotTo(HaveOccurred())
+
+		updatedCR := &experimentcontrollercomv1alpha1.ExperimentDeployment{}
+		Expect(fakeClient.Get(ctx, namespacedName, updatedCR)).To(Succeed())
+		updatedCR.Spec.StatefulSet = &experimentcontrollercomv1alpha1.StatefulSetOptions{
+			PeerPolicy: experimentcontrollercomv1alpha1.StatefulSetPeerPolicyJoin,
+		}
+		Expect(fakeClient.Update(ctx, updatedCR)).To(Succeed())
+
+		_, err = reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+		Expect(err).NotTo(HaveOccurred())
+
+		err = fakeClient.Get(ctx, types.NamespacedName{Name: "experiment-cr-headless", Namespace: testNamespace}, &corev1.Service{})
+		Expect(k8serrors.IsNotFound(err)).To(BeTrue())
+		Expect(fakeClient.Get(ctx, namespacedName, updatedCR)).To(Succeed())
+		Expect(updatedCR.Status.HeadlessServiceRef).To(BeNil())
+	})
+})
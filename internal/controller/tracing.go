@@ -0,0 +1,100 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"k8s.io/apimachinery/pkg/api/meta"
+
+	experimentcontrollercomv1alpha1 "experimentcontroller.example.com/experiment-deployment/api/v1alpha1"
+)
+
+// tracerName is the instrumentation scope of the spans of the reconciler
+const tracerName = "experimentcontroller.example.com/experiment-deployment/internal/controller"
+
+// Names of the spans of a reconcile
+const (
+	spanReconcile    = "Reconcile"
+	spanFetchSource  = "FetchSource"
+	spanRender       = "RenderWorkload"
+	spanUpsert       = "UpsertWorkload"
+	spanUpdateStatus = "UpdateStatus"
+)
+
+// Attributes of the spans of a reconcile
+const (
+	attributeExperimentName      = attribute.Key("experiment.name")
+	attributeExperimentNamespace = attribute.Key("experiment.namespace")
+	attributeSourceKind          = attribute.Key("experiment.source.kind")
+	attributeSourceName          = attribute.Key("experiment.source.name")
+	attributeWorkloadName        = attribute.Key("experiment.workload.name")
+	attributeOutcomeReason       = attribute.Key("experiment.outcome.reason")
+	attributePhase               = attribute.Key("experiment.phase")
+)
+
+// tracer returns the tracer of the reconciler, from the global tracer provider if none is set
+func (r *ExperimentDeploymentReconciler) tracer() trace.Tracer {
+	if r.TracerProvider != nil {
+		return r.TracerProvider.Tracer(tracerName)
+	}
+	return otel.Tracer(tracerName)
+}
+
+// startSpan starts a span for a step of the reconcile of an experiment
+func (r *ExperimentDeploymentReconciler) startSpan(
+	ctx context.Context,
+	name string,
+	experimentCR *experimentcontrollercomv1alpha1.ExperimentDeployment,
+	attributes ...attribute.KeyValue) (context.Context, trace.Span) {
+
+	attributes = append([]attribute.KeyValue{
+		attributeExperimentName.String(experimentCR.Name),
+		attributeSourceKind.String(string(experimentCR.Spec.SourceRef.Kind)),
+	}, attributes...)
+	return r.tracer().Start(ctx, name, trace.WithAttributes(attributes...))
+}
+
+// endSpan ends a span, marking it failed with the error if there is one
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// setOutcomeAttributes records the outcome of a reconcile on its span: the reason of the Ready
+// condition and the lifecycle phase of the experiment
+func setOutcomeAttributes(span trace.Span, experimentCR *experimentcontrollercomv1alpha1.ExperimentDeployment) {
+	if experimentCR.Spec.SourceRef.Kind != "" {
+		span.SetAttributes(
+			attributeSourceKind.String(string(experimentCR.Spec.SourceRef.Kind)),
+			attributeSourceName.String(experimentCR.Spec.SourceRef.Name),
+		)
+	}
+	if ready := meta.FindStatusCondition(experimentCR.Status.Conditions, ConditionTypeReady); ready != nil {
+		span.SetAttributes(attributeOutcomeReason.String(ready.Reason))
+	}
+	if experimentCR.Status.Phase != "" {
+		span.SetAttributes(attributePhase.String(string(experimentCR.Status.Phase)))
+	}
+}
@@ -0,0 +1,126 @@
+package controller
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	experimentcontrollercomv1alpha1 "experimentcontroller.example.com/experiment-deployment/api/v1alpha1"
+)
+
+var _ = Describe("Reconcile tracing", func() {
+	var (
+		ctx        context.Context
+		reconciler *ExperimentDeploymentReconciler
+		fakeClient client.Client
+		recorder   *tracetest.SpanRecorder
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		scheme := runtime.NewScheme()
+		Expect(experimentcontrollercomv1alpha1.AddToScheme(scheme)).To(Succeed())
+		Expect(appsv1.AddToScheme(scheme)).To(Succeed())
+		Expect(corev1.AddToScheme(scheme)).To(Succeed())
+		fakeClient = fake.NewClientBuilder().WithScheme(scheme).
+			WithStatusSubresource(&experimentcontrollercomv1alpha1.ExperimentDeployment{}).
+			WithInterceptorFuncs(withServerSideApply(interceptor.Funcs{})).Build()
+		recorder = tracetest.NewSpanRecorder()
+		reconciler = &ExperimentDeploymentReconciler{
+			Client:         fakeClient,
+			Scheme:         scheme,
+			Recorder:       record.NewFakeRecorder(100),
+			TracerProvider: sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)),
+		}
+
+		Expect(fakeClient.Create(ctx, &experimentcontrollercomv1alpha1.ExperimentDeployment{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:       testExperimentCRName,
+				Namespace:  testNamespace,
+				Finalizers: []string{experimentDeploymentFinalizer},
+			},
+			Spec: experimentcontrollercomv1alpha1.ExperimentDeploymentSpec{
+				SourceRef: experimentcontrollercomv1alpha1.SourceRef{
+					Kind: experimentcontrollercomv1alpha1.SourceKindDeployment,
+					Name: "web",
+				},
+				OverrideSpec: apiextensionsv1.JSON{Raw: []byte(`{}`)},
+			},
+		})).To(Succeed())
+	})
+
+	reconcile := func() {
+		_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: types.NamespacedName{Name: testExperimentCRName, Namespace: testNamespace}})
+		Expect(err).NotTo(HaveOccurred())
+	}
+
+	spansByName := func() map[string]sdktrace.ReadOnlySpan {
+		spans := map[string]sdktrace.ReadOnlySpan{}
+		for _, span := range recorder.Ended() {
+			spans[span.Name()] = span
+		}
+		return spans
+	}
+
+	It("should trace the steps of a reconcile as children of its span", func() {
+		Expect(fakeClient.Create(ctx, &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: testNamespace},
+			Spec: appsv1.DeploymentSpec{
+				Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "web"}},
+				Template: corev1.PodTemplateSpec{
+					ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": "web"}},
+					Spec: corev1.PodSpec{
+						Containers: []corev1.Container{{Name: "web", Image: "nginx:1.27"}},
+					},
+				},
+			},
+		})).To(Succeed())
+
+		reconcile()
+
+		spans := spansByName()
+		Expect(spans).To(HaveKey(spanReconcile))
+		root := spans[spanReconcile]
+		Expect(root.Parent().IsValid()).To(BeFalse())
+		Expect(root.Attributes()).To(ContainElements(
+			attributeExperimentName.String(testExperimentCRName),
+			attributeExperimentNamespace.String(testNamespace),
+			attributeSourceKind.String("Deployment"),
+			attributeSourceName.String("web"),
+			attributeOutcomeReason.String(experimentcontrollercomv1alpha1.ReasonNotReady),
+		))
+		for _, name := range []string{spanFetchSource, spanRender, spanUpsert, spanUpdateStatus} {
+			Expect(spans).To(HaveKey(name))
+			Expect(spans[name].Parent().SpanID()).To(Equal(root.SpanContext().SpanID()), name)
+			Expect(spans[name].Parent().TraceID()).To(Equal(root.SpanContext().TraceID()), name)
+			Expect(spans[name].Status().Code).To(Equal(codes.Unset), name)
+		}
+		Expect(spans[spanUpsert].Attributes()).To(ContainElement(attributeWorkloadName.String(testExperimentCRName)))
+	})
+
+	It("should record failed steps and the outcome reason", func() {
+		reconcile()
+
+		spans := spansByName()
+		Expect(spans[spanFetchSource].Status().Code).To(Equal(codes.Error))
+		Expect(spans[spanFetchSource].Events()).NotTo(BeEmpty())
+		Expect(spans).NotTo(HaveKey(spanUpsert))
+		Expect(spans[spanReconcile].Attributes()).To(ContainElement(
+			attributeOutcomeReason.String(experimentcontrollercomv1alpha1.ReasonSourceNotFound)))
+	})
+})
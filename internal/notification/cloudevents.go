@@ -0,0 +1,273 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package notification
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"k8s.io/client-go/util/workqueue"
+
+	experimentcontrollercomv1alpha1 "experimentcontroller.example.com/experiment-deployment/api/v1alpha1"
+)
+
+// CloudEventsMode is the HTTP content mode CloudEvents are sent in
+type CloudEventsMode string
+
+const (
+	// CloudEventsModeBinary sends the data as the body and the attributes as ce- headers
+	CloudEventsModeBinary CloudEventsMode = "binary"
+	// CloudEventsModeStructured sends the whole event as an application/cloudevents+json body
+	CloudEventsModeStructured CloudEventsMode = "structured"
+)
+
+const (
+	// CloudEventsSpecVersion is the version of the CloudEvents specification of the events
+	CloudEventsSpecVersion = "1.0"
+	// CloudEventTypePrefix prefixes the types of the CloudEvents of experiments
+	CloudEventTypePrefix = "com.example.experimentcontroller.experiment."
+)
+
+// cloudEventTypes are the CloudEvents type suffixes of the lifecycle transitions
+var cloudEventTypes = map[experimentcontrollercomv1alpha1.NotificationEventType]string{
+	experimentcontrollercomv1alpha1.NotificationEventCreated:           "created",
+	experimentcontrollercomv1alpha1.NotificationEventReady:             "workload-ready",
+	experimentcontrollercomv1alpha1.NotificationEventAnalysisCompleted: "analysis-result",
+	experimentcontrollercomv1alpha1.NotificationEventFailed:            "failed",
+	experimentcontrollercomv1alpha1.NotificationEventAborted:           "aborted",
+	experimentcontrollercomv1alpha1.NotificationEventExpired:           "expired",
+	experimentcontrollercomv1alpha1.NotificationEventPromoted:          "promoted",
+	experimentcontrollercomv1alpha1.NotificationEventDeleted:           "deleted",
+}
+
+// CloudEventType returns the CloudEvents type of a lifecycle transition
+func CloudEventType(eventType experimentcontrollercomv1alpha1.NotificationEventType) string {
+	if suffix, ok := cloudEventTypes[eventType]; ok {
+		return CloudEventTypePrefix + suffix
+	}
+	return CloudEventTypePrefix + string(eventType)
+}
+
+// CloudEventSource returns the CloudEvents source of the experiments of a namespace
+func CloudEventSource(namespace string) string {
+	return fmt.Sprintf("/apis/%s/namespaces/%s/experimentdeployments",
+		experimentcontrollercomv1alpha1.GroupVersion.String(), namespace)
+}
+
+// ExperimentData is the data of the CloudEvents of experiments
+type ExperimentData struct {
+	// Experiment is the name of the ExperimentDeployment
+	Experiment string `json:"experiment"`
+	// Namespace is the namespace of the ExperimentDeployment
+	Namespace string `json:"namespace"`
+	// SourceRef references the source workload
+	SourceRef experimentcontrollercomv1alpha1.SourceRef `json:"sourceRef"`
+	// OverrideHash identifies the overrides of the experiment
+	OverrideHash string `json:"overrideHash,omitempty"`
+	// Replicas is the desired number of replicas of the experiment workload
+	Replicas int32 `json:"replicas"`
+	// ReadyReplicas is the number of ready replicas of the experiment workload
+	ReadyReplicas int32 `json:"readyReplicas"`
+	// Labels are the labels of the ExperimentDeployment
+	Labels map[string]string `json:"labels,omitempty"`
+	// Phase is the phase of the experiment after the transition
+	Phase experimentcontrollercomv1alpha1.ExperimentPhase `json:"phase,omitempty"`
+	// AnalysisPhase is the phase of the analysis of the experiment
+	AnalysisPhase string `json:"analysisPhase,omitempty"`
+	// Reason is the reason of the Ready condition of the experiment
+	Reason string `json:"reason,omitempty"`
+	// Message describes the transition
+	Message string `json:"message,omitempty"`
+}
+
+// CloudEvent is a CloudEvents v1.0 event about an experiment
+type CloudEvent struct {
+	SpecVersion     string         `json:"specversion"`
+	ID              string         `json:"id"`
+	Source          string         `json:"source"`
+	Type            string         `json:"type"`
+	Subject         string         `json:"subject,omitempty"`
+	Time            time.Time      `json:"time"`
+	DataContentType string         `json:"datacontenttype"`
+	Data            ExperimentData `json:"data"`
+}
+
+// NewCloudEvent returns the CloudEvent of a lifecycle transition of an experiment
+func NewCloudEvent(
+	id string,
+	eventType experimentcontrollercomv1alpha1.NotificationEventType,
+	data ExperimentData,
+	eventTime time.Time) CloudEvent {
+
+	return CloudEvent{
+		SpecVersion:     CloudEventsSpecVersion,
+		ID:              id,
+		Source:          CloudEventSource(data.Namespace),
+		Type:            CloudEventType(eventType),
+		Subject:         data.Experiment,
+		Time:            eventTime.UTC(),
+		DataContentType: "application/json",
+		Data:            data,
+	}
+}
+
+// PublishCloudEvent posts the event to the sink in the content mode, in a single attempt like Deliver
+func (n *Notifier) PublishCloudEvent(
+	ctx context.Context,
+	sink string,
+	mode CloudEventsMode,
+	event CloudEvent,
+	timeout time.Duration) error {
+
+	header := http.Header{}
+	var body []byte
+	var err error
+	switch mode {
+	case CloudEventsModeStructured:
+		header.Set("Content-Type", "application/cloudevents+json")
+		body, err = json.Marshal(event)
+	case CloudEventsModeBinary, "":
+		header.Set("Content-Type", event.DataContentType)
+		header.Set("ce-specversion", event.SpecVersion)
+		header.Set("ce-id", event.ID)
+		header.Set("ce-source", event.Source)
+		header.Set("ce-type", event.Type)
+		if event.Subject != "" {
+			header.Set("ce-subject", event.Subject)
+		}
+		header.Set("ce-time", event.Time.Format(time.RFC3339Nano))
+		body, err = json.Marshal(event.Data)
+	default:
+		return &permanentError{err: fmt.Errorf("unsupported CloudEvents mode %q", mode)}
+	}
+	if err != nil {
+		return err
+	}
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	return n.post(ctx, sink, header, body, timeout)
+}
+
+// publisherWorkers is the number of CloudEvents published concurrently
+const publisherWorkers = 4
+
+// Publication is a CloudEvent queued for publishing
+type Publication struct {
+	// Namespace is the namespace of the experiment, whose sink receives the event
+	Namespace string
+	// Event is the CloudEvent
+	Event CloudEvent
+	// OnFailure is called when the event could not be published within the retries
+	OnFailure func(err error)
+}
+
+// Publisher publishes CloudEvents from a queue in the background, so slow or failing sinks do
+// not hold up reconciles. Failed publications are retried with exponential backoff. It is a
+// manager Runnable.
+type Publisher struct {
+	// Notifier sends the events, its backoff spaces the retries
+	Notifier *Notifier
+	// Mode is the HTTP content mode of the events
+	Mode CloudEventsMode
+	// Sink returns the sink of the experiments of a namespace, no events are published if empty
+	Sink func(ctx context.Context, namespace string) (string, error)
+	// Retries is the number of times a failed publication is retried
+	Retries int32
+
+	queue workqueue.TypedRateLimitingInterface[*Publication]
+}
+
+// NewPublisher returns a Publisher sending events with the notifier
+func NewPublisher(
+	notifier *Notifier,
+	mode CloudEventsMode,
+	sink func(ctx context.Context, namespace string) (string, error),
+	retries int32) *Publisher {
+
+	maxDelay := notifier.Backoff.Cap
+	if maxDelay <= 0 {
+		maxDelay = DefaultBackoff.Cap
+	}
+	return &Publisher{
+		Notifier: notifier,
+		Mode:     mode,
+		Sink:     sink,
+		Retries:  retries,
+		queue: workqueue.NewTypedRateLimitingQueueWithConfig(
+			workqueue.NewTypedItemExponentialFailureRateLimiter[*Publication](notifier.Backoff.Duration, maxDelay),
+			workqueue.TypedRateLimitingQueueConfig[*Publication]{Name: "cloudevents"}),
+	}
+}
+
+// Publish queues the event for publishing to the sink of the namespace
+func (p *Publisher) Publish(publication *Publication) {
+	p.queue.Add(publication)
+}
+
+// Start publishes the queued events until the context is done
+func (p *Publisher) Start(ctx context.Context) error {
+	var wg sync.WaitGroup
+	for range publisherWorkers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for p.processNext(ctx) {
+			}
+		}()
+	}
+	<-ctx.Done()
+	p.queue.ShutDown()
+	wg.Wait()
+	return nil
+}
+
+// processNext attempts to publish the next queued event, and reports false once the queue shut down
+func (p *Publisher) processNext(ctx context.Context) bool {
+	publication, shutdown := p.queue.Get()
+	if shutdown {
+		return false
+	}
+	defer p.queue.Done(publication)
+
+	err := p.publish(ctx, publication)
+	switch {
+	case err == nil:
+		p.queue.Forget(publication)
+	case !IsPermanent(err) && p.queue.NumRequeues(publication) < int(p.Retries) && ctx.Err() == nil:
+		p.queue.AddRateLimited(publication)
+	default:
+		p.queue.Forget(publication)
+		if publication.OnFailure != nil {
+			publication.OnFailure(err)
+		}
+	}
+	return true
+}
+
+// publish resolves the sink of the namespace and posts the event to it once
+func (p *Publisher) publish(ctx context.Context, publication *Publication) error {
+	sink, err := p.Sink(ctx, publication.Namespace)
+	if err != nil || sink == "" {
+		return err
+	}
+	return p.Notifier.PublishCloudEvent(ctx, sink, p.Mode, publication.Event, DefaultTimeout)
+}
@@ -0,0 +1,155 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package notification
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/util/wait"
+
+	experimentcontrollercomv1alpha1 "experimentcontroller.example.com/experiment-deployment/api/v1alpha1"
+)
+
+var _ = Describe("CloudEvents", func() {
+	var (
+		ctx      context.Context
+		notifier *Notifier
+		event    CloudEvent
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		notifier = NewNotifier()
+		notifier.Backoff = wait.Backoff{Duration: time.Millisecond, Factor: 2}
+		event = NewCloudEvent("uid-1-ready", experimentcontrollercomv1alpha1.NotificationEventReady, ExperimentData{
+			Experiment: "checkout-v2",
+			Namespace:  "shop",
+			SourceRef: experimentcontrollercomv1alpha1.SourceRef{
+				Kind: experimentcontrollercomv1alpha1.SourceKindDeployment,
+				Name: "checkout",
+			},
+			OverrideHash:  "0123456789abcdef",
+			Replicas:      2,
+			ReadyReplicas: 2,
+			Labels:        map[string]string{"team": "payments"},
+			Phase:         experimentcontrollercomv1alpha1.ExperimentPhaseRunning,
+		}, time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC))
+	})
+
+	It("should send the attributes as headers in binary mode", func() {
+		server := newWebhook()
+		defer server.Close()
+
+		Expect(notifier.PublishCloudEvent(ctx, server.URL, CloudEventsModeBinary, event, time.Second)).To(Succeed())
+
+		Expect(server.received()).To(HaveLen(1))
+		header := server.headers[0]
+		Expect(header.Get("Content-Type")).To(Equal("application/json"))
+		Expect(header.Get("ce-specversion")).To(Equal("1.0"))
+		Expect(header.Get("ce-id")).To(Equal("uid-1-ready"))
+		Expect(header.Get("ce-type")).To(Equal("com.example.experimentcontroller.experiment.workload-ready"))
+		Expect(header.Get("ce-source")).To(Equal("/apis/experimentcontroller.example.com/v1alpha1/namespaces/shop/experimentdeployments"))
+		Expect(header.Get("ce-subject")).To(Equal("checkout-v2"))
+		Expect(header.Get("ce-time")).To(Equal("2025-06-01T12:00:00Z"))
+		var data ExperimentData
+		Expect(json.Unmarshal(server.received()[0], &data)).To(Succeed())
+		Expect(data).To(Equal(event.Data))
+	})
+
+	It("should send the whole event as the body in structured mode", func() {
+		server := newWebhook()
+		defer server.Close()
+
+		Expect(notifier.PublishCloudEvent(ctx, server.URL, CloudEventsModeStructured, event, time.Second)).To(Succeed())
+
+		Expect(server.received()).To(HaveLen(1))
+		Expect(server.headers[0].Get("Content-Type")).To(Equal("application/cloudevents+json"))
+		Expect(server.headers[0].Get("ce-id")).To(BeEmpty())
+		var received CloudEvent
+		Expect(json.Unmarshal(server.received()[0], &received)).To(Succeed())
+		Expect(received).To(Equal(event))
+	})
+
+	It("should reject unknown modes", func() {
+		err := notifier.PublishCloudEvent(ctx, "http://localhost", CloudEventsMode("batched"), event, time.Second)
+		Expect(err).To(MatchError(`unsupported CloudEvents mode "batched"`))
+		Expect(IsPermanent(err)).To(BeTrue())
+	})
+
+	Context("Publisher", func() {
+		start := func(publisher *Publisher) {
+			publisherCtx, stop := context.WithCancel(ctx)
+			DeferCleanup(stop)
+			go func() {
+				defer GinkgoRecover()
+				Expect(publisher.Start(publisherCtx)).To(Succeed())
+			}()
+		}
+
+		It("should retry failed publications in the background", func() {
+			server := newWebhook(http.StatusServiceUnavailable, http.StatusBadGateway)
+			defer server.Close()
+			publisher := NewPublisher(notifier, CloudEventsModeBinary, func(context.Context, string) (string, error) {
+				return server.URL, nil
+			}, 3)
+			start(publisher)
+
+			publisher.Publish(&Publication{Namespace: "shop", Event: event})
+			Eventually(server.received).Should(HaveLen(3))
+			Consistently(server.received).WithTimeout(100 * time.Millisecond).Should(HaveLen(3))
+		})
+
+		It("should report publications failing after the retries", func() {
+			server := newWebhook(http.StatusServiceUnavailable, http.StatusServiceUnavailable)
+			defer server.Close()
+			publisher := NewPublisher(notifier, CloudEventsModeBinary, func(context.Context, string) (string, error) {
+				return server.URL, nil
+			}, 1)
+			start(publisher)
+
+			failures := make(chan error, 1)
+			publisher.Publish(&Publication{Namespace: "shop", Event: event, OnFailure: func(err error) { failures <- err }})
+			Eventually(failures).Should(Receive(MatchError(ContainSubstring("webhook responded 503"))))
+			Expect(server.received()).To(HaveLen(2))
+		})
+
+		It("should skip namespaces without a sink", func() {
+			var namespaces []string
+			var mu sync.Mutex
+			publisher := NewPublisher(notifier, CloudEventsModeBinary, func(_ context.Context, namespace string) (string, error) {
+				mu.Lock()
+				defer mu.Unlock()
+				namespaces = append(namespaces, namespace)
+				return "", nil
+			}, 3)
+			start(publisher)
+
+			publisher.Publish(&Publication{Namespace: "shop", Event: event, OnFailure: func(err error) { Fail(err.Error()) }})
+			Eventually(func() []string {
+				mu.Lock()
+				defer mu.Unlock()
+				return namespaces
+			}).Should(Equal([]string{"shop"}))
+		})
+	})
+})
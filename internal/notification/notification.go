@@ -0,0 +1,314 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package notification delivers the lifecycle transitions of ExperimentDeployments to webhooks,
+// in the payload formats of the NotificationProvider types, and publishes them as CloudEvents.
+package notification
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+
+	experimentcontrollercomv1alpha1 "experimentcontroller.example.com/experiment-deployment/api/v1alpha1"
+)
+
+// DefaultBackoff is the backoff between the delivery attempts of a notification
+var DefaultBackoff = wait.Backoff{
+	Duration: 500 * time.Millisecond,
+	Factor:   2,
+	Jitter:   0.1,
+	Cap:      10 * time.Second,
+}
+
+// DefaultTimeout limits a delivery attempt when the provider sets no timeout
+const DefaultTimeout = 5 * time.Second
+
+// Event is a lifecycle transition of an experiment
+type Event struct {
+	// Type is the lifecycle transition
+	Type experimentcontrollercomv1alpha1.NotificationEventType `json:"type"`
+	// Experiment is the name of the ExperimentDeployment
+	Experiment string `json:"experiment"`
+	// Namespace is the namespace of the ExperimentDeployment
+	Namespace string `json:"namespace"`
+	// SourceKind is the kind of the source workload
+	SourceKind experimentcontrollercomv1alpha1.SourceKind `json:"sourceKind"`
+	// SourceName is the name of the source workload
+	SourceName string `json:"sourceName"`
+	// Phase is the phase of the experiment after the transition
+	Phase experimentcontrollercomv1alpha1.ExperimentPhase `json:"phase,omitempty"`
+	// Reason is the reason of the Ready condition of the experiment
+	Reason string `json:"reason,omitempty"`
+	// Message describes the transition
+	Message string `json:"message,omitempty"`
+	// Time is when the transition was observed
+	Time time.Time `json:"time"`
+}
+
+// Title summarizes the event in a line
+func (e Event) Title() string {
+	var what string
+	switch e.Type {
+	case experimentcontrollercomv1alpha1.NotificationEventCreated:
+		what = "was created"
+	case experimentcontrollercomv1alpha1.NotificationEventReady:
+		what = "is ready"
+	case experimentcontrollercomv1alpha1.NotificationEventAnalysisCompleted:
+		what = "completed its analysis"
+	case experimentcontrollercomv1alpha1.NotificationEventFailed:
+		what = "failed"
+	case experimentcontrollercomv1alpha1.NotificationEventAborted:
+		what = "was aborted"
+	case experimentcontrollercomv1alpha1.NotificationEventExpired:
+		what = "expired"
+	case experimentcontrollercomv1alpha1.NotificationEventPromoted:
+		what = "was promoted"
+	case experimentcontrollercomv1alpha1.NotificationEventDeleted:
+		what = "was deleted"
+	default:
+		what = string(e.Type)
+	}
+	return fmt.Sprintf("Experiment %s/%s %s", e.Namespace, e.Experiment, what)
+}
+
+// severity classifies the event for the colors of chat messages
+func (e Event) severity() string {
+	switch e.Type {
+	case experimentcontrollercomv1alpha1.NotificationEventFailed, experimentcontrollercomv1alpha1.NotificationEventAborted:
+		return "danger"
+	case experimentcontrollercomv1alpha1.NotificationEventAnalysisCompleted:
+		if e.Phase == experimentcontrollercomv1alpha1.ExperimentPhaseSucceeded {
+			return "good"
+		}
+		return "danger"
+	case experimentcontrollercomv1alpha1.NotificationEventReady, experimentcontrollercomv1alpha1.NotificationEventPromoted:
+		return "good"
+	}
+	return "warning"
+}
+
+// facts lists the details of the event shown by chat messages
+func (e Event) facts() [][2]string {
+	facts := [][2]string{
+		{"Experiment", e.Namespace + "/" + e.Experiment},
+		{"Source", fmt.Sprintf("%s %s", e.SourceKind, e.SourceName)},
+	}
+	if e.Phase != "" {
+		facts = append(facts, [2]string{"Phase", string(e.Phase)})
+	}
+	if e.Reason != "" {
+		facts = append(facts, [2]string{"Reason", e.Reason})
+	}
+	return facts
+}
+
+type slackField struct {
+	Title string `json:"title"`
+	Value string `json:"value"`
+	Short bool   `json:"short"`
+}
+
+type slackAttachment struct {
+	Color  string       `json:"color"`
+	Text   string       `json:"text,omitempty"`
+	Fields []slackField `json:"fields"`
+	Ts     int64        `json:"ts"`
+}
+
+type slackMessage struct {
+	Text        string            `json:"text"`
+	Attachments []slackAttachment `json:"attachments"`
+}
+
+type teamsFact struct {
+	Title string `json:"title"`
+	Value string `json:"value"`
+}
+
+type teamsElement struct {
+	Type   string      `json:"type"`
+	Text   string      `json:"text,omitempty"`
+	Size   string      `json:"size,omitempty"`
+	Weight string      `json:"weight,omitempty"`
+	Color  string      `json:"color,omitempty"`
+	Wrap   bool        `json:"wrap,omitempty"`
+	Facts  []teamsFact `json:"facts,omitempty"`
+}
+
+type teamsCard struct {
+	Schema  string         `json:"$schema"`
+	Type    string         `json:"type"`
+	Version string         `json:"version"`
+	Body    []teamsElement `json:"body"`
+}
+
+type teamsAttachment struct {
+	ContentType string    `json:"contentType"`
+	Content     teamsCard `json:"content"`
+}
+
+type teamsMessage struct {
+	Type        string            `json:"type"`
+	Attachments []teamsAttachment `json:"attachments"`
+}
+
+// teamsColors maps the severities to the colors of Adaptive Card text
+var teamsColors = map[string]string{"good": "Good", "warning": "Warning", "danger": "Attention"}
+
+// Payload renders the event in the format of the provider type
+func Payload(providerType experimentcontrollercomv1alpha1.NotificationProviderType, event Event) ([]byte, error) {
+	switch providerType {
+	case experimentcontrollercomv1alpha1.NotificationProviderGeneric:
+		return json.Marshal(event)
+	case experimentcontrollercomv1alpha1.NotificationProviderSlack:
+		attachment := slackAttachment{Color: event.severity(), Text: event.Message, Ts: event.Time.Unix()}
+		for _, fact := range event.facts() {
+			attachment.Fields = append(attachment.Fields, slackField{Title: fact[0], Value: fact[1], Short: true})
+		}
+		return json.Marshal(slackMessage{Text: event.Title(), Attachments: []slackAttachment{attachment}})
+	case experimentcontrollercomv1alpha1.NotificationProviderTeams:
+		facts := teamsElement{Type: "FactSet"}
+		for _, fact := range event.facts() {
+			facts.Facts = append(facts.Facts, teamsFact{Title: fact[0], Value: fact[1]})
+		}
+		body := []teamsElement{{Type: "TextBlock", Text: event.Title(), Size: "Medium", Weight: "Bolder",
+			Color: teamsColors[event.severity()], Wrap: true}}
+		if event.Message != "" {
+			body = append(body, teamsElement{Type: "TextBlock", Text: event.Message, Wrap: true})
+		}
+		body = append(body, facts)
+		return json.Marshal(teamsMessage{
+			Type: "message",
+			Attachments: []teamsAttachment{{
+				ContentType: "application/vnd.microsoft.card.adaptive",
+				Content: teamsCard{
+					Schema:  "http://adaptivecards.io/schemas/adaptive-card.json",
+					Type:    "AdaptiveCard",
+					Version: "1.4",
+					Body:    body,
+				},
+			}},
+		})
+	}
+	return nil, fmt.Errorf("unsupported notification provider type %q", providerType)
+}
+
+// permanentError is a delivery failure retrying does not fix
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+
+func (e *permanentError) Unwrap() error { return e.err }
+
+// IsPermanent reports whether a delivery failed in a way retrying does not fix
+func IsPermanent(err error) bool {
+	var permanent *permanentError
+	return errors.As(err, &permanent)
+}
+
+// Notifier posts notifications to webhooks
+type Notifier struct {
+	// Client sends the requests
+	Client *http.Client
+	// Backoff is the backoff between delivery attempts
+	Backoff wait.Backoff
+}
+
+// NewNotifier returns a Notifier with the default backoff
+func NewNotifier() *Notifier {
+	return &Notifier{Client: &http.Client{}, Backoff: DefaultBackoff}
+}
+
+// RetryDelay returns how long to wait before retrying a delivery that failed failures times in a row
+func (n *Notifier) RetryDelay(failures int32) time.Duration {
+	delay := n.Backoff.Duration
+	for i := int32(1); i < failures && n.Backoff.Factor > 1; i++ {
+		delay = time.Duration(float64(delay) * n.Backoff.Factor)
+		if n.Backoff.Cap > 0 && delay >= n.Backoff.Cap {
+			return n.Backoff.Cap
+		}
+	}
+	return delay
+}
+
+// Deliver posts the event to the address in the format of the provider type, in a single attempt.
+// Retrying is left to the caller, unless IsPermanent reports the webhook rejected the notification
+// with a client error other than 408 or 429.
+func (n *Notifier) Deliver(
+	ctx context.Context,
+	address string,
+	providerType experimentcontrollercomv1alpha1.NotificationProviderType,
+	event Event,
+	timeout time.Duration) error {
+
+	body, err := Payload(providerType, event)
+	if err != nil {
+		return err
+	}
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	header := http.Header{}
+	header.Set("Content-Type", "application/json")
+	return n.post(ctx, address, header, body, timeout)
+}
+
+// post sends a request once
+func (n *Notifier) post(ctx context.Context, address string, header http.Header, body []byte, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	// Errors leave out the address, webhook URLs often embed a token
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, address, bytes.NewReader(body))
+	if err != nil {
+		return &permanentError{err: errors.New("invalid webhook address")}
+	}
+	request.Header = header.Clone()
+	response, err := n.Client.Do(request)
+	if err != nil {
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			return fmt.Errorf("webhook request failed: %w", urlErr.Err)
+		}
+		return err
+	}
+	defer response.Body.Close() //nolint:errcheck
+	responseBody, _ := io.ReadAll(io.LimitReader(response.Body, 512))
+
+	if response.StatusCode >= 200 && response.StatusCode < 300 {
+		return nil
+	}
+	err = fmt.Errorf("webhook responded %s", response.Status)
+	if responseBody = bytes.TrimSpace(responseBody); len(responseBody) > 0 {
+		err = fmt.Errorf("%w: %s", err, responseBody)
+	}
+	if response.StatusCode >= 500 || response.StatusCode == http.StatusTooManyRequests ||
+		response.StatusCode == http.StatusRequestTimeout {
+		return err
+	}
+	return &permanentError{err: err}
+}
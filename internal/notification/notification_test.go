@@ -0,0 +1,179 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package notification
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/util/wait"
+
+	experimentcontrollercomv1alpha1 "experimentcontroller.example.com/experiment-deployment/api/v1alpha1"
+)
+
+func TestNotification(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Notification Suite")
+}
+
+// webhook is a local HTTP server answering notifications with the queued status codes
+type webhook struct {
+	*httptest.Server
+	mu       sync.Mutex
+	statuses []int
+	bodies   [][]byte
+	headers  []http.Header
+}
+
+func newWebhook(statuses ...int) *webhook {
+	w := &webhook{statuses: statuses}
+	w.Server = httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		w.bodies = append(w.bodies, body)
+		w.headers = append(w.headers, r.Header.Clone())
+		status := http.StatusOK
+		if len(w.statuses) > 0 {
+			status, w.statuses = w.statuses[0], w.statuses[1:]
+		}
+		rw.WriteHeader(status)
+		_, _ = rw.Write([]byte(http.StatusText(status)))
+	}))
+	return w
+}
+
+func (w *webhook) received() [][]byte {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.bodies
+}
+
+var _ = Describe("Notifier", func() {
+	var (
+		ctx      context.Context
+		notifier *Notifier
+		event    Event
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		notifier = NewNotifier()
+		notifier.Backoff = wait.Backoff{Duration: time.Millisecond, Factor: 2}
+		event = Event{
+			Type:       experimentcontrollercomv1alpha1.NotificationEventReady,
+			Experiment: "checkout-v2",
+			Namespace:  "shop",
+			SourceKind: experimentcontrollercomv1alpha1.SourceKindDeployment,
+			SourceName: "checkout",
+			Phase:      experimentcontrollercomv1alpha1.ExperimentPhaseRunning,
+			Reason:     "ReconcileSuccess",
+			Message:    "Experiment Deployment is Ready",
+			Time:       time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC),
+		}
+	})
+
+	It("should post the event as JSON to generic webhooks", func() {
+		server := newWebhook()
+		defer server.Close()
+
+		Expect(notifier.Deliver(ctx, server.URL, experimentcontrollercomv1alpha1.NotificationProviderGeneric, event, time.Second)).To(Succeed())
+
+		Expect(server.received()).To(HaveLen(1))
+		Expect(server.headers[0].Get("Content-Type")).To(Equal("application/json"))
+		var received Event
+		Expect(json.Unmarshal(server.received()[0], &received)).To(Succeed())
+		Expect(received).To(Equal(event))
+	})
+
+	It("should render Slack messages", func() {
+		payload, err := Payload(experimentcontrollercomv1alpha1.NotificationProviderSlack, event)
+		Expect(err).NotTo(HaveOccurred())
+
+		var message map[string]interface{}
+		Expect(json.Unmarshal(payload, &message)).To(Succeed())
+		Expect(message["text"]).To(Equal("Experiment shop/checkout-v2 is ready"))
+		attachment := message["attachments"].([]interface{})[0].(map[string]interface{})
+		Expect(attachment["color"]).To(Equal("good"))
+		Expect(attachment["text"]).To(Equal("Experiment Deployment is Ready"))
+		Expect(attachment["fields"]).To(ContainElement(map[string]interface{}{"title": "Source", "value": "Deployment checkout", "short": true}))
+	})
+
+	It("should render Teams Adaptive Cards", func() {
+		event.Type = experimentcontrollercomv1alpha1.NotificationEventAborted
+		event.Phase = experimentcontrollercomv1alpha1.ExperimentPhaseAborted
+		payload, err := Payload(experimentcontrollercomv1alpha1.NotificationProviderTeams, event)
+		Expect(err).NotTo(HaveOccurred())
+
+		var message teamsMessage
+		Expect(json.Unmarshal(payload, &message)).To(Succeed())
+		Expect(message.Type).To(Equal("message"))
+		Expect(message.Attachments).To(HaveLen(1))
+		Expect(message.Attachments[0].ContentType).To(Equal("application/vnd.microsoft.card.adaptive"))
+		card := message.Attachments[0].Content
+		Expect(card.Type).To(Equal("AdaptiveCard"))
+		Expect(card.Body[0].Text).To(Equal("Experiment shop/checkout-v2 was aborted"))
+		Expect(card.Body[0].Color).To(Equal("Attention"))
+		Expect(card.Body[2].Facts).To(ContainElement(teamsFact{Title: "Phase", Value: "Aborted"}))
+	})
+
+	It("should attempt a delivery once and report server errors as retriable", func() {
+		server := newWebhook(http.StatusServiceUnavailable, http.StatusServiceUnavailable)
+		defer server.Close()
+
+		err := notifier.Deliver(ctx, server.URL, experimentcontrollercomv1alpha1.NotificationProviderGeneric, event, time.Second)
+		Expect(err).To(MatchError("webhook responded 503 Service Unavailable: Service Unavailable"))
+		Expect(IsPermanent(err)).To(BeFalse())
+		Expect(server.received()).To(HaveLen(1))
+	})
+
+	It("should back off exponentially up to the cap", func() {
+		notifier.Backoff = DefaultBackoff
+		Expect(notifier.RetryDelay(1)).To(Equal(500 * time.Millisecond))
+		Expect(notifier.RetryDelay(3)).To(Equal(2 * time.Second))
+		Expect(notifier.RetryDelay(10)).To(Equal(10 * time.Second))
+	})
+
+	It("should not retry rejected notifications", func() {
+		server := newWebhook(http.StatusBadRequest)
+		defer server.Close()
+
+		err := notifier.Deliver(ctx, server.URL, experimentcontrollercomv1alpha1.NotificationProviderSlack, event, time.Second)
+		Expect(err).To(MatchError(ContainSubstring("webhook responded 400 Bad Request")))
+		Expect(IsPermanent(err)).To(BeTrue())
+		Expect(server.received()).To(HaveLen(1))
+	})
+
+	It("should not reveal the webhook address in errors", func() {
+		server := newWebhook()
+		address := server.URL + "/services/T000/B000/secret-token"
+		server.Close()
+
+		err := notifier.Deliver(ctx, address, experimentcontrollercomv1alpha1.NotificationProviderSlack, event, time.Second)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).NotTo(ContainSubstring("secret-token"))
+		Expect(err.Error()).To(HavePrefix("webhook request failed"))
+	})
+})
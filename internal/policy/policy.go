@@ -0,0 +1,422 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package policy evaluates ExperimentDeployments against ClusterExperimentPolicies.
+// It is shared by the reconciler and the admission webhook, so both render the
+// experiment the same way and report the same violations.
+package policy
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"dario.cat/mergo"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	experimentcontrollercomv1alpha1 "experimentcontroller.example.com/experiment-deployment/api/v1alpha1"
+)
+
+// defaultRegistry is the registry of images referenced without one
+const defaultRegistry = "docker.io"
+
+// Input is an experiment as seen by the policy evaluation
+type Input struct {
+	// SourceNamespace is the namespace of the source workload
+	SourceNamespace string
+	// SourceSpec is the spec of the source workload, empty if it does not exist yet
+	SourceSpec map[string]interface{}
+	// OverrideSpec is the overrideSpec of the experiment
+	OverrideSpec map[string]interface{}
+	// Replicas is spec.replicas of the experiment, if set
+	Replicas *int32
+}
+
+// Violation describes a field of an experiment violating a ClusterExperimentPolicy
+type Violation struct {
+	// Policy is the name of the violated ClusterExperimentPolicy
+	Policy string
+	// Path is the offending path, relative to the workload spec unless it starts with "spec."
+	Path string
+	// Message explains the violation
+	Message string
+}
+
+// String returns the violation in the form reported in the PolicyViolation condition
+func (v Violation) String() string {
+	return fmt.Sprintf("ClusterExperimentPolicy %s: %s: %s", v.Policy, v.Path, v.Message)
+}
+
+// NewInput builds the policy input of an experiment from the spec of its source workload
+func NewInput(experimentCR *experimentcontrollercomv1alpha1.ExperimentDeployment, sourceNamespace string, sourceSpec interface{}) (Input, error) {
+	input := Input{
+		SourceNamespace: sourceNamespace,
+		SourceSpec:      map[string]interface{}{},
+		OverrideSpec:    map[string]interface{}{},
+		Replicas:        experimentCR.Spec.Replicas,
+	}
+	if sourceSpec != nil {
+		raw, err := json.Marshal(sourceSpec)
+		if err != nil {
+			return input, fmt.Errorf("failed to marshal source spec: %w", err)
+		}
+		if err := json.Unmarshal(raw, &input.SourceSpec); err != nil {
+			return input, fmt.Errorf("failed to unmarshal source spec to map: %w", err)
+		}
+	}
+	if len(experimentCR.Spec.OverrideSpec.Raw) > 0 {
+		if err := json.Unmarshal(experimentCR.Spec.OverrideSpec.Raw, &input.OverrideSpec); err != nil {
+			return input, fmt.Errorf("failed to unmarshal overrideSpec: %w", err)
+		}
+	}
+	return input, nil
+}
+
+// Evaluate returns the violations of the experiment against all the given policies
+func Evaluate(policies []experimentcontrollercomv1alpha1.ClusterExperimentPolicy, input Input) ([]Violation, error) {
+	if len(policies) == 0 {
+		return nil, nil
+	}
+
+	// Render the workload spec the way the reconciler merges overrideSpec into the source spec
+	rendered := deepCopyMap(input.SourceSpec)
+	if err := mergo.Merge(&rendered, deepCopyMap(input.OverrideSpec), mergo.WithOverride, mergo.WithSliceDeepCopy); err != nil {
+		return nil, fmt.Errorf("failed to merge overrideSpec: %w", err)
+	}
+	changed := changedPaths(input.SourceSpec, input.OverrideSpec, nil)
+
+	sorted := make([]experimentcontrollercomv1alpha1.ClusterExperimentPolicy, len(policies))
+	copy(sorted, policies)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+
+	var violations []Violation
+	for _, policy := range sorted {
+		violations = append(violations, evaluatePolicy(policy, input, rendered, changed)...)
+	}
+	return violations, nil
+}
+
+// evaluatePolicy returns the violations of the rendered experiment against a single policy
+func evaluatePolicy(
+	policy experimentcontrollercomv1alpha1.ClusterExperimentPolicy,
+	input Input,
+	rendered map[string]interface{},
+	changed [][]string) []Violation {
+
+	var violations []Violation
+	violate := func(path, format string, args ...interface{}) {
+		violations = append(violations, Violation{Policy: policy.Name, Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+	spec := policy.Spec
+
+	if len(spec.AllowedSourceNamespaces) > 0 && !containsString(spec.AllowedSourceNamespaces, input.SourceNamespace) {
+		violate("spec.sourceRef.namespace", "source namespace %s is not allowed", input.SourceNamespace)
+	}
+
+	denied := parsePatterns(spec.DeniedPaths)
+	allowed := parsePatterns(spec.AllowedPaths)
+	for _, path := range changed {
+		if pattern, ok := matchAny(denied, path); ok {
+			violate(formatPath(path), "changing %s is denied", pattern)
+			continue
+		}
+		if len(allowed) > 0 {
+			if _, ok := matchAny(allowed, path); !ok {
+				violate(formatPath(path), "path is not in the allowed paths")
+			}
+		}
+	}
+
+	if spec.MaxReplicas != nil {
+		sourceReplicas := replicasOf(input.SourceSpec)
+		maxReplicas, err := intstr.GetScaledValueFromIntOrPercent(spec.MaxReplicas, int(sourceReplicas), true)
+		if err != nil {
+			violate("replicas", "invalid maxReplicas %s: %s", spec.MaxReplicas.String(), err.Error())
+		} else {
+			replicas := replicasOf(rendered)
+			if input.Replicas != nil {
+				replicas = *input.Replicas
+			}
+			if int(replicas) > maxReplicas {
+				violate("replicas", "%d replicas exceed the maximum of %d", replicas, maxReplicas)
+			}
+		}
+	}
+
+	if len(spec.AllowedImageRegistries) > 0 {
+		for _, image := range podImages(rendered) {
+			if !registryAllowed(image.reference, spec.AllowedImageRegistries) {
+				violate(image.path, "image %s is not from an allowed registry", image.reference)
+			}
+		}
+	}
+
+	return violations
+}
+
+// changedPaths returns the paths of the values set by the override that differ from the source
+func changedPaths(source, override interface{}, path []string) [][]string {
+	switch overrideValue := override.(type) {
+	case map[string]interface{}:
+		sourceMap, _ := source.(map[string]interface{})
+		keys := make([]string, 0, len(overrideValue))
+		for key := range overrideValue {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		var changed [][]string
+		for _, key := range keys {
+			var sourceValue interface{}
+			if sourceMap != nil {
+				sourceValue = sourceMap[key]
+			}
+			changed = append(changed, changedPaths(sourceValue, overrideValue[key], appendPath(path, key))...)
+		}
+		return changed
+	case []interface{}:
+		sourceSlice, _ := source.([]interface{})
+		var changed [][]string
+		for i, item := range overrideValue {
+			var sourceValue interface{}
+			if i < len(sourceSlice) {
+				sourceValue = sourceSlice[i]
+			}
+			changed = append(changed, changedPaths(sourceValue, item, appendPath(path, strconv.Itoa(i)))...)
+		}
+		return changed
+	default:
+		if reflect.DeepEqual(source, override) {
+			return nil
+		}
+		return [][]string{path}
+	}
+}
+
+// parsePatterns splits path patterns into segments, turning "containers[0]" into "containers", "0"
+func parsePatterns(patterns []string) [][]string {
+	var parsed [][]string
+	for _, pattern := range patterns {
+		pattern = strings.TrimPrefix(strings.TrimSpace(pattern), ".")
+		if pattern == "" {
+			continue
+		}
+		var segments []string
+		for _, segment := range strings.Split(pattern, ".") {
+			for segment != "" {
+				open := strings.Index(segment, "[")
+				if open < 0 {
+					segments = append(segments, segment)
+					break
+				}
+				if open > 0 {
+					segments = append(segments, segment[:open])
+				}
+				end := strings.Index(segment[open:], "]")
+				if end < 0 {
+					segments = append(segments, segment[open+1:])
+					break
+				}
+				segments = append(segments, segment[open+1:open+end])
+				segment = segment[open+end+1:]
+			}
+		}
+		parsed = append(parsed, segments)
+	}
+	return parsed
+}
+
+// matchAny returns the first pattern matching the path
+func matchAny(patterns [][]string, path []string) (string, bool) {
+	for _, pattern := range patterns {
+		if matchPrefix(pattern, path) {
+			return formatPath(pattern), true
+		}
+	}
+	return "", false
+}
+
+// matchPrefix reports whether the pattern matches the path or one of its parents
+func matchPrefix(pattern, path []string) bool {
+	if len(pattern) == 0 {
+		return true
+	}
+	if pattern[0] == "**" {
+		for i := 0; i <= len(path); i++ {
+			if matchPrefix(pattern[1:], path[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(path) == 0 {
+		return false
+	}
+	if pattern[0] != "*" && pattern[0] != path[0] {
+		return false
+	}
+	return matchPrefix(pattern[1:], path[1:])
+}
+
+// formatPath renders path segments as "template.spec.containers[0].image"
+func formatPath(path []string) string {
+	var builder strings.Builder
+	for _, segment := range path {
+		if _, err := strconv.Atoi(segment); err == nil {
+			builder.WriteString("[" + segment + "]")
+			continue
+		}
+		if builder.Len() > 0 {
+			builder.WriteString(".")
+		}
+		builder.WriteString(segment)
+	}
+	return builder.String()
+}
+
+// podImage is a container image of the rendered pod template and its path
+type podImage struct {
+	path      string
+	reference string
+}
+
+// podImages returns the container images of the rendered pod template
+func podImages(rendered map[string]interface{}) []podImage {
+	var images []podImage
+	for _, field := range []string{"initContainers", "containers", "ephemeralContainers"} {
+		containers, _ := nestedSlice(rendered, "template", "spec", field)
+		for i, rawContainer := range containers {
+			container, ok := rawContainer.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			image, _ := container["image"].(string)
+			if image == "" {
+				continue
+			}
+			images = append(images, podImage{
+				path:      fmt.Sprintf("template.spec.%s[%d].image", field, i),
+				reference: image,
+			})
+		}
+	}
+	return images
+}
+
+// imageRepository returns the fully qualified repository of an image reference, without tag or digest
+func imageRepository(image string) string {
+	name := image
+	if at := strings.Index(name, "@"); at >= 0 {
+		name = name[:at]
+	}
+	if colon := strings.LastIndex(name, ":"); colon > strings.LastIndex(name, "/") {
+		name = name[:colon]
+	}
+	parts := strings.SplitN(name, "/", 2)
+	if len(parts) == 1 {
+		return defaultRegistry + "/library/" + name
+	}
+	if !strings.ContainsAny(parts[0], ".:") && parts[0] != "localhost" {
+		return defaultRegistry + "/" + name
+	}
+	return name
+}
+
+// registryAllowed reports whether the image comes from one of the allowed registries or repository prefixes
+func registryAllowed(image string, allowedRegistries []string) bool {
+	repository := imageRepository(image)
+	for _, allowed := range allowedRegistries {
+		allowed = strings.TrimSuffix(strings.TrimSpace(allowed), "/")
+		if allowed == "" {
+			continue
+		}
+		if repository == allowed || strings.HasPrefix(repository, allowed+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// replicasOf returns the replicas of a workload spec, defaulting to 1
+func replicasOf(spec map[string]interface{}) int32 {
+	switch replicas := spec["replicas"].(type) {
+	case float64:
+		return int32(replicas)
+	case int64:
+		return int32(replicas)
+	case int32:
+		return replicas
+	case int:
+		return int32(replicas)
+	}
+	return 1
+}
+
+// nestedSlice returns the slice at the given path of a JSON map
+func nestedSlice(object map[string]interface{}, fields ...string) ([]interface{}, bool) {
+	var current interface{} = object
+	for _, field := range fields {
+		currentMap, ok := current.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		current = currentMap[field]
+	}
+	slice, ok := current.([]interface{})
+	return slice, ok
+}
+
+// deepCopyMap copies a JSON map, so merging never modifies the input
+func deepCopyMap(in map[string]interface{}) map[string]interface{} {
+	out := make(map[string]interface{}, len(in))
+	for key, value := range in {
+		out[key] = deepCopyValue(value)
+	}
+	return out
+}
+
+func deepCopyValue(in interface{}) interface{} {
+	switch value := in.(type) {
+	case map[string]interface{}:
+		return deepCopyMap(value)
+	case []interface{}:
+		out := make([]interface{}, len(value))
+		for i, item := range value {
+			out[i] = deepCopyValue(item)
+		}
+		return out
+	default:
+		return value
+	}
+}
+
+// appendPath returns a copy of the path with the segment appended
+func appendPath(path []string, segment string) []string {
+	out := make([]string, len(path), len(path)+1)
+	copy(out, path)
+	return append(out, segment)
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,169 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package policy
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/utils/ptr"
+
+	experimentcontrollercomv1alpha1 "experimentcontroller.example.com/experiment-deployment/api/v1alpha1"
+)
+
+func TestPolicy(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Policy Suite")
+}
+
+var _ = Describe("ClusterExperimentPolicy evaluation", func() {
+	var (
+		sourceSpec   appsv1.DeploymentSpec
+		experimentCR *experimentcontrollercomv1alpha1.ExperimentDeployment
+	)
+
+	BeforeEach(func() {
+		sourceSpec = appsv1.DeploymentSpec{
+			Replicas: ptr.To(int32(4)),
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{Name: "app", Image: "ghcr.io/acme/app:1.0"}},
+				},
+			},
+		}
+		experimentCR = &experimentcontrollercomv1alpha1.ExperimentDeployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "experiment", Namespace: "default"},
+			Spec: experimentcontrollercomv1alpha1.ExperimentDeploymentSpec{
+				SourceRef: experimentcontrollercomv1alpha1.SourceRef{
+					Kind: experimentcontrollercomv1alpha1.SourceKindDeployment,
+					Name: "app",
+				},
+			},
+		}
+	})
+
+	evaluate := func(override string, specs ...experimentcontrollercomv1alpha1.ClusterExperimentPolicySpec) []Violation {
+		experimentCR.Spec.OverrideSpec = apiextensionsv1.JSON{Raw: []byte(override)}
+		input, err := NewInput(experimentCR, "default", sourceSpec)
+		Expect(err).NotTo(HaveOccurred())
+		policies := make([]experimentcontrollercomv1alpha1.ClusterExperimentPolicy, 0, len(specs))
+		for i, spec := range specs {
+			policies = append(policies, experimentcontrollercomv1alpha1.ClusterExperimentPolicy{
+				ObjectMeta: metav1.ObjectMeta{Name: string(rune('a' + i))},
+				Spec:       spec,
+			})
+		}
+		violations, err := Evaluate(policies, input)
+		Expect(err).NotTo(HaveOccurred())
+		return violations
+	}
+
+	paths := func(violations []Violation) []string {
+		result := make([]string, 0, len(violations))
+		for _, violation := range violations {
+			result = append(result, violation.Path)
+		}
+		return result
+	}
+
+	It("should report denied paths changed by the override", func() {
+		violations := evaluate(`{"template":{"spec":{"hostNetwork":true,"containers":[{"image":"ghcr.io/acme/app:2.0"}]}}}`,
+			experimentcontrollercomv1alpha1.ClusterExperimentPolicySpec{DeniedPaths: []string{"template.spec.hostNetwork"}})
+		Expect(paths(violations)).To(Equal([]string{"template.spec.hostNetwork"}))
+		Expect(violations[0].String()).To(Equal("ClusterExperimentPolicy a: template.spec.hostNetwork: changing template.spec.hostNetwork is denied"))
+	})
+
+	It("should match denied paths with wildcards and cover nested fields", func() {
+		violations := evaluate(`{"template":{"spec":{"containers":[{"securityContext":{"privileged":true}}],"volumes":[{"name":"host"}]}}}`,
+			experimentcontrollercomv1alpha1.ClusterExperimentPolicySpec{DeniedPaths: []string{"**.securityContext", "template.spec.volumes"}})
+		Expect(paths(violations)).To(ConsistOf(
+			"template.spec.containers[0].securityContext.privileged",
+			"template.spec.volumes[0].name",
+		))
+	})
+
+	It("should ignore override values equal to the source", func() {
+		violations := evaluate(`{"template":{"spec":{"containers":[{"image":"ghcr.io/acme/app:1.0"}]}}}`,
+			experimentcontrollercomv1alpha1.ClusterExperimentPolicySpec{DeniedPaths: []string{"**.image"}})
+		Expect(violations).To(BeEmpty())
+	})
+
+	It("should report paths outside the allowed paths", func() {
+		violations := evaluate(`{"template":{"spec":{"containers":[{"image":"ghcr.io/acme/app:2.0","command":["sh"]}]}}}`,
+			experimentcontrollercomv1alpha1.ClusterExperimentPolicySpec{AllowedPaths: []string{"template.spec.containers[*].image"}})
+		Expect(paths(violations)).To(Equal([]string{"template.spec.containers[0].command[0]"}))
+	})
+
+	It("should limit replicas to a percentage of the source replicas", func() {
+		maxReplicas := intstr.FromString("25%")
+		spec := experimentcontrollercomv1alpha1.ClusterExperimentPolicySpec{MaxReplicas: &maxReplicas}
+
+		experimentCR.Spec.Replicas = ptr.To(int32(1))
+		Expect(evaluate(`{}`, spec)).To(BeEmpty())
+
+		experimentCR.Spec.Replicas = ptr.To(int32(2))
+		violations := evaluate(`{}`, spec)
+		Expect(paths(violations)).To(Equal([]string{"replicas"}))
+		Expect(violations[0].Message).To(Equal("2 replicas exceed the maximum of 1"))
+	})
+
+	It("should limit replicas to an absolute number", func() {
+		maxReplicas := intstr.FromInt32(3)
+		violations := evaluate(`{}`, experimentcontrollercomv1alpha1.ClusterExperimentPolicySpec{MaxReplicas: &maxReplicas})
+		Expect(paths(violations)).To(Equal([]string{"replicas"}))
+	})
+
+	It("should only allow images from the allowed registries", func() {
+		spec := experimentcontrollercomv1alpha1.ClusterExperimentPolicySpec{AllowedImageRegistries: []string{"ghcr.io/acme"}}
+		Expect(evaluate(`{}`, spec)).To(BeEmpty())
+
+		violations := evaluate(`{"template":{"spec":{"containers":[{"image":"nginx:1.27"}]}}}`, spec)
+		Expect(paths(violations)).To(Equal([]string{"template.spec.containers[0].image"}))
+
+		spec.AllowedImageRegistries = []string{"docker.io/library", "ghcr.io"}
+		Expect(evaluate(`{"template":{"spec":{"containers":[{"image":"nginx:1.27"}]}}}`, spec)).To(BeEmpty())
+	})
+
+	It("should only allow the allowed source namespaces", func() {
+		violations := evaluate(`{}`, experimentcontrollercomv1alpha1.ClusterExperimentPolicySpec{AllowedSourceNamespaces: []string{"staging"}})
+		Expect(paths(violations)).To(Equal([]string{"spec.sourceRef.namespace"}))
+	})
+
+	It("should report the violations of every policy", func() {
+		violations := evaluate(`{"template":{"spec":{"hostNetwork":true}}}`,
+			experimentcontrollercomv1alpha1.ClusterExperimentPolicySpec{DeniedPaths: []string{"template.spec.hostNetwork"}},
+			experimentcontrollercomv1alpha1.ClusterExperimentPolicySpec{AllowedSourceNamespaces: []string{"staging"}})
+		Expect(violations).To(HaveLen(2))
+		Expect(violations[0].Policy).To(Equal("a"))
+		Expect(violations[1].Policy).To(Equal("b"))
+	})
+
+	Context("imageRepository", func() {
+		It("should resolve images to fully qualified repositories", func() {
+			Expect(imageRepository("nginx")).To(Equal("docker.io/library/nginx"))
+			Expect(imageRepository("acme/app:1.0")).To(Equal("docker.io/acme/app"))
+			Expect(imageRepository("localhost:5000/app:1.0")).To(Equal("localhost:5000/app"))
+			Expect(imageRepository("ghcr.io/acme/app@sha256:abc")).To(Equal("ghcr.io/acme/app"))
+		})
+	})
+})
@@ -0,0 +1,364 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sharding
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	coordinationv1 "k8s.io/api/coordination/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+const (
+	// MemberLabel labels the membership Leases of the replicas sharing the shards of a group
+	MemberLabel = "experimentcontroller.example.com/shard-member-of"
+
+	// DefaultLeaseDuration is how long a replica owns a shard without renewing its Lease
+	DefaultLeaseDuration = 15 * time.Second
+	// DefaultRetryPeriod is how often the Leases are renewed and rebalanced
+	DefaultRetryPeriod = 5 * time.Second
+)
+
+// ownedShardGauge reports the shards owned by the replica
+var ownedShardGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Name: "experiment_controller_owned_shard",
+	Help: "Shards whose experiments this replica of the controller handles, 1 while owned",
+}, []string{"shard"})
+
+func init() {
+	metrics.Registry.MustRegister(ownedShardGauge)
+}
+
+// LeaseOptions configure the Leases of the shards
+type LeaseOptions struct {
+	// Namespace holds the Leases
+	Namespace string
+	// Name prefixes the names of the Leases. Replicas with the same name share the shards.
+	Name string
+	// Identity identifies the replica, usually its pod name
+	Identity string
+	// Count is the number of shards
+	Count int
+	// LeaseDuration is how long a replica owns a shard without renewing its Lease
+	LeaseDuration time.Duration
+	// RetryPeriod is how often the Leases are renewed and rebalanced
+	RetryPeriod time.Duration
+}
+
+// LeaseOwner owns a fair share of the shards, holding a Lease per shard. Every replica also
+// holds a membership Lease, so replicas give up the shards above their share when others join,
+// and take over the shards of replicas that stop renewing their Leases.
+type LeaseOwner struct {
+	client client.Client
+	opts   LeaseOptions
+	// OnAcquired is called with the shards the replica acquired
+	OnAcquired func(ctx context.Context, shards []int)
+	// now returns the current time, replaced in tests
+	now func() time.Time
+
+	mu        sync.RWMutex
+	owned     map[int]bool
+	lastRenew time.Time
+}
+
+// NewLeaseOwner returns a lease owner using the client, which should not read from a cache
+func NewLeaseOwner(c client.Client, opts LeaseOptions) *LeaseOwner {
+	if opts.LeaseDuration == 0 {
+		opts.LeaseDuration = DefaultLeaseDuration
+	}
+	if opts.RetryPeriod == 0 {
+		opts.RetryPeriod = DefaultRetryPeriod
+	}
+	return &LeaseOwner{client: c, opts: opts, now: time.Now, owned: map[int]bool{}}
+}
+
+// Owns reports whether the replica owns the shard
+func (o *LeaseOwner) Owns(shard int) bool {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	return o.owned[shard]
+}
+
+// OwnedShards returns the shards owned by the replica, sorted
+func (o *LeaseOwner) OwnedShards() []int {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	shards := make([]int, 0, len(o.owned))
+	for shard := range o.owned {
+		shards = append(shards, shard)
+	}
+	slices.Sort(shards)
+	return shards
+}
+
+// NeedLeaderElection reports that every replica takes part in the sharding
+func (o *LeaseOwner) NeedLeaderElection() bool {
+	return false
+}
+
+// Start renews and rebalances the Leases until the context is done, then releases them
+func (o *LeaseOwner) Start(ctx context.Context) error {
+	log := logf.FromContext(ctx).WithName("sharding")
+	ticker := time.NewTicker(o.opts.RetryPeriod)
+	defer ticker.Stop()
+	for {
+		if err := o.Sync(ctx); err != nil {
+			log.Error(err, "Failed to sync the shard Leases")
+			o.dropIfStale(ctx)
+		}
+		select {
+		case <-ctx.Done():
+			releaseCtx, cancel := context.WithTimeout(context.Background(), o.opts.RetryPeriod)
+			defer cancel()
+			return o.release(releaseCtx)
+		case <-ticker.C:
+		}
+	}
+}
+
+// Sync renews the membership Lease and the Leases of the owned shards, releases the shards above
+// the fair share of the replica and acquires free shards up to it
+func (o *LeaseOwner) Sync(ctx context.Context) error {
+	now := o.now()
+	if err := o.renewMembership(ctx, now); err != nil {
+		return err
+	}
+	members, err := o.liveMembers(ctx, now)
+	if err != nil {
+		return err
+	}
+	fairShare := (o.opts.Count + members - 1) / members
+
+	leases := make([]*coordinationv1.Lease, o.opts.Count)
+	var held []int
+	for shard := range leases {
+		lease := &coordinationv1.Lease{}
+		if err := o.client.Get(ctx, types.NamespacedName{Namespace: o.opts.Namespace, Name: o.shardLeaseName(shard)}, lease); err != nil {
+			if k8serrors.IsNotFound(err) {
+				continue
+			}
+			return fmt.Errorf("failed to get the Lease of shard %d: %w", shard, err)
+		}
+		leases[shard] = lease
+		if ptr.Deref(lease.Spec.HolderIdentity, "") == o.opts.Identity {
+			held = append(held, shard)
+		}
+	}
+
+	var errs []error
+	owned := map[int]bool{}
+	for i, shard := range held {
+		lease := leases[shard]
+		if i >= fairShare {
+			// Hand the shard over to a replica below its share
+			lease.Spec.HolderIdentity = nil
+			if err := o.client.Update(ctx, lease); err != nil {
+				errs = append(errs, fmt.Errorf("failed to release the Lease of shard %d: %w", shard, err))
+			}
+			continue
+		}
+		lease.Spec.RenewTime = &metav1.MicroTime{Time: now}
+		lease.Spec.LeaseDurationSeconds = ptr.To(int32(o.opts.LeaseDuration.Seconds()))
+		if err := o.client.Update(ctx, lease); err != nil {
+			errs = append(errs, fmt.Errorf("failed to renew the Lease of shard %d: %w", shard, err))
+			continue
+		}
+		owned[shard] = true
+	}
+
+	var acquired []int
+	for shard, lease := range leases {
+		if len(owned) >= fairShare {
+			break
+		}
+		if owned[shard] || (lease != nil && o.heldByOther(lease, now)) {
+			continue
+		}
+		if err := o.acquire(ctx, shard, lease, now); err != nil {
+			if !k8serrors.IsConflict(err) && !k8serrors.IsAlreadyExists(err) {
+				errs = append(errs, err)
+			}
+			continue
+		}
+		owned[shard] = true
+		acquired = append(acquired, shard)
+	}
+
+	o.setOwned(ctx, owned, now)
+	if len(acquired) > 0 && o.OnAcquired != nil {
+		o.OnAcquired(ctx, acquired)
+	}
+	return errors.Join(errs...)
+}
+
+// heldByOther reports whether another replica holds a Lease that has not expired
+func (o *LeaseOwner) heldByOther(lease *coordinationv1.Lease, now time.Time) bool {
+	holder := ptr.Deref(lease.Spec.HolderIdentity, "")
+	return holder != "" && holder != o.opts.Identity && !expired(lease, now)
+}
+
+// acquire takes the Lease of a shard, creating it if it does not exist
+func (o *LeaseOwner) acquire(ctx context.Context, shard int, lease *coordinationv1.Lease, now time.Time) error {
+	if lease == nil {
+		lease = &coordinationv1.Lease{ObjectMeta: metav1.ObjectMeta{
+			Namespace: o.opts.Namespace,
+			Name:      o.shardLeaseName(shard),
+		}}
+	}
+	lease.Spec.HolderIdentity = ptr.To(o.opts.Identity)
+	lease.Spec.LeaseDurationSeconds = ptr.To(int32(o.opts.LeaseDuration.Seconds()))
+	lease.Spec.AcquireTime = &metav1.MicroTime{Time: now}
+	lease.Spec.RenewTime = &metav1.MicroTime{Time: now}
+	lease.Spec.LeaseTransitions = ptr.To(ptr.Deref(lease.Spec.LeaseTransitions, 0) + 1)
+	if lease.ResourceVersion == "" {
+		return o.client.Create(ctx, lease)
+	}
+	return o.client.Update(ctx, lease)
+}
+
+// renewMembership creates or renews the membership Lease of the replica
+func (o *LeaseOwner) renewMembership(ctx context.Context, now time.Time) error {
+	lease := &coordinationv1.Lease{}
+	err := o.client.Get(ctx, types.NamespacedName{Namespace: o.opts.Namespace, Name: o.memberLeaseName()}, lease)
+	if err != nil && !k8serrors.IsNotFound(err) {
+		return fmt.Errorf("failed to get the membership Lease: %w", err)
+	}
+	lease.Name = o.memberLeaseName()
+	lease.Namespace = o.opts.Namespace
+	lease.Labels = map[string]string{MemberLabel: o.opts.Name}
+	lease.Spec.HolderIdentity = ptr.To(o.opts.Identity)
+	lease.Spec.LeaseDurationSeconds = ptr.To(int32(o.opts.LeaseDuration.Seconds()))
+	lease.Spec.RenewTime = &metav1.MicroTime{Time: now}
+	if err != nil {
+		err = o.client.Create(ctx, lease)
+	} else {
+		err = o.client.Update(ctx, lease)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to renew the membership Lease: %w", err)
+	}
+	return nil
+}
+
+// liveMembers returns the number of replicas whose membership Lease has not expired
+func (o *LeaseOwner) liveMembers(ctx context.Context, now time.Time) (int, error) {
+	members := &coordinationv1.LeaseList{}
+	if err := o.client.List(ctx, members, client.InNamespace(o.opts.Namespace),
+		client.MatchingLabels{MemberLabel: o.opts.Name}); err != nil {
+		return 0, fmt.Errorf("failed to list the membership Leases: %w", err)
+	}
+	live := 1
+	for i := range members.Items {
+		if members.Items[i].Name != o.memberLeaseName() && !expired(&members.Items[i], now) {
+			live++
+		}
+	}
+	return live, nil
+}
+
+// setOwned records the owned shards
+func (o *LeaseOwner) setOwned(ctx context.Context, owned map[int]bool, now time.Time) {
+	o.mu.Lock()
+	changed := len(owned) != len(o.owned)
+	for shard := range o.owned {
+		if !owned[shard] {
+			changed = true
+			ownedShardGauge.DeleteLabelValues(strconv.Itoa(shard))
+		}
+	}
+	for shard := range owned {
+		ownedShardGauge.WithLabelValues(strconv.Itoa(shard)).Set(1)
+	}
+	o.owned = owned
+	o.lastRenew = now
+	o.mu.Unlock()
+
+	if changed {
+		logf.FromContext(ctx).WithName("sharding").Info("Owned shards changed",
+			"shards", o.OwnedShards(), "shardCount", o.opts.Count, "identity", o.opts.Identity)
+	}
+}
+
+// dropIfStale gives up the owned shards once their Leases may have expired, since other replicas
+// may then take them over
+func (o *LeaseOwner) dropIfStale(ctx context.Context) {
+	o.mu.RLock()
+	stale := len(o.owned) > 0 && o.now().Sub(o.lastRenew) >= o.opts.LeaseDuration
+	lastRenew := o.lastRenew
+	o.mu.RUnlock()
+	if stale {
+		o.setOwned(ctx, map[int]bool{}, lastRenew)
+	}
+}
+
+// release gives up the Leases of the owned shards and the membership Lease, so other replicas
+// take over without waiting for them to expire
+func (o *LeaseOwner) release(ctx context.Context) error {
+	var errs []error
+	for _, shard := range o.OwnedShards() {
+		lease := &coordinationv1.Lease{}
+		if err := o.client.Get(ctx, types.NamespacedName{Namespace: o.opts.Namespace, Name: o.shardLeaseName(shard)}, lease); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if ptr.Deref(lease.Spec.HolderIdentity, "") != o.opts.Identity {
+			continue
+		}
+		lease.Spec.HolderIdentity = nil
+		if err := o.client.Update(ctx, lease); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	member := &coordinationv1.Lease{ObjectMeta: metav1.ObjectMeta{Namespace: o.opts.Namespace, Name: o.memberLeaseName()}}
+	if err := o.client.Delete(ctx, member); err != nil && !k8serrors.IsNotFound(err) {
+		errs = append(errs, err)
+	}
+	o.setOwned(ctx, map[int]bool{}, o.now())
+	return errors.Join(errs...)
+}
+
+// shardLeaseName returns the name of the Lease of a shard
+func (o *LeaseOwner) shardLeaseName(shard int) string {
+	return fmt.Sprintf("%s-shard-%d", o.opts.Name, shard)
+}
+
+// memberLeaseName returns the name of the membership Lease of the replica
+func (o *LeaseOwner) memberLeaseName() string {
+	return fmt.Sprintf("%s-member-%s", o.opts.Name, o.opts.Identity)
+}
+
+// expired reports whether a Lease was not renewed within its duration
+func expired(lease *coordinationv1.Lease, now time.Time) bool {
+	if lease.Spec.RenewTime == nil {
+		return true
+	}
+	duration := time.Duration(ptr.Deref(lease.Spec.LeaseDurationSeconds, 0)) * time.Second
+	return !now.Before(lease.Spec.RenewTime.Add(duration))
+}
@@ -0,0 +1,77 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package sharding splits the namespaces of the cluster into shards with consistent hashing, so
+// that several replicas of the controller each handle the experiments of their own shards.
+// Replicas either own a fixed shard or take their share of the shards with Leases.
+package sharding
+
+import (
+	"fmt"
+	"hash/fnv"
+)
+
+// Owner reports the shards owned by a replica
+type Owner interface {
+	// Owns reports whether the replica owns the shard
+	Owns(shard int) bool
+}
+
+// Static owns a single fixed shard
+type Static int
+
+// Owns reports whether the shard is the fixed shard
+func (s Static) Owns(shard int) bool {
+	return int(s) == shard
+}
+
+// ShardFor returns the shard of a namespace among count shards. When the number of shards
+// changes, only the namespaces that must move to new shards change shards.
+func ShardFor(namespace string, count int) int {
+	if count <= 1 {
+		return 0
+	}
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(namespace))
+	return jumpHash(h.Sum64(), count)
+}
+
+// LeaderElectionID returns the leader election ID of the replicas handling the same experiments:
+// the replicas pinned to a shard, or restricted by an experiment selector, elect a leader of their
+// own instead of one leader for all the replicas.
+func LeaderElectionID(base string, count, shard int, experimentSelector string) string {
+	id := base
+	if count > 1 && shard >= 0 {
+		id = fmt.Sprintf("shard-%d-of-%d.%s", shard, count, id)
+	}
+	if experimentSelector != "" {
+		h := fnv.New32a()
+		_, _ = h.Write([]byte(experimentSelector))
+		id = fmt.Sprintf("selector-%08x.%s", h.Sum32(), id)
+	}
+	return id
+}
+
+// jumpHash is the jump consistent hash of Lamping and Veach
+func jumpHash(key uint64, buckets int) int {
+	var b, j int64 = -1, 0
+	for j < int64(buckets) {
+		b = j
+		key = key*2862933555777941757 + 1
+		j = int64(float64(b+1) * (float64(int64(1)<<31) / float64((key>>33)+1)))
+	}
+	return int(b)
+}
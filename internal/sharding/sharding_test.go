@@ -0,0 +1,159 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sharding
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	coordinationv1 "k8s.io/api/coordination/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestSharding(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Sharding Suite")
+}
+
+var _ = Describe("Namespace shards", func() {
+	It("should spread namespaces over the shards and keep them stable", func() {
+		counts := make([]int, 4)
+		for i := range 1000 {
+			namespace := fmt.Sprintf("team-%d", i)
+			shard := ShardFor(namespace, 4)
+			Expect(shard).To(BeNumerically(">=", 0))
+			Expect(shard).To(BeNumerically("<", 4))
+			Expect(ShardFor(namespace, 4)).To(Equal(shard))
+			counts[shard]++
+		}
+		for _, count := range counts {
+			Expect(count).To(BeNumerically("~", 250, 60))
+		}
+	})
+
+	It("should only move namespaces to the new shard when shards are added", func() {
+		for i := range 1000 {
+			namespace := fmt.Sprintf("team-%d", i)
+			before, after := ShardFor(namespace, 4), ShardFor(namespace, 5)
+			if before != after {
+				Expect(after).To(Equal(4), namespace)
+			}
+		}
+		Expect(ShardFor("default", 1)).To(Equal(0))
+	})
+
+	It("should own a fixed shard", func() {
+		Expect(Static(2).Owns(2)).To(BeTrue())
+		Expect(Static(2).Owns(1)).To(BeFalse())
+	})
+
+	It("should elect a leader per shard and experiment selector", func() {
+		const base = "73f42a3f.experimentcontroller.example.com"
+		Expect(LeaderElectionID(base, 1, -1, "")).To(Equal(base))
+		Expect(LeaderElectionID(base, 3, 0, "")).NotTo(Equal(LeaderElectionID(base, 3, 1, "")))
+		canary := LeaderElectionID(base, 1, -1, "controller-version=canary")
+		stable := LeaderElectionID(base, 1, -1, "controller-version!=canary")
+		Expect(canary).NotTo(Equal(base))
+		Expect(canary).NotTo(Equal(stable))
+		Expect(canary).To(MatchRegexp(`^[a-z0-9.-]+$`))
+	})
+})
+
+var _ = Describe("Lease ownership", func() {
+	const namespace = "experiment-system"
+
+	var (
+		ctx        context.Context
+		fakeClient client.Client
+		now        time.Time
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		scheme := runtime.NewScheme()
+		Expect(coordinationv1.AddToScheme(scheme)).To(Succeed())
+		fakeClient = fake.NewClientBuilder().WithScheme(scheme).Build()
+		now = time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	})
+
+	newOwner := func(identity string) *LeaseOwner {
+		owner := NewLeaseOwner(fakeClient, LeaseOptions{
+			Namespace: namespace,
+			Name:      "experiment-controller",
+			Identity:  identity,
+			Count:     4,
+		})
+		owner.now = func() time.Time { return now }
+		return owner
+	}
+
+	It("should rebalance the shards as replicas join and leave", func() {
+		first := newOwner("replica-a")
+		var acquired []int
+		first.OnAcquired = func(_ context.Context, shards []int) { acquired = append(acquired, shards...) }
+		Expect(first.Sync(ctx)).To(Succeed())
+		Expect(first.OwnedShards()).To(Equal([]int{0, 1, 2, 3}))
+		Expect(acquired).To(Equal([]int{0, 1, 2, 3}))
+
+		// The second replica waits for the first one to hand over the shards above its share
+		second := newOwner("replica-b")
+		Expect(second.Sync(ctx)).To(Succeed())
+		Expect(second.OwnedShards()).To(BeEmpty())
+		now = now.Add(DefaultRetryPeriod)
+		Expect(first.Sync(ctx)).To(Succeed())
+		Expect(first.OwnedShards()).To(Equal([]int{0, 1}))
+		Expect(second.Sync(ctx)).To(Succeed())
+		Expect(second.OwnedShards()).To(Equal([]int{2, 3}))
+
+		lease := &coordinationv1.Lease{}
+		Expect(fakeClient.Get(ctx, types.NamespacedName{Namespace: namespace, Name: "experiment-controller-shard-3"}, lease)).To(Succeed())
+		Expect(*lease.Spec.HolderIdentity).To(Equal("replica-b"))
+		Expect(*lease.Spec.LeaseTransitions).To(Equal(int32(2)))
+
+		// The first replica stops renewing, the second takes over its shards once they expire
+		now = now.Add(DefaultLeaseDuration)
+		Expect(second.Sync(ctx)).To(Succeed())
+		Expect(second.OwnedShards()).To(Equal([]int{0, 1, 2, 3}))
+	})
+
+	It("should drop stale shards and release its Leases on shutdown", func() {
+		first := newOwner("replica-a")
+		Expect(first.Sync(ctx)).To(Succeed())
+		now = now.Add(DefaultLeaseDuration / 2)
+		first.dropIfStale(ctx)
+		Expect(first.OwnedShards()).To(HaveLen(4))
+		now = now.Add(DefaultLeaseDuration)
+		first.dropIfStale(ctx)
+		Expect(first.OwnedShards()).To(BeEmpty())
+
+		Expect(first.Sync(ctx)).To(Succeed())
+		Expect(first.release(ctx)).To(Succeed())
+		Expect(first.OwnedShards()).To(BeEmpty())
+
+		// Released shards are taken over at once
+		second := newOwner("replica-b")
+		Expect(second.Sync(ctx)).To(Succeed())
+		Expect(second.OwnedShards()).To(Equal([]int{0, 1, 2, 3}))
+	})
+})
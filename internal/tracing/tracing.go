@@ -0,0 +1,84 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package tracing sets up the OpenTelemetry tracer provider of the controller, exporting the
+// spans of reconciles over OTLP.
+package tracing
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// ServiceName is the service.name resource attribute of the spans of the controller
+const ServiceName = "experiment-controller"
+
+// Options configure the export of spans
+type Options struct {
+	// Endpoint is the host:port of the OTLP gRPC receiver. Tracing is disabled if empty.
+	Endpoint string
+	// Insecure disables TLS towards the receiver
+	Insecure bool
+	// SampleRatio is the fraction of reconciles traced, between 0 and 1
+	SampleRatio float64
+}
+
+// NewTracerProvider returns the tracer provider for the options and a function flushing and
+// stopping it. Without an endpoint the provider records nothing.
+func NewTracerProvider(ctx context.Context, opts Options) (trace.TracerProvider, func(context.Context) error, error) {
+	if opts.Endpoint == "" {
+		return noop.NewTracerProvider(), func(context.Context) error { return nil }, nil
+	}
+	if opts.SampleRatio < 0 || opts.SampleRatio > 1 {
+		return nil, nil, fmt.Errorf("sample ratio %v is not between 0 and 1", opts.SampleRatio)
+	}
+
+	clientOpts := []otlptracegrpc.Option{otlptracegrpc.WithEndpoint(opts.Endpoint)}
+	if opts.Insecure {
+		clientOpts = append(clientOpts, otlptracegrpc.WithInsecure())
+	}
+	exporter, err := otlptracegrpc.New(ctx, clientOpts...)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create OTLP exporter: %w", err)
+	}
+	provider := NewSDKTracerProvider(exporter, opts.SampleRatio)
+	return provider, provider.Shutdown, nil
+}
+
+// NewSDKTracerProvider returns a tracer provider batching the sampled spans to the exporter.
+// Tests pass an in-memory exporter.
+func NewSDKTracerProvider(exporter sdktrace.SpanExporter, sampleRatio float64) *sdktrace.TracerProvider {
+	return sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(sampleRatio))),
+		sdktrace.WithResource(resource.NewSchemaless(semconv.ServiceName(ServiceName))),
+	)
+}
+
+// SetGlobal makes the tracer provider and the W3C trace context propagator the global ones
+func SetGlobal(provider trace.TracerProvider) {
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+}
@@ -0,0 +1,75 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tracing
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+func TestTracing(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Tracing Suite")
+}
+
+var _ = Describe("Tracer provider", func() {
+	ctx := context.Background()
+
+	It("should record nothing without an endpoint", func() {
+		provider, shutdown, err := NewTracerProvider(ctx, Options{SampleRatio: 1})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(provider).To(BeAssignableToTypeOf(noop.TracerProvider{}))
+		Expect(shutdown(ctx)).To(Succeed())
+	})
+
+	It("should reject sample ratios outside of 0 and 1", func() {
+		_, _, err := NewTracerProvider(ctx, Options{Endpoint: "localhost:4317", SampleRatio: 1.5})
+		Expect(err).To(MatchError("sample ratio 1.5 is not between 0 and 1"))
+	})
+
+	It("should export the sampled spans with the service name", func() {
+		exporter := tracetest.NewInMemoryExporter()
+		provider := NewSDKTracerProvider(exporter, 1)
+
+		_, span := provider.Tracer("test").Start(ctx, "Reconcile")
+		span.End()
+		Expect(provider.ForceFlush(ctx)).To(Succeed())
+
+		spans := exporter.GetSpans()
+		Expect(spans).To(HaveLen(1))
+		Expect(spans[0].Name).To(Equal("Reconcile"))
+		Expect(spans[0].Resource.Attributes()).To(ContainElement(semconv.ServiceName(ServiceName)))
+		Expect(provider.Shutdown(ctx)).To(Succeed())
+	})
+
+	It("should not export spans with a zero sample ratio", func() {
+		exporter := tracetest.NewInMemoryExporter()
+		provider := NewSDKTracerProvider(exporter, 0)
+
+		_, span := provider.Tracer("test").Start(ctx, "Reconcile")
+		span.End()
+		Expect(provider.ForceFlush(ctx)).To(Succeed())
+
+		Expect(exporter.GetSpans()).To(BeEmpty())
+	})
+})
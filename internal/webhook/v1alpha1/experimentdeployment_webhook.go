@@ -0,0 +1,238 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	experimentcontrollercomv1alpha1 "experimentcontroller.example.com/experiment-deployment/api/v1alpha1"
+	"experimentcontroller.example.com/experiment-deployment/internal/policy"
+)
+
+// log is for logging in this package.
+var experimentdeploymentlog = logf.Log.WithName("experimentdeployment-resource")
+
+// sourceGVKs maps the supported source kinds to their API versions
+var sourceGVKs = map[experimentcontrollercomv1alpha1.SourceKind]schema.GroupVersionKind{
+	experimentcontrollercomv1alpha1.SourceKindDeployment:  {Group: "apps", Version: "v1", Kind: "Deployment"},
+	experimentcontrollercomv1alpha1.SourceKindStatefulSet: {Group: "apps", Version: "v1", Kind: "StatefulSet"},
+	experimentcontrollercomv1alpha1.SourceKindRollout:     {Group: "argoproj.io", Version: "v1alpha1", Kind: "Rollout"},
+}
+
+// SetupExperimentDeploymentWebhookWithManager registers the webhook for ExperimentDeployment in the manager.
+func SetupExperimentDeploymentWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).For(&experimentcontrollercomv1alpha1.ExperimentDeployment{}).
+		WithValidator(&ExperimentDeploymentCustomValidator{Client: mgr.GetClient(), APIReader: mgr.GetAPIReader()}).
+		WithDefaulter(&ExperimentDeploymentCustomDefaulter{}).
+		Complete()
+}
+
+// +kubebuilder:webhook:path=/mutate-experimentcontroller-example-com-v1alpha1-experimentdeployment,mutating=true,failurePolicy=fail,sideEffects=None,groups=experimentcontroller.example.com,resources=experimentdeployments,verbs=create;update,versions=v1alpha1,name=mexperimentdeployment-v1alpha1.kb.io,admissionReviewVersions=v1
+
+// ExperimentDeploymentCustomDefaulter records the author of an ExperimentDeployment in the
+// created-by annotation, so the controller can authorize the experiment against the source.
+type ExperimentDeploymentCustomDefaulter struct{}
+
+var _ webhook.CustomDefaulter = &ExperimentDeploymentCustomDefaulter{}
+
+// Default implements webhook.CustomDefaulter so a webhook will be registered for the type ExperimentDeployment.
+// The author is recorded on creation and whenever the spec changes. Other updates, such as the
+// finalizer added by the controller, keep the recorded author, so the annotation cannot be forged.
+func (d *ExperimentDeploymentCustomDefaulter) Default(ctx context.Context, obj runtime.Object) error {
+	experimentCR, ok := obj.(*experimentcontrollercomv1alpha1.ExperimentDeployment)
+	if !ok {
+		return fmt.Errorf("expected an ExperimentDeployment object but got %T", obj)
+	}
+	req, err := admission.RequestFromContext(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get the admission request: %w", err)
+	}
+
+	if req.Operation == admissionv1.Update {
+		oldCR := &experimentcontrollercomv1alpha1.ExperimentDeployment{}
+		if err := json.Unmarshal(req.OldObject.Raw, oldCR); err != nil {
+			return fmt.Errorf("failed to decode the old ExperimentDeployment: %w", err)
+		}
+		if equality.Semantic.DeepEqual(oldCR.Spec, experimentCR.Spec) {
+			if createdBy, ok := oldCR.Annotations[experimentcontrollercomv1alpha1.CreatedByAnnotation]; ok {
+				setCreatedBy(experimentCR, createdBy)
+			} else {
+				delete(experimentCR.Annotations, experimentcontrollercomv1alpha1.CreatedByAnnotation)
+			}
+			return nil
+		}
+	}
+
+	createdBy, err := json.Marshal(req.UserInfo)
+	if err != nil {
+		return fmt.Errorf("failed to encode the author: %w", err)
+	}
+	experimentdeploymentlog.Info("Recording the author of ExperimentDeployment", "name", experimentCR.GetName(), "username", req.UserInfo.Username)
+	setCreatedBy(experimentCR, string(createdBy))
+	return nil
+}
+
+// setCreatedBy sets the created-by annotation of the experiment
+func setCreatedBy(experimentCR *experimentcontrollercomv1alpha1.ExperimentDeployment, createdBy string) {
+	if experimentCR.Annotations == nil {
+		experimentCR.Annotations = map[string]string{}
+	}
+	experimentCR.Annotations[experimentcontrollercomv1alpha1.CreatedByAnnotation] = createdBy
+}
+
+// +kubebuilder:webhook:path=/validate-experimentcontroller-example-com-v1alpha1-experimentdeployment,mutating=false,failurePolicy=fail,sideEffects=None,groups=experimentcontroller.example.com,resources=experimentdeployments,verbs=create;update,versions=v1alpha1,name=vexperimentdeployment-v1alpha1.kb.io,admissionReviewVersions=v1
+
+// ExperimentDeploymentCustomValidator rejects ExperimentDeployments violating a ClusterExperimentPolicy
+// when they are created or updated.
+type ExperimentDeploymentCustomValidator struct {
+	Client client.Client
+	// APIReader reads the source workloads from the API server. The cache of the manager only
+	// holds the workloads created by the controller, so sources are never found in it.
+	APIReader client.Reader
+}
+
+var _ webhook.CustomValidator = &ExperimentDeploymentCustomValidator{}
+
+// ValidateCreate implements webhook.CustomValidator so a webhook will be registered for the type ExperimentDeployment.
+func (v *ExperimentDeploymentCustomValidator) ValidateCreate(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	experimentCR, ok := obj.(*experimentcontrollercomv1alpha1.ExperimentDeployment)
+	if !ok {
+		return nil, fmt.Errorf("expected an ExperimentDeployment object but got %T", obj)
+	}
+	experimentdeploymentlog.Info("Validation for ExperimentDeployment upon creation", "name", experimentCR.GetName())
+	return v.validatePolicies(ctx, experimentCR)
+}
+
+// ValidateUpdate implements webhook.CustomValidator so a webhook will be registered for the type ExperimentDeployment.
+func (v *ExperimentDeploymentCustomValidator) ValidateUpdate(ctx context.Context, oldObj, newObj runtime.Object) (admission.Warnings, error) {
+	experimentCR, ok := newObj.(*experimentcontrollercomv1alpha1.ExperimentDeployment)
+	if !ok {
+		return nil, fmt.Errorf("expected an ExperimentDeployment object for the newObj but got %T", newObj)
+	}
+	oldExperimentCR, ok := oldObj.(*experimentcontrollercomv1alpha1.ExperimentDeployment)
+	if !ok {
+		return nil, fmt.Errorf("expected an ExperimentDeployment object for the oldObj but got %T", oldObj)
+	}
+	// Allow deleting experiments that no longer satisfy the policies
+	if !experimentCR.DeletionTimestamp.IsZero() {
+		return nil, nil
+	}
+	// Allow metadata updates, such as finalizers and labels, of experiments that no longer satisfy
+	// the policies; the reconciler reports them with the PolicyViolation condition
+	if equality.Semantic.DeepEqual(oldExperimentCR.Spec, experimentCR.Spec) {
+		return nil, nil
+	}
+	experimentdeploymentlog.Info("Validation for ExperimentDeployment upon update", "name", experimentCR.GetName())
+	return v.validatePolicies(ctx, experimentCR)
+}
+
+// ValidateDelete implements webhook.CustomValidator so a webhook will be registered for the type ExperimentDeployment.
+func (v *ExperimentDeploymentCustomValidator) ValidateDelete(_ context.Context, _ runtime.Object) (admission.Warnings, error) {
+	return nil, nil
+}
+
+// validatePolicies evaluates the experiment against all ClusterExperimentPolicies, rendering it
+// from the current spec of its source workload
+func (v *ExperimentDeploymentCustomValidator) validatePolicies(ctx context.Context, experimentCR *experimentcontrollercomv1alpha1.ExperimentDeployment) (admission.Warnings, error) {
+	policies := &experimentcontrollercomv1alpha1.ClusterExperimentPolicyList{}
+	if err := v.Client.List(ctx, policies); err != nil {
+		return nil, fmt.Errorf("failed to list ClusterExperimentPolicies: %w", err)
+	}
+	if len(policies.Items) == 0 {
+		return nil, nil
+	}
+
+	sourceNamespace := experimentCR.Spec.SourceRef.Namespace
+	if sourceNamespace == "" {
+		sourceNamespace = experimentCR.Namespace
+	}
+	sourceSpec, warnings, err := v.sourceSpec(ctx, experimentCR, sourceNamespace)
+	if err != nil {
+		return warnings, err
+	}
+
+	input, err := policy.NewInput(experimentCR, sourceNamespace, sourceSpec)
+	if err != nil {
+		return warnings, field.Invalid(field.NewPath("spec", "overrideSpec"), string(experimentCR.Spec.OverrideSpec.Raw), err.Error())
+	}
+	violations, err := policy.Evaluate(policies.Items, input)
+	if err != nil {
+		return warnings, err
+	}
+	if len(violations) == 0 {
+		return warnings, nil
+	}
+
+	var allErrs field.ErrorList
+	for _, violation := range violations {
+		allErrs = append(allErrs, field.Forbidden(field.NewPath("spec"), violation.String()))
+	}
+	return warnings, k8serrors.NewInvalid(
+		experimentcontrollercomv1alpha1.GroupVersion.WithKind("ExperimentDeployment").GroupKind(),
+		experimentCR.Name, allErrs)
+}
+
+// sourceSpec fetches the spec of the source workload. Experiments created before their source are
+// admitted with a warning and evaluated against overrideSpec alone; the reconciler re-evaluates them.
+func (v *ExperimentDeploymentCustomValidator) sourceSpec(
+	ctx context.Context,
+	experimentCR *experimentcontrollercomv1alpha1.ExperimentDeployment,
+	sourceNamespace string) (interface{}, admission.Warnings, error) {
+
+	gvk, ok := sourceGVKs[experimentCR.Spec.SourceRef.Kind]
+	if !ok {
+		return nil, nil, nil
+	}
+	source := &unstructured.Unstructured{}
+	source.SetGroupVersionKind(gvk)
+	err := v.sourceReader().Get(ctx, types.NamespacedName{Name: experimentCR.Spec.SourceRef.Name, Namespace: sourceNamespace}, source)
+	if err != nil {
+		if k8serrors.IsNotFound(err) || meta.IsNoMatchError(err) {
+			warning := fmt.Sprintf("source %s %s/%s not found, ClusterExperimentPolicies are evaluated against overrideSpec only",
+				experimentCR.Spec.SourceRef.Kind, sourceNamespace, experimentCR.Spec.SourceRef.Name)
+			return nil, admission.Warnings{warning}, nil
+		}
+		return nil, nil, fmt.Errorf("failed to get source %s %s/%s: %w", experimentCR.Spec.SourceRef.Kind, sourceNamespace, experimentCR.Spec.SourceRef.Name, err)
+	}
+	spec, _, _ := unstructured.NestedMap(source.Object, "spec")
+	return spec, nil, nil
+}
+
+// sourceReader returns the reader of source workloads, the API server when set
+func (v *ExperimentDeploymentCustomValidator) sourceReader() client.Reader {
+	if v.APIReader != nil {
+		return v.APIReader
+	}
+	return v.Client
+}
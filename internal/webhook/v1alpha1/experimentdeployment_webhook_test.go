@@ -0,0 +1,220 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	admissionv1 "k8s.io/api/admission/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	experimentcontrollercomv1alpha1 "experimentcontroller.example.com/experiment-deployment/api/v1alpha1"
+)
+
+func TestWebhook(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Webhook Suite")
+}
+
+var _ = Describe("ExperimentDeployment Webhook", func() {
+	var (
+		ctx          context.Context
+		scheme       *runtime.Scheme
+		experimentCR *experimentcontrollercomv1alpha1.ExperimentDeployment
+		policy       *experimentcontrollercomv1alpha1.ClusterExperimentPolicy
+		source       *appsv1.Deployment
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		scheme = runtime.NewScheme()
+		Expect(experimentcontrollercomv1alpha1.AddToScheme(scheme)).To(Succeed())
+		Expect(appsv1.AddToScheme(scheme)).To(Succeed())
+
+		policy = &experimentcontrollercomv1alpha1.ClusterExperimentPolicy{
+			ObjectMeta: metav1.ObjectMeta{Name: "no-host-network"},
+			Spec: experimentcontrollercomv1alpha1.ClusterExperimentPolicySpec{
+				DeniedPaths: []string{"template.spec.hostNetwork"},
+			},
+		}
+		source = &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "default"},
+			Spec: appsv1.DeploymentSpec{
+				Template: corev1.PodTemplateSpec{
+					Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "app", Image: "app:1.0"}}},
+				},
+			},
+		}
+		experimentCR = &experimentcontrollercomv1alpha1.ExperimentDeployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "experiment", Namespace: "default"},
+			Spec: experimentcontrollercomv1alpha1.ExperimentDeploymentSpec{
+				SourceRef: experimentcontrollercomv1alpha1.SourceRef{
+					Kind: experimentcontrollercomv1alpha1.SourceKindDeployment,
+					Name: "app",
+				},
+				OverrideSpec: apiextensionsv1.JSON{Raw: []byte(`{"template":{"spec":{"hostNetwork":true}}}`)},
+			},
+		}
+	})
+
+	newValidator := func(objects ...runtime.Object) *ExperimentDeploymentCustomValidator {
+		return &ExperimentDeploymentCustomValidator{
+			Client: fake.NewClientBuilder().WithScheme(scheme).WithRuntimeObjects(objects...).Build(),
+		}
+	}
+
+	It("should admit experiments when no policy exists", func() {
+		warnings, err := newValidator(source).ValidateCreate(ctx, experimentCR)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(warnings).To(BeEmpty())
+	})
+
+	It("should deny experiments violating a policy and name the path", func() {
+		_, err := newValidator(policy, source).ValidateCreate(ctx, experimentCR)
+		Expect(err).To(HaveOccurred())
+		Expect(k8serrors.IsInvalid(err)).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring("ClusterExperimentPolicy no-host-network: template.spec.hostNetwork"))
+	})
+
+	It("should deny updates violating a policy", func() {
+		oldExperimentCR := experimentCR.DeepCopy()
+		oldExperimentCR.Spec.OverrideSpec = apiextensionsv1.JSON{Raw: []byte(`{"replicas":1}`)}
+		_, err := newValidator(policy, source).ValidateUpdate(ctx, oldExperimentCR, experimentCR)
+		Expect(k8serrors.IsInvalid(err)).To(BeTrue())
+	})
+
+	It("should admit metadata updates of experiments violating a policy", func() {
+		oldExperimentCR := experimentCR.DeepCopy()
+		experimentCR.Finalizers = []string{"experimentcontroller.example.com/finalizer"}
+		experimentCR.Labels = map[string]string{"team": "payments"}
+		_, err := newValidator(policy, source).ValidateUpdate(ctx, oldExperimentCR, experimentCR)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("should admit experiments being deleted", func() {
+		now := metav1.Now()
+		experimentCR.DeletionTimestamp = &now
+		_, err := newValidator(policy, source).ValidateUpdate(ctx, experimentCR.DeepCopy(), experimentCR)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("should warn and evaluate the override only when the source does not exist", func() {
+		experimentCR.Spec.OverrideSpec = apiextensionsv1.JSON{Raw: []byte(`{"replicas":1}`)}
+		warnings, err := newValidator(policy).ValidateCreate(ctx, experimentCR)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(warnings).To(HaveLen(1))
+		Expect(warnings[0]).To(ContainSubstring("not found"))
+	})
+
+	It("should read the source from the API server when the cache only holds managed workloads", func() {
+		registryPolicy := &experimentcontrollercomv1alpha1.ClusterExperimentPolicy{
+			ObjectMeta: metav1.ObjectMeta{Name: "internal-registry"},
+			Spec: experimentcontrollercomv1alpha1.ClusterExperimentPolicySpec{
+				AllowedImageRegistries: []string{"registry.internal"},
+			},
+		}
+		experimentCR.Spec.OverrideSpec = apiextensionsv1.JSON{Raw: []byte(`{"replicas":1}`)}
+		apiServer := fake.NewClientBuilder().WithScheme(scheme).WithRuntimeObjects(registryPolicy, source).Build()
+		// The cache of the manager filters workloads by the managed-by label
+		cached := interceptor.NewClient(apiServer, interceptor.Funcs{
+			Get: func(ctx context.Context, c client.WithWatch, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+				if err := c.Get(ctx, key, obj, opts...); err != nil {
+					return err
+				}
+				if obj.GetLabels()["experiment-controller.example.com/managed-by"] != "experiment-controller" {
+					return k8serrors.NewNotFound(schema.GroupResource{Group: "apps", Resource: "deployments"}, key.Name)
+				}
+				return nil
+			},
+		})
+		validator := &ExperimentDeploymentCustomValidator{Client: cached, APIReader: apiServer}
+
+		warnings, err := validator.ValidateCreate(ctx, experimentCR)
+		Expect(warnings).To(BeEmpty())
+		Expect(k8serrors.IsInvalid(err)).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring("app:1.0"))
+	})
+
+	Context("Defaulter", func() {
+		var defaulter *ExperimentDeploymentCustomDefaulter
+
+		BeforeEach(func() {
+			defaulter = &ExperimentDeploymentCustomDefaulter{}
+		})
+
+		requestContext := func(operation admissionv1.Operation, username string, oldObj *experimentcontrollercomv1alpha1.ExperimentDeployment) context.Context {
+			req := admission.Request{AdmissionRequest: admissionv1.AdmissionRequest{
+				Operation: operation,
+				UserInfo:  authenticationv1.UserInfo{Username: username, Groups: []string{"team-a"}},
+			}}
+			if oldObj != nil {
+				raw, err := json.Marshal(oldObj)
+				Expect(err).NotTo(HaveOccurred())
+				req.OldObject = runtime.RawExtension{Raw: raw}
+			}
+			return admission.NewContextWithRequest(ctx, req)
+		}
+
+		createdBy := func(experiment *experimentcontrollercomv1alpha1.ExperimentDeployment) authenticationv1.UserInfo {
+			author := authenticationv1.UserInfo{}
+			Expect(json.Unmarshal([]byte(experiment.Annotations[experimentcontrollercomv1alpha1.CreatedByAnnotation]), &author)).To(Succeed())
+			return author
+		}
+
+		It("should record the author on creation, replacing a forged annotation", func() {
+			experimentCR.Annotations = map[string]string{experimentcontrollercomv1alpha1.CreatedByAnnotation: `{"username":"admin"}`}
+			Expect(defaulter.Default(requestContext(admissionv1.Create, "alice", nil), experimentCR)).To(Succeed())
+			author := createdBy(experimentCR)
+			Expect(author.Username).To(Equal("alice"))
+			Expect(author.Groups).To(ConsistOf("team-a"))
+		})
+
+		It("should keep the recorded author when the spec is unchanged", func() {
+			Expect(defaulter.Default(requestContext(admissionv1.Create, "alice", nil), experimentCR)).To(Succeed())
+			oldCR := experimentCR.DeepCopy()
+
+			experimentCR.Finalizers = []string{"experimentdeployments.experimentcontroller.example.com/finalizer"}
+			experimentCR.Annotations[experimentcontrollercomv1alpha1.CreatedByAnnotation] = `{"username":"admin"}`
+			Expect(defaulter.Default(requestContext(admissionv1.Update, "system:serviceaccount:experiment-system:controller", oldCR), experimentCR)).To(Succeed())
+			Expect(createdBy(experimentCR).Username).To(Equal("alice"))
+		})
+
+		It("should record the user changing the spec as the new author", func() {
+			Expect(defaulter.Default(requestContext(admissionv1.Create, "alice", nil), experimentCR)).To(Succeed())
+			oldCR := experimentCR.DeepCopy()
+
+			experimentCR.Spec.OverrideSpec = apiextensionsv1.JSON{Raw: []byte(`{"replicas":2}`)}
+			Expect(defaulter.Default(requestContext(admissionv1.Update, "bob", oldCR), experimentCR)).To(Succeed())
+			Expect(createdBy(experimentCR).Username).To(Equal("bob"))
+		})
+	})
+})
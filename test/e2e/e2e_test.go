@@ -320,6 +320,25 @@ spec:
 				g.Expect(output).To(Equal("2"))
 			}, 2*time.Minute).Should(Succeed())
 
+			By("granting experiments access to the cross-namespace source")
+			grantManifest := fmt.Sprintf(`
+apiVersion: experimentcontroller.example.com/v1alpha1
+kind: ExperimentReferenceGrant
+metadata:
+  name: allow-cross-namespace-experiments
+  namespace: %s
+spec:
+  from:
+  - namespace: %s
+  to:
+  - kind: Deployment
+    name: cross-namespace-source
+`, testNamespace, namespace)
+			cmd = exec.Command("kubectl", "apply", "-f", "-")
+			cmd.Stdin = strings.NewReader(grantManifest)
+			_, err = utils.Run(cmd)
+			Expect(err).NotTo(HaveOccurred(), "Failed to create ExperimentReferenceGrant")
+
 			By("creating ExperimentDeployment with cross-namespace source")
 			experimentCRManifest := fmt.Sprintf(`
 apiVersion: experimentcontroller.example.com/v1alpha1